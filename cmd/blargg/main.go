@@ -0,0 +1,65 @@
+// Command blargg runs blargg-style test ROMs headlessly and reports
+// pass/fail for each. Arguments may be ROM files or directories, which are
+// searched recursively for .nes files. The exit status is non-zero if any
+// test did not pass.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testrom"
+)
+
+func main() {
+	maxFrames := flag.Int("frames", testrom.DefaultMaxFrames, "timeout per ROM in emulated frames")
+	verbose := flag.Bool("v", false, "print the full result text of every test")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: blargg [flags] <rom-or-dir>...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	roms, err := collectROMs(flag.Args())
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	passed := 0
+	for _, rom := range roms {
+		res := testrom.RunBlargg(rom, testrom.BlarggOptions{MaxFrames: *maxFrames})
+		fmt.Println(res)
+		if *verbose && res.Text != "" {
+			fmt.Printf("    %s\n", strings.ReplaceAll(res.Text, "\n", "\n    "))
+		}
+		if res.Passed {
+			passed++
+		}
+	}
+
+	fmt.Printf("\n%d/%d passed\n", passed, len(roms))
+	if passed != len(roms) {
+		os.Exit(1)
+	}
+}
+
+// collectROMs expands directory arguments into the .nes files they contain
+func collectROMs(args []string) ([]string, error) {
+	var roms []string
+	for _, arg := range args {
+		found, err := testrom.ExpandROMs(arg)
+		if err != nil {
+			return nil, err
+		}
+		roms = append(roms, found...)
+	}
+	return roms, nil
+}
@@ -0,0 +1,76 @@
+// Command capture exports a range of frames from a ROM as an animated PNG
+// (when -o ends in .png or .apng) or as a numbered PNG sequence in the -o
+// directory.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/capture"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testrom"
+)
+
+func main() {
+	out := flag.String("o", "capture.png", "output .png/.apng file, or a directory for a PNG sequence")
+	start := flag.Int("start", 0, "first frame to capture")
+	count := flag.Int("count", 60, "number of frames to capture")
+	every := flag.Int("every", 1, "capture every Nth frame")
+	inputPath := flag.String("input", "", "input script to play while running")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: capture [flags] <rom-file>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var script *testrom.InputScript
+	if *inputPath != "" {
+		var err error
+		if script, err = testrom.LoadInputScript(*inputPath); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	emulator, err := nes.New(flag.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var sink capture.Sink
+	switch strings.ToLower(filepath.Ext(*out)) {
+	case ".png", ".apng":
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		sink = capture.NewAPNG(f, emulator.FrameRate()/float64(max(*every, 1)))
+	default:
+		if sink, err = capture.NewPNGSequence(*out, "frame"); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	opts := capture.Options{Start: *start, Count: *count, Every: *every}
+	if script != nil {
+		opts.Input = script.Apply
+	}
+	if err := capture.Run(emulator, sink, opts); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Captured frames %d-%d to %s\n", *start, *start+*count-1, *out)
+}
@@ -0,0 +1,97 @@
+// Command cdl runs a game headlessly with the code/data logger on and
+// writes an FCEUX-compatible .cdl file recording which PRG-ROM bytes ran as
+// code and which were read as data, for disassembly projects and for
+// checking how much of a ROM a test run covers.
+//
+// With -merge, an existing log is loaded first and added to, so several
+// runs (or input scripts) can build up one log.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testrom"
+)
+
+func main() {
+	frames := flag.Int("frames", 3600, "frames to run")
+	output := flag.String("o", "", "output .cdl file (default: ROM name with .cdl)")
+	merge := flag.Bool("merge", false, "add to the output file if it exists")
+	inputPath := flag.String("input", "", "input script to play while running")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: cdl [flags] <rom-file>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	romPath := flag.Arg(0)
+	if *output == "" {
+		*output = strings.TrimSuffix(romPath, ".nes") + ".cdl"
+	}
+
+	var script *testrom.InputScript
+	if *inputPath != "" {
+		var err error
+		if script, err = testrom.LoadInputScript(*inputPath); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	emulator, err := nes.New(romPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	emulator.SetHeadless(nes.HeadlessMaxSpeed)
+
+	var log *nes.CodeDataLog
+	if *merge {
+		if f, err := os.Open(*output); err == nil {
+			log, err = nes.ReadCodeDataLog(f, emulator.GetCartridge())
+			f.Close()
+			if err != nil {
+				fmt.Printf("Error: %s: %v\n", *output, err)
+				os.Exit(1)
+			}
+		}
+	}
+	log = emulator.EnableCDL(log)
+	emulator.Reset()
+
+	for frame := 0; frame < *frames; frame++ {
+		script.Apply(emulator, frame)
+		if err := emulator.RunFrame(); err != nil {
+			fmt.Printf("Stopped at frame %d: %v\n", frame, err)
+			break
+		}
+	}
+
+	f, err := os.Create(*output)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := log.WriteTo(f); err != nil {
+		f.Close()
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if err := f.Close(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	c := log.Coverage()
+	fmt.Printf("Wrote %s\n", *output)
+	fmt.Printf("PRG-ROM: %d bytes, %d code, %d data, %d unused (%.1f%% covered)\n",
+		c.Total, c.Code, c.Data, c.Unused, c.Percent())
+}
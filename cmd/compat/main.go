@@ -0,0 +1,134 @@
+// Command compat scans a directory of ROMs, runs each one headlessly in
+// parallel, and writes a JSON or CSV compatibility report classifying every
+// ROM (boots, renders, crashes, unsupported mapper...). A per-verdict
+// summary is printed when the scan finishes.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testrom"
+)
+
+func main() {
+	seconds := flag.Int("seconds", 10, "emulated seconds to run each ROM")
+	workers := flag.Int("workers", runtime.NumCPU(), "number of ROMs to run in parallel")
+	format := flag.String("format", "json", "report format: json or csv")
+	outPath := flag.String("o", "compat-report.json", "report file to write")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: compat [flags] <rom-dir>...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	if *format != "json" && *format != "csv" {
+		fmt.Printf("Error: unknown format %q\n", *format)
+		os.Exit(1)
+	}
+
+	var roms []string
+	for _, arg := range flag.Args() {
+		found, err := testrom.ExpandROMs(arg)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		roms = append(roms, found...)
+	}
+
+	frames := *seconds * 60
+	results := make([]testrom.Diagnosis, len(roms))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < max(*workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = testrom.Diagnose(roms[i], frames)
+			}
+		}()
+	}
+	for i := range roms {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	f, err := os.Create(*outPath)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if *format == "csv" {
+		err = writeCSV(f, results)
+	} else {
+		err = writeJSON(f, results)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		fmt.Printf("Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+
+	counts := map[string]int{}
+	for _, d := range results {
+		counts[d.Verdict]++
+	}
+	verdicts := make([]string, 0, len(counts))
+	for v := range counts {
+		verdicts = append(verdicts, v)
+	}
+	sort.Strings(verdicts)
+
+	fmt.Printf("Scanned %d ROMs -> %s\n", len(results), *outPath)
+	for _, v := range verdicts {
+		fmt.Printf("  %-20s %d\n", v, counts[v])
+	}
+}
+
+// writeJSON writes the report as an indented JSON array
+func writeJSON(w io.Writer, results []testrom.Diagnosis) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(results)
+}
+
+// writeCSV writes the report with one row per ROM
+func writeCSV(w io.Writer, results []testrom.Diagnosis) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"rom", "mapper", "verdict", "boots", "renders", "crashed", "frames", "colors", "changes", "crash_pc", "error"})
+	for _, d := range results {
+		cw.Write([]string{
+			d.ROM,
+			strconv.Itoa(d.Mapper),
+			d.Verdict,
+			strconv.FormatBool(d.Boots),
+			strconv.FormatBool(d.Renders),
+			strconv.FormatBool(d.Crashed),
+			strconv.Itoa(d.Frames),
+			strconv.Itoa(d.Colors),
+			strconv.Itoa(d.Changes),
+			d.CrashPC,
+			strings.ReplaceAll(d.Error, "\n", " "),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,129 @@
+// Command determinism runs the same ROM and input script on two emulator
+// instances and compares their state hashes after every frame, failing on
+// the first frame where they diverge. Each run can advance by whole frames
+// (RunFrame) or by single CPU cycles (Step), so the check also catches state
+// that depends on how the host drives the core.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"os"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testrom"
+)
+
+func main() {
+	frames := flag.Int("frames", 600, "number of frames to compare")
+	inputPath := flag.String("input", "", "input script applied to both runs")
+	modeA := flag.String("a", "frame", "granularity of the first run: frame or step")
+	modeB := flag.String("b", "step", "granularity of the second run: frame or step")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: determinism [flags] <rom-file>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	advanceA, err := advancer(*modeA)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	advanceB, err := advancer(*modeB)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var script *testrom.InputScript
+	if *inputPath != "" {
+		script, err = testrom.LoadInputScript(*inputPath)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	a, err := nes.New(flag.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	b, _ := nes.New(flag.Arg(0))
+	a.Reset()
+	b.Reset()
+
+	for frame := 0; frame < *frames; frame++ {
+		script.Apply(a, frame)
+		script.Apply(b, frame)
+		advanceA(a)
+		advanceB(b)
+
+		if a.HashState(true) == b.HashState(true) {
+			continue
+		}
+
+		fmt.Printf("DIVERGED at frame %d (%s vs %s)\n", frame, *modeA, *modeB)
+		fmt.Printf("  cycles: %d vs %d\n", a.GetCycles(), b.GetCycles())
+		for _, c := range components {
+			ha, hb := c.hash(a), c.hash(b)
+			mark := ""
+			if ha != hb {
+				mark = "  <- differs"
+			}
+			fmt.Printf("  %-12s %016x %016x%s\n", c.name, ha, hb, mark)
+		}
+		os.Exit(1)
+	}
+
+	fmt.Printf("OK: %d frames identical (%s vs %s), final hash %016x\n",
+		*frames, *modeA, *modeB, a.HashState(true))
+}
+
+// advancer returns the function that runs one frame in the given mode
+func advancer(mode string) (func(*nes.NES), error) {
+	switch mode {
+	case "frame":
+		return func(n *nes.NES) { n.RunFrame() }, nil
+	case "step":
+		return func(n *nes.NES) {
+			start := n.GetPPU().GetFrameCount()
+			for n.GetPPU().GetFrameCount() == start {
+				n.Step()
+			}
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown granularity %q (want frame or step)", mode)
+}
+
+// component hashes one part of the system, to narrow down a divergence
+type component struct {
+	name  string
+	write func(*nes.NES, *state.Writer)
+}
+
+var components = []component{
+	{"cpu", func(n *nes.NES, w *state.Writer) { n.GetCPU().WriteState(w) }},
+	{"ram/input", func(n *nes.NES, w *state.Writer) { n.GetBus().WriteState(w) }},
+	{"ppu", func(n *nes.NES, w *state.Writer) { n.GetPPU().WriteState(w) }},
+	{"apu", func(n *nes.NES, w *state.Writer) { n.GetAPU().WriteState(w) }},
+	{"mapper", func(n *nes.NES, w *state.Writer) { n.GetCartridge().GetMapper().WriteState(w) }},
+	{"framebuffer", func(n *nes.NES, w *state.Writer) { w.Block(n.GetFrameBuffer()[:]) }},
+}
+
+// hash returns the component's state hash
+func (c component) hash(n *nes.NES) uint64 {
+	w := state.NewWriter(16 * 1024)
+	c.write(n, w)
+	h := fnv.New64a()
+	h.Write(w.Bytes())
+	return h.Sum64()
+}
@@ -0,0 +1,67 @@
+// Command golden runs a golden-frame manifest: each entry names a ROM, a
+// frame number and the expected frame hash and/or PNG. Entries are run
+// headlessly and compared; failures write the actual frame and a diff image
+// to the output directory. With -update the manifest's hashes and PNGs are
+// regenerated instead.
+//
+// Manifest format (paths are relative to the manifest):
+//
+//	{"entries": [{"rom": "../roms/nestest.nes", "frame": 60, "hash": "...", "png": "nestest_60.png"}]}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testrom"
+)
+
+func main() {
+	outDir := flag.String("out", "golden-failures", "directory for actual and diff images of failures")
+	update := flag.Bool("update", false, "re-capture all entries and rewrite the manifest")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: golden [flags] <manifest.json>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+	path := flag.Arg(0)
+
+	manifest, err := testrom.LoadGoldenManifest(path)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *update {
+		if err := manifest.Update(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := manifest.Save(path); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Updated %d entries\n", len(manifest.Entries))
+		return
+	}
+
+	passed := 0
+	results := manifest.Run(*outDir)
+	for _, res := range results {
+		fmt.Println(res)
+		if res.Passed && res.Err == nil {
+			passed++
+		}
+	}
+
+	fmt.Printf("\n%d/%d passed\n", passed, len(results))
+	if passed != len(results) {
+		os.Exit(1)
+	}
+}
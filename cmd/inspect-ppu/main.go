@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes/debug"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
 )
 
@@ -32,10 +34,23 @@ func main() {
 		emulator.RunFrame()
 	}
 
-	_ = emulator.GetPPU() // Get PPU reference (for future use)
-
-	// We need to add methods to expose PPU internal state
-	// For now, let's check what we can see from the frame buffer
+	dbg := debug.New(emulator)
+	state := dbg.PPU()
+
+	fmt.Println("\nPPU Registers")
+	fmt.Printf("  Scanline: %d  Cycle: %d  Frame: %d (odd=%v)\n", state.Scanline, state.Cycle, state.Frame, state.OddFrame)
+	fmt.Printf("  PPUCTRL: $%02X  PPUMASK: $%02X  PPUSTATUS: $%02X  OAMADDR: $%02X\n",
+		state.Control, state.Mask, state.Status, state.OAMAddress)
+	fmt.Printf("  v: $%04X  t: $%04X  fine X: %d  write latch: %v  read buffer: $%02X\n",
+		state.V, state.T, state.FineX, state.WriteLatch, state.ReadBuffer)
+	fmt.Printf("  Mirroring: %s\n", cartridge.MirroringName(state.Mirroring))
+	fmt.Printf("  BG shifters: pattern $%04X/$%04X  attrib $%04X/$%04X\n",
+		state.BgShifterPatternLo, state.BgShifterPatternHi, state.BgShifterAttribLo, state.BgShifterAttribHi)
+	fmt.Printf("  Sprites on line: %d (sprite 0: %v)\n", state.SpriteCount, state.Sprite0Present)
+	for i := 0; i < int(state.SpriteCount); i++ {
+		fmt.Printf("    #%d  X=%3d  attr=$%02X  pattern=$%02X/$%02X\n", i, state.SpritePositions[i],
+			state.SpriteAttributes[i], state.SpritePatternLo[i], state.SpritePatternHi[i])
+	}
 
 	frameBuffer := emulator.GetFrameBuffer()
 
@@ -68,14 +83,12 @@ func main() {
 
 	// Check if we have the pattern table data in CHR-ROM
 	fmt.Println("\n\nCHR-ROM")
-	cart := emulator.GetCartridge()
-	mapper := cart.GetMapper()
 
 	// Sample pattern table 0
 	fmt.Println("Pattern Table 0 (first 32 bytes):")
 	fmt.Print("  ")
 	for addr := uint16(0x0000); addr < 0x0020; addr++ {
-		fmt.Printf("%02X ", mapper.ReadCHR(addr))
+		fmt.Printf("%02X ", dbg.PeekVRAM(addr))
 	}
 	fmt.Println()
 
@@ -83,19 +96,31 @@ func main() {
 	fmt.Println("Pattern Table 1 (first 32 bytes):")
 	fmt.Print("  ")
 	for addr := uint16(0x1000); addr < 0x1020; addr++ {
-		fmt.Printf("%02X ", mapper.ReadCHR(addr))
+		fmt.Printf("%02X ", dbg.PeekVRAM(addr))
 	}
 	fmt.Println()
 
 	// Check nametable
 	fmt.Println("\nNametable")
-	fmt.Println("Reading nametable directly from PPU memory...")
-	// We need to add a method to read nametable
-	// For now, show what we know
+	fmt.Println("Nametable 0 (first 4 rows of tile IDs):")
+	for row := uint16(0); row < 4; row++ {
+		fmt.Print("  ")
+		for col := uint16(0); col < 32; col++ {
+			fmt.Printf("%02X ", dbg.PeekVRAM(0x2000+row*32+col))
+		}
+		fmt.Println()
+	}
 
 	fmt.Println("\nPalette RAM")
-	// We need to expose palette RAM reading
-	// Try to infer from frame buffer colors
+	palette := dbg.Palette()
+	for i := 0; i < 32; i += 4 {
+		kind := "BG "
+		if i >= 16 {
+			kind = "SPR"
+		}
+		fmt.Printf("  %s %d: %02X %02X %02X %02X\n", kind, (i/4)%4,
+			palette[i], palette[i+1], palette[i+2], palette[i+3])
+	}
 
 	colorUsage := make(map[uint8]int)
 	for _, color := range frameBuffer {
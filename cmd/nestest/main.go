@@ -0,0 +1,70 @@
+// Command nestest runs Kevin Horton's nestest.nes in automated "C000 mode"
+// and compares the CPU trace against a golden nestest.log.
+//
+// In C000 mode execution starts at $C000 with P=$24, SP=$FD and 7 cycles
+// already elapsed, and the ROM runs every official and unofficial opcode
+// test without needing the PPU. The first line that differs from the golden
+// log is reported with the preceding lines for context. Without -log the
+// trace is printed to stdout instead.
+//
+// The same check runs as part of go test (pkg/testrom) when the log is
+// placed at testdata/nestest.log.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testrom"
+)
+
+func main() {
+	logPath := flag.String("log", "", "golden nestest.log to compare against")
+	maxLines := flag.Int("lines", testrom.NestestLines, "number of instructions to trace")
+	context := flag.Int("context", 5, "lines of context shown before a divergence")
+	strict := flag.Bool("strict", false, "also compare the PPU scanline/dot column")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: nestest [flags] <nestest.nes>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	opts := testrom.NestestOptions{MaxLines: *maxLines, StrictPPU: *strict}
+	if *logPath != "" {
+		golden, err := testrom.ReadTraceLog(*logPath)
+		if err != nil {
+			fmt.Printf("Error reading log: %v\n", err)
+			os.Exit(1)
+		}
+		opts.Golden = golden
+	}
+
+	res, err := testrom.RunNestest(flag.Arg(0), opts)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if opts.Golden == nil {
+		for _, line := range res.Trace {
+			fmt.Println(line)
+		}
+		fmt.Fprintf(os.Stderr, "Result codes: $02=%02X $03=%02X\n", res.Official, res.Unofficial)
+		return
+	}
+
+	if res.Divergence != 0 {
+		if res.Halted {
+			fmt.Printf("CPU halted after %d of %d lines\n", len(res.Trace), len(opts.Golden))
+		}
+		fmt.Print(res.Report(*context))
+		os.Exit(1)
+	}
+	fmt.Printf("PASS: %d lines match (result codes $02=%02X $03=%02X)\n", len(res.Trace), res.Official, res.Unofficial)
+}
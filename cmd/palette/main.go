@@ -0,0 +1,103 @@
+// Command palette renders an NES palette to a PNG swatch (all 64 colors
+// for each of the 8 emphasis combinations) and converts between .pal
+// formats. Without -pal it works on the default built-in palette; -pal
+// accepts a .pal file or a preset name (default, composite, deuteranopia,
+// protanopia, tritanopia).
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
+)
+
+// Swatch layout: 8 blocks (one per emphasis) of 4 rows x 16 colors
+const (
+	cellSize = 16
+	blockGap = 4
+)
+
+func main() {
+	palPath := flag.String("pal", "", "palette file (.pal, 192 or 1536 bytes) or built-in preset name")
+	pngPath := flag.String("png", "", "write a swatch image to this PNG file")
+	exportPath := flag.String("export", "", "write the palette to this .pal file")
+	full := flag.Bool("full", true, "export all 8 emphasis variants (1536 bytes) instead of 64 colors")
+	flag.Parse()
+
+	pal := ppu.DefaultPalette()
+	if preset, ok := ppu.LookupPalettePreset(*palPath); ok {
+		pal = preset.Palette()
+	} else if *palPath != "" {
+		var err error
+		if pal, err = ppu.LoadPalette(*palPath); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *pngPath == "" && *exportPath == "" {
+		printPalette(pal)
+		return
+	}
+
+	if *pngPath != "" {
+		if err := writeSwatch(*pngPath, pal); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote swatch to %s\n", *pngPath)
+	}
+	if *exportPath != "" {
+		if err := pal.Save(*exportPath, *full); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Wrote palette to %s\n", *exportPath)
+	}
+}
+
+// printPalette lists the base colors as hex
+func printPalette(pal *ppu.Palette) {
+	for row := 0; row < 4; row++ {
+		fmt.Printf("$%X0:", row)
+		for col := 0; col < 16; col++ {
+			c := pal.Color(uint8(row*16+col), 0)
+			fmt.Printf(" %02X%02X%02X", c.R, c.G, c.B)
+		}
+		fmt.Println()
+	}
+}
+
+// writeSwatch renders the palette with emphasis 0-7 from top to bottom
+func writeSwatch(path string, pal *ppu.Palette) error {
+	blockHeight := 4*cellSize + blockGap
+	img := image.NewRGBA(image.Rect(0, 0, 16*cellSize, 8*blockHeight-blockGap))
+
+	for e := 0; e < 8; e++ {
+		for index := 0; index < 64; index++ {
+			c := pal.Color(uint8(index), uint8(e))
+			x0 := (index % 16) * cellSize
+			y0 := e*blockHeight + (index/16)*cellSize
+			for y := y0; y < y0+cellSize; y++ {
+				for x := x0; x < x0+cellSize; x++ {
+					img.SetRGBA(x, y, color.RGBA{c.R, c.G, c.B, 255})
+				}
+			}
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
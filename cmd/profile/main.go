@@ -0,0 +1,93 @@
+// Command profile runs a game headlessly and reports where the CPU spends
+// its time: cycles per PRG bank, then the hottest instruction addresses
+// with their disassembly. Useful for finding hot loops in homebrew.
+//
+// Addresses in bank-switched ROM are reported per bank. Disassembly is
+// only shown for code in a bank still mapped when the run ends.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testrom"
+)
+
+func main() {
+	frames := flag.Int("frames", 600, "frames to run")
+	start := flag.Int("start", 0, "frame to start profiling at")
+	top := flag.Int("top", 30, "number of addresses to list")
+	inputPath := flag.String("input", "", "input script to play while running")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: profile [flags] <rom-file>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var script *testrom.InputScript
+	if *inputPath != "" {
+		var err error
+		if script, err = testrom.LoadInputScript(*inputPath); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	emulator, err := nes.New(flag.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	emulator.SetHeadless(nes.HeadlessMaxSpeed)
+	emulator.Reset()
+
+	for frame := 0; frame < *frames; frame++ {
+		if frame == *start {
+			emulator.EnableProfiler()
+		}
+		script.Apply(emulator, frame)
+		if err := emulator.RunFrame(); err != nil {
+			fmt.Printf("Stopped at frame %d: %v\n", frame, err)
+			break
+		}
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	profile := emulator.Profile()
+	fmt.Fprintf(out, "%d cycles profiled\n\nBank    Cycles        %%\n", profile.Cycles)
+	for _, b := range profile.Banks() {
+		fmt.Fprintf(out, "%-4s  %10d  %6.2f\n", bankName(b.Bank), b.Cycles, profile.Percent(b.Cycles))
+	}
+
+	banks, _ := emulator.GetCartridge().GetMapper().(cartridge.PRGBankMapper)
+	peek := emulator.GetBus().Peek
+	fmt.Fprintf(out, "\nAddr   Bank    Cycles        %%      Count  Instruction\n")
+	for _, e := range profile.Top(*top) {
+		listing := ""
+		if e.Bank < 0 || banks == nil || banks.PRGBank(e.Addr) == e.Bank {
+			listing = cpu.Decode(peek, e.Addr).String()
+		}
+		fmt.Fprintf(out, "$%04X  %-4s  %10d  %6.2f  %9d  %s\n",
+			e.Addr, bankName(e.Bank), e.Cycles, profile.Percent(e.Cycles), e.Count, listing)
+	}
+}
+
+// bankName formats a bank number, "-" for code running from RAM
+func bankName(bank int) string {
+	if bank < 0 {
+		return "-"
+	}
+	return fmt.Sprint(bank)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -14,63 +15,56 @@ func main() {
 	}
 
 	romPath := os.Args[1]
+	fmt.Printf("ROM File: %s\n", romPath)
 
-	// Read the ROM file
-	data, err := os.ReadFile(romPath)
-	if err != nil {
-		fmt.Printf("Error reading ROM: %v\n", err)
+	cart, err := cartridge.LoadFromFile(romPath)
+	var unsupported cartridge.ErrUnsupportedMapper
+	switch {
+	case errors.As(err, &unsupported):
+		fmt.Printf("UNSUPPORTED: mapper %d is not implemented\n", unsupported.ID)
 		os.Exit(1)
-	}
-
-	if len(data) < 16 {
-		fmt.Println("File too small to be a valid iNES ROM")
+	case errors.Is(err, cartridge.ErrBadHeader), errors.Is(err, cartridge.ErrTruncatedROM):
+		fmt.Printf("CORRUPT: %v\n", err)
+		os.Exit(1)
+	case err != nil:
+		fmt.Printf("ERROR: %v\n", err)
 		os.Exit(1)
 	}
 
-	// Parse header
-	fmt.Printf("ROM File: %s\n", romPath)
-	fmt.Printf("File Size: %d bytes\n\n", len(data))
-
-	// Check magic
-	magic := string(data[0:4])
-	fmt.Printf("Magic: %q (should be \"NES\\x1a\")\n", magic)
-
-	prgBanks := data[4]
-	chrBanks := data[5]
-	flags6 := data[6]
-	flags7 := data[7]
-
-	fmt.Printf("PRG-ROM Banks: %d (= %d KB)\n", prgBanks, prgBanks*16)
-	fmt.Printf("CHR-ROM Banks: %d (= %d KB)\n", chrBanks, chrBanks*8)
+	info := cart.Info()
 
-	// Parse flags
-	mirroring := flags6 & 0x01
-	hasSaveRAM := (flags6 & 0x02) != 0
-	hasTrainer := (flags6 & 0x04) != 0
-	fourScreen := (flags6 & 0x08) != 0
-
-	mapperLow := (flags6 & 0xF0) >> 4
-	mapperHigh := flags7 & 0xF0
-	mapperID := mapperHigh | mapperLow
-
-	fmt.Printf("\nFlags 6: 0x%02X\n", flags6)
-	fmt.Printf("  Mirroring: %s (%d)\n", []string{"Horizontal", "Vertical"}[mirroring], mirroring)
-	fmt.Printf("  Battery-backed RAM: %v\n", hasSaveRAM)
-	fmt.Printf("  Trainer: %v\n", hasTrainer)
-	fmt.Printf("  Four-screen VRAM: %v\n", fourScreen)
-	fmt.Printf("  Mapper (low nibble): %d\n", mapperLow)
+	format := "iNES"
+	if info.NES20 {
+		format = "NES 2.0"
+	}
+	fmt.Printf("Format: %s\n", format)
+	if info.Title != "" {
+		fmt.Printf("Title: %s (ROM database)\n", info.Title)
+	}
 
-	fmt.Printf("\nFlags 7: 0x%02X\n", flags7)
-	fmt.Printf("  Mapper (high nibble): %d\n", mapperHigh>>4)
+	fmt.Printf("\nMapper: %d (%s)", info.Mapper, info.MapperName)
+	if info.Submapper != 0 {
+		fmt.Printf(", submapper %d", info.Submapper)
+	}
+	fmt.Println()
+	fmt.Printf("PRG-ROM: %d x 16KB = %dKB\n", info.PRGBanks, int(info.PRGBanks)*16)
+	if info.CHRBanks > 0 {
+		fmt.Printf("CHR-ROM: %d x 8KB = %dKB\n", info.CHRBanks, int(info.CHRBanks)*8)
+	}
+	if info.CHRRAMSize > 0 {
+		fmt.Printf("CHR-RAM: %dKB\n", info.CHRRAMSize/1024)
+	}
+	if info.PRGRAMSize > 0 {
+		fmt.Printf("PRG-RAM: %dKB (battery-backed: %v)\n", info.PRGRAMSize/1024, info.Battery)
+	}
+	fmt.Printf("Trainer: %v\n", info.Trainer)
+	fmt.Printf("Mirroring: %s\n", cartridge.MirroringName(info.Mirroring))
+	fmt.Printf("Region: %s\n", info.Region)
 
-	fmt.Printf("\nMapper ID: %d\n", mapperID)
+	fmt.Printf("\nCRC32: %08X\n", info.CRC32)
+	fmt.Printf("SHA-1: %X\n", info.SHA1)
 
-	// Try to load with cartridge loader
-	fmt.Println("\nAttempting to load with cartridge loader...")
-	cart, err := cartridge.LoadFromFile(romPath)
-	if err != nil {
-		fmt.Printf("ERROR: %v\n", err)
-	} else {
-		fmt.Printf("SUCCESS: Loaded mapper %d\n", cart.GetMapperID())
+	for _, w := range info.Warnings {
+		fmt.Printf("WARNING: %s\n", w)
 	}
 }
@@ -0,0 +1,199 @@
+// Command romdb-gen converts the NesCartDB XML export into the romdb
+// games.txt format, to regenerate pkg/romdb/nescartdb.txt:
+//
+//	romdb-gen [-o pkg/romdb/nescartdb.txt] nescartdb.xml
+//
+// Each <cartridge> becomes one entry keyed on its PRG+CHR hashes, with
+// the mapper, solder-pad mirroring, work RAM, battery and console region
+// of its board. Cartridges for unknown systems or without a usable
+// board are skipped and counted on stderr.
+package main
+
+import (
+	"bufio"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The parts of the NesCartDB schema romdb uses
+type database struct {
+	Games []game `xml:"game"`
+}
+
+type game struct {
+	Name       string      `xml:"name,attr"`
+	Region     string      `xml:"region,attr"`
+	Cartridges []cartridge `xml:"cartridge"`
+}
+
+type cartridge struct {
+	System string  `xml:"system,attr"`
+	CRC    string  `xml:"crc,attr"`
+	SHA1   string  `xml:"sha1,attr"`
+	Dump   string  `xml:"dump,attr"`
+	Boards []board `xml:"board"`
+}
+
+type board struct {
+	Mapper string   `xml:"mapper,attr"`
+	WRAM   []memory `xml:"wram"`
+	VRAM   []memory `xml:"vram"`
+	Chips  []memory `xml:"chip"`
+	Pad    *struct {
+		H string `xml:"h,attr"`
+		V string `xml:"v,attr"`
+	} `xml:"pad"`
+}
+
+type memory struct {
+	Size    string `xml:"size,attr"`
+	Battery string `xml:"battery,attr"`
+}
+
+// regions maps NesCartDB systems to romdb region names
+var regions = map[string]string{
+	"NES-NTSC":  "ntsc",
+	"Famicom":   "ntsc",
+	"NES-PAL":   "pal",
+	"NES-PAL-A": "pal",
+	"NES-PAL-B": "pal",
+	"Dendy":     "dendy",
+}
+
+func main() {
+	out := flag.String("o", "", "output file (default stdout)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: romdb-gen [-o games.txt] nescartdb.xml")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	var db database
+	err = xml.NewDecoder(f).Decode(&db)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", flag.Arg(0), err)
+		os.Exit(1)
+	}
+
+	lines, skipped := convert(db)
+
+	w := io.Writer(os.Stdout)
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		w = file
+	}
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "# Generated by cmd/romdb-gen from the NesCartDB XML export. Do not edit;")
+	fmt.Fprintln(bw, "# add hand-verified entries to games.txt instead.")
+	fmt.Fprintln(bw, "#")
+	fmt.Fprintln(bw, "# crc32    sha1                                     mapper mirror prgram battery region title")
+	for _, line := range lines {
+		fmt.Fprintln(bw, line)
+	}
+	if err := bw.Flush(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Fprintf(os.Stderr, "%d entries, %d cartridges skipped\n", len(lines), skipped)
+}
+
+// convert turns every usable cartridge into a games.txt line, sorted by
+// title
+func convert(db database) (lines []string, skipped int) {
+	for _, g := range db.Games {
+		for _, c := range g.Cartridges {
+			line, ok := entry(g, c)
+			if !ok {
+				skipped++
+				continue
+			}
+			lines = append(lines, line)
+		}
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		return strings.Join(strings.Fields(lines[i])[7:], " ") < strings.Join(strings.Fields(lines[j])[7:], " ")
+	})
+	return lines, skipped
+}
+
+// entry formats one cartridge, or reports false if romdb cannot hold it
+func entry(g game, c cartridge) (string, bool) {
+	region, ok := regions[c.System]
+	if !ok || len(c.Boards) == 0 || len(c.CRC) != 8 {
+		return "", false
+	}
+	b := c.Boards[0]
+	mapper, err := strconv.Atoi(b.Mapper)
+	if err != nil || mapper < 0 || mapper > 4095 {
+		return "", false
+	}
+
+	// Solder pads are named for the mirroring they select; four-screen
+	// boards carry their own VRAM
+	mirror := "mapper"
+	switch {
+	case len(b.VRAM) > 0:
+		mirror = "4"
+	case b.Pad != nil && b.Pad.V == "1":
+		mirror = "v"
+	case b.Pad != nil && b.Pad.H == "1":
+		mirror = "h"
+	}
+
+	ram, battery := 0, "n"
+	for _, m := range b.WRAM {
+		ram += kilobytes(m.Size)
+		if m.Battery == "1" {
+			battery = "y"
+		}
+	}
+	for _, chip := range b.Chips {
+		if chip.Battery == "1" {
+			battery = "y"
+		}
+	}
+
+	sha := strings.ToUpper(c.SHA1)
+	if len(sha) != 40 {
+		sha = "-"
+	}
+	title := strings.Join(strings.Fields(g.Name), " ")
+	if title == "" {
+		title = "untitled"
+	}
+	if g.Region != "" {
+		title += " (" + g.Region + ")"
+	}
+	if c.Dump == "bad" {
+		title += " [b]"
+	}
+	return fmt.Sprintf("%s %s %d %s %dk %s %s %s",
+		strings.ToUpper(c.CRC), sha, mapper, mirror, ram, battery, region, title), true
+}
+
+// kilobytes parses a NesCartDB size such as "8k"
+func kilobytes(size string) int {
+	n, _ := strconv.Atoi(strings.TrimSuffix(strings.ToLower(size), "k"))
+	return n
+}
@@ -0,0 +1,171 @@
+// Command romsplit splits an iNES file into its sections and reassembles
+// them, for ROM-hacking workflows that patch PRG or CHR data directly.
+//
+//	romsplit split [-o dir] game.nes    writes header.bin, prg.bin, chr.bin,
+//	                                    trainer.bin and extra.bin as present
+//	romsplit join [-o out.nes] [-fix] dir
+//
+// join validates the section sizes against header.bin; -fix instead updates
+// the header's bank counts and trainer flag to match the files. Both
+// commands print the size, CRC32 and SHA-1 of every section.
+package main
+
+import (
+	"crypto/sha1"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+)
+
+// section names a ROMParts field and its file name
+type section struct {
+	file string
+	data func(p *cartridge.ROMParts) *[]byte
+}
+
+var sections = []section{
+	{"trainer.bin", func(p *cartridge.ROMParts) *[]byte { return &p.Trainer }},
+	{"prg.bin", func(p *cartridge.ROMParts) *[]byte { return &p.PRG }},
+	{"chr.bin", func(p *cartridge.ROMParts) *[]byte { return &p.CHR }},
+	{"extra.bin", func(p *cartridge.ROMParts) *[]byte { return &p.Extra }},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "split":
+		err = split(os.Args[2:])
+	case "join":
+		err = join(os.Args[2:])
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Println("Usage: romsplit split [-o dir] <rom-file>")
+	fmt.Println("       romsplit join [-o out.nes] [-fix] <dir>")
+	os.Exit(1)
+}
+
+func split(args []string) error {
+	flags := flag.NewFlagSet("split", flag.ExitOnError)
+	out := flags.String("o", "", "output directory (default: ROM name without extension)")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+
+	romPath := flags.Arg(0)
+	data, err := os.ReadFile(romPath)
+	if err != nil {
+		return err
+	}
+	parts, err := cartridge.SplitROM(data)
+	if err != nil {
+		return err
+	}
+
+	dir := *out
+	if dir == "" {
+		dir = romPath[:len(romPath)-len(filepath.Ext(romPath))]
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	if err := writePart(dir, "header.bin", parts.Header[:]); err != nil {
+		return err
+	}
+	for _, s := range sections {
+		if data := *s.data(parts); data != nil {
+			if err := writePart(dir, s.file, data); err != nil {
+				return err
+			}
+		}
+	}
+	report(romPath, data)
+	return nil
+}
+
+func join(args []string) error {
+	flags := flag.NewFlagSet("join", flag.ExitOnError)
+	out := flags.String("o", "", "output ROM (default: <dir>.nes)")
+	fix := flags.Bool("fix", false, "update the header to match the section sizes")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+	dir := filepath.Clean(flags.Arg(0))
+
+	parts := &cartridge.ROMParts{}
+	header, err := os.ReadFile(filepath.Join(dir, "header.bin"))
+	if err != nil {
+		return err
+	}
+	if len(header) != len(parts.Header) {
+		return fmt.Errorf("header.bin is %d bytes, want %d", len(header), len(parts.Header))
+	}
+	copy(parts.Header[:], header)
+	report("header.bin", header)
+
+	for _, s := range sections {
+		data, err := os.ReadFile(filepath.Join(dir, s.file))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		*s.data(parts) = data
+		report(s.file, data)
+	}
+
+	if *fix {
+		if err := parts.FixHeader(); err != nil {
+			return err
+		}
+	}
+	if err := parts.Validate(); err != nil {
+		return err
+	}
+
+	path := *out
+	if path == "" {
+		path = dir + ".nes"
+	}
+	rom := parts.Bytes()
+	if err := os.WriteFile(path, rom, 0o644); err != nil {
+		return err
+	}
+	report(path, rom)
+	return nil
+}
+
+// writePart writes one section file and reports it
+func writePart(dir, name string, data []byte) error {
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+		return err
+	}
+	report(filepath.Join(dir, name), data)
+	return nil
+}
+
+// report prints a section's size and hashes
+func report(name string, data []byte) {
+	fmt.Printf("%-28s %8d bytes  crc32 %08X  sha1 %X\n", name, len(data), crc32.ChecksumIEEE(data), sha1.Sum(data))
+}
@@ -0,0 +1,75 @@
+// Command scoreboard runs a suite of test ROMs grouped by category and
+// writes a JSON scoreboard plus a markdown summary, comparing against the
+// previous run so accuracy progress can be tracked over time.
+//
+// Suite format (paths are relative to the suite file; directories are
+// searched for .nes files):
+//
+//	{"categories": [
+//	  {"name": "cpu", "roms": ["nes-test-roms/instr_test-v5/rom_singles"]},
+//	  {"name": "ppu", "roms": ["nes-test-roms/ppu_vbl_nmi/rom_singles"]}
+//	]}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testrom"
+)
+
+func main() {
+	jsonPath := flag.String("json", "scoreboard.json", "scoreboard JSON to write (the previous contents are compared against)")
+	mdPath := flag.String("md", "", "write the markdown summary to this file instead of stdout")
+	previousPath := flag.String("previous", "", "compare against this scoreboard instead of the existing -json file")
+	maxFrames := flag.Int("frames", testrom.DefaultMaxFrames, "timeout per ROM in emulated frames")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: scoreboard [flags] <suite.json>")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	suite, err := testrom.LoadSuite(flag.Arg(0))
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *previousPath == "" {
+		*previousPath = *jsonPath
+	}
+	previous, err := testrom.LoadScoreboard(*previousPath)
+	if err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Error reading previous scoreboard: %v\n", err)
+		os.Exit(1)
+	}
+
+	board, err := suite.Run(testrom.BlarggOptions{MaxFrames: *maxFrames})
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := board.Save(*jsonPath); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	out := os.Stdout
+	if *mdPath != "" {
+		f, err := os.Create(*mdPath)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+	board.WriteMarkdown(out, previous)
+}
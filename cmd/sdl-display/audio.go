@@ -0,0 +1,79 @@
+package main
+
+import (
+	"unsafe"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/apu"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/avsync"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+// Audio output settings
+const (
+	audioRate    = 44100 // Device sample rate in Hz
+	audioSamples = 1024  // SDL buffer size in samples
+	audioBuffer  = 4096  // Queue capacity for rate control (~93ms; target is half)
+)
+
+// audioOutput feeds APU samples to an SDL audio queue
+//
+// The APU is resampled to the device rate, nudged up or down by dynamic
+// rate control so the queue hovers around half full: frames are paced by
+// the video clock, and small differences between it and the audio clock
+// would otherwise slowly underrun or overflow the queue.
+type audioOutput struct {
+	dev  sdl.AudioDeviceID
+	apu  *apu.APU
+	rate avsync.RateControl
+}
+
+// openAudio opens the default audio device and points the APU at its rate
+func openAudio(a *apu.APU) (*audioOutput, error) {
+	want := sdl.AudioSpec{
+		Freq:     audioRate,
+		Format:   sdl.AUDIO_F32SYS,
+		Channels: 1,
+		Samples:  audioSamples,
+	}
+	dev, err := sdl.OpenAudioDevice("", false, &want, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	a.SetSampleRate(audioRate)
+	a.Samples() // Drop anything generated at the old rate
+	sdl.PauseAudioDevice(dev, false)
+	return &audioOutput{dev: dev, apu: a}, nil
+}
+
+// queue sends the samples generated since the last call and adjusts the
+// APU's output rate for the new queue level
+func (o *audioOutput) queue() {
+	samples := o.apu.Samples()
+
+	// After a pause or stall the queue is far behind; start over
+	fill := int(sdl.GetQueuedAudioSize(o.dev) / 4)
+	if fill > 2*audioBuffer {
+		sdl.ClearQueuedAudio(o.dev)
+		fill = 0
+	}
+
+	if len(samples) > 0 {
+		data := unsafe.Slice((*byte)(unsafe.Pointer(&samples[0])), len(samples)*4)
+		sdl.QueueAudio(o.dev, data)
+		fill += len(samples)
+	}
+
+	o.apu.SetSampleRate(o.rate.OutputRate(audioRate, fill, audioBuffer))
+}
+
+// clear drops queued audio, e.g. on pause
+func (o *audioOutput) clear() {
+	sdl.ClearQueuedAudio(o.dev)
+	o.apu.Samples()
+}
+
+// close shuts the audio device
+func (o *audioOutput) close() {
+	sdl.CloseAudioDevice(o.dev)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// maxRecentROMs is how many recently played ROMs the boot menu remembers
+const maxRecentROMs = 8
+
+// config holds frontend settings persisted between runs
+type config struct {
+	ROMDir  string   `json:"romDir,omitempty"`  // Directory the boot menu browses
+	Recent  []string `json:"recent,omitempty"`  // Recently played ROMs, newest first
+	Palette string   `json:"palette,omitempty"` // Palette preset name or .pal file
+
+	path string // File the config was loaded from
+}
+
+// configPath returns the settings file location
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "go-nes-emulator", "sdl-display.json"), nil
+}
+
+// loadConfig reads the settings file; a missing file yields defaults
+func loadConfig() *config {
+	cfg := &config{ROMDir: "roms"}
+	path, err := configPath()
+	if err != nil {
+		return cfg
+	}
+	cfg.path = path
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			fmt.Printf("Warning: ignoring settings: %v\n", err)
+		}
+		return cfg
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		fmt.Printf("Warning: ignoring settings in %s: %v\n", path, err)
+	}
+	return cfg
+}
+
+// save writes the settings file, creating its directory
+func (c *config) save() error {
+	if c.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0o644)
+}
+
+// addRecent moves a ROM to the front of the recent list
+func (c *config) addRecent(romPath string) {
+	if abs, err := filepath.Abs(romPath); err == nil {
+		romPath = abs
+	}
+	c.Recent = slices.DeleteFunc(c.Recent, func(p string) bool { return p == romPath })
+	c.Recent = slices.Insert(c.Recent, 0, romPath)
+	if len(c.Recent) > maxRecentROMs {
+		c.Recent = c.Recent[:maxRecentROMs]
+	}
+}
@@ -1,12 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"unsafe"
 
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/apu"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/avsync"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/controller"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
 	"github.com/veandco/go-sdl2/sdl"
@@ -19,27 +27,60 @@ const (
 )
 
 func main() {
-	if len(os.Args) < 2 {
-		fmt.Println("Usage: sdl-display <rom-file>")
+	extraLines := flag.Int("overclock-lines", 0, "extra idle scanlines per frame (reduces slowdown)")
+	romDir := flag.String("rom-dir", "", "directory the boot menu browses (remembered)")
+	paletteName := flag.String("palette", "", "palette preset or .pal file (remembered; C cycles presets)")
+	cpuMultiplier := flag.Int("cpu-multiplier", 1, "CPU cycles per PPU-clocked cycle outside rendering")
+	noAudio := flag.Bool("no-audio", false, "disable sound")
+	fastRender := flag.Bool("fast-render", false, "draw whole scanlines at once (faster, less exact mapper IRQ timing)")
+	cropOverscan := flag.Bool("crop-overscan", false, "hide the top and bottom 8 lines, as a TV would")
+	cropSides := flag.Int("crop-sides", 0, "also hide this many columns at the left and right edges")
+	runAhead := flag.Int("run-ahead", 0, "frames to emulate ahead to hide input lag (0-8)")
+	regionName := flag.String("region", "", "force timing region: ntsc, pal, dendy or multi (default from ROM)")
+	flag.Usage = func() {
+		fmt.Println("Usage: sdl-display [options] [rom-file]")
 		fmt.Println("Example: sdl-display ../../roms/donkeykong.nes")
-		os.Exit(1)
+		fmt.Println("Without a ROM, a boot menu lists the ROM directory and recent games.")
+		flag.PrintDefaults()
 	}
+	flag.Parse()
 
-	romPath := os.Args[1]
+	cfg := loadConfig()
+	if *romDir != "" {
+		cfg.ROMDir = *romDir
+	}
+	if *paletteName != "" {
+		cfg.Palette = *paletteName
+	}
+
+	// Core tracing, e.g. NES_LOG=ppu=debug,mapper,dma=trace
+	logging.SetHandler(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logging.LevelTrace}))
+	if err := logging.Configure(os.Getenv("NES_LOG")); err != nil {
+		log.Fatalf("Invalid NES_LOG: %v", err)
+	}
 
 	// Initialize SDL
-	if err := sdl.Init(sdl.INIT_VIDEO); err != nil {
+	if err := sdl.Init(sdl.INIT_VIDEO | sdl.INIT_AUDIO); err != nil {
 		log.Fatalf("Failed to initialize SDL: %v", err)
 	}
 	defer sdl.Quit()
 
+	// Edges hidden like a TV's overscan
+	var overscan ppu.Overscan
+	if *cropOverscan {
+		overscan = ppu.OverscanTV
+	}
+	overscan.Left, overscan.Right = *cropSides, *cropSides
+	visible := overscan.Rect()
+	srcRect := &sdl.Rect{X: int32(visible.Min.X), Y: int32(visible.Min.Y), W: int32(visible.Dx()), H: int32(visible.Dy())}
+
 	// Create window
 	window, err := sdl.CreateWindow(
-		"NES Emulator - "+romPath,
+		"NES Emulator",
 		sdl.WINDOWPOS_UNDEFINED,
 		sdl.WINDOWPOS_UNDEFINED,
-		ScreenWidth*WindowScale,
-		ScreenHeight*WindowScale,
+		srcRect.W*WindowScale,
+		srcRect.H*WindowScale,
 		sdl.WINDOW_SHOWN,
 	)
 	if err != nil {
@@ -54,10 +95,10 @@ func main() {
 	}
 	defer renderer.Destroy()
 
-	// Create texture for NES display (256x240)
-	// Try RGB24 format
+	// Create texture for NES display (256x240), in the byte order of
+	// NES.GetFrameRGBA and image.RGBA
 	texture, err := renderer.CreateTexture(
-		sdl.PIXELFORMAT_RGB24,
+		sdl.PIXELFORMAT_RGBA32,
 		sdl.TEXTUREACCESS_STREAMING,
 		ScreenWidth,
 		ScreenHeight,
@@ -67,29 +108,91 @@ func main() {
 	}
 	defer texture.Destroy()
 
+	// Pick a ROM from the boot menu if none was given
+	romPath := flag.Arg(0)
+	if romPath == "" {
+		romPath = runBootMenu(renderer, texture, cfg)
+		if romPath == "" {
+			return
+		}
+	}
+	window.SetTitle("NES Emulator - " + romPath)
+
 	// Load NES ROM
 	fmt.Printf("\nLoading ROM\n")
 	fmt.Printf("File: %s\n", romPath)
 	emulator, err := nes.New(romPath)
 	if err != nil {
+		var unsupported cartridge.ErrUnsupportedMapper
+		switch {
+		case errors.As(err, &unsupported):
+			log.Fatalf("This game uses mapper %d, which is not supported yet", unsupported.ID)
+		case errors.Is(err, cartridge.ErrBadHeader), errors.Is(err, cartridge.ErrTruncatedROM):
+			log.Fatalf("ROM file is corrupt or not an NES ROM: %v", err)
+		}
 		log.Fatalf("Failed to load ROM: %v", err)
 	}
 
+	cfg.addRecent(romPath)
+	if err := cfg.save(); err != nil {
+		fmt.Printf("Warning: could not save settings: %v\n", err)
+	}
+
 	// Show cartridge info
 	cart := emulator.GetCartridge()
 	fmt.Printf("Mapper: %d\n", cart.GetMapperID())
 	fmt.Printf("PRG Banks: %d x 16KB = %dKB\n", cart.GetPRGBanks(), cart.GetPRGBanks()*16)
 	fmt.Printf("CHR Banks: %d x 8KB = %dKB\n", cart.GetCHRBanks(), cart.GetCHRBanks()*8)
+	for _, w := range cart.Warnings() {
+		fmt.Printf("Warning: %s\n", w)
+	}
+
+	// Timing region: the ROM's, unless overridden
+	if *regionName != "" {
+		region, err := cartridge.ParseRegion(*regionName)
+		if err != nil {
+			log.Fatalf("Invalid -region: %v", err)
+		}
+		emulator.SetRegion(region)
+	}
+	fmt.Printf("Region: %s (%.2f Hz)\n", emulator.GetRegion(), emulator.FrameRate())
+
+	// Battery/EEPROM saves live next to the ROM
+	saveFile := savePath(romPath)
+	if err := loadSave(cart, saveFile); err != nil {
+		fmt.Printf("Warning: could not load save file: %v\n", err)
+	}
+
+	// Save states, game.ss0 to game.ss9 next to the ROM
+	slots := nes.NewSlotManager(emulator, romBase(romPath))
+	slot := 0
+
+	// Per-game overclocking
+	emulator.SetOverclock(nes.Overclock{
+		ExtraScanlines: *extraLines,
+		CPUMultiplier:  *cpuMultiplier,
+	})
+	if *fastRender {
+		emulator.SetRenderMode(nes.RenderFast)
+	}
+	if err := emulator.SetRunAhead(*runAhead); err != nil {
+		log.Fatalf("Invalid -run-ahead: %v", err)
+	}
+
+	// Display palette
+	palettes := newPaletteCycle(cfg.Palette)
+	emulator.GetPPU().SetPalette(palettes.current())
+	emulator.GetPPU().SetOverscan(overscan)
 
 	// Reset NES to power-on state
 	emulator.Reset()
 
-	// Buffer for RGB pixels (256x240x3 bytes)
-	pixels := make([]byte, ScreenWidth*ScreenHeight*3)
+	// Buffer for RGBA pixels
+	pixels := make([]uint32, ScreenWidth*ScreenHeight)
 
 	// Run many frames to let the game initialize
 	fmt.Println("\nInitializing (2 seconds)...")
-	for i := 0; i < 120; i++ { // ~2 seconds at 60 FPS
+	for i := 0; i < int(2*emulator.FrameRate()); i++ {
 		emulator.RunFrame()
 	}
 
@@ -98,9 +201,27 @@ func main() {
 	ctrl := emulator.GetBus().GetController(0)
 
 	fmt.Println("\nEmulator Ready")
-	fmt.Println("System: ESC=quit | P=pause | SPACE=step | R=reset | F=force render | D=debug")
+	fmt.Println("System: ESC=quit | P=pause | SPACE=step | R=reset | F=force render | D=debug | C=palette | 1-6=mute channel")
+	fmt.Println("States: F5=save | F6=next slot | F7=load")
 	fmt.Println("Game:   Arrows=D-pad | Z=B | X=A | Enter=Start | RShift=Select")
 
+	// Sound
+	var audio *audioOutput
+	if !*noAudio {
+		audio, err = openAudio(emulator.GetAPU())
+		if err != nil {
+			fmt.Printf("Warning: audio disabled: %v\n", err)
+		} else {
+			defer audio.close()
+		}
+	}
+	if audio == nil {
+		emulator.SetHeadless(nes.HeadlessSilent)
+	}
+
+	// Frame pacing at the console's real refresh rate
+	pacer := avsync.NewPacer(emulator.FrameRate())
+
 	running := true
 	paused := false
 	frameCount := 0
@@ -131,12 +252,29 @@ func main() {
 							fmt.Printf("Frame %d rendered\n", frameCount)
 						}
 						continue
+					case sdl.K_l, sdl.K_v:
+						// Step one scanline, or run to the start of VBlank,
+						// when paused
+						if paused {
+							if e.Keysym.Sym == sdl.K_l {
+								emulator.RunScanline()
+							} else {
+								emulator.RunToCycle(ppuUnit.DebugState().VBlankScanline, 1)
+							}
+							line, dot := ppuUnit.Position()
+							fmt.Printf("PPU at scanline %d, dot %d\n", line, dot)
+						}
+						continue
 					case sdl.K_p:
 						// Toggle pause
 						paused = !paused
 						if paused {
-							fmt.Println("Paused (press SPACE to step, P to resume)")
+							if audio != nil {
+								audio.clear()
+							}
+							fmt.Println("Paused (press SPACE to step a frame, L a scanline, V to VBlank, P to resume)")
 						} else {
+							pacer.Reset()
 							fmt.Println("Resumed")
 						}
 						continue
@@ -160,6 +298,15 @@ func main() {
 							fmt.Println("Forced rendering OFF (game controls PPU)")
 						}
 						continue
+					case sdl.K_c:
+						// Cycle display palettes
+						ppuUnit.SetPalette(palettes.next())
+						cfg.Palette = palettes.name()
+						if err := cfg.save(); err != nil {
+							fmt.Printf("Warning: could not save settings: %v\n", err)
+						}
+						fmt.Printf("Palette: %s\n", palettes.name())
+						continue
 					case sdl.K_d:
 						// Toggle debug output
 						debugFrame = !debugFrame
@@ -169,6 +316,46 @@ func main() {
 							fmt.Println("Debug output OFF")
 						}
 						continue
+					case sdl.K_F5:
+						// Save state to the current slot
+						if _, err := slots.SaveSlot(slot); err != nil {
+							fmt.Printf("Could not save slot %d: %v\n", slot, err)
+						} else {
+							fmt.Printf("Saved slot %d\n", slot)
+						}
+						continue
+					case sdl.K_F6:
+						// Select the next slot
+						slot = (slot + 1) % nes.NumSlots
+						if info, err := slots.Info(slot); err == nil {
+							fmt.Printf("Slot %d: frame %d, saved %s\n", slot, info.Frame, info.Time.Format("2006-01-02 15:04:05"))
+						} else {
+							fmt.Printf("Slot %d: %v\n", slot, err)
+						}
+						continue
+					case sdl.K_F7:
+						// Load state from the current slot
+						if _, err := slots.LoadSlot(slot); err != nil {
+							fmt.Printf("Could not load slot %d: %v\n", slot, err)
+							continue
+						}
+						if audio != nil {
+							audio.clear()
+						}
+						pacer.Reset()
+						fmt.Printf("Loaded slot %d\n", slot)
+						continue
+					case sdl.K_1, sdl.K_2, sdl.K_3, sdl.K_4, sdl.K_5, sdl.K_6:
+						// Mute/unmute an APU channel
+						ch := apu.Channel(e.Keysym.Sym - sdl.K_1)
+						a := emulator.GetAPU()
+						a.SetChannelEnabled(ch, !a.ChannelEnabled(ch))
+						if a.ChannelEnabled(ch) {
+							fmt.Printf("Channel %s ON\n", ch)
+						} else {
+							fmt.Printf("Channel %s muted\n", ch)
+						}
+						continue
 					}
 				}
 
@@ -198,38 +385,24 @@ func main() {
 		if !paused {
 			emulator.RunFrame()
 			frameCount++
+			if audio != nil {
+				audio.queue()
+			}
 		}
 
-		// Convert frame buffer to RGB
-		frameBuffer := emulator.GetFrameBuffer()
+		// Convert frame buffer to RGBA
+		ppuUnit.FrameRGBA(pixels)
 
 		// Track unique colors for debug info
 		colorCounts := make(map[uint8]int)
 		uniqueColors := 0
-
-		for i := 0; i < ScreenWidth*ScreenHeight; i++ {
-			paletteIndex := frameBuffer[i]
-
-			// Track color usage
-			if colorCounts[paletteIndex] == 0 {
-				uniqueColors++
-			}
-			colorCounts[paletteIndex]++
-
-			// Bounds check - palette indices should be 0-63
-			if paletteIndex >= 64 {
-				if debugFrame {
-					fmt.Printf("ERROR: palette index %d out of bounds at pixel %d\n", paletteIndex, i)
+		if debugFrame {
+			for _, paletteIndex := range emulator.GetFrameBuffer() {
+				if colorCounts[paletteIndex] == 0 {
+					uniqueColors++
 				}
-				paletteIndex = 0x0F // Black
+				colorCounts[paletteIndex]++
 			}
-
-			color := ppu.HardwarePalette[paletteIndex]
-
-			// Write pixels in RGB order for RGB24 format
-			pixels[i*3+0] = color.R
-			pixels[i*3+1] = color.G
-			pixels[i*3+2] = color.B
 		}
 
 		// Show periodic status updates
@@ -253,19 +426,23 @@ func main() {
 			}
 		}
 
-		texture.Update(nil, unsafe.Pointer(&pixels[0]), ScreenWidth*3)
+		texture.Update(nil, unsafe.Pointer(&pixels[0]), ScreenWidth*4)
 
 		renderer.Clear()
-		renderer.Copy(texture, nil, nil)
+		renderer.Copy(texture, srcRect, nil)
 		renderer.Present()
 
-		// ~60 FPS
 		if !paused {
-			sdl.Delay(16)
+			pacer.FrameDone()
+			pacer.Wait(context.Background())
 		} else {
 			sdl.Delay(100) // Slower refresh when paused
 		}
 	}
 
 	fmt.Printf("\nTotal frames rendered: %d\n", frameCount)
+
+	if err := writeSave(cart, saveFile); err != nil {
+		fmt.Printf("Warning: could not write save file: %v\n", err)
+	}
 }
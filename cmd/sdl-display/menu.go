@@ -0,0 +1,230 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unsafe"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// Boot menu layout, in NES pixels
+const (
+	menuLineHeight = 13
+	menuTop        = 40 // First entry's baseline area
+	menuRows       = 14 // Entries visible at once
+	menuTextX      = 12
+	menuMaxChars   = 32 // Longer names are truncated
+)
+
+// Boot menu colors
+var (
+	menuBackground = color.RGBA{0, 0, 88, 255}
+	menuTitle      = color.RGBA{252, 216, 168, 255}
+	menuText       = color.RGBA{236, 238, 236, 255}
+	menuDim        = color.RGBA{152, 150, 152, 255}
+	menuHighlight  = color.RGBA{48, 50, 236, 255}
+)
+
+// menuEntry is one selectable line of the boot menu
+type menuEntry struct {
+	label  string
+	path   string
+	dir    bool
+	recent bool
+}
+
+// bootMenu is the ROM picker shown when no ROM is given on the command line
+type bootMenu struct {
+	cfg     *config
+	dir     string
+	entries []menuEntry
+	cursor  int
+	scroll  int
+	status  string
+}
+
+// newBootMenu opens the menu on the configured ROM directory
+func newBootMenu(cfg *config) *bootMenu {
+	dir, err := filepath.Abs(cfg.ROMDir)
+	if err != nil {
+		dir = cfg.ROMDir
+	}
+	m := &bootMenu{cfg: cfg}
+	m.open(dir)
+	return m
+}
+
+// open lists a directory: recent ROMs first, then "..", subdirectories
+// and .nes files
+func (m *bootMenu) open(dir string) {
+	m.dir = dir
+	m.entries = m.entries[:0]
+	m.cursor, m.scroll, m.status = 0, 0, ""
+
+	for _, p := range m.cfg.Recent {
+		if _, err := os.Stat(p); err == nil {
+			m.entries = append(m.entries, menuEntry{label: filepath.Base(p), path: p, recent: true})
+		}
+	}
+
+	if parent := filepath.Dir(dir); parent != dir {
+		m.entries = append(m.entries, menuEntry{label: "..", path: parent, dir: true})
+	}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		m.status = "Cannot read directory"
+		return
+	}
+	var dirs, roms []menuEntry
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, f.Name())
+		switch {
+		case f.IsDir():
+			dirs = append(dirs, menuEntry{label: f.Name() + "/", path: path, dir: true})
+		case strings.EqualFold(filepath.Ext(f.Name()), ".nes"):
+			roms = append(roms, menuEntry{label: f.Name(), path: path})
+		}
+	}
+	byLabel := func(a, b menuEntry) int { return strings.Compare(strings.ToLower(a.label), strings.ToLower(b.label)) }
+	slices.SortFunc(dirs, byLabel)
+	slices.SortFunc(roms, byLabel)
+	m.entries = append(m.entries, dirs...)
+	m.entries = append(m.entries, roms...)
+
+	if len(roms) == 0 && len(dirs) == 0 {
+		m.status = "No .nes files here"
+	}
+}
+
+// move shifts the cursor, keeping it on screen
+func (m *bootMenu) move(delta int) {
+	if len(m.entries) == 0 {
+		return
+	}
+	m.cursor = min(max(m.cursor+delta, 0), len(m.entries)-1)
+	if m.cursor < m.scroll {
+		m.scroll = m.cursor
+	}
+	if m.cursor >= m.scroll+menuRows {
+		m.scroll = m.cursor - menuRows + 1
+	}
+}
+
+// activate opens the selected directory or returns the selected ROM
+func (m *bootMenu) activate() string {
+	if m.cursor >= len(m.entries) {
+		return ""
+	}
+	e := m.entries[m.cursor]
+	if e.dir {
+		m.open(e.path)
+		return ""
+	}
+	return e.path
+}
+
+// draw renders the menu into a 256x240 image
+func (m *bootMenu) draw(img *image.RGBA) {
+	draw.Draw(img, img.Bounds(), image.NewUniform(menuBackground), image.Point{}, draw.Src)
+
+	text := func(x, y int, c color.Color, s string) {
+		d := font.Drawer{Dst: img, Src: image.NewUniform(c), Face: basicfont.Face7x13, Dot: fixed.P(x, y)}
+		d.DrawString(s)
+	}
+
+	text(menuTextX, 14, menuTitle, "NES EMULATOR")
+	text(menuTextX, 28, menuDim, truncateLeft(m.dir, menuMaxChars))
+
+	for row := 0; row < menuRows && m.scroll+row < len(m.entries); row++ {
+		i := m.scroll + row
+		e := m.entries[i]
+		top := menuTop + row*menuLineHeight
+		if i == m.cursor {
+			draw.Draw(img, image.Rect(menuTextX-4, top, ScreenWidth-menuTextX+4, top+menuLineHeight),
+				image.NewUniform(menuHighlight), image.Point{}, draw.Src)
+		}
+
+		c := menuText
+		label := e.label
+		if e.recent {
+			label = "* " + label
+			c = menuTitle
+		}
+		if len(label) > menuMaxChars {
+			label = label[:menuMaxChars-1] + "~"
+		}
+		text(menuTextX, top+menuLineHeight-3, c, label)
+	}
+
+	footer := "Enter:Play Bksp:Up Esc:Quit"
+	if m.status != "" {
+		footer = m.status
+	}
+	text(menuTextX, ScreenHeight-6, menuDim, footer)
+}
+
+// truncateLeft shortens s to n characters, keeping its end
+func truncateLeft(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return "~" + s[len(s)-n+1:]
+}
+
+// runBootMenu shows the menu until a ROM is picked; it returns "" if the
+// user quits
+func runBootMenu(renderer *sdl.Renderer, texture *sdl.Texture, cfg *config) string {
+	menu := newBootMenu(cfg)
+	img := image.NewRGBA(image.Rect(0, 0, ScreenWidth, ScreenHeight))
+
+	for {
+		for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+			switch e := event.(type) {
+			case *sdl.QuitEvent:
+				return ""
+			case *sdl.KeyboardEvent:
+				if e.Type != sdl.KEYDOWN {
+					continue
+				}
+				switch e.Keysym.Sym {
+				case sdl.K_ESCAPE:
+					return ""
+				case sdl.K_UP:
+					menu.move(-1)
+				case sdl.K_DOWN:
+					menu.move(1)
+				case sdl.K_PAGEUP:
+					menu.move(-menuRows)
+				case sdl.K_PAGEDOWN:
+					menu.move(menuRows)
+				case sdl.K_BACKSPACE, sdl.K_LEFT:
+					menu.open(filepath.Dir(menu.dir))
+				case sdl.K_RETURN, sdl.K_RIGHT, sdl.K_x:
+					if rom := menu.activate(); rom != "" {
+						return rom
+					}
+				}
+			}
+		}
+
+		menu.draw(img)
+		texture.Update(nil, unsafe.Pointer(&img.Pix[0]), img.Stride)
+		renderer.Clear()
+		renderer.Copy(texture, nil, nil)
+		renderer.Present()
+		sdl.Delay(16)
+	}
+}
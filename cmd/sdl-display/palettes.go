@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
+)
+
+// paletteChoice is one entry of the palette cycle
+type paletteChoice struct {
+	name    string
+	palette *ppu.Palette
+}
+
+// paletteCycle is the list of palettes the C key steps through: the
+// built-in presets, plus the user's .pal file if one is configured
+type paletteCycle struct {
+	choices []paletteChoice
+	index   int
+}
+
+// newPaletteCycle starts at the configured palette, which is a preset
+// name or a .pal file path; unknown names fall back to the default
+func newPaletteCycle(selected string) *paletteCycle {
+	c := &paletteCycle{}
+	for _, p := range ppu.PalettePresets {
+		c.choices = append(c.choices, paletteChoice{p.Name, p.Palette()})
+	}
+
+	if selected == "" {
+		return c
+	}
+	if _, ok := ppu.LookupPalettePreset(selected); !ok {
+		pal, err := ppu.LoadPalette(selected)
+		if err != nil {
+			fmt.Printf("Warning: palette %q: %v\n", selected, err)
+			return c
+		}
+		c.choices = append(c.choices, paletteChoice{selected, pal})
+	}
+	for i, choice := range c.choices {
+		if choice.name == selected {
+			c.index = i
+		}
+	}
+	return c
+}
+
+// current returns the selected palette
+func (c *paletteCycle) current() *ppu.Palette {
+	return c.choices[c.index].palette
+}
+
+// name returns the selected preset name or .pal path
+func (c *paletteCycle) name() string {
+	return c.choices[c.index].name
+}
+
+// next selects and returns the following palette
+func (c *paletteCycle) next() *ppu.Palette {
+	c.index = (c.index + 1) % len(c.choices)
+	return c.current()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+)
+
+// romBase returns a ROM path without its extension: game.nes -> game
+func romBase(romPath string) string {
+	if i := strings.LastIndexByte(romPath, '.'); i > strings.LastIndexAny(romPath, `/\`) {
+		romPath = romPath[:i]
+	}
+	return romPath
+}
+
+// savePath returns the save file path next to a ROM: game.nes -> game.sav
+func savePath(romPath string) string {
+	return romBase(romPath) + ".sav"
+}
+
+// loadSave copies an existing save file into the cartridge's save memory.
+// A missing file or a cartridge without saves is not an error.
+func loadSave(cart *cartridge.Cartridge, path string) error {
+	mem := cart.GetSaveData()
+	if mem == nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	copy(mem, data)
+	return nil
+}
+
+// writeSave writes the cartridge's save memory to path, if it has any
+func writeSave(cart *cartridge.Cartridge, path string) error {
+	mem := cart.GetSaveData()
+	if mem == nil {
+		return nil
+	}
+	return os.WriteFile(path, mem, 0o644)
+}
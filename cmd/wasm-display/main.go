@@ -5,11 +5,12 @@ package main
 import (
 	"fmt"
 	"syscall/js"
+	"unsafe"
 
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/avsync"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/controller"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
-	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
 )
 
 const (
@@ -28,22 +29,9 @@ var (
 	paused      bool
 	loopStarted bool
 
-	pixels      []byte
-	rgbaPalette [64][4]byte
-
-	lastFrameTime float64
-	frameInterval float64 = 1000.0 / 60.0
+	pacer *avsync.Pacer
 )
 
-func init() {
-	pixels = make([]byte, screenWidth*screenHeight*4)
-
-	for i := 0; i < 64; i++ {
-		color := ppu.HardwarePalette[i]
-		rgbaPalette[i] = [4]byte{color.R, color.G, color.B, 255}
-	}
-}
-
 func main() {
 	fmt.Println("NES Emulator WASM initialized")
 
@@ -94,6 +82,7 @@ func loadROM(this js.Value, args []js.Value) interface{} {
 
 	running = true
 	paused = false
+	pacer = avsync.NewPacer(emulator.FrameRate())
 	if !loopStarted {
 		loopStarted = true
 		renderLoopFunc = js.FuncOf(renderLoop)
@@ -118,33 +107,25 @@ func renderLoop(this js.Value, args []js.Value) interface{} {
 		return nil
 	}
 
-	now := args[0].Float()
-
-	elapsed := now - lastFrameTime
-	if elapsed < frameInterval {
-		return nil
+	// requestAnimationFrame follows the display refresh, which need not
+	// match the console's; run however many frames are due (if any)
+	ran := false
+	for i := 0; i < avsync.DefaultMaxLag && pacer.Until() <= 0; i++ {
+		emulator.RunFrame()
+		pacer.FrameDone()
+		ran = true
+	}
+	if ran {
+		renderFrame()
 	}
-	lastFrameTime = now
-
-	emulator.RunFrame()
-
-	renderFrame()
 
 	return nil
 }
 
 func renderFrame() {
-	frameBuffer := emulator.GetFrameBuffer()
-
-	for i := 0; i < screenWidth*screenHeight; i++ {
-		paletteIndex := frameBuffer[i] & 0x3F
-		rgba := rgbaPalette[paletteIndex]
-		offset := i * 4
-		pixels[offset+0] = rgba[0]
-		pixels[offset+1] = rgba[1]
-		pixels[offset+2] = rgba[2]
-		pixels[offset+3] = rgba[3]
-	}
+	// The packed pixels are already in ImageData's RGBA byte order
+	rgba := emulator.GetFrameRGBA()
+	pixels := unsafe.Slice((*byte)(unsafe.Pointer(&rgba[0])), len(rgba)*4)
 
 	js.CopyBytesToJS(pixelArray, pixels)
 
@@ -167,6 +148,9 @@ func pause(this js.Value, args []js.Value) interface{} {
 
 func resume(this js.Value, args []js.Value) interface{} {
 	paused = false
+	if pacer != nil {
+		pacer.Reset()
+	}
 	fmt.Println("Emulator resumed")
 	return nil
 }
@@ -23,6 +23,7 @@ func main() {
 	}
 
 	emulator.Reset()
+	emulator.SetWatchdog(&nes.Watchdog{Frames: 60, OnStuck: reportStuck})
 	cpu := emulator.GetCPU()
 	bus := emulator.GetBus()
 
@@ -34,8 +35,9 @@ func main() {
 		emulator.RunFrame()
 
 		if frame%30 == 0 || frame < 10 {
-			// Read PPU status
-			ppuStatus := bus.Read(0x2002)
+			// Peek PPU status; a real read would clear VBlank and the
+			// $2005/$2006 write latch under the game
+			ppuStatus := bus.Peek(0x2002)
 
 			// Count unique palette indices
 			frameBuffer := emulator.GetFrameBuffer()
@@ -44,8 +46,9 @@ func main() {
 				paletteCounts[idx] = true
 			}
 
+			regs := cpu.DebugState()
 			fmt.Printf("%5d | $%04X | $%02X | $%02X | $%02X | $%02X        | %d\n",
-				frame, cpu.PC, cpu.A, cpu.X, cpu.Y, ppuStatus, len(paletteCounts))
+				frame, regs.PC, regs.A, regs.X, regs.Y, ppuStatus, len(paletteCounts))
 		}
 	}
 
@@ -73,3 +76,14 @@ func main() {
 		fmt.Printf("Frame %3d: Center region sum = %d\n", targetFrame, centerSum)
 	}
 }
+
+// reportStuck prints the watchdog's view of a frozen game
+func reportStuck(e nes.StuckEvent) {
+	fmt.Printf("\n*** CPU stuck for %d frames at frame %d (loop $%04X-$%04X) ***\n",
+		e.Frames, e.Frame, e.LoopStart, e.LoopEnd)
+	for _, line := range e.Disassembly {
+		fmt.Printf("    %s\n", line)
+	}
+	fmt.Printf("    %s  PPUCTRL:$%02X PPUMASK:$%02X PPUSTATUS:$%02X scanline %d\n\n",
+		e.CPU.Flags(), e.PPU.Control, e.PPU.Mask, e.PPU.Status, e.PPU.Scanline)
+}
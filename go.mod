@@ -2,6 +2,6 @@ module github.com/andrewthecodertx/go-nes-emulator
 
 go 1.25.3
 
-require github.com/andrewthecodertx/go-6502-emulator v0.1.0
-
 require github.com/veandco/go-sdl2 v0.4.40
+
+require golang.org/x/image v0.24.0
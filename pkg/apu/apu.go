@@ -0,0 +1,434 @@
+// Package apu implements the NES Audio Processing Unit (2A03 sound).
+//
+// The APU generates sound from five channels mixed into a single output.
+// It runs on the CPU clock and is driven one CPU cycle at a time by the bus.
+//
+// Hardware Specifications:
+//   - Clock speed: CPU clock (~1.79 MHz NTSC / ~1.66 MHz PAL)
+//   - Two pulse channels with duty cycle, envelope, sweep and length counter
+//   - Triangle channel with linear counter and 32-step waveform
+//   - Noise channel driven by a 15-bit LFSR with long and short modes
+//   - Delta modulation channel (DMC) playing samples from CPU memory by DMA,
+//     stalling the CPU for each fetched byte
+//   - Frame counter: 4-step (~240 Hz with IRQ) or 5-step sequence that
+//     clocks envelopes, sweeps and length counters
+//   - Non-linear mixer combining the channels into one analog output
+//
+// The mixed output is resampled to the host sample rate (SetSampleRate) with
+// band-limited steps, passed through a filter chain modelling the console's
+// analog output (SetFilters), and read with ReadSamples or Samples.
+//
+// Register Map:
+//   - $4000-$4003: Pulse 1
+//   - $4004-$4007: Pulse 2
+//   - $4008-$400B: Triangle
+//   - $400C-$400F: Noise
+//   - $4010-$4013: DMC
+//   - $4015: Channel enable (write) / status (read)
+//   - $4017: Frame counter mode and IRQ inhibit
+package apu
+
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// DefaultSampleRate is the output sample rate until SetSampleRate is called
+const DefaultSampleRate = 44100
+
+// Frame counter step timings in CPU cycles, for the 4-step and 5-step
+// sequences. NTSC and Dendy share the NTSC table.
+var (
+	frameStepsNTSC = [2][5]uint32{
+		{7457, 14913, 22371, 29829, 29830},
+		{7457, 14913, 22371, 29829, 37282},
+	}
+	frameStepsPAL = [2][5]uint32{
+		{8313, 16627, 24939, 33253, 33254},
+		{8313, 16627, 24939, 33253, 41566},
+	}
+)
+
+// APU represents the NES Audio Processing Unit
+type APU struct {
+	pulse1 pulse
+	pulse2 pulse
+	tri    triangle
+	noise  noise
+	dmc    dmc
+
+	expansion Expansion // Cartridge sound, if any
+
+	// Frame counter ($4017)
+	frameSteps   *[2][5]uint32
+	frameMode    uint8  // 0 = 4-step, 1 = 5-step
+	frameCycle   uint32 // CPU cycles into the current sequence
+	irqInhibit   bool
+	frameIRQ     bool
+	evenCycle    bool // Pulse timers tick every other CPU cycle
+	outputActive bool // Produce samples (see SetOutputEnabled)
+	outputHeld   bool // Generation paused (see HoldOutput)
+
+	// Mixing (see SetChannelEnabled and SetChannelGain)
+	muted     [NumChannels]bool
+	gain      [NumChannels]float32
+	mixGain   [NumChannels]float32 // Gain with mutes applied
+	mixTables bool                 // All channels at unity gain
+
+	// Sample generation (see blipBuffer)
+	clockRate  float64 // CPU clock in Hz
+	sampleRate float64
+	blip       blipBuffer
+	filters    []filter
+	samples    []float32 // Scratch for Samples
+}
+
+// NewAPU creates an APU in its power-on state, timed for NTSC
+func NewAPU() *APU {
+	a := &APU{
+		pulse1:       pulse{sweepOnesComplement: true},
+		noise:        noise{periods: &noisePeriodsNTSC, period: noisePeriodsNTSC[0], shift: 1},
+		dmc:          dmc{rates: &dmcRatesNTSC, rate: dmcRatesNTSC[0], bufferEmpty: true, bitsRemaining: 8, silence: true},
+		frameSteps:   &frameStepsNTSC,
+		outputActive: true,
+		clockRate:    1789773,
+		sampleRate:   DefaultSampleRate,
+	}
+	for c := range a.gain {
+		a.gain[c] = 1
+	}
+	a.updateMix()
+	a.updateSampleTiming()
+	a.SetFilters(HardwareFilters)
+	return a
+}
+
+// PowerOn puts the frame counter back in 4-step mode with IRQs enabled, as
+// at power-on, then resets the APU
+func (a *APU) PowerOn() {
+	a.frameMode = 0
+	a.irqInhibit = false
+	a.Reset()
+}
+
+// Reset silences all channels and restarts the frame counter, as the
+// console's reset line does. The frame counter mode is kept.
+func (a *APU) Reset() {
+	a.WriteRegister(0x4015, 0)
+	a.frameCycle = 0
+	a.frameIRQ = false
+	a.blip.reset()
+}
+
+// SetRegion selects the frame counter, noise and DMC timing for a console
+// region
+func (a *APU) SetRegion(region cartridge.Region) {
+	if region == cartridge.RegionPAL {
+		a.frameSteps = &frameStepsPAL
+		a.noise.periods = &noisePeriodsPAL
+		a.dmc.rates = &dmcRatesPAL
+	} else {
+		a.frameSteps = &frameStepsNTSC
+		a.noise.periods = &noisePeriodsNTSC
+		a.dmc.rates = &dmcRatesNTSC
+	}
+}
+
+// SetMemoryReader sets the function the DMC uses to fetch sample bytes from
+// CPU memory. Until it is set, samples read as zero.
+func (a *APU) SetMemoryReader(read func(addr uint16) uint8) {
+	a.dmc.read = read
+}
+
+// TakeStall returns the number of CPU cycles the CPU must be halted for DMC
+// sample fetches since the last call
+func (a *APU) TakeStall() uint8 {
+	stall := a.dmc.stall
+	a.dmc.stall = 0
+	return stall
+}
+
+// SetClockRate sets the CPU clock frequency in Hz, used to convert APU
+// cycles into output samples
+func (a *APU) SetClockRate(hz float64) {
+	a.clockRate = hz
+	a.updateSampleTiming()
+}
+
+// SetSampleRate sets the output sample rate in Hz. It can be changed at
+// any time, e.g. by dynamic rate control, without disturbing the output.
+func (a *APU) SetSampleRate(hz float64) {
+	a.sampleRate = hz
+	a.updateSampleTiming()
+}
+
+// SampleRate returns the output sample rate in Hz
+func (a *APU) SampleRate() float64 {
+	return a.sampleRate
+}
+
+func (a *APU) updateSampleTiming() {
+	a.blip.setRatio(a.clockRate, a.sampleRate)
+	a.updateFilters()
+}
+
+// SetOutputEnabled turns sample generation on or off. Channel state keeps
+// running either way, so $4015 status and IRQ timing are unaffected.
+func (a *APU) SetOutputEnabled(enabled bool) {
+	a.outputActive = enabled
+	if !enabled {
+		a.blip.reset()
+	}
+}
+
+// HoldOutput pauses or resumes sample generation without discarding the
+// samples not yet read, so the APU can be run ahead speculatively and
+// restored from a saved state (see nes.NES.SetRunAhead). The output picks
+// up where it left off as long as the state is restored before resuming.
+func (a *APU) HoldOutput(hold bool) {
+	a.outputHeld = hold
+}
+
+// SamplesAvailable returns the number of output samples ready to read
+func (a *APU) SamplesAvailable() int {
+	return a.blip.available()
+}
+
+// ReadSamples moves up to len(dst) generated samples into dst, oldest
+// first, and returns the number read. Samples left unread for a second of
+// output are discarded.
+func (a *APU) ReadSamples(dst []float32) int {
+	n := a.blip.read(dst, len(dst))
+	a.filterSamples(dst[:n])
+	return n
+}
+
+// Samples reads all available samples. The slice is only valid until the
+// next call to Samples.
+func (a *APU) Samples() []float32 {
+	n := a.blip.available()
+	if cap(a.samples) < n {
+		a.samples = make([]float32, n)
+	}
+	a.samples = a.samples[:n]
+	return a.samples[:a.ReadSamples(a.samples)]
+}
+
+// ReadStatus reads $4015: length counter status per channel (bits 0-3), DMC
+// active (bit 4), and the frame (bit 6) and DMC (bit 7) interrupt flags.
+// Reading clears the frame interrupt.
+func (a *APU) ReadStatus() uint8 {
+	var value uint8
+	if a.pulse1.length.counter > 0 {
+		value |= 0x01
+	}
+	if a.pulse2.length.counter > 0 {
+		value |= 0x02
+	}
+	if a.tri.length.counter > 0 {
+		value |= 0x04
+	}
+	if a.noise.length.counter > 0 {
+		value |= 0x08
+	}
+	if a.dmc.bytesRemaining > 0 {
+		value |= 0x10
+	}
+	if a.frameIRQ {
+		value |= 0x40
+	}
+	if a.dmc.irq {
+		value |= 0x80
+	}
+	a.frameIRQ = false
+	return value
+}
+
+// PeekStatus returns $4015 without clearing the frame interrupt
+func (a *APU) PeekStatus() uint8 {
+	irq := a.frameIRQ
+	value := a.ReadStatus()
+	a.frameIRQ = irq
+	return value
+}
+
+// WriteRegister handles CPU writes to $4000-$4017
+func (a *APU) WriteRegister(addr uint16, value uint8) {
+	if logging.Enabled(logging.APU, logging.LevelTrace) {
+		logging.Log(logging.APU, logging.LevelTrace, "register write", logging.Hex16("addr", addr), logging.Hex8("value", value))
+	}
+
+	switch {
+	case addr >= 0x4000 && addr <= 0x4003:
+		a.pulse1.write(addr&3, value)
+
+	case addr >= 0x4004 && addr <= 0x4007:
+		a.pulse2.write(addr&3, value)
+
+	case addr >= 0x4008 && addr <= 0x400B:
+		a.tri.write(addr&3, value)
+
+	case addr >= 0x400C && addr <= 0x400F:
+		a.noise.write(addr&3, value)
+
+	case addr >= 0x4010 && addr <= 0x4013:
+		a.dmc.write(addr&3, value)
+
+	case addr == 0x4015:
+		a.pulse1.length.setEnabled(value&0x01 != 0)
+		a.pulse2.length.setEnabled(value&0x02 != 0)
+		a.tri.length.setEnabled(value&0x04 != 0)
+		a.noise.length.setEnabled(value&0x08 != 0)
+		a.dmc.setEnabled(value&0x10 != 0)
+
+	case addr == 0x4017:
+		a.frameMode = value >> 7
+		a.irqInhibit = value&0x40 != 0
+		if a.irqInhibit {
+			a.frameIRQ = false
+		}
+		a.frameCycle = 0
+		// 5-step mode clocks the units immediately
+		if a.frameMode == 1 {
+			a.quarterFrame()
+			a.halfFrame()
+		}
+		if logging.Enabled(logging.APU, slog.LevelDebug) {
+			logging.Log(logging.APU, slog.LevelDebug, "frame counter", "mode", a.frameMode, "irq_inhibit", a.irqInhibit)
+		}
+	}
+}
+
+// IRQ reports whether the APU is asserting the CPU IRQ line
+func (a *APU) IRQ() bool {
+	return a.frameIRQ || a.dmc.irq
+}
+
+// Clock advances the APU by one CPU cycle
+func (a *APU) Clock() {
+	a.clockFrameCounter()
+
+	a.tri.clockTimer()
+	a.noise.clockTimer()
+	a.dmc.clock()
+	if a.expansion != nil {
+		a.expansion.ClockAudio()
+	}
+	a.evenCycle = !a.evenCycle
+	if a.evenCycle {
+		a.pulse1.clockTimer()
+		a.pulse2.clockTimer()
+	}
+
+	if a.outputActive && !a.outputHeld {
+		a.blip.clock(a.output())
+		if excess := a.blip.available() - int(a.sampleRate); excess > 0 {
+			// Nobody is collecting samples; don't grow without bound
+			a.blip.read(nil, excess)
+		}
+	}
+}
+
+// clockFrameCounter advances the frame sequencer by one CPU cycle
+func (a *APU) clockFrameCounter() {
+	a.frameCycle++
+	steps := &a.frameSteps[a.frameMode]
+
+	switch a.frameCycle {
+	case steps[0], steps[2]:
+		a.quarterFrame()
+	case steps[1]:
+		a.quarterFrame()
+		a.halfFrame()
+	case steps[3]:
+		if a.frameMode == 0 {
+			a.quarterFrame()
+			a.halfFrame()
+			a.setFrameIRQ()
+		}
+	case steps[4]:
+		if a.frameMode == 0 {
+			a.setFrameIRQ()
+		} else {
+			a.quarterFrame()
+			a.halfFrame()
+		}
+		a.frameCycle = 0
+	}
+}
+
+func (a *APU) setFrameIRQ() {
+	if !a.irqInhibit {
+		a.frameIRQ = true
+	}
+}
+
+// quarterFrame clocks the envelopes (and the triangle's linear counter)
+func (a *APU) quarterFrame() {
+	a.pulse1.envelope.clock()
+	a.pulse2.envelope.clock()
+	a.noise.envelope.clock()
+	a.tri.clockLinear()
+}
+
+// halfFrame clocks the length counters and sweep units
+func (a *APU) halfFrame() {
+	a.pulse1.length.clock()
+	a.pulse2.length.clock()
+	a.tri.length.clock()
+	a.noise.length.clock()
+	a.pulse1.clockSweep()
+	a.pulse2.clockSweep()
+}
+
+// output returns the mixed channel output, nominally in the range [0, 1]
+func (a *APU) output() float32 {
+	var out float32
+	if a.expansion != nil {
+		out = a.mixGain[ExpansionAudio] * a.expansion.AudioOutput()
+	}
+	if a.mixTables {
+		return out + pulseMix[a.pulse1.output()+a.pulse2.output()] +
+			tndMix[3*a.tri.output()+2*a.noise.output()+a.dmc.output()]
+	}
+	g := &a.mixGain
+	return out + mixLevels(
+		g[Pulse1]*float32(a.pulse1.output()),
+		g[Pulse2]*float32(a.pulse2.output()),
+		g[Triangle]*float32(a.tri.output()),
+		g[Noise]*float32(a.noise.output()),
+		g[DMC]*float32(a.dmc.output()),
+	)
+}
+
+// WriteState appends the channel, frame counter and sample timing state to w
+func (a *APU) WriteState(w *state.Writer) {
+	a.pulse1.writeState(w)
+	a.pulse2.writeState(w)
+	a.tri.writeState(w)
+	a.noise.writeState(w)
+	a.dmc.writeState(w)
+	w.U8(a.frameMode)
+	w.U32(a.frameCycle)
+	w.Bool(a.irqInhibit)
+	w.Bool(a.frameIRQ)
+	w.Bool(a.evenCycle)
+}
+
+// ReadState restores state written by WriteState. Errors are recorded in r
+// (see state.Reader.Err).
+func (a *APU) ReadState(r *state.Reader) {
+	a.pulse1.readState(r)
+	a.pulse2.readState(r)
+	a.tri.readState(r)
+	a.noise.readState(r)
+	a.dmc.readState(r)
+	a.frameMode = r.U8()
+	a.frameCycle = r.U32()
+	a.irqInhibit = r.Bool()
+	a.frameIRQ = r.Bool()
+	a.evenCycle = r.Bool()
+}
@@ -0,0 +1,74 @@
+package apu
+
+import "fmt"
+
+// Channel identifies one of the APU's sound channels
+type Channel uint8
+
+const (
+	Pulse1 Channel = iota
+	Pulse2
+	Triangle
+	Noise
+	DMC
+	ExpansionAudio // Cartridge sound (see SetExpansion)
+
+	NumChannels
+)
+
+var channelNames = [NumChannels]string{"pulse1", "pulse2", "triangle", "noise", "dmc", "expansion"}
+
+// String returns the channel's lowercase name
+func (c Channel) String() string {
+	if c < NumChannels {
+		return channelNames[c]
+	}
+	return fmt.Sprintf("channel(%d)", c)
+}
+
+// SetChannelEnabled mutes or unmutes a channel in the mix. A muted
+// channel keeps running, so $4015 status and IRQs are unaffected.
+func (a *APU) SetChannelEnabled(c Channel, enabled bool) {
+	if c < NumChannels {
+		a.muted[c] = !enabled
+		a.updateMix()
+	}
+}
+
+// ChannelEnabled reports whether a channel is audible in the mix
+func (a *APU) ChannelEnabled(c Channel) bool {
+	return c < NumChannels && !a.muted[c]
+}
+
+// SetChannelGain scales a channel's level before mixing (1 = unchanged).
+// The gain is kept while the channel is muted.
+func (a *APU) SetChannelGain(c Channel, gain float32) {
+	if c < NumChannels {
+		a.gain[c] = max(gain, 0)
+		a.updateMix()
+	}
+}
+
+// ChannelGain returns a channel's gain
+func (a *APU) ChannelGain(c Channel) float32 {
+	if c < NumChannels {
+		return a.gain[c]
+	}
+	return 0
+}
+
+// updateMix recomputes the effective channel gains and picks the mixer:
+// the lookup tables when every channel is at unity gain, otherwise the
+// mixer formulas evaluated per cycle
+func (a *APU) updateMix() {
+	a.mixTables = true
+	for c := range a.mixGain {
+		a.mixGain[c] = a.gain[c]
+		if a.muted[c] {
+			a.mixGain[c] = 0
+		}
+		if a.mixGain[c] != 1 {
+			a.mixTables = false
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package apu
+
+import "github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+
+// DMC output rates in CPU cycles per bit, indexed by the 4-bit rate field
+var (
+	dmcRatesNTSC = [16]uint16{428, 380, 340, 320, 286, 254, 226, 214, 190, 160, 142, 128, 106, 84, 72, 54}
+	dmcRatesPAL  = [16]uint16{398, 354, 316, 298, 276, 236, 210, 198, 176, 148, 132, 118, 98, 78, 66, 50}
+)
+
+// dmcFetchStall is the number of CPU cycles a DMC sample fetch halts the CPU
+const dmcFetchStall = 4
+
+// dmc is the delta modulation channel, which plays 1-bit delta-encoded
+// samples fetched from CPU memory by DMA
+//
+// Registers (relative to $4010):
+//
+//	0: IL-- RRRR  IRQ enable, loop, rate index
+//	1: -DDD DDDD  direct load of the output level
+//	2: AAAA AAAA  sample address = $C000 + A*64
+//	3: LLLL LLLL  sample length = L*16 + 1 bytes
+//
+// Each sample byte is fetched by the memory reader when the one-byte buffer
+// empties, stalling the CPU for a few cycles. The output unit shifts the
+// byte out a bit per timer period, moving the 7-bit level up or down by 2.
+type dmc struct {
+	rates    *[16]uint16
+	rate     uint16
+	timerVal uint16
+
+	irqEnabled bool
+	loop       bool
+	irq        bool
+
+	// Memory reader
+	sampleAddr     uint16
+	sampleLength   uint16
+	currentAddr    uint16
+	bytesRemaining uint16
+	buffer         uint8
+	bufferEmpty    bool
+
+	// Output unit
+	shift         uint8
+	bitsRemaining uint8
+	silence       bool
+	level         uint8 // 7-bit output level
+
+	read  func(addr uint16) uint8 // CPU memory, for sample fetches
+	stall uint8                   // CPU cycles owed to sample fetches
+}
+
+// write handles a write to one of the channel's registers
+func (d *dmc) write(reg uint16, value uint8) {
+	switch reg {
+	case 0:
+		d.irqEnabled = value&0x80 != 0
+		d.loop = value&0x40 != 0
+		d.rate = d.rates[value&0x0F]
+		if !d.irqEnabled {
+			d.irq = false
+		}
+	case 1:
+		d.level = value & 0x7F
+	case 2:
+		d.sampleAddr = 0xC000 | uint16(value)<<6
+	case 3:
+		d.sampleLength = uint16(value)<<4 | 1
+	}
+}
+
+// setEnabled applies the DMC's $4015 enable bit: disabling stops the
+// sample after the buffered byte; enabling starts it if it had finished
+func (d *dmc) setEnabled(enabled bool) {
+	d.irq = false
+	if !enabled {
+		d.bytesRemaining = 0
+	} else if d.bytesRemaining == 0 {
+		d.restart()
+	}
+}
+
+// restart begins playing the sample from its start address
+func (d *dmc) restart() {
+	d.currentAddr = d.sampleAddr
+	d.bytesRemaining = d.sampleLength
+}
+
+// clock runs the memory reader and output timer; called every CPU cycle
+func (d *dmc) clock() {
+	if d.bufferEmpty && d.bytesRemaining > 0 {
+		d.fetch()
+	}
+
+	if d.timerVal > 0 {
+		d.timerVal--
+		return
+	}
+	d.timerVal = d.rate - 1
+
+	if !d.silence {
+		if d.shift&1 != 0 {
+			if d.level <= 125 {
+				d.level += 2
+			}
+		} else if d.level >= 2 {
+			d.level -= 2
+		}
+	}
+	d.shift >>= 1
+
+	d.bitsRemaining--
+	if d.bitsRemaining == 0 {
+		d.bitsRemaining = 8
+		d.silence = d.bufferEmpty
+		if !d.bufferEmpty {
+			d.shift = d.buffer
+			d.bufferEmpty = true
+		}
+	}
+}
+
+// fetch reads the next sample byte into the buffer, stalling the CPU
+func (d *dmc) fetch() {
+	if d.read != nil {
+		d.buffer = d.read(d.currentAddr)
+	}
+	d.bufferEmpty = false
+	d.stall += dmcFetchStall
+
+	// The address wraps from $FFFF back to $8000
+	d.currentAddr++
+	if d.currentAddr == 0 {
+		d.currentAddr = 0x8000
+	}
+
+	d.bytesRemaining--
+	if d.bytesRemaining == 0 {
+		if d.loop {
+			d.restart()
+		} else if d.irqEnabled {
+			d.irq = true
+		}
+	}
+}
+
+// output returns the channel's current level (0-127)
+func (d *dmc) output() uint8 {
+	return d.level
+}
+
+func (d *dmc) writeState(w *state.Writer) {
+	w.U16(d.rate)
+	w.U16(d.timerVal)
+	w.Bool(d.irqEnabled)
+	w.Bool(d.loop)
+	w.Bool(d.irq)
+	w.U16(d.sampleAddr)
+	w.U16(d.sampleLength)
+	w.U16(d.currentAddr)
+	w.U16(d.bytesRemaining)
+	w.U8(d.buffer)
+	w.Bool(d.bufferEmpty)
+	w.U8(d.shift)
+	w.U8(d.bitsRemaining)
+	w.Bool(d.silence)
+	w.U8(d.level)
+	w.U8(d.stall)
+}
+
+func (d *dmc) readState(r *state.Reader) {
+	d.rate = r.U16()
+	d.timerVal = r.U16()
+	d.irqEnabled = r.Bool()
+	d.loop = r.Bool()
+	d.irq = r.Bool()
+	d.sampleAddr = r.U16()
+	d.sampleLength = r.U16()
+	d.currentAddr = r.U16()
+	d.bytesRemaining = r.U16()
+	d.buffer = r.U8()
+	d.bufferEmpty = r.Bool()
+	d.shift = r.U8()
+	d.bitsRemaining = r.U8()
+	d.silence = r.Bool()
+	d.level = r.U8()
+	d.stall = r.U8()
+}
@@ -0,0 +1,16 @@
+package apu
+
+// Expansion is a cartridge sound source (FDS, VRC6, VRC7, Namco 163...)
+// mixed into the APU output. It is clocked once per CPU cycle.
+//
+// Cartridge mappers with sound implement this through
+// cartridge.AudioMapper, so the two interfaces match method for method.
+type Expansion interface {
+	ClockAudio()
+	AudioOutput() float32 // Current level, on the same scale as the APU mix
+}
+
+// SetExpansion attaches a cartridge sound source, or detaches it if nil
+func (a *APU) SetExpansion(e Expansion) {
+	a.expansion = e
+}
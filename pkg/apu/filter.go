@@ -0,0 +1,94 @@
+package apu
+
+import "math"
+
+// FilterKind selects a filter stage's response
+type FilterKind uint8
+
+const (
+	HighPass FilterKind = iota
+	LowPass
+)
+
+// FilterStage is one first-order filter in the output chain
+type FilterStage struct {
+	Kind   FilterKind
+	Cutoff float64 // -3 dB frequency in Hz
+}
+
+// HardwareFilters models the analog output stage of a front-loading NES:
+// two high-pass filters (90 Hz and 440 Hz) that remove the mixer's DC
+// offset, and a 14 kHz low-pass. It is the APU's default chain.
+var HardwareFilters = []FilterStage{
+	{HighPass, 90},
+	{HighPass, 440},
+	{LowPass, 14000},
+}
+
+// filter is a first-order IIR filter running at the output sample rate
+type filter struct {
+	stage FilterStage
+	coef  float32
+	prevX float32
+	prevY float32
+}
+
+// setRate computes the coefficient for a sample rate
+func (f *filter) setRate(sampleRate float64) {
+	rc := 1 / (2 * math.Pi * f.stage.Cutoff)
+	dt := 1 / sampleRate
+	if f.stage.Kind == HighPass {
+		f.coef = float32(rc / (rc + dt))
+	} else {
+		f.coef = float32(dt / (rc + dt))
+	}
+}
+
+// apply filters one sample
+func (f *filter) apply(x float32) float32 {
+	var y float32
+	if f.stage.Kind == HighPass {
+		y = f.coef * (f.prevY + x - f.prevX)
+	} else {
+		y = f.prevY + f.coef*(x-f.prevY)
+	}
+	f.prevX, f.prevY = x, y
+	return y
+}
+
+// SetFilters replaces the output filter chain, applied in order to every
+// sample read. An empty chain gives the raw mixer output in [0, 1], e.g.
+// for capture or analysis.
+func (a *APU) SetFilters(stages []FilterStage) {
+	a.filters = a.filters[:0]
+	for _, s := range stages {
+		a.filters = append(a.filters, filter{stage: s})
+	}
+	a.updateFilters()
+}
+
+// Filters returns the output filter chain
+func (a *APU) Filters() []FilterStage {
+	stages := make([]FilterStage, len(a.filters))
+	for i, f := range a.filters {
+		stages[i] = f.stage
+	}
+	return stages
+}
+
+// updateFilters recomputes the filter coefficients for the sample rate
+func (a *APU) updateFilters() {
+	for i := range a.filters {
+		a.filters[i].setRate(a.sampleRate)
+	}
+}
+
+// filterSamples runs the chain over samples in place
+func (a *APU) filterSamples(samples []float32) {
+	for i := range a.filters {
+		f := &a.filters[i]
+		for j, x := range samples {
+			samples[j] = f.apply(x)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package apu
+
+// pulseMix is the non-linear mixer output for the sum of both pulse levels:
+// 95.52 / (8128/n + 100)
+var pulseMix = func() (t [31]float32) {
+	for n := 1; n < len(t); n++ {
+		t[n] = float32(95.52 / (8128.0/float64(n) + 100))
+	}
+	return t
+}()
+
+// tndMix is the non-linear mixer output for the triangle, noise and DMC
+// levels combined as 3*triangle + 2*noise + dmc: 163.67 / (24329/n + 100)
+var tndMix = func() (t [203]float32) {
+	for n := 1; n < len(t); n++ {
+		t[n] = float32(163.67 / (24329.0/float64(n) + 100))
+	}
+	return t
+}()
+
+// mixLevels evaluates the mixer for fractional channel levels, as produced
+// by per-channel gains, using the same approximation as the lookup tables
+func mixLevels(pulse1, pulse2, triangle, noise, dmc float32) float32 {
+	var out float32
+	if p := pulse1 + pulse2; p > 0 {
+		out = 95.52 / (8128/p + 100)
+	}
+	if tnd := 3*triangle + 2*noise + dmc; tnd > 0 {
+		out += 163.67 / (24329/tnd + 100)
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package apu
+
+import "github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+
+// Noise timer periods in CPU cycles, indexed by the 4-bit period field
+var (
+	noisePeriodsNTSC = [16]uint16{4, 8, 16, 32, 64, 96, 128, 160, 202, 254, 380, 508, 762, 1016, 2034, 4068}
+	noisePeriodsPAL  = [16]uint16{4, 8, 14, 30, 60, 88, 118, 148, 188, 236, 354, 472, 708, 944, 1890, 3778}
+)
+
+// noise is the pseudo-random noise channel
+//
+// Registers (relative to $400C):
+//
+//	0: --LC VVVV  length halt / envelope loop, constant volume, volume
+//	2: M--- PPPP  mode, period index
+//	3: LLLL L---  length index (restarts envelope)
+//
+// The waveform comes from a 15-bit linear feedback shift register. Mode 0
+// feeds back bit 1 for a long (32767-step) hiss; mode 1 feeds back bit 6,
+// giving a short 93- or 31-step metallic tone.
+type noise struct {
+	periods  *[16]uint16
+	period   uint16
+	timerVal uint16
+	mode     bool
+	shift    uint16 // LFSR, never zero
+
+	envelope envelope
+	length   lengthCounter
+}
+
+// write handles a write to one of the channel's registers
+func (n *noise) write(reg uint16, value uint8) {
+	switch reg {
+	case 0:
+		n.length.halt = value&0x20 != 0
+		n.envelope.write(value)
+	case 2:
+		n.mode = value&0x80 != 0
+		n.period = n.periods[value&0x0F]
+	case 3:
+		n.length.load(value >> 3)
+		n.envelope.start = true
+	}
+}
+
+// clockTimer shifts the LFSR once per period; called every CPU cycle
+func (n *noise) clockTimer() {
+	if n.timerVal > 0 {
+		n.timerVal--
+		return
+	}
+	n.timerVal = n.period - 1
+
+	tap := uint16(1)
+	if n.mode {
+		tap = 6
+	}
+	feedback := (n.shift ^ n.shift>>tap) & 1
+	n.shift = n.shift>>1 | feedback<<14
+}
+
+// output returns the channel's current level (0-15)
+func (n *noise) output() uint8 {
+	if n.length.counter == 0 || n.shift&1 != 0 {
+		return 0
+	}
+	return n.envelope.output()
+}
+
+func (n *noise) writeState(w *state.Writer) {
+	w.U16(n.period)
+	w.U16(n.timerVal)
+	w.Bool(n.mode)
+	w.U16(n.shift)
+	n.envelope.writeState(w)
+	n.length.writeState(w)
+}
+
+func (n *noise) readState(r *state.Reader) {
+	n.period = r.U16()
+	n.timerVal = r.U16()
+	n.mode = r.Bool()
+	n.shift = r.U16()
+	n.envelope.readState(r)
+	n.length.readState(r)
+}
@@ -0,0 +1,144 @@
+package apu
+
+import "github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+
+// dutyTable holds the four 8-step pulse waveforms (12.5%, 25%, 50%, 25% negated)
+var dutyTable = [4][8]uint8{
+	{0, 1, 0, 0, 0, 0, 0, 0},
+	{0, 1, 1, 0, 0, 0, 0, 0},
+	{0, 1, 1, 1, 1, 0, 0, 0},
+	{1, 0, 0, 1, 1, 1, 1, 1},
+}
+
+// pulse is one of the two square wave channels
+//
+// Registers (relative to $4000 / $4004):
+//
+//	0: DDLC VVVV  duty, length halt / envelope loop, constant volume, volume
+//	1: EPPP NSSS  sweep enable, period, negate, shift
+//	2: TTTT TTTT  timer low
+//	3: LLLL LTTT  length index, timer high (restarts envelope and phase)
+type pulse struct {
+	duty     uint8
+	step     uint8  // Position in the duty waveform
+	timer    uint16 // Timer period (11 bits)
+	timerVal uint16
+
+	envelope envelope
+	length   lengthCounter
+
+	sweepEnabled bool
+	sweepPeriod  uint8
+	sweepNegate  bool
+	sweepShift   uint8
+	sweepReload  bool
+	sweepDivider uint8
+
+	// Pulse 1 negates with ones' complement (subtracting one extra)
+	sweepOnesComplement bool
+}
+
+// write handles a write to one of the channel's four registers
+func (p *pulse) write(reg uint16, value uint8) {
+	switch reg {
+	case 0:
+		p.duty = value >> 6
+		p.length.halt = value&0x20 != 0
+		p.envelope.write(value)
+	case 1:
+		p.sweepEnabled = value&0x80 != 0
+		p.sweepPeriod = (value >> 4) & 0x07
+		p.sweepNegate = value&0x08 != 0
+		p.sweepShift = value & 0x07
+		p.sweepReload = true
+	case 2:
+		p.timer = p.timer&0x0700 | uint16(value)
+	case 3:
+		p.timer = p.timer&0x00FF | uint16(value&0x07)<<8
+		p.length.load(value >> 3)
+		p.envelope.start = true
+		p.step = 0
+	}
+}
+
+// clockTimer advances the waveform; called every other CPU cycle
+func (p *pulse) clockTimer() {
+	if p.timerVal == 0 {
+		p.timerVal = p.timer
+		p.step = (p.step + 1) & 7
+	} else {
+		p.timerVal--
+	}
+}
+
+// sweepTarget returns the period the sweep unit would switch to
+func (p *pulse) sweepTarget() uint16 {
+	change := p.timer >> p.sweepShift
+	if !p.sweepNegate {
+		return p.timer + change
+	}
+	if p.sweepOnesComplement {
+		change++
+	}
+	if change > p.timer {
+		return 0
+	}
+	return p.timer - change
+}
+
+// muted reports whether the sweep unit is silencing the channel. This
+// applies even when the sweep is disabled.
+func (p *pulse) muted() bool {
+	return p.timer < 8 || p.sweepTarget() > 0x7FF
+}
+
+// clockSweep runs the sweep unit on half frames
+func (p *pulse) clockSweep() {
+	if p.sweepDivider == 0 && p.sweepEnabled && p.sweepShift > 0 && !p.muted() {
+		p.timer = p.sweepTarget()
+	}
+	if p.sweepDivider == 0 || p.sweepReload {
+		p.sweepDivider = p.sweepPeriod
+		p.sweepReload = false
+	} else {
+		p.sweepDivider--
+	}
+}
+
+// output returns the channel's current level (0-15)
+func (p *pulse) output() uint8 {
+	if p.length.counter == 0 || p.muted() || dutyTable[p.duty][p.step] == 0 {
+		return 0
+	}
+	return p.envelope.output()
+}
+
+func (p *pulse) writeState(w *state.Writer) {
+	w.U8(p.duty)
+	w.U8(p.step)
+	w.U16(p.timer)
+	w.U16(p.timerVal)
+	p.envelope.writeState(w)
+	p.length.writeState(w)
+	w.Bool(p.sweepEnabled)
+	w.U8(p.sweepPeriod)
+	w.Bool(p.sweepNegate)
+	w.U8(p.sweepShift)
+	w.Bool(p.sweepReload)
+	w.U8(p.sweepDivider)
+}
+
+func (p *pulse) readState(r *state.Reader) {
+	p.duty = r.U8()
+	p.step = r.U8()
+	p.timer = r.U16()
+	p.timerVal = r.U16()
+	p.envelope.readState(r)
+	p.length.readState(r)
+	p.sweepEnabled = r.Bool()
+	p.sweepPeriod = r.U8()
+	p.sweepNegate = r.Bool()
+	p.sweepShift = r.U8()
+	p.sweepReload = r.Bool()
+	p.sweepDivider = r.U8()
+}
@@ -0,0 +1,119 @@
+package apu
+
+import "math"
+
+// Band-limited step kernel dimensions
+const (
+	blipTaps   = 16 // Kernel width in output samples
+	blipPhases = 64 // Sub-sample positions the kernel is tabulated for
+)
+
+// blipKernel holds, for each sub-sample phase, the band-limited impulse to
+// deposit for an amplitude step at that phase. Integrating the deposited
+// impulses yields a step with no energy above the output Nyquist frequency.
+var blipKernel = func() (k [blipPhases][blipTaps]float32) {
+	const cutoff = 0.9 // Fraction of the output Nyquist frequency kept
+	for p := range k {
+		frac := float64(p) / blipPhases
+		var sum float64
+		var taps [blipTaps]float64
+		for j := range taps {
+			x := float64(j) - blipTaps/2 - frac + 1
+			sinc := 1.0
+			if x != 0 {
+				sinc = math.Sin(math.Pi*cutoff*x) / (math.Pi * cutoff * x)
+			}
+			t := (float64(j) - frac + 1) / blipTaps // Blackman window position
+			window := 0.42 - 0.5*math.Cos(2*math.Pi*t) + 0.08*math.Cos(4*math.Pi*t)
+			taps[j] = sinc * window
+			sum += taps[j]
+		}
+		for j := range taps {
+			k[p][j] = float32(taps[j] / sum)
+		}
+	}
+	return k
+}()
+
+// blipBuffer resamples the APU's CPU-rate output to the host sample rate
+// without aliasing
+//
+// Rather than sampling the channel output, it records each change in
+// amplitude as a band-limited step placed at the change's exact sub-sample
+// time. The buffer holds the steps' derivatives, so reading out integrates
+// them back into a waveform. Because the output only changes when a
+// channel's level does, the work done per CPU cycle is a single add.
+type blipBuffer struct {
+	step  float64   // Output samples per CPU cycle
+	pos   float64   // Current time in output samples from buf[0]
+	buf   []float32 // Pending step derivatives
+	last  float32   // Amplitude at the current time
+	level float32   // Running integral of samples already read
+}
+
+// setRatio sets the conversion from CPU cycles to output samples
+func (b *blipBuffer) setRatio(clockRate, sampleRate float64) {
+	b.step = sampleRate / clockRate
+}
+
+// clock advances one CPU cycle with the channel output at amplitude amp
+func (b *blipBuffer) clock(amp float32) {
+	if amp != b.last {
+		b.addDelta(amp - b.last)
+		b.last = amp
+	}
+	b.pos += b.step
+}
+
+// addDelta deposits an amplitude step at the current time
+func (b *blipBuffer) addDelta(delta float32) {
+	i := int(b.pos)
+	if need := i + blipTaps; need > len(b.buf) {
+		b.buf = append(b.buf, make([]float32, need-len(b.buf))...)
+	}
+	kernel := &blipKernel[int((b.pos-float64(i))*blipPhases)]
+	out := b.buf[i : i+blipTaps]
+	for j, k := range kernel {
+		out[j] += delta * k
+	}
+}
+
+// available returns the number of output samples ready to read. Steps
+// still being deposited only affect samples at or after the current time.
+func (b *blipBuffer) available() int {
+	return int(b.pos)
+}
+
+// read integrates up to len(dst) ready samples into dst and removes them
+// from the buffer, returning the number read. A nil dst discards samples.
+func (b *blipBuffer) read(dst []float32, n int) int {
+	n = min(n, b.available())
+	for i := 0; i < n; i++ {
+		// The buffer only extends as far as the last deposited step
+		if i < len(b.buf) {
+			b.level += b.buf[i]
+		}
+		if dst != nil {
+			dst[i] = b.level
+		}
+	}
+
+	// Shift the pending tail down and clear the freed space
+	if n >= len(b.buf) {
+		b.buf = b.buf[:0]
+	} else {
+		copied := copy(b.buf, b.buf[n:])
+		clear(b.buf[copied:])
+		b.buf = b.buf[:copied]
+	}
+	b.pos -= float64(n)
+	return n
+}
+
+// reset drops all pending output, keeping the current amplitude as the
+// starting level
+func (b *blipBuffer) reset() {
+	b.buf = b.buf[:0]
+	b.pos = 0
+	b.level = b.last
+}
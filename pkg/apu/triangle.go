@@ -0,0 +1,100 @@
+package apu
+
+import "github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+
+// triangleSequence is the 32-step triangle waveform
+var triangleSequence = [32]uint8{
+	15, 14, 13, 12, 11, 10, 9, 8, 7, 6, 5, 4, 3, 2, 1, 0,
+	0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15,
+}
+
+// triangle is the triangle wave channel
+//
+// Registers (relative to $4008):
+//
+//	0: CRRR RRRR  length halt / linear counter control, linear reload value
+//	2: TTTT TTTT  timer low
+//	3: LLLL LTTT  length index, timer high (sets the linear reload flag)
+//
+// Unlike the pulse channels the timer runs at the full CPU rate, and the
+// waveform only advances while both the length and linear counters are
+// non-zero; silencing holds the current level rather than dropping to zero.
+type triangle struct {
+	step     uint8 // Position in triangleSequence
+	timer    uint16
+	timerVal uint16
+
+	length lengthCounter
+
+	linearCounter uint8
+	linearReload  uint8 // Reload value
+	linearFlag    bool  // Reload on the next quarter frame
+	control       bool  // Linear counter control (also length halt)
+}
+
+// write handles a write to one of the channel's registers
+func (t *triangle) write(reg uint16, value uint8) {
+	switch reg {
+	case 0:
+		t.control = value&0x80 != 0
+		t.length.halt = t.control
+		t.linearReload = value & 0x7F
+	case 2:
+		t.timer = t.timer&0x0700 | uint16(value)
+	case 3:
+		t.timer = t.timer&0x00FF | uint16(value&0x07)<<8
+		t.length.load(value >> 3)
+		t.linearFlag = true
+	}
+}
+
+// clockTimer advances the waveform; called every CPU cycle
+func (t *triangle) clockTimer() {
+	if t.timerVal > 0 {
+		t.timerVal--
+		return
+	}
+	t.timerVal = t.timer
+	if t.length.counter > 0 && t.linearCounter > 0 {
+		t.step = (t.step + 1) & 31
+	}
+}
+
+// clockLinear runs the linear counter on quarter frames
+func (t *triangle) clockLinear() {
+	if t.linearFlag {
+		t.linearCounter = t.linearReload
+	} else if t.linearCounter > 0 {
+		t.linearCounter--
+	}
+	if !t.control {
+		t.linearFlag = false
+	}
+}
+
+// output returns the channel's current level (0-15)
+func (t *triangle) output() uint8 {
+	return triangleSequence[t.step]
+}
+
+func (t *triangle) writeState(w *state.Writer) {
+	w.U8(t.step)
+	w.U16(t.timer)
+	w.U16(t.timerVal)
+	t.length.writeState(w)
+	w.U8(t.linearCounter)
+	w.U8(t.linearReload)
+	w.Bool(t.linearFlag)
+	w.Bool(t.control)
+}
+
+func (t *triangle) readState(r *state.Reader) {
+	t.step = r.U8()
+	t.timer = r.U16()
+	t.timerVal = r.U16()
+	t.length.readState(r)
+	t.linearCounter = r.U8()
+	t.linearReload = r.U8()
+	t.linearFlag = r.Bool()
+	t.control = r.Bool()
+}
@@ -0,0 +1,114 @@
+package apu
+
+import "github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+
+// lengthTable maps the 5-bit length index written to a channel's last
+// register to a length counter value
+var lengthTable = [32]uint8{
+	10, 254, 20, 2, 40, 4, 80, 6, 160, 8, 60, 10, 14, 12, 26, 14,
+	12, 16, 24, 18, 48, 20, 96, 22, 192, 24, 72, 26, 16, 28, 32, 30,
+}
+
+// lengthCounter silences a channel after a programmed number of half frames
+type lengthCounter struct {
+	counter uint8
+	halt    bool // Also the envelope loop flag on pulse/noise
+	enabled bool // Channel enable bit in $4015
+}
+
+// load sets the counter from a length index, if the channel is enabled
+func (l *lengthCounter) load(index uint8) {
+	if l.enabled {
+		l.counter = lengthTable[index&0x1F]
+	}
+}
+
+// setEnabled applies the channel's $4015 enable bit; disabling clears the counter
+func (l *lengthCounter) setEnabled(enabled bool) {
+	l.enabled = enabled
+	if !enabled {
+		l.counter = 0
+	}
+}
+
+// clock counts down on half frames unless halted
+func (l *lengthCounter) clock() {
+	if !l.halt && l.counter > 0 {
+		l.counter--
+	}
+}
+
+func (l *lengthCounter) writeState(w *state.Writer) {
+	w.U8(l.counter)
+	w.Bool(l.halt)
+	w.Bool(l.enabled)
+}
+
+func (l *lengthCounter) readState(r *state.Reader) {
+	l.counter = r.U8()
+	l.halt = r.Bool()
+	l.enabled = r.Bool()
+}
+
+// envelope generates a decaying (optionally looping) or constant volume
+type envelope struct {
+	start    bool
+	loop     bool
+	constant bool
+	volume   uint8 // Constant volume, or the divider period
+	divider  uint8
+	decay    uint8
+}
+
+// write sets the loop, constant and volume fields from a --LC VVVV register
+func (e *envelope) write(value uint8) {
+	e.loop = value&0x20 != 0
+	e.constant = value&0x10 != 0
+	e.volume = value & 0x0F
+}
+
+// clock runs the envelope on quarter frames
+func (e *envelope) clock() {
+	if e.start {
+		e.start = false
+		e.decay = 15
+		e.divider = e.volume
+		return
+	}
+	if e.divider > 0 {
+		e.divider--
+		return
+	}
+	e.divider = e.volume
+	if e.decay > 0 {
+		e.decay--
+	} else if e.loop {
+		e.decay = 15
+	}
+}
+
+// output returns the current volume (0-15)
+func (e *envelope) output() uint8 {
+	if e.constant {
+		return e.volume
+	}
+	return e.decay
+}
+
+func (e *envelope) writeState(w *state.Writer) {
+	w.Bool(e.start)
+	w.Bool(e.loop)
+	w.Bool(e.constant)
+	w.U8(e.volume)
+	w.U8(e.divider)
+	w.U8(e.decay)
+}
+
+func (e *envelope) readState(r *state.Reader) {
+	e.start = r.Bool()
+	e.loop = r.Bool()
+	e.constant = r.Bool()
+	e.volume = r.U8()
+	e.divider = r.U8()
+	e.decay = r.U8()
+}
@@ -0,0 +1,463 @@
+// Package vrc7 implements the FM synthesizer of Konami's VRC7 mapper.
+//
+// The VRC7's sound unit is a cut-down Yamaha YM2413 (OPLL): six 2-operator
+// FM channels, fifteen fixed instruments plus one user-defined patch, and
+// no rhythm mode. Lagrange Point is the only game that uses it.
+//
+// This is a behavioural model rather than a die-accurate one: operators,
+// envelopes, feedback, key scaling and the vibrato/tremolo LFOs follow the
+// OPLL's documented behaviour, but timing and rounding are approximated in
+// floating point.
+//
+// Registers (written through the mapper's $9010 select / $9030 data ports):
+//
+//	$00-$07: Custom instrument patch
+//	$10-$15: Channel F-number, low 8 bits
+//	$20-$25: Sustain (bit 5), key on (bit 4), block (bits 1-3), F-number bit 8
+//	$30-$35: Instrument (bits 4-7), volume attenuation (bits 0-3)
+package vrc7
+
+import (
+	"math"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// Chip timing: the OPLL produces one sample every 72 cycles of its
+// 3.58 MHz clock, which is every 36 NES CPU cycles
+const (
+	cyclesPerSample = 36
+	sampleRate      = 1789773.0 / cyclesPerSample
+	numChannels     = 6
+)
+
+// outputScale converts the sum of the channel outputs (each within ±1) to
+// the APU mix scale
+const outputScale = 0.12
+
+// patchROM holds the 15 built-in instruments, 8 bytes each in register
+// $00-$07 layout
+var patchROM = [15][8]uint8{
+	{0x03, 0x21, 0x05, 0x06, 0xE8, 0x81, 0x42, 0x27}, // Buzzy bell
+	{0x13, 0x41, 0x14, 0x0D, 0xD8, 0xF6, 0x23, 0x12}, // Guitar
+	{0x11, 0x11, 0x08, 0x08, 0xFA, 0xB2, 0x20, 0x12}, // Wurly
+	{0x31, 0x61, 0x0C, 0x07, 0xA8, 0x64, 0x61, 0x27}, // Flute
+	{0x32, 0x21, 0x1E, 0x06, 0xE1, 0x76, 0x01, 0x28}, // Clarinet
+	{0x02, 0x01, 0x06, 0x00, 0xA3, 0xE2, 0xF4, 0xF4}, // Synth
+	{0x21, 0x61, 0x1D, 0x07, 0x82, 0x81, 0x11, 0x07}, // Trumpet
+	{0x23, 0x21, 0x22, 0x17, 0xA2, 0x72, 0x01, 0x17}, // Organ
+	{0x35, 0x11, 0x25, 0x00, 0x40, 0x73, 0x72, 0x01}, // Bells
+	{0xB5, 0x01, 0x0F, 0x0F, 0xA8, 0xA5, 0x51, 0x02}, // Vibes
+	{0x17, 0xC1, 0x24, 0x07, 0xF8, 0xF8, 0x22, 0x12}, // Vibraphone
+	{0x71, 0x23, 0x11, 0x06, 0x65, 0x74, 0x18, 0x16}, // Tutti
+	{0x01, 0x02, 0xD3, 0x05, 0xC9, 0x95, 0x03, 0x02}, // Fretless
+	{0x61, 0x63, 0x0C, 0x00, 0x94, 0xC0, 0x33, 0xF6}, // Synth bass
+	{0x21, 0x72, 0x0D, 0x00, 0xC1, 0xD5, 0x56, 0x06}, // Sweep
+}
+
+// multiples maps the 4-bit MULT field to a frequency multiplier
+var multiples = [16]float64{0.5, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 10, 12, 12, 15, 15}
+
+// kslBase is the key scale level attenuation in dB for block 7, indexed by
+// the top 4 bits of the F-number
+var kslBase = [16]float64{0, 18, 24, 27.75, 30, 32.25, 33.75, 35.25, 36, 37.5, 38.25, 39, 39.75, 40.5, 41.25, 42}
+
+// Tables for the operator output path
+var (
+	sineTable [1024]float64
+	dbTable   [1024]float64 // Linear gain for attenuation in 1/8 dB steps
+)
+
+func init() {
+	for i := range sineTable {
+		sineTable[i] = math.Sin(2 * math.Pi * float64(i) / float64(len(sineTable)))
+	}
+	for i := range dbTable {
+		dbTable[i] = math.Pow(10, -float64(i)/8/20)
+	}
+}
+
+// maxAttenuation is the envelope level at which an operator is silent
+const maxAttenuation = 48.0
+
+// envelope phases
+const (
+	envOff uint8 = iota
+	envAttack
+	envDecay
+	envSustain
+	envRelease
+)
+
+// operator is one of a channel's two FM operators
+type operator struct {
+	phase float64 // Position in the waveform, in cycles
+	env   float64 // Envelope attenuation in dB
+	stage uint8
+}
+
+// channel is one FM voice: a modulator feeding a carrier
+type channel struct {
+	fnum       uint16 // 9-bit F-number
+	block      uint8  // Octave
+	keyOn      bool
+	sustain    bool
+	instrument uint8
+	volume     uint8
+
+	mod, car operator
+	feedback [2]float64 // Last two modulator outputs
+}
+
+// patch is a decoded instrument
+type patch struct {
+	am, vib, eg, ksr [2]bool
+	mult             [2]float64
+	ksl              [2]uint8
+	tl               uint8 // Modulator total level
+	rectify          [2]bool
+	fb               uint8
+	ar, dr, sl, rr   [2]uint8
+}
+
+// decodePatch unpacks an 8-byte instrument definition. Index 0 is the
+// modulator and 1 the carrier.
+func decodePatch(b *[8]uint8) patch {
+	var p patch
+	for op := 0; op < 2; op++ {
+		p.am[op] = b[op]&0x80 != 0
+		p.vib[op] = b[op]&0x40 != 0
+		p.eg[op] = b[op]&0x20 != 0
+		p.ksr[op] = b[op]&0x10 != 0
+		p.mult[op] = multiples[b[op]&0x0F]
+		p.ksl[op] = b[2+op] >> 6
+		p.ar[op] = b[4+op] >> 4
+		p.dr[op] = b[4+op] & 0x0F
+		p.sl[op] = b[6+op] >> 4
+		p.rr[op] = b[6+op] & 0x0F
+	}
+	p.tl = b[2] & 0x3F
+	p.rectify[1] = b[3]&0x10 != 0
+	p.rectify[0] = b[3]&0x08 != 0
+	p.fb = b[3] & 0x07
+	return p
+}
+
+// romPatches holds the decoded built-in instruments
+var romPatches = func() (p [15]patch) {
+	for i := range patchROM {
+		p[i] = decodePatch(&patchROM[i])
+	}
+	return p
+}()
+
+// Chip is the VRC7 FM sound unit
+type Chip struct {
+	regs        [0x40]uint8
+	custom      [8]uint8
+	customPatch patch // Decoded custom instrument
+	channels    [numChannels]channel
+
+	cycles    uint8   // CPU cycles towards the next sample
+	lfoTime   float64 // Seconds, for vibrato and tremolo
+	output    float32
+	muted     bool // $E000 bit 6 of the mapper silences and resets the unit
+	addrLatch uint8
+}
+
+// New creates a VRC7 sound unit with all channels keyed off
+func New() *Chip {
+	c := &Chip{}
+	for i := range c.channels {
+		c.channels[i].mod.env = maxAttenuation
+		c.channels[i].car.env = maxAttenuation
+	}
+	return c
+}
+
+// SelectRegister latches the register number for the next data write ($9010)
+func (c *Chip) SelectRegister(value uint8) {
+	c.addrLatch = value & 0x3F
+}
+
+// WriteData writes to the selected register ($9030)
+func (c *Chip) WriteData(value uint8) {
+	reg := c.addrLatch
+	c.regs[reg] = value
+
+	switch {
+	case reg < 0x08:
+		c.custom[reg] = value
+		c.customPatch = decodePatch(&c.custom)
+	case reg >= 0x10 && reg < 0x16:
+		ch := &c.channels[reg-0x10]
+		ch.fnum = ch.fnum&0x100 | uint16(value)
+	case reg >= 0x20 && reg < 0x26:
+		ch := &c.channels[reg-0x20]
+		ch.fnum = ch.fnum&0x0FF | uint16(value&0x01)<<8
+		ch.block = (value >> 1) & 0x07
+		ch.sustain = value&0x20 != 0
+		key := value&0x10 != 0
+		if key && !ch.keyOn {
+			c.keyOn(ch)
+		} else if !key && ch.keyOn {
+			ch.mod.stage = envRelease
+			ch.car.stage = envRelease
+		}
+		ch.keyOn = key
+	case reg >= 0x30 && reg < 0x36:
+		ch := &c.channels[reg-0x30]
+		ch.instrument = value >> 4
+		ch.volume = value & 0x0F
+	}
+}
+
+// SetMuted silences the unit (the mapper's sound reset bit)
+func (c *Chip) SetMuted(muted bool) {
+	c.muted = muted
+	if muted {
+		c.output = 0
+	}
+}
+
+// keyOn restarts a channel's operators
+func (c *Chip) keyOn(ch *channel) {
+	for _, op := range []*operator{&ch.mod, &ch.car} {
+		op.phase = 0
+		op.stage = envAttack
+	}
+	ch.feedback = [2]float64{}
+}
+
+// patchFor returns the decoded instrument of a channel
+func (c *Chip) patchFor(ch *channel) *patch {
+	if ch.instrument == 0 {
+		return &c.customPatch
+	}
+	return &romPatches[ch.instrument-1]
+}
+
+// Clock advances the unit by one CPU cycle
+func (c *Chip) Clock() {
+	c.cycles++
+	if c.cycles < cyclesPerSample {
+		return
+	}
+	c.cycles = 0
+	if c.muted {
+		return
+	}
+
+	c.lfoTime += 1 / sampleRate
+	// Vibrato: ±7 cents at 6.4 Hz; tremolo: 4.8 dB at 3.7 Hz
+	vibrato := 1 + 0.004*math.Sin(2*math.Pi*6.4*c.lfoTime)
+	tremolo := 2.4 * (1 + math.Sin(2*math.Pi*3.7*c.lfoTime))
+
+	var sum float64
+	for i := range c.channels {
+		sum += c.channels[i].generate(c.patchFor(&c.channels[i]), vibrato, tremolo)
+	}
+	c.output = float32(sum * outputScale)
+}
+
+// Output returns the current level on the APU mix scale
+func (c *Chip) Output() float32 {
+	return c.output
+}
+
+// generate computes the channel's next sample
+func (ch *channel) generate(p *patch, vibrato, tremolo float64) float64 {
+	if ch.mod.stage == envOff && ch.car.stage == envOff {
+		return 0
+	}
+
+	base := float64(ch.fnum) * float64(uint32(1)<<ch.block) / (1 << 19)
+	ksl := kslAttenuation(ch.fnum, ch.block)
+	rks := ch.keyScaleRate()
+
+	ops := [2]*operator{&ch.mod, &ch.car}
+	var out [2]float64
+	for i, op := range ops {
+		step := base * p.mult[i]
+		if p.vib[i] {
+			step *= vibrato
+		}
+
+		rate := rks
+		if !p.ksr[i] {
+			rate >>= 2
+		}
+		op.clockEnvelope(p, i, rate, ch.sustain)
+
+		atten := op.env
+		if p.ksl[i] != 0 {
+			atten += ksl / float64(uint(1)<<(3-p.ksl[i]))
+		}
+		if p.am[i] {
+			atten += tremolo
+		}
+		if i == 0 {
+			atten += float64(p.tl) * 0.75
+		} else {
+			atten += float64(ch.volume) * 3
+		}
+
+		var phaseMod float64
+		if i == 0 {
+			if p.fb != 0 {
+				// FB 1 = pi/16 ... FB 7 = 4 pi of phase deviation
+				phaseMod = (ch.feedback[0] + ch.feedback[1]) / 2 * float64(uint(1)<<p.fb) / 64
+			}
+		} else {
+			phaseMod = out[0] * 2
+		}
+
+		out[i] = wave(op.phase+phaseMod, p.rectify[i]) * gain(atten)
+		op.phase += step
+		op.phase -= math.Floor(op.phase)
+	}
+
+	ch.feedback[1] = ch.feedback[0]
+	ch.feedback[0] = out[0]
+	return out[1]
+}
+
+// keyScaleRate returns the envelope rate boost for the channel's pitch
+func (ch *channel) keyScaleRate() uint8 {
+	return ch.block<<1 | uint8(ch.fnum>>8)
+}
+
+// kslAttenuation returns the full (6 dB/octave) key scale attenuation
+func kslAttenuation(fnum uint16, block uint8) float64 {
+	return max(kslBase[fnum>>5]-6*float64(7-block), 0)
+}
+
+// clockEnvelope advances the operator's ADSR envelope by one sample
+func (op *operator) clockEnvelope(p *patch, i int, rks uint8, sustain bool) {
+	switch op.stage {
+	case envAttack:
+		if p.ar[i] == 15 {
+			op.env = 0
+		} else if p.ar[i] != 0 {
+			// Exponential approach, roughly 8x faster than the decay rate
+			k := 8 * decayStep(p.ar[i], rks) / maxAttenuation
+			op.env -= (op.env + 1) * k
+		}
+		if op.env <= 0 {
+			op.env = 0
+			op.stage = envDecay
+		}
+
+	case envDecay:
+		op.env += decayStep(p.dr[i], rks)
+		if level := float64(p.sl[i]) * 3; op.env >= level {
+			op.env = level
+			op.stage = envSustain
+		}
+
+	case envSustain:
+		// Percussive instruments keep decaying at the release rate
+		if !p.eg[i] {
+			op.env += decayStep(p.rr[i], rks)
+		}
+
+	case envRelease:
+		rate := uint8(7)
+		switch {
+		case sustain:
+			rate = 5
+		case p.eg[i]:
+			rate = p.rr[i]
+		}
+		op.env += decayStep(rate, rks)
+	}
+
+	if op.env >= maxAttenuation {
+		op.env = maxAttenuation
+		if op.stage != envAttack {
+			op.stage = envOff
+		}
+	}
+}
+
+// decayStep returns the dB increase per sample for a 4-bit rate. Every
+// step of the effective rate (4*rate + key scaling) halves the time taken
+// to decay through the full range, from about 10 s at rate 1.
+func decayStep(rate, rks uint8) float64 {
+	if rate == 0 {
+		return 0
+	}
+	effective := min(int(rate)*4+int(rks), 63)
+	seconds := 10 * math.Pow(2, -float64(effective-4)/4)
+	return maxAttenuation / (seconds * sampleRate)
+}
+
+// wave samples the sine waveform at a phase in cycles; rectified
+// operators output only the positive half
+func wave(phase float64, rectify bool) float64 {
+	phase -= math.Floor(phase)
+	s := sineTable[int(phase*float64(len(sineTable)))&(len(sineTable)-1)]
+	if rectify && s < 0 {
+		return 0
+	}
+	return s
+}
+
+// gain converts an attenuation in dB to a linear factor
+func gain(db float64) float64 {
+	i := int(db * 8)
+	if i >= len(dbTable) {
+		return 0
+	}
+	return dbTable[max(i, 0)]
+}
+
+// WriteState appends the registers and synthesis state to w
+func (c *Chip) WriteState(w *state.Writer) {
+	w.Block(c.regs[:])
+	w.U8(c.addrLatch)
+	w.U8(c.cycles)
+	w.Bool(c.muted)
+	w.U64(math.Float64bits(c.lfoTime))
+	for i := range c.channels {
+		ch := &c.channels[i]
+		w.Bool(ch.keyOn)
+		for _, op := range []*operator{&ch.mod, &ch.car} {
+			w.U64(math.Float64bits(op.phase))
+			w.U64(math.Float64bits(op.env))
+			w.U8(op.stage)
+		}
+		w.U64(math.Float64bits(ch.feedback[0]))
+		w.U64(math.Float64bits(ch.feedback[1]))
+	}
+}
+
+// ReadState restores state written by WriteState. The decoded instrument
+// and channel settings are rebuilt from the registers.
+func (c *Chip) ReadState(r *state.Reader) {
+	r.Block(c.regs[:])
+	c.addrLatch = r.U8()
+	c.cycles = r.U8()
+	c.muted = r.Bool()
+	c.lfoTime = math.Float64frombits(r.U64())
+	for i := range c.channels {
+		ch := &c.channels[i]
+		ch.keyOn = r.Bool()
+		for _, op := range []*operator{&ch.mod, &ch.car} {
+			op.phase = math.Float64frombits(r.U64())
+			op.env = math.Float64frombits(r.U64())
+			op.stage = r.U8()
+		}
+		ch.feedback[0] = math.Float64frombits(r.U64())
+		ch.feedback[1] = math.Float64frombits(r.U64())
+
+		ch.fnum = uint16(c.regs[0x20+i]&0x01)<<8 | uint16(c.regs[0x10+i])
+		ch.block = (c.regs[0x20+i] >> 1) & 0x07
+		ch.sustain = c.regs[0x20+i]&0x20 != 0
+		ch.instrument = c.regs[0x30+i] >> 4
+		ch.volume = c.regs[0x30+i] & 0x0F
+	}
+	copy(c.custom[:], c.regs[:8])
+	c.customPatch = decodePatch(&c.custom)
+	c.output = 0
+}
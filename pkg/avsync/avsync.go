@@ -0,0 +1,128 @@
+// Package avsync paces emulation against the host: a frame pacer that
+// schedules frames on the monotonic clock, and dynamic rate control that
+// nudges the audio resampling ratio to keep the output buffer half full.
+//
+// Frontends share these so they all sync audio and video the same way.
+// Video-paced frontends (no audio, or vsync-driven) use only the Pacer;
+// audio-driven ones feed RateControl with their buffer fill level each
+// frame and resample by the returned ratio.
+package avsync
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultMaxLag is how many frames a Pacer may fall behind before it gives
+// up catching up and re-anchors its schedule
+const DefaultMaxLag = 4
+
+// Pacer schedules frames at a fixed rate against the monotonic clock
+//
+// Deadlines are computed from an anchor time rather than by sleeping a
+// fixed amount per frame, so time spent emulating and rendering does not
+// accumulate as drift. If the caller falls more than MaxLag frames behind
+// (e.g. the process was suspended) the schedule is re-anchored instead of
+// fast-forwarding.
+type Pacer struct {
+	MaxLag int // Frames of lag tolerated before re-anchoring (0 = DefaultMaxLag)
+
+	period time.Duration
+	start  time.Time
+	frames int64
+	now    func() time.Time
+}
+
+// NewPacer creates a pacer running at rate frames per second, anchored now
+func NewPacer(rate float64) *Pacer {
+	p := &Pacer{now: time.Now}
+	p.SetRate(rate)
+	return p
+}
+
+// SetRate changes the frame rate and re-anchors the schedule
+func (p *Pacer) SetRate(rate float64) {
+	p.period = time.Duration(float64(time.Second) / rate)
+	p.Reset()
+}
+
+// Period returns the duration of one frame
+func (p *Pacer) Period() time.Duration {
+	return p.period
+}
+
+// Reset re-anchors the schedule at the current time, e.g. after a pause
+func (p *Pacer) Reset() {
+	p.start = p.now()
+	p.frames = 0
+}
+
+// FrameDone records that one more frame has been emulated
+func (p *Pacer) FrameDone() {
+	p.frames++
+}
+
+// Until returns how long until the next frame is due. Zero or less means
+// it is due now; the schedule is re-anchored (and zero returned) if the
+// caller is more than MaxLag frames behind.
+func (p *Pacer) Until() time.Duration {
+	wait := p.start.Add(time.Duration(p.frames) * p.period).Sub(p.now())
+	maxLag := p.MaxLag
+	if maxLag <= 0 {
+		maxLag = DefaultMaxLag
+	}
+	if -wait > time.Duration(maxLag)*p.period {
+		p.Reset()
+		return 0
+	}
+	return wait
+}
+
+// Wait blocks until the next frame is due or ctx is cancelled
+func (p *Pacer) Wait(ctx context.Context) error {
+	wait := p.Until()
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// DefaultMaxRateDelta is the largest resampling adjustment RateControl
+// makes (0.5%), small enough that the pitch change is inaudible
+const DefaultMaxRateDelta = 0.005
+
+// RateControl implements dynamic rate control: audio is resampled slightly
+// faster when the output buffer runs low and slightly slower when it fills
+// up, so audio and video stay in sync without dropped or repeated frames
+type RateControl struct {
+	MaxDelta float64 // Largest ratio adjustment (0 = DefaultMaxRateDelta)
+}
+
+// Ratio returns the factor to scale the output sample count by, given the
+// buffer's current fill level and capacity (both in samples). It is
+// 1+MaxDelta for an empty buffer, 1 at half full and 1-MaxDelta when full.
+func (r RateControl) Ratio(fill, capacity int) float64 {
+	if capacity <= 0 {
+		return 1
+	}
+	delta := r.MaxDelta
+	if delta <= 0 {
+		delta = DefaultMaxRateDelta
+	}
+	level := min(max(float64(fill)/float64(capacity), 0), 1)
+	return 1 + delta*(1-2*level)
+}
+
+// OutputRate returns the resampler's effective output rate: the device
+// rate scaled by Ratio for the current buffer level
+func (r RateControl) OutputRate(deviceRate float64, fill, capacity int) float64 {
+	return deviceRate * r.Ratio(fill, capacity)
+}
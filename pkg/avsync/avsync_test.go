@@ -0,0 +1,74 @@
+package avsync
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock for Pacer
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestPacer(rate float64) (*Pacer, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	p := &Pacer{now: clock.now}
+	p.SetRate(rate)
+	return p, clock
+}
+
+func TestPacerDoesNotDrift(t *testing.T) {
+	p, clock := newTestPacer(50)
+
+	// Frames that take 15ms of a 20ms slot leave 5ms to wait, every time
+	for i := 0; i < 100; i++ {
+		clock.advance(15 * time.Millisecond)
+		p.FrameDone()
+		wait := p.Until()
+		if wait != 5*time.Millisecond {
+			t.Fatalf("frame %d: wait %v, want 5ms", i, wait)
+		}
+		clock.advance(wait)
+	}
+}
+
+func TestPacerCatchesUpThenReanchors(t *testing.T) {
+	p, clock := newTestPacer(50)
+
+	// A 50ms stall is within the lag limit: the next frames are due at once
+	clock.advance(50 * time.Millisecond)
+	p.FrameDone()
+	if wait := p.Until(); wait != -30*time.Millisecond {
+		t.Fatalf("after short stall: wait %v, want -30ms", wait)
+	}
+
+	// A long stall re-anchors instead of fast-forwarding
+	clock.advance(time.Second)
+	p.FrameDone()
+	if wait := p.Until(); wait != 0 {
+		t.Fatalf("after long stall: wait %v, want 0", wait)
+	}
+	p.FrameDone()
+	if wait := p.Until(); wait != 20*time.Millisecond {
+		t.Fatalf("after re-anchor: wait %v, want 20ms", wait)
+	}
+}
+
+func TestRateControl(t *testing.T) {
+	r := RateControl{MaxDelta: 0.01}
+	for _, tc := range []struct {
+		fill, capacity int
+		want           float64
+	}{
+		{0, 1000, 1.01},
+		{500, 1000, 1},
+		{1000, 1000, 0.99},
+		{2000, 1000, 0.99},
+		{0, 0, 1},
+	} {
+		if got := r.Ratio(tc.fill, tc.capacity); got != tc.want {
+			t.Errorf("Ratio(%d, %d) = %v, want %v", tc.fill, tc.capacity, got, tc.want)
+		}
+	}
+}
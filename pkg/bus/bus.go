@@ -2,13 +2,19 @@
 package bus
 
 import (
-	"github.com/andrewthecodertx/go-6502-emulator/pkg/core"
+	"fmt"
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/apu"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/controller"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
 )
 
-// NESBus implements the core.Bus interface for the NES system
+// NESBus implements the cpu.Bus interface for the NES system
 //
 // CPU Memory Map:
 //
@@ -26,33 +32,140 @@ type NESBus struct {
 	// PPU (Picture Processing Unit)
 	ppu *ppu.PPU
 
+	// APU (Audio Processing Unit), clocked with the CPU
+	apu *apu.APU
+
 	// Cartridge mapper
 	mapper cartridge.Mapper
 
+	// Mapper clocked every CPU cycle, if it needs it
+	clockedMapper cartridge.CPUClockedMapper
+
 	// Controllers
 	controller1 *controller.Controller
 	controller2 *controller.Controller
 
-	// DMA transfer state
+	// OAM DMA state (see oamDMACycle)
 	dmaPage     uint8
 	dmaTransfer bool
+	dmaHalted   bool  // The CPU has been halted; the transfer proper has begun
+	dmaLatched  bool  // dmaData holds a byte read but not yet written
+	dmaIndex    uint8 // Offset of the next byte in the source page
+	dmaData     uint8
+
+	cpuStall uint16 // CPU cycles left halted by DMC sample fetches
+
+	// The CPU's most recent access, which a DMA halts and repeats
+	lastAddr uint16
+	lastRead bool
+
+	// accessHook observes CPU accesses (see SetAccessHook), sampleHook
+	// the DMC's sample fetches (see SetSampleHook)
+	accessHook AccessHook
+	sampleHook func(addr uint16, value uint8)
+
+	// Watched ranges (see AddWatch), and the CPU for their reports
+	watches []watch
+	nextID  WatchID
+	cpu     interface{ InstructionPC() uint16 }
+
+	// dmcReadGlitch enables the NTSC 2A03's controller conflict (see
+	// repeatControllerRead); the PAL 2A07 fixed it
+	dmcReadGlitch bool
+
+	// cycles counts the CPU cycles clocked. DMA reads happen on "get"
+	// (even) cycles and writes on "put" (odd) ones.
+	cycles uint64
+
+	// PPU dots per CPU cycle, in fifths: 15 (3.0) for NTSC and Dendy,
+	// 16 (3.2) for PAL. ppuClockDebt carries the fractional remainder.
+	ppuClockRatio uint8
+	ppuClockDebt  uint8
 }
 
-// Ensure NESBus implements core.Bus
-var _ core.Bus = (*NESBus)(nil)
+// AccessHook is called after each CPU read or write completes, with the
+// value read or written. DMA transfers and Peek do not call it.
+type AccessHook func(addr uint16, value uint8, write bool)
+
+// Ensure NESBus implements cpu.Bus
+var _ cpu.Bus = (*NESBus)(nil)
 
 // NewNESBus creates a new NES system bus
 func NewNESBus(ppuUnit *ppu.PPU, mapper cartridge.Mapper) *NESBus {
-	return &NESBus{
-		ppu:         ppuUnit,
-		mapper:      mapper,
-		controller1: controller.NewController(),
-		controller2: controller.NewController(),
+	b := &NESBus{
+		ppu:           ppuUnit,
+		apu:           apu.NewAPU(),
+		mapper:        mapper,
+		controller1:   controller.NewController(),
+		controller2:   controller.NewController(),
+		ppuClockRatio: 15,
+		dmcReadGlitch: true,
+	}
+	b.clockedMapper, _ = mapper.(cartridge.CPUClockedMapper)
+	b.apu.SetMemoryReader(b.readSample)
+	return b
+}
+
+// SetRegion selects the CPU/PPU clock ratio for the given timing region
+func (b *NESBus) SetRegion(region cartridge.Region) {
+	if region == cartridge.RegionPAL {
+		b.ppuClockRatio = 16
+	} else {
+		b.ppuClockRatio = 15
 	}
+	b.ppuClockDebt = 0
+	b.dmcReadGlitch = region != cartridge.RegionPAL
+}
+
+// ClearRAM zeroes the 2KB of CPU RAM, as after a power cycle. A reset leaves
+// RAM alone, which is how games tell the two apart.
+func (b *NESBus) ClearRAM() {
+	clear(b.cpuRAM[:])
 }
 
-// Read implements core.Bus.Read for the CPU
+// Read implements cpu.Bus.Read for the CPU
 func (b *NESBus) Read(addr uint16) uint8 {
+	b.lastAddr = addr
+	b.lastRead = true
+
+	watched := len(b.watches) != 0 && b.watched(addr)
+	var old uint8
+	if watched {
+		old = b.Peek(addr)
+	}
+	value := b.read(addr)
+	if watched {
+		b.notifyWatches(addr, false, old, value)
+	}
+	if b.accessHook != nil {
+		b.accessHook(addr, value, false)
+	}
+	return value
+}
+
+// SetAccessHook installs a hook observing every CPU bus access, e.g. for
+// breakpoints (nil removes it)
+func (b *NESBus) SetAccessHook(hook AccessHook) {
+	b.accessHook = hook
+}
+
+// SetSampleHook installs a hook called after each DMC sample fetch, with
+// the address and byte fetched (nil removes it)
+func (b *NESBus) SetSampleHook(hook func(addr uint16, value uint8)) {
+	b.sampleHook = hook
+}
+
+// readSample performs a DMC sample fetch
+func (b *NESBus) readSample(addr uint16) uint8 {
+	value := b.read(addr)
+	if b.sampleHook != nil {
+		b.sampleHook(addr, value)
+	}
+	return value
+}
+
+// read performs a read on the CPU address space, for the CPU or a DMA
+func (b *NESBus) read(addr uint16) uint8 {
 	switch {
 	case addr < 0x2000:
 		// CPU RAM (with mirroring)
@@ -63,17 +176,24 @@ func (b *NESBus) Read(addr uint16) uint8 {
 		return b.ppu.ReadCPURegister(0x2000 + (addr & 0x0007))
 
 	case addr == 0x4015:
-		// APU Status register (stub - APU not implemented)
-		// Return 0 to indicate no sound channels active
-		return 0
+		// APU status (reading clears the frame interrupt)
+		return b.apu.ReadStatus()
 
 	case addr == 0x4016:
 		// Controller 1
-		return b.controller1.Read()
+		value := b.controller1.Read()
+		if logging.Enabled(logging.Input, logging.LevelTrace) {
+			logging.Log(logging.Input, logging.LevelTrace, "controller read", "port", 1, "bit", value)
+		}
+		return value
 
 	case addr == 0x4017:
 		// Controller 2
-		return b.controller2.Read()
+		value := b.controller2.Read()
+		if logging.Enabled(logging.Input, logging.LevelTrace) {
+			logging.Log(logging.Input, logging.LevelTrace, "controller read", "port", 2, "bit", value)
+		}
+		return value
 
 	case addr >= 0x4000 && addr < 0x4020:
 		// Other APU/IO registers - return 0 (open bus)
@@ -87,8 +207,35 @@ func (b *NESBus) Read(addr uint16) uint8 {
 	return 0
 }
 
-// Write implements core.Bus.Write for the CPU
+// Peek reads CPU address space without side effects, for debuggers and
+// memory viewers. PPU registers are sampled without clearing flags or
+// advancing the VRAM address, and controller ports read as 0 so their shift
+// registers are not disturbed.
+func (b *NESBus) Peek(addr uint16) uint8 {
+	switch {
+	case addr < 0x2000:
+		return b.cpuRAM[addr&0x07FF]
+	case addr < 0x4000:
+		return b.ppu.PeekCPURegister(addr)
+	case addr == 0x4015:
+		return b.apu.PeekStatus()
+	case addr >= 0x4020:
+		return b.mapper.ReadPRG(addr)
+	}
+	return 0
+}
+
+// Write implements cpu.Bus.Write for the CPU
 func (b *NESBus) Write(addr uint16, data uint8) {
+	b.lastAddr = addr
+	b.lastRead = false
+
+	watched := len(b.watches) != 0 && b.watched(addr)
+	var old uint8
+	if watched {
+		old = b.Peek(addr)
+	}
+
 	switch {
 	case addr < 0x2000:
 		// CPU RAM (with mirroring)
@@ -99,40 +246,134 @@ func (b *NESBus) Write(addr uint16, data uint8) {
 		b.ppu.WriteCPURegister(0x2000+(addr&0x0007), data)
 
 	case addr == 0x4014:
-		// OAMDMA: DMA transfer of 256 bytes from CPU memory to OAM
+		// OAMDMA: DMA transfer of 256 bytes from CPU memory to OAM,
+		// starting on the next cycle
 		b.dmaPage = data
 		b.dmaTransfer = true
+		b.dmaHalted = false
+		b.dmaLatched = false
+		b.dmaIndex = 0
+		if logging.Enabled(logging.DMA, slog.LevelDebug) {
+			logging.Log(logging.DMA, slog.LevelDebug, "oam dma", logging.Hex16("source", uint16(data)<<8))
+		}
+
+	case addr < 0x4014, addr == 0x4015, addr == 0x4017:
+		// APU channel, status and frame counter registers
+		b.apu.WriteRegister(addr, data)
 
 	case addr == 0x4016:
 		// Controller strobe
 		// Writing 1 then 0 latches controller button states
 		b.controller1.Write(data)
 		b.controller2.Write(data)
+		if logging.Enabled(logging.Input, logging.LevelTrace) {
+			logging.Log(logging.Input, logging.LevelTrace, "controller strobe", "value", data&1)
+		}
 
 	case addr >= 0x4020:
-		// Cartridge space
+		// Cartridge space. Bank and mirroring changes must not reach
+		// scanline dots the PPU has yet to draw.
+		b.ppu.Sync()
 		b.mapper.WritePRG(addr, data)
 	}
+
+	if watched {
+		b.notifyWatches(addr, true, old, data)
+	}
+	if b.accessHook != nil {
+		b.accessHook(addr, data, true)
+	}
 }
 
 // Clock advances the bus by one CPU cycle
-// This runs the PPU at 3x CPU speed and handles DMA transfers
+// This runs the APU and the PPU at 3x CPU speed (3.2x on PAL)
 func (b *NESBus) Clock() {
-	// PPU runs at 3x CPU speed
-	b.ppu.Clock()
-	b.ppu.Clock()
-	b.ppu.Clock()
+	b.cycles++
 
-	// Handle DMA transfer if active
+	if b.clockedMapper != nil {
+		b.clockedMapper.ClockCPU()
+	}
+
+	b.apu.Clock()
+	if stall := b.apu.TakeStall(); stall > 0 {
+		b.cpuStall += uint16(stall)
+		if b.dmcReadGlitch {
+			b.repeatControllerRead()
+		}
+	}
+
+	// PPU runs at 3x CPU speed; PAL adds an extra dot every fifth cycle
+	b.ppuClockDebt += b.ppuClockRatio
+	for b.ppuClockDebt >= 5 {
+		b.ppu.Clock()
+		b.ppuClockDebt -= 5
+	}
+}
+
+// StallCycle reports whether the CPU is halted by DMA for the current cycle,
+// consuming one stall cycle (and running the OAM DMA's access for it) if
+// so. The bus keeps clocking while the CPU is halted.
+func (b *NESBus) StallCycle() bool {
 	if b.dmaTransfer {
-		// DMA transfers 256 bytes from CPU memory page to OAM
-		// Simplified implementation: copy all bytes immediately
-		// (Real hardware takes 513-514 cycles, but this works for most games)
-		for i := 0; i < 256; i++ {
-			addr := uint16(b.dmaPage)<<8 | uint16(i)
-			b.ppu.WriteOAM(uint8(i), b.Read(addr))
+		b.oamDMACycle()
+		return true
+	}
+	if b.cpuStall > 0 {
+		b.cpuStall--
+		return true
+	}
+	return false
+}
+
+// oamDMACycle runs one cycle of an OAM DMA. The first halts the CPU; then
+// each byte is read on a get cycle and written to $2004 on the following
+// put cycle, waiting one more cycle first if the transfer would otherwise
+// start on a put. That makes 513 cycles, or 514 when the $4014 write landed
+// on a put cycle.
+func (b *NESBus) oamDMACycle() {
+	switch {
+	case !b.dmaHalted:
+		b.dmaHalted = true
+
+	case !b.dmaLatched:
+		if b.cycles&1 != 0 {
+			// Alignment: the next read must wait for a get cycle
+			return
 		}
-		b.dmaTransfer = false
+		b.dmaData = b.read(uint16(b.dmaPage)<<8 | uint16(b.dmaIndex))
+		b.dmaLatched = true
+
+	default:
+		b.ppu.WriteCPURegister(0x2004, b.dmaData)
+		b.dmaLatched = false
+		b.dmaIndex++
+		if b.dmaIndex == 0 {
+			b.dmaTransfer = false
+		}
+	}
+}
+
+// repeatControllerRead models the DMC DMA conflict on NTSC consoles. The DMA
+// halts the CPU on a read and the halted read is repeated until the sample
+// fetch is done; when it was a controller port, the controller sees the
+// repeats as a separate read and shifts out a bit the CPU never gets.
+// Games that play DMC samples read the pad until two reads agree.
+func (b *NESBus) repeatControllerRead() {
+	if !b.lastRead {
+		return
+	}
+	var pad *controller.Controller
+	switch b.lastAddr {
+	case 0x4016:
+		pad = b.controller1
+	case 0x4017:
+		pad = b.controller2
+	default:
+		return
+	}
+	pad.Read()
+	if logging.Enabled(logging.DMA, logging.LevelTrace) {
+		logging.Log(logging.DMA, logging.LevelTrace, "dmc dma repeated controller read", logging.Hex16("addr", b.lastAddr))
 	}
 }
 
@@ -141,11 +382,22 @@ func (b *NESBus) IsNMI() bool {
 	return b.ppu.GetNMI()
 }
 
+// IsIRQ returns true if the APU is asserting the IRQ line. Mapper IRQs are
+// polled separately through the mapper.
+func (b *NESBus) IsIRQ() bool {
+	return b.apu.IRQ()
+}
+
 // GetPPU returns a pointer to the PPU
 func (b *NESBus) GetPPU() *ppu.PPU {
 	return b.ppu
 }
 
+// GetAPU returns a pointer to the APU
+func (b *NESBus) GetAPU() *apu.APU {
+	return b.apu
+}
+
 // GetController returns a pointer to the specified controller (0 or 1)
 func (b *NESBus) GetController(num int) *controller.Controller {
 	if num == 0 {
@@ -153,3 +405,92 @@ func (b *NESBus) GetController(num int) *controller.Controller {
 	}
 	return b.controller2
 }
+
+// dmaStateVersion tags MarshalDMA's encoding
+const dmaStateVersion = 1
+
+// MarshalDMA encodes the DMA units' progress: an OAM DMA in flight (page,
+// halt and alignment cycles, the byte being copied), the CPU cycles still
+// owed to DMC sample fetches, the CPU's last access, which a DMC fetch can
+// repeat, and the cycle count whose parity aligns a DMA. Together with
+// cpu.CPU.MarshalBinary it captures everything that decides what the CPU
+// does on the next cycle.
+func (b *NESBus) MarshalDMA() ([]byte, error) {
+	w := state.NewWriter(16)
+	w.U8(dmaStateVersion)
+	b.writeDMAState(w)
+	return w.Bytes(), nil
+}
+
+// UnmarshalDMA restores state encoded by MarshalDMA, leaving the bus
+// untouched if data is invalid
+func (b *NESBus) UnmarshalDMA(data []byte) error {
+	r := state.NewReader(data)
+	if v := r.U8(); v != dmaStateVersion {
+		return fmt.Errorf("bus: unsupported DMA state version %d", v)
+	}
+	dmaPage := r.U8()
+	dmaTransfer := r.Bool()
+	dmaHalted := r.Bool()
+	dmaLatched := r.Bool()
+	dmaIndex := r.U8()
+	dmaData := r.U8()
+	cpuStall := r.U16()
+	lastAddr := r.U16()
+	lastRead := r.Bool()
+	cycles := r.U64()
+	if err := r.Err(); err != nil {
+		return fmt.Errorf("bus: %w", err)
+	}
+	if r.Remaining() != 0 {
+		return fmt.Errorf("bus: %d trailing bytes in DMA state", r.Remaining())
+	}
+
+	b.dmaPage, b.dmaTransfer, b.dmaHalted, b.dmaLatched = dmaPage, dmaTransfer, dmaHalted, dmaLatched
+	b.dmaIndex, b.dmaData, b.cpuStall = dmaIndex, dmaData, cpuStall
+	b.lastAddr, b.lastRead, b.cycles = lastAddr, lastRead, cycles
+	return nil
+}
+
+// writeDMAState appends the DMA units' state to w
+func (b *NESBus) writeDMAState(w *state.Writer) {
+	w.U8(b.dmaPage)
+	w.Bool(b.dmaTransfer)
+	w.Bool(b.dmaHalted)
+	w.Bool(b.dmaLatched)
+	w.U8(b.dmaIndex)
+	w.U8(b.dmaData)
+	w.U16(b.cpuStall)
+	w.U16(b.lastAddr)
+	w.Bool(b.lastRead)
+	w.U64(b.cycles)
+}
+
+// WriteState appends CPU RAM, DMA and controller state to w. The PPU, APU
+// and cartridge write their own state.
+func (b *NESBus) WriteState(w *state.Writer) {
+	w.Block(b.cpuRAM[:])
+	b.writeDMAState(w)
+	w.U8(b.ppuClockDebt)
+	b.controller1.WriteState(w)
+	b.controller2.WriteState(w)
+}
+
+// ReadState restores state written by WriteState. Errors are recorded in r
+// (see state.Reader.Err).
+func (b *NESBus) ReadState(r *state.Reader) {
+	r.Block(b.cpuRAM[:])
+	b.dmaPage = r.U8()
+	b.dmaTransfer = r.Bool()
+	b.dmaHalted = r.Bool()
+	b.dmaLatched = r.Bool()
+	b.dmaIndex = r.U8()
+	b.dmaData = r.U8()
+	b.cpuStall = r.U16()
+	b.lastAddr = r.U16()
+	b.lastRead = r.Bool()
+	b.cycles = r.U64()
+	b.ppuClockDebt = r.U8()
+	b.controller1.ReadState(r)
+	b.controller2.ReadState(r)
+}
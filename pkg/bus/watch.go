@@ -0,0 +1,71 @@
+package bus
+
+// WatchEvent describes one CPU access to a watched range
+type WatchEvent struct {
+	Addr  uint16
+	Write bool
+	Old   uint8  // Value at Addr before the access, as Peek sees it
+	New   uint8  // Value read or written
+	PC    uint16 // Address of the instruction making the access
+	Cycle uint64 // CPU cycles the bus had clocked before the access
+}
+
+// WatchID identifies a watch for RemoveWatch
+type WatchID int
+
+// watch is a watched address range
+type watch struct {
+	id         WatchID
+	start, end uint16
+	fn         func(WatchEvent)
+}
+
+// AttachCPU gives the bus the CPU whose instruction addresses watch events
+// report (a *cpu.CPU or any other core)
+func (b *NESBus) AttachCPU(c interface{ InstructionPC() uint16 }) {
+	b.cpu = c
+}
+
+// AddWatch calls fn for every CPU read and write of an address in
+// [start, end], including the controller ports and other registers with
+// read side effects. DMA transfers are not reported. The old value is taken
+// with Peek, so for registers it shows the state before a read's side
+// effects (e.g. the VBlank flag before a $2002 read clears it).
+func (b *NESBus) AddWatch(start, end uint16, fn func(WatchEvent)) WatchID {
+	b.nextID++
+	b.watches = append(b.watches, watch{id: b.nextID, start: start, end: end, fn: fn})
+	return b.nextID
+}
+
+// RemoveWatch removes a watch added with AddWatch
+func (b *NESBus) RemoveWatch(id WatchID) {
+	for i, w := range b.watches {
+		if w.id == id {
+			b.watches = append(b.watches[:i], b.watches[i+1:]...)
+			return
+		}
+	}
+}
+
+// watched reports whether any watch covers addr
+func (b *NESBus) watched(addr uint16) bool {
+	for _, w := range b.watches {
+		if addr >= w.start && addr <= w.end {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyWatches reports an access to every watch covering addr
+func (b *NESBus) notifyWatches(addr uint16, write bool, old, value uint8) {
+	ev := WatchEvent{Addr: addr, Write: write, Old: old, New: value, Cycle: b.cycles}
+	if b.cpu != nil {
+		ev.PC = b.cpu.InstructionPC()
+	}
+	for _, w := range b.watches {
+		if addr >= w.start && addr <= w.end {
+			w.fn(ev)
+		}
+	}
+}
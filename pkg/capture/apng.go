@@ -0,0 +1,160 @@
+package capture
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"io"
+	"math"
+)
+
+// pngSignature starts every PNG file
+var pngSignature = []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1A, '\n'}
+
+// APNG collects frames and writes them as one animated PNG on Close
+//
+// Each frame is compressed as it arrives (by image/png) and only its image
+// data is kept, but the file itself can only be written once the frame
+// count is known.
+type APNG struct {
+	w      io.Writer
+	fps    float64
+	ihdr   []byte   // Header of the first frame; all frames must match
+	frames [][]byte // Concatenated IDAT payload per frame
+}
+
+// NewAPNG creates an animated PNG writer playing at fps frames per second
+// (e.g. nes.FrameRateNTSC, divided by the capture step)
+func NewAPNG(w io.Writer, fps float64) *APNG {
+	return &APNG{w: w, fps: fps}
+}
+
+// AddFrame compresses and stores one frame
+func (a *APNG) AddFrame(img image.Image) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return err
+	}
+
+	ihdr, data, err := splitPNG(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	if a.ihdr == nil {
+		a.ihdr = ihdr
+	} else if !bytes.Equal(ihdr, a.ihdr) {
+		return fmt.Errorf("apng: frame %d differs in size or color type from frame 0", len(a.frames))
+	}
+	a.frames = append(a.frames, data)
+	return nil
+}
+
+// Close writes the animation
+func (a *APNG) Close() error {
+	if len(a.frames) == 0 {
+		return errors.New("apng: no frames captured")
+	}
+
+	// Frame delay in seconds is delayNum/delayDen; both are 16 bits
+	fps := min(max(a.fps, 1), 600)
+	delayNum, delayDen := uint16(100), uint16(math.Round(fps*100))
+	width := binary.BigEndian.Uint32(a.ihdr[0:4])
+	height := binary.BigEndian.Uint32(a.ihdr[4:8])
+
+	chunks := []chunk{
+		{"IHDR", a.ihdr},
+		{"acTL", be32(uint32(len(a.frames)), 0)}, // 0 plays = loop forever
+	}
+	var seq uint32
+	for i, data := range a.frames {
+		fctl := append(be32(seq, width, height, 0, 0),
+			byte(delayNum>>8), byte(delayNum), byte(delayDen>>8), byte(delayDen),
+			0, 0) // dispose_op none, blend_op source
+		chunks = append(chunks, chunk{"fcTL", fctl})
+		seq++
+
+		if i == 0 {
+			chunks = append(chunks, chunk{"IDAT", data})
+			continue
+		}
+		chunks = append(chunks, chunk{"fdAT", append(be32(seq), data...)})
+		seq++
+	}
+	chunks = append(chunks, chunk{"IEND", nil})
+
+	if _, err := a.w.Write(pngSignature); err != nil {
+		return err
+	}
+	for _, c := range chunks {
+		if err := c.write(a.w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// splitPNG returns the IHDR payload and the concatenated IDAT payloads of
+// an encoded PNG
+func splitPNG(b []byte) (ihdr, data []byte, err error) {
+	if !bytes.HasPrefix(b, pngSignature) {
+		return nil, nil, errors.New("apng: not a PNG stream")
+	}
+	b = b[len(pngSignature):]
+	for len(b) >= 12 {
+		length := binary.BigEndian.Uint32(b[0:4])
+		if uint64(length)+12 > uint64(len(b)) {
+			break
+		}
+		kind := string(b[4:8])
+		payload := b[8 : 8+length]
+		switch kind {
+		case "IHDR":
+			ihdr = payload
+		case "IDAT":
+			data = append(data, payload...)
+		}
+		b = b[12+length:]
+	}
+	if ihdr == nil || data == nil {
+		return nil, nil, errors.New("apng: encoded frame is missing IHDR or IDAT")
+	}
+	return ihdr, data, nil
+}
+
+// chunk is one PNG chunk
+type chunk struct {
+	kind string
+	data []byte
+}
+
+// write writes the chunk with its length and CRC
+func (c chunk) write(w io.Writer) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(c.data)))
+	copy(header[4:8], c.kind)
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:8])
+	crc.Write(c.data)
+
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(c.data); err != nil {
+		return err
+	}
+	return binary.Write(w, binary.BigEndian, crc.Sum32())
+}
+
+// be32 encodes values as consecutive big-endian uint32s
+func be32(values ...uint32) []byte {
+	out := make([]byte, 0, 4*len(values))
+	for _, v := range values {
+		out = binary.BigEndian.AppendUint32(out, v)
+	}
+	return out
+}
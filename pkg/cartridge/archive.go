@@ -0,0 +1,79 @@
+package cartridge
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// maxROMSize bounds how much is decompressed from an archive, well above
+// the largest real cartridge
+const maxROMSize = 16 << 20
+
+var (
+	zipMagic  = []byte("PK\x03\x04")
+	gzipMagic = []byte{0x1F, 0x8B}
+)
+
+// unpackROM returns the ROM image inside a .zip or .gz file, or data
+// unchanged if it is not an archive. A zip must contain exactly one .nes
+// file.
+func unpackROM(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, zipMagic):
+		return unzipROM(data)
+	case bytes.HasPrefix(data, gzipMagic):
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadArchive, err)
+		}
+		defer zr.Close()
+		return readLimited(zr)
+	}
+	return data, nil
+}
+
+// unzipROM extracts the single .nes file from a zip archive
+func unzipROM(data []byte) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadArchive, err)
+	}
+
+	var rom *zip.File
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !strings.EqualFold(path.Ext(f.Name), ".nes") {
+			continue
+		}
+		if rom != nil {
+			return nil, fmt.Errorf("%w: more than one .nes file (%s, %s)", ErrBadArchive, rom.Name, f.Name)
+		}
+		rom = f
+	}
+	if rom == nil {
+		return nil, fmt.Errorf("%w: no .nes file in zip", ErrBadArchive)
+	}
+
+	r, err := rom.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrBadArchive, rom.Name, err)
+	}
+	defer r.Close()
+	return readLimited(r)
+}
+
+// readLimited reads a decompressed ROM, refusing anything over maxROMSize
+func readLimited(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxROMSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadArchive, err)
+	}
+	if len(data) > maxROMSize {
+		return nil, fmt.Errorf("%w: decompressed ROM exceeds %d bytes", ErrBadArchive, maxROMSize)
+	}
+	return data, nil
+}
@@ -0,0 +1,136 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// ErrNoBarcodeReader is returned by ScanBarcode on boards without a reader
+var ErrNoBarcodeReader = errors.New("cartridge has no barcode reader")
+
+// BarcodeReader is implemented by mappers with a barcode reader attached
+// (the Datach Joint ROM System)
+type BarcodeReader interface {
+	ScanBarcode(code string) error
+}
+
+// barcodeCyclesPerModule is how long the reader presents each bar or space
+const barcodeCyclesPerModule = 1000
+
+// EAN digit encodings as 7 modules, most significant first (1 = bar)
+var (
+	eanL = [10]uint8{0x0D, 0x19, 0x13, 0x3D, 0x23, 0x31, 0x2F, 0x3B, 0x37, 0x0B}
+	eanG = [10]uint8{0x27, 0x33, 0x1B, 0x21, 0x1D, 0x39, 0x05, 0x11, 0x09, 0x17}
+	eanR = [10]uint8{0x72, 0x66, 0x6C, 0x42, 0x5C, 0x4E, 0x50, 0x44, 0x48, 0x74}
+
+	// Which of EAN-13 digits 2-7 use G codes, by the first digit (bit 5 = digit 2)
+	eanParity = [10]uint8{0x00, 0x0B, 0x0D, 0x0E, 0x13, 0x19, 0x1C, 0x15, 0x16, 0x1A}
+)
+
+// barcodeReader plays a scanned barcode to the game as a serial bit
+// stream on $6000-$7FFF bit 3, one module per barcodeCyclesPerModule CPU
+// cycles, with bars reading as 0
+type barcodeReader struct {
+	modules []bool // true = bar
+	cycle   uint32 // CPU cycles since the scan started
+}
+
+// scan encodes a barcode and starts playing it
+func (b *barcodeReader) scan(code string) error {
+	if len(code) != 8 && len(code) != 13 {
+		return fmt.Errorf("barcode %q: want 8 or 13 digits", code)
+	}
+	digits := make([]uint8, len(code))
+	for i, c := range code {
+		if c < '0' || c > '9' {
+			return fmt.Errorf("barcode %q: not a digit: %q", code, c)
+		}
+		digits[i] = uint8(c - '0')
+	}
+
+	var modules []bool
+	put := func(pattern uint8, width int) {
+		for i := width - 1; i >= 0; i-- {
+			modules = append(modules, (pattern>>i)&1 != 0)
+		}
+	}
+
+	put(0, 8) // Leading quiet zone
+	put(0b101, 3)
+	if len(digits) == 13 {
+		parity := eanParity[digits[0]]
+		for i, d := range digits[1:7] {
+			if parity&(0x20>>i) != 0 {
+				put(eanG[d], 7)
+			} else {
+				put(eanL[d], 7)
+			}
+		}
+		put(0b01010, 5)
+		for _, d := range digits[7:] {
+			put(eanR[d], 7)
+		}
+	} else {
+		for _, d := range digits[:4] {
+			put(eanL[d], 7)
+		}
+		put(0b01010, 5)
+		for _, d := range digits[4:] {
+			put(eanR[d], 7)
+		}
+	}
+	put(0b101, 3)
+	put(0, 8) // Trailing quiet zone
+
+	b.modules = modules
+	b.cycle = 0
+	return nil
+}
+
+// clock advances the stream by one CPU cycle
+func (b *barcodeReader) clock() {
+	if b.modules != nil {
+		b.cycle++
+		if int(b.cycle/barcodeCyclesPerModule) >= len(b.modules) {
+			b.modules = nil
+		}
+	}
+}
+
+// read returns bit 3 of the reader port: clear while a bar is under the
+// sensor, set for spaces and when idle
+func (b *barcodeReader) read() uint8 {
+	if b.modules != nil && b.modules[b.cycle/barcodeCyclesPerModule] {
+		return 0
+	}
+	return 0x08
+}
+
+// writeState appends the reader's position to w; the encoded stream is
+// derived from the scanned code, so it is stored as-is
+func (b *barcodeReader) writeState(w *state.Writer) {
+	w.U32(b.cycle)
+	w.U32(uint32(len(b.modules)))
+	for _, bar := range b.modules {
+		w.Bool(bar)
+	}
+}
+
+func (b *barcodeReader) readState(r *state.Reader) {
+	b.cycle = r.U32()
+	n := r.U32()
+	if n > uint32(r.Remaining()) {
+		r.U8() // Too long for the data left: fail on the short read
+		return
+	}
+	if n == 0 {
+		b.modules = nil // Idle: clock and read test for nil
+		return
+	}
+	b.modules = make([]bool, n)
+	for i := range b.modules {
+		b.modules[i] = r.Bool()
+	}
+}
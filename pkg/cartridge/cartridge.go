@@ -1,8 +1,14 @@
 package cartridge
 
 import (
+	"crypto/sha1"
 	"fmt"
+	"log/slog"
 	"os"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/patch"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/romdb"
 )
 
 const (
@@ -10,6 +16,7 @@ const (
 	inesHeaderSize = 16
 	prgROMBankSize = 16384 // 16 KB
 	chrROMBankSize = 8192  // 8 KB
+	trainerSize    = 512
 
 	// iNES header magic number
 	inesMagic = "NES\x1a"
@@ -27,48 +34,84 @@ const (
 // Cartridge represents a loaded NES ROM cartridge
 type Cartridge struct {
 	mapper      Mapper
-	mapperID    uint8
+	mapperID    uint16
+	submapper   uint8
 	prgBanks    uint8
 	chrBanks    uint8
 	mirroring   uint8
 	hasSaveRAM  bool
 	hasTrainer  bool
+	region      Region
+	prgNVRAM    int // Battery-backed bytes at the end of PRG-RAM, -1 for all
+	nes20       bool
+	crc32       uint32
+	sha1        [sha1.Size]byte
+	title       string
+	warnings    []Warning
 }
 
-// LoadFromFile loads an iNES format ROM file (.nes)
+// LoadFromFile loads an iNES format ROM file (.nes). The file may also be
+// a .zip holding a single .nes ROM, or a gzip-compressed ROM; archives are
+// recognized by content, not extension.
 func LoadFromFile(filename string) (*Cartridge, error) {
+	return LoadWithOptions(filename, LoadOptions{})
+}
+
+// readROMFile reads a ROM file, unpacking it if it is an archive
+func readROMFile(filename string) ([]byte, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read ROM file: %w", err)
 	}
-
-	return LoadFromBytes(data)
+	return unpackROM(data)
 }
 
 // LoadFromBytes parses an iNES format ROM from a byte slice
 func LoadFromBytes(data []byte) (*Cartridge, error) {
+	return LoadBytesWithOptions(data, LoadOptions{})
+}
+
+// LoadBytesWithOptions parses an iNES format ROM from a byte slice,
+// applying opts on top of the header
+func LoadBytesWithOptions(data []byte, opts LoadOptions) (*Cartridge, error) {
+	if opts.Patch != nil {
+		patched, err := patch.Apply(data, opts.Patch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply patch: %w", err)
+		}
+		data = patched
+	}
+
 	if len(data) < inesHeaderSize {
-		return nil, fmt.Errorf("file too small to be a valid iNES ROM")
+		return nil, fmt.Errorf("%w: file is %d bytes, smaller than the %d-byte header", ErrTruncatedROM, len(data), inesHeaderSize)
 	}
 
 	// Verify iNES header magic
 	if string(data[0:4]) != inesMagic {
-		return nil, fmt.Errorf("invalid iNES header magic: expected %q, got %q", inesMagic, string(data[0:4]))
+		return nil, fmt.Errorf("%w: bad magic: expected %q, got %q", ErrBadHeader, inesMagic, string(data[0:4]))
 	}
 
 	// Parse iNES header
 	header := parseINESHeader(data)
+	if header.prgBanks == 0 {
+		return nil, fmt.Errorf("%w: no PRG-ROM banks declared", ErrBadHeader)
+	}
 
 	// Calculate ROM offsets
 	offset := inesHeaderSize
+	var trainer []byte
 	if header.hasTrainer {
-		offset += 512 // Skip trainer data
+		if len(data) < offset+trainerSize {
+			return nil, fmt.Errorf("%w: need %d bytes of trainer, have %d", ErrTruncatedROM, trainerSize, len(data)-offset)
+		}
+		trainer = data[offset : offset+trainerSize]
+		offset += trainerSize
 	}
 
 	// Extract PRG-ROM
 	prgSize := int(header.prgBanks) * prgROMBankSize
 	if len(data) < offset+prgSize {
-		return nil, fmt.Errorf("file too small for PRG-ROM data")
+		return nil, fmt.Errorf("%w: need %d bytes of PRG-ROM, have %d", ErrTruncatedROM, prgSize, max(len(data)-offset, 0))
 	}
 	prgROM := data[offset : offset+prgSize]
 	offset += prgSize
@@ -78,40 +121,115 @@ func LoadFromBytes(data []byte) (*Cartridge, error) {
 	var chrROM []byte
 	if chrSize > 0 {
 		if len(data) < offset+chrSize {
-			return nil, fmt.Errorf("file too small for CHR-ROM data")
+			return nil, fmt.Errorf("%w: need %d bytes of CHR-ROM, have %d", ErrTruncatedROM, chrSize, max(len(data)-offset, 0))
 		}
 		chrROM = data[offset : offset+chrSize]
+		offset += chrSize
 	} else {
 		// No CHR-ROM means CHR-RAM will be used
 		chrROM = nil
 	}
 
+	// Repair the header from the ROM database when the dump is known
+	warnings := checkDump(data, offset, prgROM, chrROM)
+	var title string
+	crc, sum := romdb.Hash(prgROM, chrROM)
+	if !opts.SkipDatabase {
+		if entry, ok := romdb.Default().LookupHash(crc, sum); ok {
+			header.applyDBEntry(entry)
+			title = entry.Title
+			if entry.BadDump {
+				warnings = append(warnings, Warning{Kind: WarnBadDump, Message: "known bad dump: " + entry.Title})
+			}
+		}
+	}
+	opts.apply(&header)
+
 	// Create appropriate mapper
-	mapper, err := createMapper(header.mapperID, prgROM, chrROM, header.mirroring)
+	mapper, err := createMapper(header.mapperID, header.submapper, prgROM, chrROM, header.mirroring)
 	if err != nil {
 		return nil, err
 	}
 
+	// Boards whose PRG-RAM varies get the size the header declares
+	if s, ok := mapper.(prgRAMSizer); ok && header.prgRAMSize >= 0 {
+		s.setPRGRAMSize(header.prgRAMSize)
+	}
+
+	// Install the trainer at $7000-$71FF, where it expects to run from
+	if trainer != nil {
+		installTrainer(mapper, trainer)
+	}
+
 	return &Cartridge{
 		mapper:      mapper,
 		mapperID:    header.mapperID,
+		submapper:   header.submapper,
 		prgBanks:    header.prgBanks,
 		chrBanks:    header.chrBanks,
 		mirroring:   header.mirroring,
 		hasSaveRAM:  header.hasSaveRAM,
 		hasTrainer:  header.hasTrainer,
+		region:      header.region,
+		prgNVRAM:    header.prgNVRAM,
+		nes20:       isNES20(data),
+		crc32:       crc,
+		sha1:        sum,
+		title:       title,
+		warnings:    warnings,
 	}, nil
 }
 
+// applyDBEntry overrides the header fields a ROM database entry knows
+// better
+func (h *inesHeader) applyDBEntry(e romdb.Entry) {
+	if logging.Enabled(logging.Mapper, slog.LevelDebug) && (e.Mapper != h.mapperID || e.Submapper != h.submapper) {
+		logging.Log(logging.Mapper, slog.LevelDebug, "romdb header override", "title", e.Title,
+			"mapper", h.mapperID, "dbMapper", e.Mapper, "dbSubmapper", e.Submapper)
+	}
+
+	h.mapperID = e.Mapper
+	h.submapper = e.Submapper
+	h.hasSaveRAM = e.Battery
+	h.prgRAMSize = e.PRGRAM
+	h.prgNVRAM = -1
+	h.region = Region(e.Region)
+
+	switch e.Mirroring {
+	case romdb.MirrorHorizontal:
+		h.mirroring = MirrorHorizontal
+	case romdb.MirrorVertical:
+		h.mirroring = MirrorVertical
+	case romdb.MirrorFourScreen:
+		h.mirroring = MirrorFourScreen
+	}
+}
+
+// installTrainer copies trainer data into the mapper's PRG-RAM at $7000.
+// Boards without PRG-RAM have nowhere to put it, so it is dropped.
+func installTrainer(mapper Mapper, trainer []byte) {
+	m, ok := mapper.(PRGRAMMapper)
+	if !ok {
+		return
+	}
+	if ram := m.PRGRAM(); len(ram) >= 0x1000+trainerSize {
+		copy(ram[0x1000:], trainer)
+	}
+}
+
 // inesHeader represents the parsed iNES header
 type inesHeader struct {
-	prgBanks    uint8 // Number of 16KB PRG-ROM banks
-	chrBanks    uint8 // Number of 8KB CHR-ROM banks
-	mapperID    uint8 // Mapper number
-	mirroring   uint8 // Nametable mirroring mode
-	hasSaveRAM  bool  // Battery-backed PRG-RAM present
-	hasTrainer  bool  // 512-byte trainer present
-	fourScreen  bool  // Four-screen VRAM
+	prgBanks    uint8  // Number of 16KB PRG-ROM banks
+	chrBanks    uint8  // Number of 8KB CHR-ROM banks
+	mapperID    uint16 // Mapper number
+	submapper   uint8  // NES 2.0 submapper (0 for iNES 1.0)
+	mirroring   uint8  // Nametable mirroring mode
+	hasSaveRAM  bool   // Battery-backed PRG-RAM present
+	hasTrainer  bool   // 512-byte trainer present
+	fourScreen  bool   // Four-screen VRAM
+	region      Region // CPU/PPU timing region
+	prgRAMSize  int    // PRG-RAM bytes, -1 if not specified
+	prgNVRAM    int    // Battery-backed part of prgRAMSize, -1 if not specified
 }
 
 // parseINESHeader extracts information from the 16-byte iNES header
@@ -137,13 +255,27 @@ func parseINESHeader(data []byte) inesHeader {
 	// Mapper ID is split across flags 6 and 7
 	mapperLow := (flags6 & 0xF0) >> 4
 	mapperHigh := flags7 & 0xF0
-	header.mapperID = mapperHigh | mapperLow
+	if !isNES20(data) && !cleanPadding(data) {
+		// Old dumps with garbage such as "DiskDude!" from byte 7 on; the
+		// high nibble is part of it
+		mapperHigh = 0
+	}
+	header.mapperID = uint16(mapperHigh | mapperLow)
+
+	// NES 2.0 byte 8: submapper, then mapper bits 8-11
+	if isNES20(data) {
+		header.mapperID |= uint16(data[8]&0x0F) << 8
+		header.submapper = data[8] >> 4
+	}
+
+	header.region = detectRegion(data)
+	header.prgRAMSize, header.prgNVRAM = detectPRGRAM(data)
 
 	return header
 }
 
 // createMapper instantiates the appropriate mapper for the given mapper ID
-func createMapper(mapperID uint8, prgROM, chrROM []byte, mirroring uint8) (Mapper, error) {
+func createMapper(mapperID uint16, submapper uint8, prgROM, chrROM []byte, mirroring uint8) (Mapper, error) {
 	switch mapperID {
 	case 0:
 		// NROM (Mapper 0)
@@ -168,15 +300,100 @@ func createMapper(mapperID uint8, prgROM, chrROM []byte, mirroring uint8) (Mappe
 	case 4:
 		// MMC3 (Mapper 4)
 		// Games: Super Mario Bros. 2, Super Mario Bros. 3, Mega Man 3-6
-		return NewMapper4(prgROM, chrROM, mirroring), nil
+		return NewMapper4(prgROM, chrROM, mirroring, submapper), nil
 
 	case 7:
 		// AxROM (Mapper 7)
 		// Games: Battletoads, Marble Madness, Wizards & Warriors
 		return NewMapper7(prgROM, chrROM, mirroring), nil
 
+	case 11:
+		// Color Dreams (Mapper 11)
+		// Games: Crystal Mines, Bible Adventures, Menace Beach
+		return NewMapper11(prgROM, chrROM, mirroring), nil
+
+	case 16:
+		// Bandai FCG-1/2, LZ93D50 with 24C02 EEPROM (Mapper 16)
+		// Games: Dragon Ball Z series, SD Gundam Gaiden
+		return NewMapper16(prgROM, chrROM, mirroring), nil
+
+	case 19:
+		// Namco 129/163 (Mapper 19)
+		// Games: Megami Tensei II, Final Lap, Rolling Thunder (Japan)
+		return NewMapper19(prgROM, chrROM, mirroring), nil
+
+	case 21:
+		// Konami VRC4a/VRC4c (Mapper 21)
+		// Games: Wai Wai World 2, Ganbare Goemon Gaiden 2
+		return NewMapper21(prgROM, chrROM, mirroring), nil
+
+	case 22:
+		// Konami VRC2a (Mapper 22)
+		// Games: TwinBee 3, Ganbare Pennant Race
+		return NewMapper22(prgROM, chrROM, mirroring), nil
+
+	case 23:
+		// Konami VRC2b/VRC4e (Mapper 23)
+		// Games: Contra (Japan), Getsu Fuuma Den, Tiny Toon Adventures (Japan)
+		return NewMapper23(prgROM, chrROM, mirroring), nil
+
+	case 25:
+		// Konami VRC2c/VRC4b/VRC4d (Mapper 25)
+		// Games: Gradius II, Ganbare Goemon 2, TMNT (Japan)
+		return NewMapper25(prgROM, chrROM, mirroring), nil
+
+	case 34:
+		// BNROM / NINA-001 (Mapper 34)
+		// Games: Deadly Towers, Impossible Mission II
+		return NewMapper34(prgROM, chrROM, mirroring, submapper), nil
+
+	case 71:
+		// Camerica/Codemasters BF909x (Mapper 71)
+		// Games: Micro Machines, Fire Hawk, Bee 52
+		return NewMapper71(prgROM, chrROM, mirroring, submapper == 1), nil
+
+	case 85:
+		// Konami VRC7 with FM sound (Mapper 85)
+		// Games: Lagrange Point, Tiny Toon Adventures 2 (Japan)
+		return NewMapper85(prgROM, chrROM, mirroring), nil
+
+	case 105:
+		// NES-EVENT, MMC1 with a countdown timer (Mapper 105)
+		// Games: Nintendo World Championships 1990
+		return NewMapper105(prgROM, chrROM, mirroring), nil
+
+	case 118:
+		// TxSROM, MMC3 with CHR-controlled nametables (Mapper 118)
+		// Games: Armadillo, NES Play Action Football
+		return NewMapper118(prgROM, chrROM, mirroring), nil
+
+	case 119:
+		// TQROM, MMC3 with mixed CHR-ROM and CHR-RAM (Mapper 119)
+		// Games: Pin Bot, High Speed
+		return NewMapper119(prgROM, chrROM, mirroring), nil
+
+	case 157:
+		// Bandai Datach Joint ROM System (Mapper 157)
+		// Games: Datach Dragon Ball Z, Datach SD Gundam Wars
+		return NewMapper157(prgROM, chrROM, mirroring), nil
+
+	case 159:
+		// Bandai LZ93D50 with X24C01 EEPROM (Mapper 159)
+		// Games: Dragon Ball Z: Kyoushuu! Saiyajin, Magical Taruruuto-kun
+		return NewMapper159(prgROM, chrROM, mirroring), nil
+
+	case 206:
+		// Namco 108 / DxROM (Mapper 206)
+		// Games: Gauntlet, Pac-Mania, Karnov
+		return NewMapper206(prgROM, chrROM, mirroring), nil
+
+	case 228:
+		// Active Enterprises multicart (Mapper 228)
+		// Games: Action 52, Cheetahmen II
+		return NewMapper228(prgROM, chrROM, mirroring), nil
+
 	default:
-		return nil, fmt.Errorf("unsupported mapper: %d", mapperID)
+		return nil, ErrUnsupportedMapper{ID: mapperID}
 	}
 }
 
@@ -186,11 +403,13 @@ func (c *Cartridge) GetMapper() Mapper {
 }
 
 // GetMapperID returns the mapper number
-func (c *Cartridge) GetMapperID() uint8 {
+func (c *Cartridge) GetMapperID() uint16 {
 	return c.mapperID
 }
 
-// GetMirroring returns the nametable mirroring mode
+// GetMirroring returns the nametable mirroring mode declared by the header.
+// Mappers with mirroring control report the live mode through
+// Mapper.GetMirroring.
 func (c *Cartridge) GetMirroring() uint8 {
 	return c.mirroring
 }
@@ -200,6 +419,53 @@ func (c *Cartridge) GetPRGBanks() uint8 {
 	return c.prgBanks
 }
 
+// PRGOffset returns the PRG-ROM byte mapped at a CPU address in
+// $8000-$FFFF, or -1 when there is none. Mappers that do not implement
+// PRGBankMapper are treated as unbanked, with PRG-ROM mirrored to fill the
+// window.
+func (c *Cartridge) PRGOffset(addr uint16) int {
+	size := int(c.prgBanks) * prgROMBankSize
+	if addr < 0x8000 || size == 0 {
+		return -1
+	}
+	banks, ok := c.mapper.(PRGBankMapper)
+	if !ok {
+		return int(addr-0x8000) % size
+	}
+	bank := banks.PRGBank(addr)
+	if bank < 0 {
+		return -1
+	}
+	bankSize := banks.PRGBankSize()
+	return (bank*bankSize + int(addr)%bankSize) % size
+}
+
+// GetPRGRAMSize returns the size in bytes of the board's PRG-RAM, as
+// sized from the header, ROM database or LoadOptions
+func (c *Cartridge) GetPRGRAMSize() int {
+	return len(c.getPRGRAM())
+}
+
+// getPRGRAM returns the mapper's PRG-RAM, or nil if it has none
+func (c *Cartridge) getPRGRAM() []uint8 {
+	if m, ok := c.mapper.(PRGRAMMapper); ok {
+		return m.PRGRAM()
+	}
+	return nil
+}
+
+// GetTitle returns the game title from the ROM database, or "" for dumps
+// it doesn't know (all but nestest unless the database has been filled in,
+// see package romdb)
+func (c *Cartridge) GetTitle() string {
+	return c.title
+}
+
+// GetSubmapper returns the NES 2.0 submapper number (0 for iNES 1.0 ROMs)
+func (c *Cartridge) GetSubmapper() uint8 {
+	return c.submapper
+}
+
 // GetCHRBanks returns the number of 8KB CHR-ROM banks
 func (c *Cartridge) GetCHRBanks() uint8 {
 	return c.chrBanks
@@ -209,3 +475,39 @@ func (c *Cartridge) GetCHRBanks() uint8 {
 func (c *Cartridge) HasSaveRAM() bool {
 	return c.hasSaveRAM
 }
+
+// GetRegion returns the timing region declared by the ROM header, or by the
+// ROM database entry when the dump is in the database
+func (c *Cartridge) GetRegion() Region {
+	return c.region
+}
+
+// GetCHRRAM returns the mapper's live CHR-RAM, or nil if the cartridge has
+// CHR-ROM or its mapper does not expose CHR-RAM
+func (c *Cartridge) GetCHRRAM() []uint8 {
+	if m, ok := c.mapper.(CHRRAMMapper); ok {
+		return m.CHRRAM()
+	}
+	return nil
+}
+
+// GetSaveData returns the cartridge's persistent save memory, or nil if
+// it has none. That is the mapper's own save memory (see BatteryMapper),
+// or else the battery-backed part of PRG-RAM: all of it, unless an NES 2.0
+// header declares a smaller non-volatile size.
+func (c *Cartridge) GetSaveData() []uint8 {
+	if m, ok := c.mapper.(BatteryMapper); ok {
+		return m.SaveData()
+	}
+	if !c.hasSaveRAM {
+		return nil
+	}
+	ram := c.getPRGRAM()
+	if c.prgNVRAM >= 0 && c.prgNVRAM < len(ram) {
+		ram = ram[len(ram)-c.prgNVRAM:]
+	}
+	if len(ram) == 0 {
+		return nil
+	}
+	return ram
+}
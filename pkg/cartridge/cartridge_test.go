@@ -0,0 +1,24 @@
+package cartridge
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testutil"
+)
+
+func TestNES20MapperHighBits(t *testing.T) {
+	cart, err := LoadFromBytes(testutil.NewROM(0x104).NES20(0, 0).Build())
+	var unsupported ErrUnsupportedMapper
+	if !errors.As(err, &unsupported) || unsupported.ID != 0x104 {
+		t.Fatalf("mapper 260 loaded as %v, %v", cart, err)
+	}
+
+	cart, err = LoadFromBytes(testutil.NewROM(4).NES20(1, 0).Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cart.GetMapperID() != 4 || cart.GetSubmapper() != 1 {
+		t.Errorf("mapper %d.%d, want 4.1", cart.GetMapperID(), cart.GetSubmapper())
+	}
+}
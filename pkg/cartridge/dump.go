@@ -0,0 +1,75 @@
+package cartridge
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// WarningKind classifies a problem found with a ROM dump
+type WarningKind uint8
+
+const (
+	WarnTrailingData WarningKind = iota // Data after the last declared ROM bank
+	WarnMirroredROM                     // PRG- or CHR-ROM repeats itself (overdump)
+	WarnDirtyHeader                     // Garbage in the iNES padding bytes
+	WarnBadDump                         // ROM database lists the hash as a bad dump
+)
+
+// Warning is a problem with a ROM that did not stop it loading but may
+// make it misbehave. Frontends should show Message to the user.
+type Warning struct {
+	Kind    WarningKind
+	Message string
+}
+
+func (w Warning) String() string {
+	return w.Message
+}
+
+// checkDump looks for signs of a bad or overdumped ROM. end is the offset
+// just past the last declared ROM bank.
+func checkDump(data []byte, end int, prgROM, chrROM []byte) []Warning {
+	var warnings []Warning
+	add := func(kind WarningKind, format string, args ...any) {
+		warnings = append(warnings, Warning{Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// PlayChoice-10 and NES 2.0 miscellaneous ROMs legitimately follow
+	// CHR-ROM
+	trusted := isNES20(data) || cleanPadding(data)
+	extraROM := (trusted && data[7]&0x02 != 0) || (isNES20(data) && data[14]&0x03 != 0)
+	if extra := len(data) - end; extra > 0 && !extraROM {
+		add(WarnTrailingData, "%d bytes of unexpected data after the ROM (overdump or bad header)", extra)
+	}
+
+	if halfRepeats(prgROM, 2*prgROMBankSize) {
+		add(WarnMirroredROM, "PRG-ROM is the same %dKB twice (overdump)", len(prgROM)/2/1024)
+	}
+	if halfRepeats(chrROM, 2*chrROMBankSize) {
+		add(WarnMirroredROM, "CHR-ROM is the same %dKB twice (overdump)", len(chrROM)/2/1024)
+	}
+
+	if !isNES20(data) && !cleanPadding(data) {
+		add(WarnDirtyHeader, "header padding contains %q; ignored the mapper number's high nibble", bytes.TrimRight(data[7:16], "\x00"))
+	}
+
+	return warnings
+}
+
+// halfRepeats reports whether rom, at least min bytes long, consists of
+// the same half twice
+func halfRepeats(rom []byte, min int) bool {
+	if len(rom) < min {
+		return false
+	}
+	half := len(rom) / 2
+	return bytes.Equal(rom[:half], rom[half:])
+}
+
+// Warnings returns problems found with the ROM dump while loading, or nil
+// if it looks good. The size and header checks work on any ROM; known bad
+// dumps are only recognised if the ROM database lists them, and the
+// bundled one lists none (see package romdb).
+func (c *Cartridge) Warnings() []Warning {
+	return c.warnings
+}
@@ -0,0 +1,222 @@
+package cartridge
+
+import "github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+
+// eepromState is the serial protocol phase of an I2C EEPROM
+type eepromState uint8
+
+const (
+	eepromIdle    eepromState = iota
+	eepromDevice              // Receiving the device select byte (24C02)
+	eepromAddress             // Receiving the word address
+	eepromWrite               // Receiving data bytes
+	eepromRead                // Sending data bytes
+	eepromAck                 // Driving the acknowledge bit
+	eepromWaitAck             // Waiting for the host to acknowledge a read byte
+)
+
+// eeprom emulates the serial EEPROMs on Bandai boards, bit-banged by the
+// game through SCL/SDA register bits
+//
+// The 24C02 (256 bytes) speaks standard I2C: START, device select
+// ($A0/$A1), word address, data, STOP, with bytes sent MSB first. The
+// X24C01 (128 bytes) skips the device byte: its first byte is a 7-bit word
+// address plus the read/write bit, and all bytes are sent LSB first.
+type eeprom struct {
+	data     []uint8
+	x24c01   bool
+	pageMask uint8 // Sequential writes wrap within a page
+
+	scl, sda bool // Line levels from the last write
+	out      bool // SDA level driven by the EEPROM (true = released/high)
+
+	state   eepromState
+	next    eepromState // State after the current acknowledge
+	bits    uint8       // Bits transferred in the current byte
+	shift   uint8       // Byte being shifted in or out
+	address uint8       // Current word address
+}
+
+// newEEPROM24C02 creates a 256-byte 24C02
+func newEEPROM24C02() *eeprom {
+	return &eeprom{data: make([]uint8, 256), pageMask: 0x07, out: true}
+}
+
+// newEEPROMX24C01 creates a 128-byte X24C01
+func newEEPROMX24C01() *eeprom {
+	return &eeprom{data: make([]uint8, 128), x24c01: true, pageMask: 0x03, out: true}
+}
+
+// read returns the SDA level the EEPROM presents
+func (e *eeprom) read() bool {
+	return e.out
+}
+
+// write updates the SCL and SDA lines driven by the host
+func (e *eeprom) write(scl, sda bool) {
+	prevSCL, prevSDA := e.scl, e.sda
+	e.scl, e.sda = scl, sda
+
+	switch {
+	case prevSCL && scl && prevSDA && !sda:
+		// START (or repeated START): SDA falls while SCL is high
+		e.bits, e.shift, e.out = 0, 0, true
+		if e.x24c01 {
+			e.state = eepromAddress
+		} else {
+			e.state = eepromDevice
+		}
+
+	case prevSCL && scl && !prevSDA && sda:
+		// STOP: SDA rises while SCL is high
+		e.state, e.out = eepromIdle, true
+
+	case !prevSCL && scl:
+		e.risingEdge(sda)
+
+	case prevSCL && !scl:
+		e.fallingEdge()
+	}
+}
+
+// risingEdge samples SDA while receiving, or counts a sent bit
+func (e *eeprom) risingEdge(sda bool) {
+	switch e.state {
+	case eepromDevice, eepromAddress, eepromWrite:
+		if e.bits < 8 {
+			e.receiveBit(sda)
+		}
+	case eepromRead:
+		e.bits++
+	case eepromWaitAck:
+		// Host pulls SDA low to ask for another byte
+		if sda {
+			e.next = eepromIdle
+		} else {
+			e.next = eepromRead
+		}
+	}
+}
+
+// fallingEdge completes bytes and moves between protocol phases
+func (e *eeprom) fallingEdge() {
+	switch e.state {
+	case eepromDevice:
+		if e.bits == 8 {
+			if e.shift&0xF0 != 0xA0 {
+				e.state = eepromIdle
+				return
+			}
+			e.acknowledge(e.readOrAddress(e.shift&1 != 0))
+		}
+
+	case eepromAddress:
+		if e.bits == 8 {
+			if e.x24c01 {
+				// Seven address bits, then the read/write bit
+				e.address = e.shift & 0x7F
+				e.acknowledge(e.readOrWrite(e.shift&0x80 != 0))
+			} else {
+				e.address = e.shift
+				e.acknowledge(eepromWrite)
+			}
+		}
+
+	case eepromWrite:
+		if e.bits == 8 {
+			e.data[int(e.address)%len(e.data)] = e.shift
+			e.address = e.address&^e.pageMask | (e.address+1)&e.pageMask
+			e.acknowledge(eepromWrite)
+		}
+
+	case eepromAck:
+		e.state, e.bits, e.shift, e.out = e.next, 0, 0, true
+		if e.state == eepromRead {
+			e.shift = e.data[int(e.address)%len(e.data)]
+			e.out = e.outputBit()
+		}
+
+	case eepromRead:
+		if e.bits < 8 {
+			e.out = e.outputBit()
+			return
+		}
+		e.address++
+		e.state, e.out = eepromWaitAck, true
+
+	case eepromWaitAck:
+		e.state, e.bits = e.next, 0
+		if e.state == eepromRead {
+			e.shift = e.data[int(e.address)%len(e.data)]
+			e.out = e.outputBit()
+		}
+	}
+}
+
+// readOrAddress picks the phase after a 24C02 device select byte
+func (e *eeprom) readOrAddress(read bool) eepromState {
+	if read {
+		return eepromRead
+	}
+	return eepromAddress
+}
+
+// readOrWrite picks the phase after an X24C01 address byte
+func (e *eeprom) readOrWrite(read bool) eepromState {
+	if read {
+		return eepromRead
+	}
+	return eepromWrite
+}
+
+// acknowledge drives SDA low for the ninth clock, then continues in next
+func (e *eeprom) acknowledge(next eepromState) {
+	e.state, e.next, e.out = eepromAck, next, false
+}
+
+// receiveBit shifts in one bit in the chip's bit order
+func (e *eeprom) receiveBit(bit bool) {
+	var b uint8
+	if bit {
+		b = 1
+	}
+	if e.x24c01 {
+		e.shift = e.shift>>1 | b<<7
+	} else {
+		e.shift = e.shift<<1 | b
+	}
+	e.bits++
+}
+
+// outputBit returns the next bit to send in the chip's bit order
+func (e *eeprom) outputBit() bool {
+	if e.x24c01 {
+		return (e.shift>>e.bits)&1 != 0
+	}
+	return (e.shift>>(7-e.bits))&1 != 0
+}
+
+// writeState appends the EEPROM contents and protocol state to w
+func (e *eeprom) writeState(w *state.Writer) {
+	w.Block(e.data)
+	w.Bool(e.scl)
+	w.Bool(e.sda)
+	w.Bool(e.out)
+	w.U8(uint8(e.state))
+	w.U8(uint8(e.next))
+	w.U8(e.bits)
+	w.U8(e.shift)
+	w.U8(e.address)
+}
+
+func (e *eeprom) readState(r *state.Reader) {
+	r.Block(e.data)
+	e.scl = r.Bool()
+	e.sda = r.Bool()
+	e.out = r.Bool()
+	e.state = eepromState(r.U8())
+	e.next = eepromState(r.U8())
+	e.bits = r.U8()
+	e.shift = r.U8()
+	e.address = r.U8()
+}
@@ -0,0 +1,30 @@
+package cartridge
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Sentinel errors returned (wrapped) by the ROM loader. Use errors.Is to test
+// for them; the wrapped message carries the specifics.
+var (
+	// ErrBadHeader means the file is not a usable iNES/NES 2.0 image
+	ErrBadHeader = errors.New("invalid iNES header")
+
+	// ErrTruncatedROM means the file is shorter than its header declares
+	ErrTruncatedROM = errors.New("truncated ROM file")
+
+	// ErrBadArchive means a .zip/.gz file could not be read or does not
+	// hold exactly one .nes ROM
+	ErrBadArchive = errors.New("unusable ROM archive")
+)
+
+// ErrUnsupportedMapper is returned when a ROM is well-formed but uses a mapper
+// this emulator does not implement. Use errors.As to retrieve the mapper ID.
+type ErrUnsupportedMapper struct {
+	ID uint16
+}
+
+func (e ErrUnsupportedMapper) Error() string {
+	return fmt.Sprintf("unsupported mapper: %d", e.ID)
+}
@@ -0,0 +1,57 @@
+package cartridge
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testutil"
+)
+
+// FuzzLoadFromBytes checks that malformed ROM images are rejected with an
+// error instead of panicking, and that any image that does load can be
+// accessed across the whole CPU and PPU cartridge space without over-reads
+func FuzzLoadFromBytes(f *testing.F) {
+	if data, err := os.ReadFile("../../roms/nestest.nes"); err == nil {
+		f.Add(data)
+	}
+	for mapperID := range mapperNames {
+		f.Add(testutil.NewROM(mapperID).Build())
+		f.Add(testutil.NewROM(mapperID).PRGBanks(2).CHRBanks(1).Battery().Build())
+		f.Add(testutil.NewROM(mapperID).NES20(0, 1).Build())
+	}
+	f.Add(testutil.NewROM(0).Trainer(nil).Build()[:100]) // trainer, truncated
+	noPRG := testutil.NewROM(0).Header()
+	noPRG[4] = 0
+	f.Add(append(noPRG, 0xFF))                                           // no PRG banks
+	f.Add([]byte("NES\x1a"))                                             // header only
+	f.Add(append([]byte("NES\x1a\x08\x08\x08\x08"), make([]byte, 8)...)) // NES 2.0, sizes but no data
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		cart, err := LoadFromBytes(data)
+		if err != nil {
+			var unsupported ErrUnsupportedMapper
+			if !errors.Is(err, ErrBadHeader) && !errors.Is(err, ErrTruncatedROM) && !errors.As(err, &unsupported) {
+				t.Fatalf("untyped loader error: %v", err)
+			}
+			return
+		}
+
+		m := cart.GetMapper()
+		for addr := 0x4020; addr <= 0xFFFF; addr += 7 {
+			m.ReadPRG(uint16(addr))
+		}
+		for addr := 0x0000; addr < 0x2000; addr += 3 {
+			m.ReadCHR(uint16(addr))
+			m.WriteCHR(uint16(addr), uint8(addr))
+		}
+		// Bank switch to every value the registers can hold, then read again
+		for i, value := range data {
+			addr := uint16(0x6000 + (i*0x3FF)%0xA000)
+			m.WritePRG(addr, value)
+			m.ReadPRG(0x8000 + uint16(i)*0x81)
+			m.ReadCHR(uint16(i*0x47) & 0x1FFF)
+			m.Scanline()
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package cartridge
+
+import (
+	"crypto/sha1"
+	"fmt"
+)
+
+// Info describes a loaded cartridge, combining the header, the ROM
+// database and what the mapper actually allocated
+type Info struct {
+	Mapper     uint16
+	Submapper  uint8
+	MapperName string // Board name, e.g. "MMC3"
+
+	PRGBanks   uint8 // 16KB PRG-ROM banks
+	CHRBanks   uint8 // 8KB CHR-ROM banks, 0 for CHR-RAM boards
+	CHRRAMSize int   // Bytes of CHR-RAM
+	PRGRAMSize int   // Bytes of PRG-RAM (work RAM) on the board
+	Battery    bool  // PRG-RAM or EEPROM is battery-backed
+	Trainer    bool  // File carried a 512-byte trainer
+
+	Mirroring uint8 // Mirror* constant from the header
+	Region    Region
+	NES20     bool // Header is in NES 2.0 format
+
+	// Hashes of PRG-ROM followed by CHR-ROM, as used by the ROM database
+	CRC32 uint32
+	SHA1  [sha1.Size]byte
+
+	Title string // From the ROM database, "" if unknown
+
+	Warnings []Warning // Signs of a bad dump, see Cartridge.Warnings
+}
+
+// Info returns the cartridge's metadata
+func (c *Cartridge) Info() Info {
+	return Info{
+		Mapper:     c.mapperID,
+		Submapper:  c.submapper,
+		MapperName: MapperName(c.mapperID),
+		PRGBanks:   c.prgBanks,
+		CHRBanks:   c.chrBanks,
+		CHRRAMSize: len(c.GetCHRRAM()),
+		PRGRAMSize: c.GetPRGRAMSize(),
+		Battery:    c.hasSaveRAM,
+		Trainer:    c.hasTrainer,
+		Mirroring:  c.mirroring,
+		Region:     c.region,
+		NES20:      c.nes20,
+		CRC32:      c.crc32,
+		SHA1:       c.sha1,
+		Title:      c.title,
+		Warnings:   c.warnings,
+	}
+}
+
+// mapperNames gives the board or chip name of each supported mapper
+var mapperNames = map[uint16]string{
+	0:   "NROM",
+	1:   "MMC1",
+	2:   "UxROM",
+	3:   "CNROM",
+	4:   "MMC3",
+	7:   "AxROM",
+	11:  "Color Dreams",
+	16:  "Bandai FCG",
+	19:  "Namco 163",
+	21:  "VRC4a/VRC4c",
+	22:  "VRC2a",
+	23:  "VRC2b/VRC4e",
+	25:  "VRC2c/VRC4b/VRC4d",
+	34:  "BNROM/NINA-001",
+	71:  "Camerica BF909x",
+	85:  "VRC7",
+	105: "NES-EVENT",
+	118: "TxSROM",
+	119: "TQROM",
+	157: "Bandai Datach",
+	159: "Bandai LZ93D50",
+	206: "Namco 108",
+	228: "Action 52",
+}
+
+// MapperName returns the board name for a mapper number, or "mapper N"
+// for mappers this emulator does not implement
+func MapperName(id uint16) string {
+	if name, ok := mapperNames[id]; ok {
+		return name
+	}
+	return fmt.Sprintf("mapper %d", id)
+}
+
+// MirroringName returns a lowercase name for a Mirror* constant
+func MirroringName(m uint8) string {
+	switch m {
+	case MirrorHorizontal:
+		return "horizontal"
+	case MirrorVertical:
+		return "vertical"
+	case MirrorSingleLow:
+		return "single-screen low"
+	case MirrorSingleHigh:
+		return "single-screen high"
+	case MirrorFourScreen:
+		return "four-screen"
+	}
+	return "unknown"
+}
@@ -5,6 +5,8 @@
 // to extend the NES's memory space through bank switching.
 package cartridge
 
+import "github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+
 // Mapper defines the interface for NES cartridge mappers
 //
 // Mappers handle the translation between CPU/PPU addresses and actual
@@ -30,7 +32,90 @@ type Mapper interface {
 	// GetMirroring returns the current nametable mirroring mode
 	GetMirroring() uint8
 
-	// IRQState returns true if an IRQ is pending and clears the flag
-	// Most mappers return false; MMC3 uses this for scanline-based IRQs
+	// IRQState reports the level of the mapper's IRQ line, which stays
+	// asserted from the IRQ until the game acknowledges it (on MMC3, a
+	// $E000 write). Reading it has no side effects. Most mappers return
+	// false.
 	IRQState() bool
+
+	// WriteState appends the mapper's mutable state (bank registers, IRQ
+	// counters, PRG-RAM, CHR-RAM) to w. ROM contents are not included.
+	WriteState(w *state.Writer)
+
+	// ReadState restores state written by WriteState on the same
+	// cartridge. Errors are recorded in r (see state.Reader.Err), and
+	// may leave the mapper partly restored.
+	ReadState(r *state.Reader)
+}
+
+// CHRRAMMapper is implemented by mappers that can have CHR-RAM. CHRRAM
+// returns the live CHR-RAM (writes show up on the next fetch), or nil when
+// the cartridge has CHR-ROM.
+type CHRRAMMapper interface {
+	CHRRAM() []uint8
+}
+
+// PRGRAMMapper is implemented by mappers with PRG-RAM at $6000-$7FFF.
+// PRGRAM returns the live RAM, indexed from $6000, or nil when the board
+// has none.
+type PRGRAMMapper interface {
+	PRGRAM() []uint8
+}
+
+// PRGBankMapper is implemented by mappers that switch PRG-ROM banks.
+// PRGBank returns the bank mapped at a CPU address in $8000-$FFFF, counted
+// in PRGBankSize units from the start of PRG-ROM (negative when nothing is
+// mapped there), so profilers and code loggers can tell apart code that
+// shares an address.
+type PRGBankMapper interface {
+	PRGBank(addr uint16) int
+	PRGBankSize() int
+}
+
+// CPUClockedMapper is implemented by mappers with logic that runs on every
+// CPU cycle (cycle-based IRQ counters, serial devices). The bus calls
+// ClockCPU once per CPU cycle.
+type CPUClockedMapper interface {
+	ClockCPU()
+}
+
+// BatteryMapper is implemented by mappers with persistent save memory
+// (battery-backed RAM or EEPROM). SaveData returns the live memory:
+// frontends copy a save file into it after loading and write it back out
+// on exit.
+type BatteryMapper interface {
+	SaveData() []uint8
+}
+
+// AudioMapper is implemented by mappers with expansion sound hardware. The
+// APU clocks it every CPU cycle and mixes its output (see apu.Expansion).
+type AudioMapper interface {
+	ClockAudio()
+	AudioOutput() float32
+}
+
+// A12Mapper is implemented by mappers that clock a counter from PPU
+// address line A12 (MMC3's scanline counter). The PPU calls A12Rise on
+// each rise that follows a long enough low period, and A12Fall on every
+// fall, in place of Scanline.
+type A12Mapper interface {
+	A12Rise()
+	A12Fall()
+}
+
+// NametableMapper is implemented by mappers that pick the CIRAM page for
+// each nametable individually rather than through a fixed mirroring mode.
+// NametablePage returns the 1KB page (0 or 1) backing nametable 0-3; the
+// PPU uses it in place of GetMirroring.
+type NametableMapper interface {
+	NametablePage(table uint8) uint8
+}
+
+// NametableROMMapper is implemented by mappers that can map cartridge
+// memory into the nametables (Namco 163). ReadNametable is given a PPU
+// address in $2000-$2FFF and returns ok=false when that nametable is
+// console RAM. The PPU drops writes to nametables served from the
+// cartridge.
+type NametableROMMapper interface {
+	ReadNametable(addr uint16) (value uint8, ok bool)
 }
@@ -1,5 +1,7 @@
 package cartridge
 
+import "github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+
 // Mapper0 implements iNES Mapper 0 (NROM)
 //
 // NROM is the simplest mapper with no bank switching.
@@ -7,7 +9,7 @@ package cartridge
 // CHR-ROM: 8KB (or CHR-RAM if no CHR-ROM present)
 //
 // CPU Memory Map:
-//   $6000-$7FFF: Family Basic only (not implemented)
+//   $6000-$7FFF: 8 KB PRG-RAM (Family Basic; also used by test ROMs)
 //   $8000-$BFFF: First 16 KB of ROM
 //   $C000-$FFFF: Last 16 KB of ROM (mirror of first 16KB if only one bank)
 //
@@ -16,6 +18,7 @@ package cartridge
 type Mapper0 struct {
 	prgROM []uint8 // PRG-ROM (16KB or 32KB)
 	chrMem []uint8 // CHR-ROM or CHR-RAM (8KB)
+	prgRAM []uint8 // PRG-RAM at $6000-$7FFF, 8KB by default
 
 	prgBanks    uint8 // Number of 16KB PRG banks (1 or 2)
 	chrIsRAM    bool  // True if using CHR-RAM instead of CHR-ROM
@@ -26,6 +29,7 @@ type Mapper0 struct {
 func NewMapper0(prgROM, chrROM []uint8, mirroring uint8) *Mapper0 {
 	m := &Mapper0{
 		prgROM:    make([]uint8, len(prgROM)),
+		prgRAM:    make([]uint8, 8192),
 		mirroring: mirroring,
 	}
 
@@ -49,8 +53,15 @@ func NewMapper0(prgROM, chrROM []uint8, mirroring uint8) *Mapper0 {
 	return m
 }
 
-// ReadPRG reads from PRG-ROM (CPU $8000-$FFFF)
+// ReadPRG reads from PRG-RAM or PRG-ROM (CPU $6000-$FFFF)
 func (m *Mapper0) ReadPRG(addr uint16) uint8 {
+	if addr < 0x8000 {
+		if addr >= 0x6000 {
+			return readPRGRAM(m.prgRAM, addr)
+		}
+		return 0
+	}
+
 	// Map $8000-$FFFF to ROM
 	addr -= 0x8000
 
@@ -66,11 +77,12 @@ func (m *Mapper0) ReadPRG(addr uint16) uint8 {
 	return 0
 }
 
-// WritePRG handles writes to PRG space (CPU $8000-$FFFF)
-// NROM has no PRG-RAM or mapper registers, so writes are ignored
+// WritePRG handles writes to PRG space (CPU $6000-$FFFF)
+// NROM has no mapper registers, so only PRG-RAM writes have an effect
 func (m *Mapper0) WritePRG(addr uint16, value uint8) {
-	// NROM has no writable PRG space
-	// Writes are ignored
+	if addr >= 0x6000 && addr < 0x8000 {
+		writePRGRAM(m.prgRAM, addr, value)
+	}
 }
 
 // ReadCHR reads from CHR-ROM/RAM (PPU $0000-$1FFF)
@@ -104,3 +116,38 @@ func (m *Mapper0) GetMirroring() uint8 {
 func (m *Mapper0) IRQState() bool {
 	return false
 }
+
+// WriteState appends PRG-RAM and CHR-RAM (if present) to w
+func (m *Mapper0) WriteState(w *state.Writer) {
+	w.Block(m.prgRAM)
+	if m.chrIsRAM {
+		w.Block(m.chrMem)
+	}
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper0) ReadState(r *state.Reader) {
+	r.Block(m.prgRAM)
+	if m.chrIsRAM {
+		r.Block(m.chrMem)
+	}
+}
+
+// CHRRAM returns the CHR-RAM, or nil for CHR-ROM cartridges
+func (m *Mapper0) CHRRAM() []uint8 {
+	if !m.chrIsRAM {
+		return nil
+	}
+	return m.chrMem
+}
+
+// PRGRAM returns the PRG-RAM at $6000-$7FFF (8KB unless the header says
+// otherwise)
+func (m *Mapper0) PRGRAM() []uint8 {
+	return m.prgRAM
+}
+
+// setPRGRAMSize resizes PRG-RAM to the header's size, up to 8KB
+func (m *Mapper0) setPRGRAMSize(size int) {
+	m.prgRAM = make([]uint8, min(size, prgRAMWindow))
+}
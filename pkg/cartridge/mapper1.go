@@ -1,5 +1,12 @@
 package cartridge
 
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
 // Mapper1 implements iNES Mapper 1 (MMC1)
 //
 // MMC1 is used by games like The Legend of Zelda, Metroid, Mega Man 2, Kid Icarus.
@@ -9,11 +16,11 @@ package cartridge
 // - Switchable PRG-ROM banks (16KB or 32KB mode)
 // - Switchable CHR-ROM banks (4KB or 8KB mode)
 // - Configurable mirroring
-// - Optional PRG-RAM (8KB, may be battery-backed)
+// - Optional PRG-RAM (8KB, 16KB on SOROM or 32KB on SXROM, may be battery-backed)
 //
 // PRG-ROM: Up to 512KB (32 banks of 16KB)
 // CHR-ROM: Up to 128KB (32 banks of 4KB)
-// PRG-RAM: 8KB at $6000-$7FFF (optional)
+// PRG-RAM: 8KB at $6000-$7FFF (optional), banked when larger
 //
 // CPU Memory Map:
 //   $6000-$7FFF: 8KB PRG-RAM (optional, may be battery-backed)
@@ -38,7 +45,7 @@ package cartridge
 type Mapper1 struct {
 	prgROM []uint8 // Full PRG-ROM (all banks)
 	chrMem []uint8 // CHR-ROM or CHR-RAM
-	prgRAM []uint8 // 8KB PRG-RAM at $6000-$7FFF
+	prgRAM []uint8 // PRG-RAM, 8KB by default (up to 32KB, banked)
 
 	prgBanks uint8 // Number of 16KB PRG banks
 	chrBanks uint8 // Number of 4KB CHR banks
@@ -100,51 +107,53 @@ func (m *Mapper1) ReadPRG(addr uint16) uint8 {
 	switch {
 	case addr >= 0x6000 && addr < 0x8000:
 		// $6000-$7FFF: PRG-RAM
-		if m.prgRAMEnabled {
-			return m.prgRAM[addr-0x6000]
+		if m.prgRAMEnabled && len(m.prgRAM) > 0 {
+			return m.prgRAM[m.prgRAMOffset(addr)]
 		}
 		return 0
 
-	case addr >= 0x8000 && addr < 0xC000:
-		// $8000-$BFFF: First PRG bank
-		var bank uint8
-		switch m.prgMode {
-		case 0, 1:
-			// 32KB mode: ignore bit 0 of prgBank
-			bank = (m.prgBank & 0xFE)
-		case 2:
-			// Fix first bank at $8000
-			bank = 0
-		case 3:
-			// Switch 16KB bank at $8000
-			bank = m.prgBank
-		}
-		offset := uint32(bank)*0x4000 + uint32(addr-0x8000)
+	case addr >= 0x8000:
+		offset := uint32(m.PRGBank(addr))*0x4000 + uint32(addr&0x3FFF)
 		if int(offset) < len(m.prgROM) {
 			return m.prgROM[offset]
 		}
+	}
 
-	case addr >= 0xC000:
-		// $C000-$FFFF: Second PRG bank
-		var bank uint8
+	return 0
+}
+
+// PRGBank returns the 16KB PRG bank mapped at addr ($8000-$FFFF)
+func (m *Mapper1) PRGBank(addr uint16) int {
+	if addr < 0xC000 {
+		// $8000-$BFFF: First PRG bank
 		switch m.prgMode {
 		case 0, 1:
-			// 32KB mode: use odd bank
-			bank = (m.prgBank & 0xFE) | 1
+			// 32KB mode: ignore bit 0 of prgBank
+			return int(m.prgBank & 0xFE)
 		case 2:
-			// Switch 16KB bank at $C000
-			bank = m.prgBank
-		case 3:
-			// Fix last bank at $C000
-			bank = m.prgBanks - 1
-		}
-		offset := uint32(bank)*0x4000 + uint32(addr-0xC000)
-		if int(offset) < len(m.prgROM) {
-			return m.prgROM[offset]
+			// Fix first bank at $8000
+			return 0
 		}
+		// Switch 16KB bank at $8000
+		return int(m.prgBank)
 	}
 
-	return 0
+	// $C000-$FFFF: Second PRG bank
+	switch m.prgMode {
+	case 0, 1:
+		// 32KB mode: use odd bank
+		return int(m.prgBank&0xFE) | 1
+	case 2:
+		// Switch 16KB bank at $C000
+		return int(m.prgBank)
+	}
+	// Fix last bank at $C000
+	return int(m.prgBanks - 1)
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper1) PRGBankSize() int {
+	return 0x4000
 }
 
 // WritePRG handles writes to PRG space (CPU $6000-$FFFF)
@@ -152,8 +161,8 @@ func (m *Mapper1) WritePRG(addr uint16, value uint8) {
 	switch {
 	case addr >= 0x6000 && addr < 0x8000:
 		// $6000-$7FFF: PRG-RAM
-		if m.prgRAMEnabled {
-			m.prgRAM[addr-0x6000] = value
+		if m.prgRAMEnabled && len(m.prgRAM) > 0 {
+			m.prgRAM[m.prgRAMOffset(addr)] = value
 		}
 
 	case addr >= 0x8000:
@@ -183,6 +192,11 @@ func (m *Mapper1) WritePRG(addr uint16, value uint8) {
 
 // writeRegister writes to MMC1 internal registers after shift register fills
 func (m *Mapper1) writeRegister(addr uint16, value uint8) {
+	if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+		logging.Log(logging.Mapper, slog.LevelDebug, "mmc1 register write",
+			logging.Hex16("addr", addr&0xE000), logging.Hex8("value", value))
+	}
+
 	switch {
 	case addr >= 0x8000 && addr < 0xA000:
 		// $8000-$9FFF: Control register
@@ -289,3 +303,72 @@ func (m *Mapper1) GetMirroring() uint8 {
 func (m *Mapper1) IRQState() bool {
 	return false
 }
+
+// WriteState appends the shift register, bank registers, PRG-RAM and
+// CHR-RAM to w
+func (m *Mapper1) WriteState(w *state.Writer) {
+	w.U8(m.shiftRegister)
+	w.U8(m.shiftCount)
+	w.U8(m.mirroring)
+	w.U8(m.prgMode)
+	w.U8(m.chrMode)
+	w.U8(m.chrBank0)
+	w.U8(m.chrBank1)
+	w.U8(m.prgBank)
+	w.Bool(m.prgRAMEnabled)
+	w.Block(m.prgRAM)
+	if m.chrIsRAM {
+		w.Block(m.chrMem)
+	}
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper1) ReadState(r *state.Reader) {
+	m.shiftRegister = r.U8()
+	m.shiftCount = r.U8()
+	m.mirroring = r.U8()
+	m.prgMode = r.U8()
+	m.chrMode = r.U8()
+	m.chrBank0 = r.U8()
+	m.chrBank1 = r.U8()
+	m.prgBank = r.U8()
+	m.prgRAMEnabled = r.Bool()
+	r.Block(m.prgRAM)
+	if m.chrIsRAM {
+		r.Block(m.chrMem)
+	}
+}
+
+// CHRRAM returns the CHR-RAM, or nil for CHR-ROM cartridges
+func (m *Mapper1) CHRRAM() []uint8 {
+	if !m.chrIsRAM {
+		return nil
+	}
+	return m.chrMem
+}
+
+// PRGRAM returns the PRG-RAM (8KB unless the header says otherwise), in
+// bank order
+func (m *Mapper1) PRGRAM() []uint8 {
+	return m.prgRAM
+}
+
+// setPRGRAMSize resizes PRG-RAM to the header's size, up to the 32KB of
+// SXROM
+func (m *Mapper1) setPRGRAMSize(size int) {
+	m.prgRAM = make([]uint8, min(size, 4*prgRAMWindow))
+}
+
+// prgRAMOffset maps a $6000-$7FFF address into PRG-RAM. Boards with more
+// than 8KB select the bank through CHR bank 0: bit 3 on SOROM (16KB),
+// bits 2-3 on SXROM (32KB).
+func (m *Mapper1) prgRAMOffset(addr uint16) int {
+	offset := int(addr - 0x6000)
+	switch {
+	case len(m.prgRAM) > 2*prgRAMWindow:
+		offset |= int(m.chrBank0>>2&0x03) << 13
+	case len(m.prgRAM) > prgRAMWindow:
+		offset |= int(m.chrBank0>>3&0x01) << 13
+	}
+	return offset % len(m.prgRAM)
+}
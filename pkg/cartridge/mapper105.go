@@ -0,0 +1,182 @@
+package cartridge
+
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// Mapper105 implements iNES Mapper 105 (NES-EVENT)
+//
+// The Nintendo World Championships 1990 cartridge: an MMC1 with two 128KB
+// PRG-ROM chips, 8KB CHR-RAM, 8KB PRG-RAM and a 30-bit countdown timer
+// whose length is set by four DIP switches. When time runs out it raises
+// an IRQ and the game ends the round.
+//
+// The MMC1's CHR bank 0 register ($A000) is repurposed as board control,
+// since the CHR-RAM is never banked:
+//
+//	Bit 4: Timer reset/stop (1) or run (0); also acknowledges the IRQ
+//	Bit 3: PRG chip: 0 = first chip, 1 = second chip
+//	Bits 1-2: 32KB PRG bank within the first chip
+//
+// The first chip is always banked in 32KB units by bits 1-2. The second
+// chip is banked by the normal MMC1 PRG register and PRG mode (low 3 bits).
+// At power-on the board holds the first 32KB of the first chip until the
+// game sets bit 4 and clears it again.
+//
+// The IRQ fires when the counter reaches (16 + DIP switches) << 25 CPU
+// cycles: 5:00 with all switches off, 6:15 (the competition setting) with
+// the default of %0100.
+type Mapper105 struct {
+	Mapper1
+
+	initState   uint8  // 0 = wait for bit 4 set, 1 = wait for clear, 2 = running
+	counter     uint32 // Timer, counts CPU cycles
+	dipSwitches uint8  // Timer length DIP switches (0-15)
+	irqPending  bool
+}
+
+// DefaultNWCDIPSwitches is the DIP switch setting used in competition
+// (6 minutes 15 seconds)
+const DefaultNWCDIPSwitches = 0x04
+
+// NewMapper105 creates a new NES-EVENT mapper (Mapper 105)
+func NewMapper105(prgROM, chrROM []uint8, mirroring uint8) *Mapper105 {
+	m := &Mapper105{
+		Mapper1:     *NewMapper1(prgROM, nil, mirroring),
+		dipSwitches: DefaultNWCDIPSwitches,
+	}
+	// Start with the timer held in reset, as if bit 4 were set
+	m.chrBank0 = 0x10
+	return m
+}
+
+// SetDIPSwitches sets the timer length switches (0-15); each step adds
+// about 18.75 seconds (NTSC) to the 5 minute base
+func (m *Mapper105) SetDIPSwitches(value uint8) {
+	m.dipSwitches = value & 0x0F
+}
+
+// ReadPRG reads from PRG-RAM or one of the two PRG-ROM chips
+func (m *Mapper105) ReadPRG(addr uint16) uint8 {
+	if addr < 0x8000 {
+		return m.Mapper1.ReadPRG(addr)
+	}
+
+	offset := uint32(m.PRGBank(addr))*0x4000 + uint32(addr&0x3FFF)
+	return m.prgROM[offset%uint32(len(m.prgROM))]
+}
+
+// PRGBank returns the 16KB PRG bank mapped at addr ($8000-$FFFF), counting
+// the second chip's banks after the first chip's eight
+func (m *Mapper105) PRGBank(addr uint16) int {
+	var bank int
+	switch {
+	case m.initState < 2:
+		bank = int(addr-0x8000) >> 14
+
+	case m.chrBank0&0x08 == 0:
+		// First chip: 32KB banks
+		bank = int(m.chrBank0>>1&0x03)*2 + int(addr-0x8000)>>14
+
+	default:
+		// Second chip: MMC1 PRG banking
+		prg := int(m.prgBank & 0x07)
+		upper := addr >= 0xC000
+		switch m.prgMode {
+		case 0, 1:
+			bank = prg &^ 1
+			if upper {
+				bank |= 1
+			}
+		case 2:
+			if upper {
+				bank = prg
+			}
+		case 3:
+			bank = 7
+			if !upper {
+				bank = prg
+			}
+		}
+		bank += 8
+	}
+
+	return bank
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper105) PRGBankSize() int {
+	return 0x4000
+}
+
+// WritePRG handles PRG-RAM and MMC1 writes, tracking the board control
+// bits in the CHR bank 0 register
+func (m *Mapper105) WritePRG(addr uint16, value uint8) {
+	completes := addr >= 0x8000 && value&0x80 == 0 && m.shiftCount == 4
+	m.Mapper1.WritePRG(addr, value)
+	if !completes || addr < 0xA000 || addr >= 0xC000 {
+		return
+	}
+
+	timerStopped := m.chrBank0&0x10 != 0
+	switch {
+	case m.initState == 0 && timerStopped:
+		m.initState = 1
+	case m.initState == 1 && !timerStopped:
+		m.initState = 2
+	}
+	if timerStopped {
+		m.counter = 0
+		m.irqPending = false
+	}
+	if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+		logging.Log(logging.Mapper, slog.LevelDebug, "nes-event control", logging.Hex8("value", m.chrBank0), "init", m.initState)
+	}
+}
+
+// ReadCHR reads from the unbanked 8KB CHR-RAM (PPU $0000-$1FFF)
+func (m *Mapper105) ReadCHR(addr uint16) uint8 {
+	return m.chrMem[addr&0x1FFF]
+}
+
+// WriteCHR writes to the unbanked 8KB CHR-RAM (PPU $0000-$1FFF)
+func (m *Mapper105) WriteCHR(addr uint16, value uint8) {
+	m.chrMem[addr&0x1FFF] = value
+}
+
+// ClockCPU runs the countdown timer for one CPU cycle
+func (m *Mapper105) ClockCPU() {
+	if m.chrBank0&0x10 != 0 {
+		return
+	}
+	m.counter++
+	if m.counter == (0x10|uint32(m.dipSwitches))<<25 {
+		m.irqPending = true
+	}
+}
+
+// IRQState reports whether the timer has expired (until it is stopped)
+func (m *Mapper105) IRQState() bool {
+	return m.irqPending
+}
+
+// WriteState appends the MMC1 state and the timer to w
+func (m *Mapper105) WriteState(w *state.Writer) {
+	m.Mapper1.WriteState(w)
+	w.U8(m.initState)
+	w.U32(m.counter)
+	w.U8(m.dipSwitches)
+	w.Bool(m.irqPending)
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper105) ReadState(r *state.Reader) {
+	m.Mapper1.ReadState(r)
+	m.initState = r.U8()
+	m.counter = r.U32()
+	m.dipSwitches = r.U8()
+	m.irqPending = r.Bool()
+}
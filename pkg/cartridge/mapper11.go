@@ -0,0 +1,125 @@
+package cartridge
+
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// Mapper11 implements iNES Mapper 11 (Color Dreams)
+//
+// Color Dreams' unlicensed discrete logic board, used by Crystal Mines,
+// Bible Adventures, Menace Beach and Wisdom Tree titles.
+//
+// PRG-ROM: Up to 128KB (4 banks of 32KB)
+// CHR-ROM: Up to 128KB (16 banks of 8KB)
+//
+// CPU Memory Map:
+//
+//	$8000-$FFFF: 32 KB switchable PRG-ROM bank
+//
+// PPU Memory Map:
+//
+//	$0000-$1FFF: 8 KB switchable CHR-ROM bank
+//
+// Bank Switching (write to $8000-$FFFF):
+//
+//	Bits 0-1: PRG bank
+//	Bits 4-7: CHR bank
+//
+// Bus Conflicts:
+//
+//	The ROM keeps driving the data bus during writes, so the latch sees
+//	the written value ANDed with the ROM byte at that address. Games write
+//	to a location holding the same value to avoid corruption.
+type Mapper11 struct {
+	prgROM []uint8 // Full PRG-ROM
+	chrROM []uint8 // Full CHR-ROM
+
+	prgBank   uint8 // Selected 32KB PRG bank
+	chrBank   uint8 // Selected 8KB CHR bank
+	mirroring uint8 // Fixed nametable mirroring
+}
+
+// NewMapper11 creates a new Color Dreams mapper (Mapper 11)
+func NewMapper11(prgROM, chrROM []uint8, mirroring uint8) *Mapper11 {
+	m := &Mapper11{
+		prgROM:    make([]uint8, len(prgROM)),
+		chrROM:    make([]uint8, len(chrROM)),
+		mirroring: mirroring,
+	}
+	copy(m.prgROM, prgROM)
+	copy(m.chrROM, chrROM)
+	return m
+}
+
+// ReadPRG reads from the selected 32KB PRG-ROM bank (CPU $8000-$FFFF)
+func (m *Mapper11) ReadPRG(addr uint16) uint8 {
+	if addr < 0x8000 || len(m.prgROM) == 0 {
+		return 0
+	}
+	offset := uint32(m.prgBank)*0x8000 + uint32(addr-0x8000)
+	return m.prgROM[offset%uint32(len(m.prgROM))]
+}
+
+// PRGBank returns the 32KB PRG bank mapped at $8000-$FFFF
+func (m *Mapper11) PRGBank(addr uint16) int {
+	return int(m.prgBank)
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper11) PRGBankSize() int {
+	return 0x8000
+}
+
+// WritePRG latches the PRG and CHR banks, subject to bus conflicts
+func (m *Mapper11) WritePRG(addr uint16, value uint8) {
+	if addr < 0x8000 {
+		return
+	}
+	value &= m.ReadPRG(addr)
+
+	m.prgBank = value & 0x03
+	m.chrBank = value >> 4
+	if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+		logging.Log(logging.Mapper, slog.LevelDebug, "color dreams banks", "prg", m.prgBank, "chr", m.chrBank)
+	}
+}
+
+// ReadCHR reads from the selected CHR-ROM bank (PPU $0000-$1FFF)
+func (m *Mapper11) ReadCHR(addr uint16) uint8 {
+	if len(m.chrROM) == 0 {
+		return 0
+	}
+	offset := uint32(m.chrBank)*0x2000 + uint32(addr&0x1FFF)
+	return m.chrROM[offset%uint32(len(m.chrROM))]
+}
+
+// WriteCHR ignores writes (CHR-ROM is read-only)
+func (m *Mapper11) WriteCHR(addr uint16, value uint8) {}
+
+// Scanline is a no-op (no IRQ hardware)
+func (m *Mapper11) Scanline() {}
+
+// GetMirroring returns the fixed nametable mirroring mode
+func (m *Mapper11) GetMirroring() uint8 {
+	return m.mirroring
+}
+
+// IRQState returns false (Color Dreams has no IRQ support)
+func (m *Mapper11) IRQState() bool {
+	return false
+}
+
+// WriteState appends the selected banks to w
+func (m *Mapper11) WriteState(w *state.Writer) {
+	w.U8(m.prgBank)
+	w.U8(m.chrBank)
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper11) ReadState(r *state.Reader) {
+	m.prgBank = r.U8()
+	m.chrBank = r.U8()
+}
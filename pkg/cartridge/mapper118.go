@@ -0,0 +1,52 @@
+package cartridge
+
+// Mapper118 implements iNES Mapper 118 (TxSROM)
+//
+// An MMC3 board that wires CHR bank bit 7 to the nametable RAM's A10
+// instead of using the mirroring register, so each nametable can be
+// pointed at either CIRAM page. Used by Armadillo and NES Play Action
+// Football.
+//
+// Nametable control follows the CHR banks covering $0000-$0FFF:
+//
+//	CHR mode 0: R0 bit 7 selects nametables 0-1, R1 bit 7 nametables 2-3
+//	CHR mode 1: R2-R5 bit 7 select nametables 0-3
+//
+// Everything else, including the scanline IRQ, is plain MMC3 (Mapper4).
+type Mapper118 struct {
+	Mapper4
+}
+
+// NewMapper118 creates a new TxSROM mapper (Mapper 118)
+func NewMapper118(prgROM, chrROM []uint8, mirroring uint8) *Mapper118 {
+	return &Mapper118{Mapper4: *NewMapper4(prgROM, chrROM, mirroring, 0)}
+}
+
+// WritePRG handles MMC3 register writes, ignoring the mirroring register
+func (m *Mapper118) WritePRG(addr uint16, value uint8) {
+	if addr >= 0xA000 && addr < 0xC000 && addr&1 == 0 {
+		return
+	}
+	m.Mapper4.WritePRG(addr, value)
+}
+
+// NametablePage returns the CIRAM page selected by CHR bank bit 7
+func (m *Mapper118) NametablePage(table uint8) uint8 {
+	return m.chrBank(uint16(table&0x03)<<10) >> 7
+}
+
+// GetMirroring returns the mirroring mode closest to the current
+// nametable arrangement (the PPU uses NametablePage directly)
+func (m *Mapper118) GetMirroring() uint8 {
+	nt0, nt1, nt2, nt3 := m.NametablePage(0), m.NametablePage(1), m.NametablePage(2), m.NametablePage(3)
+	switch {
+	case nt0 == nt1 && nt1 == nt2 && nt2 == nt3:
+		if nt0 == 0 {
+			return MirrorSingleLow
+		}
+		return MirrorSingleHigh
+	case nt0 == nt2 && nt1 == nt3:
+		return MirrorVertical
+	}
+	return MirrorHorizontal
+}
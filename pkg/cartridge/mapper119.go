@@ -0,0 +1,57 @@
+package cartridge
+
+import "github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+
+// Mapper119 implements iNES Mapper 119 (TQROM)
+//
+// An MMC3 board carrying both CHR-ROM and 8KB of CHR-RAM. Bit 6 of each
+// CHR bank number picks the chip: clear for ROM, set for RAM (where only
+// bits 0-2 are used). Used by Pin Bot and High Speed.
+//
+// Everything else, including the scanline IRQ, is plain MMC3 (Mapper4).
+type Mapper119 struct {
+	Mapper4
+	chrRAM []uint8 // 8KB CHR-RAM
+}
+
+// NewMapper119 creates a new TQROM mapper (Mapper 119)
+func NewMapper119(prgROM, chrROM []uint8, mirroring uint8) *Mapper119 {
+	return &Mapper119{
+		Mapper4: *NewMapper4(prgROM, chrROM, mirroring, 0),
+		chrRAM:  make([]uint8, 8192),
+	}
+}
+
+// ReadCHR reads from CHR-ROM or CHR-RAM depending on the bank (PPU $0000-$1FFF)
+func (m *Mapper119) ReadCHR(addr uint16) uint8 {
+	bank := m.chrBank(addr)
+	if bank&0x40 != 0 {
+		return m.chrRAM[uint32(bank&0x07)*0x400+uint32(addr&0x03FF)]
+	}
+	return m.chrMem[m.chrOffset(addr)]
+}
+
+// WriteCHR writes to CHR-RAM when a RAM bank is mapped (PPU $0000-$1FFF)
+func (m *Mapper119) WriteCHR(addr uint16, value uint8) {
+	bank := m.chrBank(addr)
+	if bank&0x40 != 0 {
+		m.chrRAM[uint32(bank&0x07)*0x400+uint32(addr&0x03FF)] = value
+	}
+}
+
+// CHRRAM returns the 8KB CHR-RAM
+func (m *Mapper119) CHRRAM() []uint8 {
+	return m.chrRAM
+}
+
+// WriteState appends the MMC3 state and CHR-RAM to w
+func (m *Mapper119) WriteState(w *state.Writer) {
+	m.Mapper4.WriteState(w)
+	w.Block(m.chrRAM)
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper119) ReadState(r *state.Reader) {
+	m.Mapper4.ReadState(r)
+	r.Block(m.chrRAM)
+}
@@ -0,0 +1,285 @@
+package cartridge
+
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// Mapper16 implements the Bandai FCG board family: iNES Mappers 16, 159
+// and 157 (Datach Joint ROM System)
+//
+// Used by: Dragon Ball Z series, SD Gundam Gaiden, Datach titles
+//
+// PRG-ROM: Up to 256KB (16 banks of 16KB)
+// CHR-ROM: Up to 256KB (256 banks of 1KB); Datach uses 8KB CHR-RAM
+//
+// CPU Memory Map:
+//
+//	$6000-$7FFF: Reads: bit 4 = EEPROM data, bit 3 = barcode (Datach)
+//	$8000-$BFFF: 16 KB switchable PRG-ROM bank
+//	$C000-$FFFF: 16 KB PRG-ROM bank (fixed to last bank)
+//
+// Registers (address & $0F, at $6000-$7FFF on FCG-1/2 and $8000-$FFFF on
+// LZ93D50 boards; Mapper 16 accepts both since iNES 1.0 can't tell them apart):
+//
+//	$0-$7: 1 KB CHR bank for PPU $0000-$1FFF
+//	$8:    PRG bank
+//	$9:    Mirroring (0 = vertical, 1 = horizontal, 2/3 = one-screen)
+//	$A:    IRQ enable (bit 0); acknowledges the IRQ
+//	$B/$C: IRQ counter low/high (FCG-1/2) or reload latch (LZ93D50)
+//	$D:    EEPROM control: bit 5 = SCL, bit 6 = SDA
+//
+// The IRQ counter decrements every CPU cycle while enabled and raises an IRQ
+// when it reaches zero. On LZ93D50 boards, writing $A also reloads the
+// counter from the latch.
+//
+// Saves live in a serial EEPROM: a 24C02 (256 bytes) on Mapper 16 and
+// Datach, an X24C01 (128 bytes) on Mapper 159.
+type Mapper16 struct {
+	prgROM   []uint8 // Full PRG-ROM
+	chrMem   []uint8 // CHR-ROM, or CHR-RAM on Datach
+	chrIsRAM bool
+
+	prgBanks uint8 // Number of 16KB PRG banks
+
+	chrBanks  [8]uint8 // 1KB CHR bank registers
+	prgBank   uint8    // Switchable PRG bank
+	mirroring uint8
+
+	irqEnabled bool
+	irqPending bool
+	irqCounter uint16
+	irqLatch   uint16
+
+	eeprom  *eeprom
+	barcode *barcodeReader // Datach only
+}
+
+// NewMapper16 creates a Bandai FCG / LZ93D50 mapper with a 24C02 EEPROM
+func NewMapper16(prgROM, chrROM []uint8, mirroring uint8) *Mapper16 {
+	return newBandaiFCG(prgROM, chrROM, mirroring, newEEPROM24C02())
+}
+
+// NewMapper159 creates an LZ93D50 mapper with an X24C01 EEPROM
+func NewMapper159(prgROM, chrROM []uint8, mirroring uint8) *Mapper16 {
+	return newBandaiFCG(prgROM, chrROM, mirroring, newEEPROMX24C01())
+}
+
+// NewMapper157 creates a Datach Joint ROM System mapper: an LZ93D50 with
+// a 24C02 EEPROM, CHR-RAM and a barcode reader (see ScanBarcode)
+func NewMapper157(prgROM, chrROM []uint8, mirroring uint8) *Mapper16 {
+	m := newBandaiFCG(prgROM, nil, mirroring, newEEPROM24C02())
+	m.barcode = &barcodeReader{}
+	return m
+}
+
+// newBandaiFCG builds the shared board
+func newBandaiFCG(prgROM, chrROM []uint8, mirroring uint8, e *eeprom) *Mapper16 {
+	m := &Mapper16{
+		prgROM:    make([]uint8, len(prgROM)),
+		prgBanks:  uint8(len(prgROM) / 16384),
+		mirroring: mirroring,
+		eeprom:    e,
+	}
+	copy(m.prgROM, prgROM)
+
+	if len(chrROM) > 0 {
+		m.chrMem = make([]uint8, len(chrROM))
+		copy(m.chrMem, chrROM)
+	} else {
+		m.chrMem = make([]uint8, 8192)
+		m.chrIsRAM = true
+	}
+	return m
+}
+
+// ReadPRG reads the EEPROM/barcode port or PRG-ROM (CPU $6000-$FFFF)
+func (m *Mapper16) ReadPRG(addr uint16) uint8 {
+	switch {
+	case addr >= 0x6000 && addr < 0x8000:
+		var value uint8
+		if m.eeprom.read() {
+			value |= 0x10
+		}
+		if m.barcode != nil {
+			value |= m.barcode.read()
+		}
+		return value
+
+	case addr >= 0x8000:
+		offset := uint32(m.PRGBank(addr))*0x4000 + uint32(addr&0x3FFF)
+		return m.prgROM[offset%uint32(len(m.prgROM))]
+	}
+	return 0
+}
+
+// PRGBank returns the 16KB PRG bank mapped at addr ($8000-$FFFF): the
+// selected one below $C000, the last one above
+func (m *Mapper16) PRGBank(addr uint16) int {
+	if addr >= 0xC000 {
+		return int(m.prgBanks - 1)
+	}
+	return int(m.prgBank)
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper16) PRGBankSize() int {
+	return 0x4000
+}
+
+// WritePRG handles register writes (CPU $6000-$FFFF)
+func (m *Mapper16) WritePRG(addr uint16, value uint8) {
+	if addr < 0x6000 {
+		return
+	}
+	lz93d50 := addr >= 0x8000
+
+	switch reg := addr & 0x0F; {
+	case reg < 8:
+		m.chrBanks[reg] = value
+
+	case reg == 0x8:
+		m.prgBank = value & 0x0F
+		if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+			logging.Log(logging.Mapper, slog.LevelDebug, "bandai prg bank", "bank", m.prgBank)
+		}
+
+	case reg == 0x9:
+		switch value & 0x03 {
+		case 0:
+			m.mirroring = MirrorVertical
+		case 1:
+			m.mirroring = MirrorHorizontal
+		case 2:
+			m.mirroring = MirrorSingleLow
+		case 3:
+			m.mirroring = MirrorSingleHigh
+		}
+
+	case reg == 0xA:
+		m.irqEnabled = value&0x01 != 0
+		m.irqPending = false
+		if lz93d50 {
+			m.irqCounter = m.irqLatch
+		}
+
+	case reg == 0xB:
+		if lz93d50 {
+			m.irqLatch = m.irqLatch&0xFF00 | uint16(value)
+		} else {
+			m.irqCounter = m.irqCounter&0xFF00 | uint16(value)
+		}
+
+	case reg == 0xC:
+		if lz93d50 {
+			m.irqLatch = m.irqLatch&0x00FF | uint16(value)<<8
+		} else {
+			m.irqCounter = m.irqCounter&0x00FF | uint16(value)<<8
+		}
+
+	case reg == 0xD:
+		m.eeprom.write(value&0x20 != 0, value&0x40 != 0)
+	}
+}
+
+// ReadCHR reads from banked CHR-ROM or CHR-RAM (PPU $0000-$1FFF)
+func (m *Mapper16) ReadCHR(addr uint16) uint8 {
+	if m.chrIsRAM {
+		return m.chrMem[addr&0x1FFF]
+	}
+	offset := uint32(m.chrBanks[(addr>>10)&7])*0x400 + uint32(addr&0x03FF)
+	return m.chrMem[offset%uint32(len(m.chrMem))]
+}
+
+// WriteCHR writes to CHR-RAM (PPU $0000-$1FFF)
+func (m *Mapper16) WriteCHR(addr uint16, value uint8) {
+	if m.chrIsRAM {
+		m.chrMem[addr&0x1FFF] = value
+	}
+}
+
+// ClockCPU runs the IRQ counter and barcode reader for one CPU cycle
+func (m *Mapper16) ClockCPU() {
+	if m.irqEnabled {
+		if m.irqCounter == 0 {
+			m.irqPending = true
+		}
+		m.irqCounter--
+	}
+	if m.barcode != nil {
+		m.barcode.clock()
+	}
+}
+
+// Scanline is a no-op; the IRQ counter runs on CPU cycles
+func (m *Mapper16) Scanline() {}
+
+// GetMirroring returns the nametable mirroring mode
+func (m *Mapper16) GetMirroring() uint8 {
+	return m.mirroring
+}
+
+// IRQState reports whether the IRQ line is asserted (until acknowledged)
+func (m *Mapper16) IRQState() bool {
+	return m.irqPending
+}
+
+// SaveData returns the EEPROM contents
+func (m *Mapper16) SaveData() []uint8 {
+	return m.eeprom.data
+}
+
+// CHRRAM returns the CHR-RAM, or nil for CHR-ROM cartridges
+func (m *Mapper16) CHRRAM() []uint8 {
+	if !m.chrIsRAM {
+		return nil
+	}
+	return m.chrMem
+}
+
+// ScanBarcode feeds an EAN-13 or EAN-8 barcode (digits only) to the Datach
+// barcode reader, as if a card had been swiped. It fails on other boards.
+func (m *Mapper16) ScanBarcode(code string) error {
+	if m.barcode == nil {
+		return ErrNoBarcodeReader
+	}
+	return m.barcode.scan(code)
+}
+
+// WriteState appends bank registers, IRQ state, the EEPROM and CHR-RAM to w
+func (m *Mapper16) WriteState(w *state.Writer) {
+	w.Block(m.chrBanks[:])
+	w.U8(m.prgBank)
+	w.U8(m.mirroring)
+	w.Bool(m.irqEnabled)
+	w.Bool(m.irqPending)
+	w.U16(m.irqCounter)
+	w.U16(m.irqLatch)
+	m.eeprom.writeState(w)
+	if m.barcode != nil {
+		m.barcode.writeState(w)
+	}
+	if m.chrIsRAM {
+		w.Block(m.chrMem)
+	}
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper16) ReadState(r *state.Reader) {
+	r.Block(m.chrBanks[:])
+	m.prgBank = r.U8()
+	m.mirroring = r.U8()
+	m.irqEnabled = r.Bool()
+	m.irqPending = r.Bool()
+	m.irqCounter = r.U16()
+	m.irqLatch = r.U16()
+	m.eeprom.readState(r)
+	if m.barcode != nil {
+		m.barcode.readState(r)
+	}
+	if m.chrIsRAM {
+		r.Block(m.chrMem)
+	}
+}
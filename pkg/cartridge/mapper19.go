@@ -0,0 +1,321 @@
+package cartridge
+
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// Mapper19 implements iNES Mapper 19 (Namco 129/163)
+//
+// Used by: Megami Tensei II, Final Lap, Rolling Thunder (Japan),
+// Star Wars (Namco), Erika to Satoru no Yume Bouken
+//
+// PRG-ROM: Up to 512KB (64 banks of 8KB)
+// CHR-ROM: Up to 256KB (256 banks of 1KB)
+// PRG-RAM: 8KB at $6000-$7FFF
+// Internal RAM: 128 bytes (shared with the sound channels)
+//
+// CPU Memory Map:
+//
+//	$6000-$7FFF: 8 KB PRG-RAM
+//	$8000-$9FFF: 8 KB switchable PRG-ROM bank
+//	$A000-$BFFF: 8 KB switchable PRG-ROM bank
+//	$C000-$DFFF: 8 KB switchable PRG-ROM bank
+//	$E000-$FFFF: 8 KB PRG-ROM bank (fixed to last bank)
+//
+// Registers:
+//
+//	$4800-$4FFF: Internal RAM data port
+//	$5000-$57FF: IRQ counter bits 0-7 (read/write)
+//	$5800-$5FFF: IRQ counter bits 8-14, bit 7 = enable (read/write)
+//	$8000-$BFFF: CHR banks 0-7 (1KB each, one per $800)
+//	$C000-$DFFF: Nametable banks 0-3: $E0-$FF select console RAM page
+//	             (bit 0), anything lower a 1KB CHR-ROM bank
+//	$E000:       PRG bank 0 (bits 0-5)
+//	$E800:       PRG bank 1 (bits 0-5)
+//	$F000:       PRG bank 2 (bits 0-5)
+//	$F800:       Internal RAM address (bits 0-6), auto-increment (bit 7);
+//	             also PRG-RAM write protect (writable when bits 4-7 are
+//	             %0100, per 2KB window in bits 0-3)
+//
+// The IRQ counter counts up every CPU cycle while enabled and raises an
+// IRQ on reaching $7FFF, where it stops. Writing either counter register
+// acknowledges it.
+//
+// Not emulated: CHR banks $E0-$FF selecting console RAM as pattern memory
+// (they read CHR-ROM instead), and the expansion sound channels.
+type Mapper19 struct {
+	prgROM []uint8 // Full PRG-ROM
+	chrMem []uint8 // CHR-ROM or CHR-RAM
+	prgRAM []uint8 // PRG-RAM, 8KB by default
+
+	prgBanks uint8 // Number of 8KB PRG banks
+	chrIsRAM bool
+
+	prgBank   [3]uint8
+	chrBanks  [8]uint8
+	ntBanks   [4]uint8
+	protect   uint8 // Last $F800 write (PRG-RAM write protect)
+	mirroring uint8
+
+	// Internal RAM and its address port
+	internalRAM [128]uint8
+	ramAddr     uint8
+	ramAutoInc  bool
+
+	irqCounter uint16 // 15-bit up counter
+	irqEnabled bool
+	irqPending bool
+}
+
+// NewMapper19 creates a new Namco 163 mapper (Mapper 19)
+func NewMapper19(prgROM, chrROM []uint8, mirroring uint8) *Mapper19 {
+	m := &Mapper19{
+		prgROM:    make([]uint8, len(prgROM)),
+		prgRAM:    make([]uint8, 8192),
+		prgBanks:  uint8(len(prgROM) / 8192),
+		mirroring: mirroring,
+	}
+	copy(m.prgROM, prgROM)
+
+	if len(chrROM) > 0 {
+		m.chrMem = make([]uint8, len(chrROM))
+		copy(m.chrMem, chrROM)
+	} else {
+		m.chrMem = make([]uint8, 8192)
+		m.chrIsRAM = true
+	}
+
+	// Until the game sets them up, point the nametables at console RAM
+	// following the header's mirroring
+	if mirroring == MirrorVertical {
+		m.ntBanks = [4]uint8{0xE0, 0xE1, 0xE0, 0xE1}
+	} else {
+		m.ntBanks = [4]uint8{0xE0, 0xE0, 0xE1, 0xE1}
+	}
+	return m
+}
+
+// ReadPRG reads from chip registers, PRG-RAM or banked PRG-ROM
+func (m *Mapper19) ReadPRG(addr uint16) uint8 {
+	switch {
+	case addr >= 0x4800 && addr < 0x5000:
+		value := m.internalRAM[m.ramAddr]
+		m.stepRAMAddr()
+		return value
+
+	case addr >= 0x5000 && addr < 0x5800:
+		return uint8(m.irqCounter)
+
+	case addr >= 0x5800 && addr < 0x6000:
+		value := uint8(m.irqCounter >> 8)
+		if m.irqEnabled {
+			value |= 0x80
+		}
+		return value
+
+	case addr >= 0x6000 && addr < 0x8000:
+		return readPRGRAM(m.prgRAM, addr)
+
+	case addr >= 0x8000:
+		offset := uint32(m.PRGBank(addr))*0x2000 + uint32(addr&0x1FFF)
+		return m.prgROM[offset%uint32(len(m.prgROM))]
+	}
+	return 0
+}
+
+// PRGBank returns the 8KB PRG bank mapped at addr ($8000-$FFFF): one of the
+// three switchable slots, or the last bank at $E000
+func (m *Mapper19) PRGBank(addr uint16) int {
+	if slot := (addr - 0x8000) >> 13; slot < 3 {
+		return int(m.prgBank[slot])
+	}
+	return int(m.prgBanks - 1)
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper19) PRGBankSize() int {
+	return 0x2000
+}
+
+// WritePRG handles chip registers and PRG-RAM writes
+func (m *Mapper19) WritePRG(addr uint16, value uint8) {
+	switch {
+	case addr >= 0x4800 && addr < 0x5000:
+		m.internalRAM[m.ramAddr] = value
+		m.stepRAMAddr()
+
+	case addr >= 0x5000 && addr < 0x5800:
+		m.irqCounter = m.irqCounter&0x7F00 | uint16(value)
+		m.irqPending = false
+
+	case addr >= 0x5800 && addr < 0x6000:
+		m.irqCounter = m.irqCounter&0x00FF | uint16(value&0x7F)<<8
+		m.irqEnabled = value&0x80 != 0
+		m.irqPending = false
+
+	case addr >= 0x6000 && addr < 0x8000:
+		if m.prgRAMWritable(addr) {
+			writePRGRAM(m.prgRAM, addr, value)
+		}
+
+	case addr >= 0x8000 && addr < 0xC000:
+		slot := (addr - 0x8000) >> 11
+		m.chrBanks[slot] = value
+		if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+			logging.Log(logging.Mapper, slog.LevelDebug, "namco 163 chr bank", "slot", slot, "bank", value)
+		}
+
+	case addr >= 0xC000 && addr < 0xE000:
+		m.ntBanks[(addr-0xC000)>>11] = value
+
+	case addr >= 0xE000 && addr < 0xE800:
+		m.prgBank[0] = value & 0x3F
+
+	case addr >= 0xE800 && addr < 0xF000:
+		m.prgBank[1] = value & 0x3F
+
+	case addr >= 0xF000 && addr < 0xF800:
+		m.prgBank[2] = value & 0x3F
+
+	case addr >= 0xF800:
+		m.protect = value
+		m.ramAddr = value & 0x7F
+		m.ramAutoInc = value&0x80 != 0
+	}
+}
+
+// stepRAMAddr advances the internal RAM address after a data port access
+func (m *Mapper19) stepRAMAddr() {
+	if m.ramAutoInc {
+		m.ramAddr = (m.ramAddr + 1) & 0x7F
+	}
+}
+
+// prgRAMWritable reports whether $F800 allows writes to the 2KB PRG-RAM
+// window containing addr
+func (m *Mapper19) prgRAMWritable(addr uint16) bool {
+	if m.protect&0xF0 != 0x40 {
+		return false
+	}
+	window := (addr - 0x6000) >> 11
+	return m.protect&(1<<window) == 0
+}
+
+// ReadCHR reads from banked CHR memory (PPU $0000-$1FFF)
+func (m *Mapper19) ReadCHR(addr uint16) uint8 {
+	return m.chrMem[m.chrOffset(m.chrBanks[(addr>>10)&7], addr)]
+}
+
+// WriteCHR writes to CHR-RAM (PPU $0000-$1FFF)
+func (m *Mapper19) WriteCHR(addr uint16, value uint8) {
+	if m.chrIsRAM {
+		m.chrMem[m.chrOffset(m.chrBanks[(addr>>10)&7], addr)] = value
+	}
+}
+
+// chrOffset maps a PPU address within a 1KB CHR bank to CHR memory
+func (m *Mapper19) chrOffset(bank uint8, addr uint16) uint32 {
+	offset := uint32(bank)*0x400 + uint32(addr&0x03FF)
+	return offset % uint32(len(m.chrMem))
+}
+
+// NametablePage returns the console RAM page for nametables mapped to it
+func (m *Mapper19) NametablePage(table uint8) uint8 {
+	return m.ntBanks[table&3] & 1
+}
+
+// ReadNametable reads nametables mapped to CHR-ROM (PPU $2000-$2FFF)
+func (m *Mapper19) ReadNametable(addr uint16) (uint8, bool) {
+	bank := m.ntBanks[(addr>>10)&3]
+	if bank >= 0xE0 {
+		return 0, false
+	}
+	return m.chrMem[m.chrOffset(bank, addr)], true
+}
+
+// ClockCPU runs the IRQ counter for one CPU cycle
+func (m *Mapper19) ClockCPU() {
+	if !m.irqEnabled || m.irqCounter == 0x7FFF {
+		return
+	}
+	m.irqCounter++
+	if m.irqCounter == 0x7FFF {
+		m.irqPending = true
+	}
+}
+
+// Scanline is a no-op; the IRQ counter runs on CPU cycles
+func (m *Mapper19) Scanline() {}
+
+// GetMirroring returns the mirroring mode closest to the nametable banks
+// (the PPU uses NametablePage and ReadNametable directly)
+func (m *Mapper19) GetMirroring() uint8 {
+	if m.ntBanks[0]&1 == m.ntBanks[1]&1 {
+		return MirrorHorizontal
+	}
+	return MirrorVertical
+}
+
+// IRQState reports whether the IRQ line is asserted (until acknowledged)
+func (m *Mapper19) IRQState() bool {
+	return m.irqPending
+}
+
+// CHRRAM returns the CHR-RAM, or nil for CHR-ROM cartridges
+func (m *Mapper19) CHRRAM() []uint8 {
+	if !m.chrIsRAM {
+		return nil
+	}
+	return m.chrMem
+}
+
+// WriteState appends PRG-RAM, internal RAM, bank registers and IRQ state
+// to w
+func (m *Mapper19) WriteState(w *state.Writer) {
+	w.Block(m.prgRAM)
+	w.Block(m.internalRAM[:])
+	w.U8(m.ramAddr)
+	w.Bool(m.ramAutoInc)
+	w.Block(m.prgBank[:])
+	w.Block(m.chrBanks[:])
+	w.Block(m.ntBanks[:])
+	w.U8(m.protect)
+	w.U16(m.irqCounter)
+	w.Bool(m.irqEnabled)
+	w.Bool(m.irqPending)
+	if m.chrIsRAM {
+		w.Block(m.chrMem)
+	}
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper19) ReadState(r *state.Reader) {
+	r.Block(m.prgRAM)
+	r.Block(m.internalRAM[:])
+	m.ramAddr = r.U8()
+	m.ramAutoInc = r.Bool()
+	r.Block(m.prgBank[:])
+	r.Block(m.chrBanks[:])
+	r.Block(m.ntBanks[:])
+	m.protect = r.U8()
+	m.irqCounter = r.U16()
+	m.irqEnabled = r.Bool()
+	m.irqPending = r.Bool()
+	if m.chrIsRAM {
+		r.Block(m.chrMem)
+	}
+}
+
+// PRGRAM returns the PRG-RAM at $6000-$7FFF (8KB unless the header says
+// otherwise)
+func (m *Mapper19) PRGRAM() []uint8 {
+	return m.prgRAM
+}
+
+// setPRGRAMSize resizes PRG-RAM to the header's size, up to 8KB
+func (m *Mapper19) setPRGRAMSize(size int) {
+	m.prgRAM = make([]uint8, min(size, prgRAMWindow))
+}
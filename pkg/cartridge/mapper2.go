@@ -1,5 +1,12 @@
 package cartridge
 
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
 // Mapper2 implements iNES Mapper 2 (UxROM)
 //
 // UxROM is used by games like Mega Man, Castlevania, Duck Tales.
@@ -53,22 +60,29 @@ func (m *Mapper2) ReadPRG(addr uint16) uint8 {
 		// $6000-$7FFF: Open bus (no PRG-RAM on standard UxROM)
 		return 0
 
-	case addr >= 0x8000 && addr < 0xC000:
-		// $8000-$BFFF: Switchable bank
-		bank := m.prgBank & (m.prgBanks - 1)
-		offset := uint32(bank)*0x4000 + uint32(addr-0x8000)
-		return m.prgROM[offset%uint32(len(m.prgROM))]
-
-	case addr >= 0xC000:
-		// $C000-$FFFF: Fixed to last bank
-		lastBank := m.prgBanks - 1
-		offset := uint32(lastBank)*0x4000 + uint32(addr-0xC000)
+	case addr >= 0x8000:
+		offset := uint32(m.PRGBank(addr))*0x4000 + uint32(addr&0x3FFF)
 		return m.prgROM[offset%uint32(len(m.prgROM))]
 	}
 
 	return 0
 }
 
+// PRGBank returns the 16KB PRG bank mapped at addr ($8000-$FFFF)
+func (m *Mapper2) PRGBank(addr uint16) int {
+	if addr < 0xC000 {
+		// $8000-$BFFF: Switchable bank
+		return int(m.prgBank & (m.prgBanks - 1))
+	}
+	// $C000-$FFFF: Fixed to last bank
+	return int(m.prgBanks - 1)
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper2) PRGBankSize() int {
+	return 0x4000
+}
+
 // WritePRG handles writes to PRG space (CPU $8000-$FFFF)
 // Writing to any address in $8000-$FFFF selects the PRG bank
 func (m *Mapper2) WritePRG(addr uint16, value uint8) {
@@ -76,6 +90,9 @@ func (m *Mapper2) WritePRG(addr uint16, value uint8) {
 		// Select PRG bank (only lower bits used depending on ROM size)
 		// Mask to valid bank number
 		m.prgBank = value & (m.prgBanks - 1)
+		if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+			logging.Log(logging.Mapper, slog.LevelDebug, "uxrom prg bank", "bank", m.prgBank)
+		}
 	}
 }
 
@@ -109,3 +126,20 @@ func (m *Mapper2) GetMirroring() uint8 {
 func (m *Mapper2) IRQState() bool {
 	return false
 }
+
+// WriteState appends the selected bank and CHR-RAM to w
+func (m *Mapper2) WriteState(w *state.Writer) {
+	w.U8(m.prgBank)
+	w.Block(m.chrRAM)
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper2) ReadState(r *state.Reader) {
+	m.prgBank = r.U8()
+	r.Block(m.chrRAM)
+}
+
+// CHRRAM returns the 8KB CHR-RAM
+func (m *Mapper2) CHRRAM() []uint8 {
+	return m.chrRAM
+}
@@ -0,0 +1,67 @@
+package cartridge
+
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+)
+
+// Mapper206 implements iNES Mapper 206 (Namco 108 / DxROM)
+//
+// The Namco 108 is the chip MMC3 was derived from. Used by Gauntlet,
+// Pac-Mania, Karnov and many other Namco and Tengen titles.
+//
+// PRG-ROM: Up to 128KB (16 banks of 8KB)
+// CHR-ROM: Up to 64KB (64 banks of 1KB)
+//
+// Banking is MMC3's with both mode bits stuck at 0, so it reuses Mapper4.
+// Compared with MMC3 there is:
+//   - No PRG/CHR mode switching (bank select bits 6-7 are ignored)
+//   - No mirroring control (fixed by solder pads)
+//   - No PRG-RAM
+//   - No scanline IRQ
+//
+// Registers:
+//
+//	$8000-$9FFE (even): Bank select (bits 0-2)
+//	$8001-$9FFF (odd):  Bank data
+//	$A000-$FFFF:        Not connected
+type Mapper206 struct {
+	Mapper4
+}
+
+// NewMapper206 creates a new Namco 108 mapper (Mapper 206)
+func NewMapper206(prgROM, chrROM []uint8, mirroring uint8) *Mapper206 {
+	m := &Mapper206{Mapper4: *NewMapper4(prgROM, chrROM, mirroring, 0)}
+	m.prgRAMEnabled = false
+	return m
+}
+
+// WritePRG handles the bank select and bank data registers
+func (m *Mapper206) WritePRG(addr uint16, value uint8) {
+	if addr < 0x8000 || addr >= 0xA000 {
+		return
+	}
+
+	if addr&1 == 0 {
+		m.bankSelect = value & 0x07
+		return
+	}
+	m.registers[m.bankSelect] = value & 0x3F
+	if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+		logging.Log(logging.Mapper, slog.LevelDebug, "namco 108 bank data", "register", m.bankSelect, "bank", m.registers[m.bankSelect])
+	}
+}
+
+// PRGRAM returns nil (Namco 108 boards have no PRG-RAM)
+func (m *Mapper206) PRGRAM() []uint8 {
+	return nil
+}
+
+// Scanline is a no-op (Namco 108 has no IRQ counter)
+func (m *Mapper206) Scanline() {}
+
+// IRQState returns false (Namco 108 has no IRQ support)
+func (m *Mapper206) IRQState() bool {
+	return false
+}
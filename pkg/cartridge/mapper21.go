@@ -0,0 +1,309 @@
+package cartridge
+
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// Mapper21 implements the Konami VRC2/VRC4 family: iNES Mappers 21, 22,
+// 23 and 25
+//
+// Used by: Gradius II, Wai Wai World, Ganbare Goemon 2, Tiny Toon
+// Adventures (Japan), Teenage Mutant Ninja Turtles (Japan)
+//
+// The boards differ mainly in which CPU address lines select the register
+// within each $1000 block. iNES 1.0 headers don't identify the board, so
+// each mapper number ORs together the lines of all boards sharing it:
+//
+//	Mapper 21: VRC4a (A1, A2) and VRC4c (A6, A7)
+//	Mapper 22: VRC2a (A1, A0), CHR banks in 2KB units
+//	Mapper 23: VRC2b (A0, A1) and VRC4e (A2, A3)
+//	Mapper 25: VRC2c/VRC4b (A1, A0) and VRC4d (A3, A2)
+//
+// PRG-ROM: Up to 256KB (32 banks of 8KB)
+// CHR-ROM: Up to 512KB (512 banks of 1KB)
+// PRG-RAM: 8KB at $6000-$7FFF
+//
+// CPU Memory Map:
+//
+//	$6000-$7FFF: 8 KB PRG-RAM
+//	$8000-$9FFF: 8 KB switchable PRG-ROM bank (or fixed to second-last bank)
+//	$A000-$BFFF: 8 KB switchable PRG-ROM bank
+//	$C000-$DFFF: 8 KB PRG-ROM bank fixed to second-last (or switchable)
+//	$E000-$FFFF: 8 KB PRG-ROM bank (fixed to last bank)
+//
+// Registers (register 0-3 within each block):
+//
+//	$8000:       PRG bank 0
+//	$9000/$9001: Mirroring (0 = vertical, 1 = horizontal, 2/3 = one-screen)
+//	$9002/$9003: PRG swap mode (bit 1): swaps $8000 and $C000 (VRC4)
+//	$A000:       PRG bank 1
+//	$B000-$E003: CHR banks 0-7, low 4 bits then high bits
+//	$F000/$F001: IRQ latch low/high 4 bits (VRC4)
+//	$F002:       IRQ control: bit 0 = enable after ack, bit 1 = enable,
+//	             bit 2 = cycle mode
+//	$F003:       IRQ acknowledge
+//
+// The VRC4 IRQ counter is described on vrcIRQ.
+type Mapper21 struct {
+	prgROM []uint8 // Full PRG-ROM
+	chrMem []uint8 // CHR-ROM or CHR-RAM
+	prgRAM []uint8 // PRG-RAM, 8KB by default
+
+	prgBanks uint8 // Number of 8KB PRG banks
+	chrIsRAM bool
+
+	// Register select address lines (bit 0 and bit 1 masks)
+	regLine0 uint16
+	regLine1 uint16
+	chrShift uint8 // 1 on VRC2a, whose CHR banks ignore the low bit
+
+	prgBank   [2]uint8
+	prgSwap   bool
+	chrBanks  [8]uint16
+	mirroring uint8
+
+	irq vrcIRQ
+}
+
+// NewMapper21 creates a VRC4a/VRC4c mapper (Mapper 21)
+func NewMapper21(prgROM, chrROM []uint8, mirroring uint8) *Mapper21 {
+	return newVRC(prgROM, chrROM, mirroring, 0x0042, 0x0084, 0)
+}
+
+// NewMapper22 creates a VRC2a mapper (Mapper 22)
+func NewMapper22(prgROM, chrROM []uint8, mirroring uint8) *Mapper21 {
+	return newVRC(prgROM, chrROM, mirroring, 0x0002, 0x0001, 1)
+}
+
+// NewMapper23 creates a VRC2b/VRC4e mapper (Mapper 23)
+func NewMapper23(prgROM, chrROM []uint8, mirroring uint8) *Mapper21 {
+	return newVRC(prgROM, chrROM, mirroring, 0x0005, 0x000A, 0)
+}
+
+// NewMapper25 creates a VRC2c/VRC4b/VRC4d mapper (Mapper 25)
+func NewMapper25(prgROM, chrROM []uint8, mirroring uint8) *Mapper21 {
+	return newVRC(prgROM, chrROM, mirroring, 0x000A, 0x0005, 0)
+}
+
+// newVRC builds a VRC2/VRC4 board with the given register select lines
+func newVRC(prgROM, chrROM []uint8, mirroring uint8, line0, line1 uint16, chrShift uint8) *Mapper21 {
+	m := &Mapper21{
+		prgROM:    make([]uint8, len(prgROM)),
+		prgRAM:    make([]uint8, 8192),
+		prgBanks:  uint8(len(prgROM) / 8192),
+		regLine0:  line0,
+		regLine1:  line1,
+		chrShift:  chrShift,
+		mirroring: mirroring,
+	}
+	copy(m.prgROM, prgROM)
+
+	if len(chrROM) > 0 {
+		m.chrMem = make([]uint8, len(chrROM))
+		copy(m.chrMem, chrROM)
+	} else {
+		m.chrMem = make([]uint8, 8192)
+		m.chrIsRAM = true
+	}
+	return m
+}
+
+// register decodes the register number (0-3) from the address lines
+func (m *Mapper21) register(addr uint16) uint16 {
+	var reg uint16
+	if addr&m.regLine0 != 0 {
+		reg |= 1
+	}
+	if addr&m.regLine1 != 0 {
+		reg |= 2
+	}
+	return reg
+}
+
+// ReadPRG reads from PRG-RAM or banked PRG-ROM (CPU $6000-$FFFF)
+func (m *Mapper21) ReadPRG(addr uint16) uint8 {
+	if addr < 0x6000 {
+		return 0
+	}
+	if addr < 0x8000 {
+		return readPRGRAM(m.prgRAM, addr)
+	}
+
+	offset := uint32(m.PRGBank(addr))*0x2000 + uint32(addr&0x1FFF)
+	return m.prgROM[offset%uint32(len(m.prgROM))]
+}
+
+// PRGBank returns the 8KB PRG bank mapped at addr ($8000-$FFFF)
+func (m *Mapper21) PRGBank(addr uint16) int {
+	var bank uint8
+	switch addr & 0xE000 {
+	case 0x8000:
+		bank = m.prgBank[0]
+		if m.prgSwap {
+			bank = m.prgBanks - 2
+		}
+	case 0xA000:
+		bank = m.prgBank[1]
+	case 0xC000:
+		bank = m.prgBanks - 2
+		if m.prgSwap {
+			bank = m.prgBank[0]
+		}
+	default:
+		bank = m.prgBanks - 1
+	}
+	return int(bank)
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper21) PRGBankSize() int {
+	return 0x2000
+}
+
+// WritePRG handles PRG-RAM and register writes (CPU $6000-$FFFF)
+func (m *Mapper21) WritePRG(addr uint16, value uint8) {
+	if addr < 0x6000 {
+		return
+	}
+	if addr < 0x8000 {
+		writePRGRAM(m.prgRAM, addr, value)
+		return
+	}
+
+	reg := m.register(addr)
+	switch block := addr & 0xF000; block {
+	case 0x8000:
+		m.prgBank[0] = value & 0x1F
+
+	case 0x9000:
+		if reg < 2 {
+			switch value & 0x03 {
+			case 0:
+				m.mirroring = MirrorVertical
+			case 1:
+				m.mirroring = MirrorHorizontal
+			case 2:
+				m.mirroring = MirrorSingleLow
+			case 3:
+				m.mirroring = MirrorSingleHigh
+			}
+		} else {
+			m.prgSwap = value&0x02 != 0
+		}
+
+	case 0xA000:
+		m.prgBank[1] = value & 0x1F
+
+	case 0xB000, 0xC000, 0xD000, 0xE000:
+		bank := (block-0xB000)>>11 | reg>>1
+		if reg&1 == 0 {
+			m.chrBanks[bank] = m.chrBanks[bank]&0x1F0 | uint16(value&0x0F)
+		} else {
+			m.chrBanks[bank] = m.chrBanks[bank]&0x00F | uint16(value&0x1F)<<4
+		}
+		if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+			logging.Log(logging.Mapper, slog.LevelDebug, "vrc chr bank", "slot", bank, "bank", m.chrBanks[bank])
+		}
+
+	case 0xF000:
+		switch reg {
+		case 0:
+			m.irq.latch = m.irq.latch&0xF0 | value&0x0F
+		case 1:
+			m.irq.latch = m.irq.latch&0x0F | value<<4
+		case 2:
+			m.irq.writeControl(value)
+		case 3:
+			m.irq.acknowledge()
+		}
+	}
+}
+
+// ReadCHR reads from banked CHR memory (PPU $0000-$1FFF)
+func (m *Mapper21) ReadCHR(addr uint16) uint8 {
+	return m.chrMem[m.chrOffset(addr)]
+}
+
+// WriteCHR writes to CHR-RAM (PPU $0000-$1FFF)
+func (m *Mapper21) WriteCHR(addr uint16, value uint8) {
+	if m.chrIsRAM {
+		m.chrMem[m.chrOffset(addr)] = value
+	}
+}
+
+// chrOffset maps a PPU address through the 1KB CHR banks
+func (m *Mapper21) chrOffset(addr uint16) uint32 {
+	bank := m.chrBanks[(addr>>10)&7] >> m.chrShift
+	offset := uint32(bank)*0x400 + uint32(addr&0x03FF)
+	return offset % uint32(len(m.chrMem))
+}
+
+// ClockCPU runs the VRC4 IRQ counter for one CPU cycle
+func (m *Mapper21) ClockCPU() {
+	m.irq.clock()
+}
+
+// Scanline is a no-op; the IRQ counter runs on CPU cycles
+func (m *Mapper21) Scanline() {}
+
+// GetMirroring returns the nametable mirroring mode
+func (m *Mapper21) GetMirroring() uint8 {
+	return m.mirroring
+}
+
+// IRQState reports whether the IRQ line is asserted (until acknowledged)
+func (m *Mapper21) IRQState() bool {
+	return m.irq.asserted()
+}
+
+// CHRRAM returns the CHR-RAM, or nil for CHR-ROM cartridges
+func (m *Mapper21) CHRRAM() []uint8 {
+	if !m.chrIsRAM {
+		return nil
+	}
+	return m.chrMem
+}
+
+// WriteState appends PRG-RAM, bank registers and IRQ state to w
+func (m *Mapper21) WriteState(w *state.Writer) {
+	w.Block(m.prgRAM)
+	w.Block(m.prgBank[:])
+	w.Bool(m.prgSwap)
+	for _, b := range m.chrBanks {
+		w.U16(b)
+	}
+	w.U8(m.mirroring)
+	m.irq.writeState(w)
+	if m.chrIsRAM {
+		w.Block(m.chrMem)
+	}
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper21) ReadState(r *state.Reader) {
+	r.Block(m.prgRAM)
+	r.Block(m.prgBank[:])
+	m.prgSwap = r.Bool()
+	for i := range m.chrBanks {
+		m.chrBanks[i] = r.U16()
+	}
+	m.mirroring = r.U8()
+	m.irq.readState(r)
+	if m.chrIsRAM {
+		r.Block(m.chrMem)
+	}
+}
+
+// PRGRAM returns the PRG-RAM at $6000-$7FFF (8KB unless the header says
+// otherwise)
+func (m *Mapper21) PRGRAM() []uint8 {
+	return m.prgRAM
+}
+
+// setPRGRAMSize resizes PRG-RAM to the header's size, up to 8KB
+func (m *Mapper21) setPRGRAMSize(size int) {
+	m.prgRAM = make([]uint8, min(size, prgRAMWindow))
+}
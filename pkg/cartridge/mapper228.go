@@ -0,0 +1,161 @@
+package cartridge
+
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// Mapper228 implements iNES Mapper 228 (Active Enterprises)
+//
+// Used by the Action 52 and Cheetahmen II multicarts.
+//
+// PRG-ROM: Up to 1.5MB (three 512KB chips)
+// CHR-ROM: Up to 512KB (64 banks of 8KB)
+//
+// Action 52 has sockets for four 512KB PRG chips but only chips 0, 1 and
+// 3 are fitted, so its 1.5MB image stores chip 3 directly after chip 1.
+// Selecting chip 2 reads open bus.
+//
+// Bank Switching (write to $8000-$FFFF, address and data both matter):
+//
+//	A13:     Mirroring (0 = vertical, 1 = horizontal)
+//	A11-A12: PRG chip
+//	A6-A10:  16KB PRG bank within the chip
+//	A5:      PRG mode (0 = 32KB, 1 = 16KB mirrored at $8000 and $C000)
+//	A0-A3:   CHR bank bits 2-5
+//	D0-D1:   CHR bank bits 0-1
+//
+// $4020-$5FFF holds four 4-bit RAM registers (mirrored), used by the
+// Action 52 menu.
+type Mapper228 struct {
+	prgROM []uint8 // Full PRG-ROM
+	chrROM []uint8 // Full CHR-ROM
+
+	chip      uint8 // Selected PRG chip (0-3)
+	prgBank   uint8 // 16KB bank within the chip
+	prg16     bool  // 16KB PRG mode
+	chrBank   uint8 // Selected 8KB CHR bank
+	mirroring uint8
+	ram       [4]uint8 // 4-bit registers at $4020-$5FFF
+}
+
+// NewMapper228 creates a new Action 52 mapper (Mapper 228)
+func NewMapper228(prgROM, chrROM []uint8, mirroring uint8) *Mapper228 {
+	m := &Mapper228{
+		prgROM:    make([]uint8, len(prgROM)),
+		chrROM:    make([]uint8, len(chrROM)),
+		mirroring: mirroring,
+	}
+	copy(m.prgROM, prgROM)
+	copy(m.chrROM, chrROM)
+	return m
+}
+
+// ReadPRG reads the RAM registers or the selected PRG-ROM bank
+func (m *Mapper228) ReadPRG(addr uint16) uint8 {
+	switch {
+	case addr >= 0x4020 && addr < 0x6000:
+		return m.ram[addr&0x03] & 0x0F
+
+	case addr >= 0x8000:
+		if m.chip == 2 {
+			return 0 // Empty socket
+		}
+		offset := uint32(m.PRGBank(addr))*0x4000 + uint32(addr&0x3FFF)
+		return m.prgROM[offset%uint32(len(m.prgROM))]
+	}
+	return 0
+}
+
+// PRGBank returns the 16KB PRG bank mapped at addr ($8000-$FFFF), counting
+// through the fitted chips in PRG-ROM order, or -1 for the empty socket
+func (m *Mapper228) PRGBank(addr uint16) int {
+	if m.chip == 2 {
+		return -1
+	}
+	chip := int(m.chip)
+	if chip == 3 {
+		chip = 2 // Third chip fitted, stored after chip 1
+	}
+
+	bank := int(m.prgBank)
+	if !m.prg16 {
+		bank = bank&^1 | int(addr-0x8000)>>14
+	}
+	return chip*32 + bank
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper228) PRGBankSize() int {
+	return 0x4000
+}
+
+// WritePRG handles the RAM registers and the address-decoded bank latch
+func (m *Mapper228) WritePRG(addr uint16, value uint8) {
+	switch {
+	case addr >= 0x4020 && addr < 0x6000:
+		m.ram[addr&0x03] = value & 0x0F
+
+	case addr >= 0x8000:
+		m.chip = uint8(addr>>11) & 0x03
+		m.prgBank = uint8(addr>>6) & 0x1F
+		m.prg16 = addr&0x20 != 0
+		m.chrBank = uint8(addr&0x0F)<<2 | value&0x03
+		if addr&0x2000 != 0 {
+			m.mirroring = MirrorHorizontal
+		} else {
+			m.mirroring = MirrorVertical
+		}
+		if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+			logging.Log(logging.Mapper, slog.LevelDebug, "action 52 banks",
+				"chip", m.chip, "prg", m.prgBank, "prg16", m.prg16, "chr", m.chrBank)
+		}
+	}
+}
+
+// ReadCHR reads from the selected 8KB CHR-ROM bank (PPU $0000-$1FFF)
+func (m *Mapper228) ReadCHR(addr uint16) uint8 {
+	if len(m.chrROM) == 0 {
+		return 0
+	}
+	offset := uint32(m.chrBank)*0x2000 + uint32(addr&0x1FFF)
+	return m.chrROM[offset%uint32(len(m.chrROM))]
+}
+
+// WriteCHR ignores writes (CHR-ROM is read-only)
+func (m *Mapper228) WriteCHR(addr uint16, value uint8) {}
+
+// Scanline is a no-op (no IRQ hardware)
+func (m *Mapper228) Scanline() {}
+
+// GetMirroring returns the nametable mirroring mode
+func (m *Mapper228) GetMirroring() uint8 {
+	return m.mirroring
+}
+
+// IRQState returns false (no IRQ support)
+func (m *Mapper228) IRQState() bool {
+	return false
+}
+
+// WriteState appends the bank latch and RAM registers to w
+func (m *Mapper228) WriteState(w *state.Writer) {
+	w.U8(m.chip)
+	w.U8(m.prgBank)
+	w.Bool(m.prg16)
+	w.U8(m.chrBank)
+	w.U8(m.mirroring)
+	w.Block(m.ram[:])
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper228) ReadState(r *state.Reader) {
+	m.chip = r.U8()
+	m.prgBank = r.U8()
+	m.prg16 = r.Bool()
+	m.chrBank = r.U8()
+	m.mirroring = r.U8()
+	r.Block(m.ram[:])
+}
@@ -1,5 +1,12 @@
 package cartridge
 
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
 // Mapper3 implements iNES Mapper 3 (CNROM)
 //
 // CNROM is used by games like Arkanoid, Cybernoid, Solomon's Key.
@@ -70,6 +77,9 @@ func (m *Mapper3) WritePRG(addr uint16, value uint8) {
 		// Mask to valid bank number
 		if m.chrBanks > 0 {
 			m.chrBank = value & (m.chrBanks - 1)
+			if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+				logging.Log(logging.Mapper, slog.LevelDebug, "cnrom chr bank", "bank", m.chrBank)
+			}
 		}
 	}
 }
@@ -105,3 +115,13 @@ func (m *Mapper3) GetMirroring() uint8 {
 func (m *Mapper3) IRQState() bool {
 	return false
 }
+
+// WriteState appends the selected CHR bank to w
+func (m *Mapper3) WriteState(w *state.Writer) {
+	w.U8(m.chrBank)
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper3) ReadState(r *state.Reader) {
+	m.chrBank = r.U8()
+}
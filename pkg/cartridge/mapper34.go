@@ -0,0 +1,188 @@
+package cartridge
+
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// Mapper34 implements iNES Mapper 34 (BNROM and NINA-001)
+//
+// Two unrelated boards share this mapper number:
+//
+//	BNROM (Deadly Towers): 32KB PRG banks selected by writes to
+//	$8000-$FFFF (with bus conflicts), 8KB CHR-RAM
+//
+//	NINA-001 (Impossible Mission II): 32KB PRG bank at $7FFD, 4KB CHR-ROM
+//	banks at $7FFE ($0000) and $7FFF ($1000), 8KB PRG-RAM at $6000 that
+//	the register writes also land in
+//
+// NES 2.0 submapper 1 selects NINA-001 and 2 selects BNROM. Otherwise the
+// board is chosen by CHR presence: only NINA-001 carries CHR-ROM.
+type Mapper34 struct {
+	prgROM []uint8 // Full PRG-ROM
+	chrMem []uint8 // CHR-ROM (NINA-001) or 8KB CHR-RAM (BNROM)
+	prgRAM []uint8 // 8KB PRG-RAM (NINA-001 only)
+
+	nina      bool     // NINA-001 register layout
+	prgBank   uint8    // Selected 32KB PRG bank
+	chrBanks  [2]uint8 // Selected 4KB CHR banks (NINA-001)
+	mirroring uint8    // Fixed nametable mirroring
+}
+
+// NewMapper34 creates a new BNROM/NINA-001 mapper (Mapper 34)
+func NewMapper34(prgROM, chrROM []uint8, mirroring, submapper uint8) *Mapper34 {
+	m := &Mapper34{
+		prgROM:    make([]uint8, len(prgROM)),
+		mirroring: mirroring,
+		chrBanks:  [2]uint8{0, 1},
+	}
+	copy(m.prgROM, prgROM)
+
+	switch submapper {
+	case 1:
+		m.nina = true
+	case 2:
+		m.nina = false
+	default:
+		m.nina = len(chrROM) > 0
+	}
+
+	if len(chrROM) > 0 {
+		m.chrMem = make([]uint8, len(chrROM))
+		copy(m.chrMem, chrROM)
+	} else {
+		m.chrMem = make([]uint8, 8192)
+	}
+	if m.nina {
+		m.prgRAM = make([]uint8, 8192)
+	}
+	return m
+}
+
+// ReadPRG reads from PRG-RAM or the selected PRG-ROM bank (CPU $6000-$FFFF)
+func (m *Mapper34) ReadPRG(addr uint16) uint8 {
+	switch {
+	case addr >= 0x8000:
+		offset := uint32(m.prgBank)*0x8000 + uint32(addr-0x8000)
+		return m.prgROM[offset%uint32(len(m.prgROM))]
+
+	case addr >= 0x6000 && m.nina:
+		return m.prgRAM[addr-0x6000]
+	}
+	return 0
+}
+
+// PRGBank returns the 32KB PRG bank mapped at $8000-$FFFF
+func (m *Mapper34) PRGBank(addr uint16) int {
+	return int(m.prgBank)
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper34) PRGBankSize() int {
+	return 0x8000
+}
+
+// WritePRG handles the bank registers of either board
+func (m *Mapper34) WritePRG(addr uint16, value uint8) {
+	if !m.nina {
+		if addr >= 0x8000 {
+			value &= m.ReadPRG(addr)
+			m.prgBank = value
+			if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+				logging.Log(logging.Mapper, slog.LevelDebug, "bnrom prg bank", "bank", m.prgBank)
+			}
+		}
+		return
+	}
+
+	if addr < 0x6000 || addr >= 0x8000 {
+		return
+	}
+	m.prgRAM[addr-0x6000] = value
+
+	switch addr {
+	case 0x7FFD:
+		m.prgBank = value & 0x01
+	case 0x7FFE:
+		m.chrBanks[0] = value & 0x0F
+	case 0x7FFF:
+		m.chrBanks[1] = value & 0x0F
+	default:
+		return
+	}
+	if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+		logging.Log(logging.Mapper, slog.LevelDebug, "nina-001 banks", "prg", m.prgBank, "chr0", m.chrBanks[0], "chr1", m.chrBanks[1])
+	}
+}
+
+// ReadCHR reads from CHR memory (PPU $0000-$1FFF)
+func (m *Mapper34) ReadCHR(addr uint16) uint8 {
+	return m.chrMem[m.chrOffset(addr)]
+}
+
+// WriteCHR writes to CHR-RAM (BNROM only)
+func (m *Mapper34) WriteCHR(addr uint16, value uint8) {
+	if !m.nina {
+		m.chrMem[m.chrOffset(addr)] = value
+	}
+}
+
+// chrOffset maps a PPU address through the 4KB CHR banks on NINA-001
+func (m *Mapper34) chrOffset(addr uint16) uint32 {
+	addr &= 0x1FFF
+	if !m.nina {
+		return uint32(addr) % uint32(len(m.chrMem))
+	}
+	offset := uint32(m.chrBanks[addr>>12])*0x1000 + uint32(addr&0x0FFF)
+	return offset % uint32(len(m.chrMem))
+}
+
+// Scanline is a no-op (no IRQ hardware)
+func (m *Mapper34) Scanline() {}
+
+// GetMirroring returns the fixed nametable mirroring mode
+func (m *Mapper34) GetMirroring() uint8 {
+	return m.mirroring
+}
+
+// IRQState returns false (neither board has IRQ support)
+func (m *Mapper34) IRQState() bool {
+	return false
+}
+
+// CHRRAM returns the 8KB CHR-RAM on BNROM, or nil on NINA-001
+func (m *Mapper34) CHRRAM() []uint8 {
+	if m.nina {
+		return nil
+	}
+	return m.chrMem
+}
+
+// WriteState appends the bank registers and board memory to w
+func (m *Mapper34) WriteState(w *state.Writer) {
+	w.U8(m.prgBank)
+	w.Block(m.chrBanks[:])
+	if m.nina {
+		w.Block(m.prgRAM)
+	} else {
+		w.Block(m.chrMem)
+	}
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper34) ReadState(r *state.Reader) {
+	m.prgBank = r.U8()
+	r.Block(m.chrBanks[:])
+	if m.nina {
+		r.Block(m.prgRAM)
+	} else {
+		r.Block(m.chrMem)
+	}
+}
+
+// PRGRAM returns the 8KB PRG-RAM on NINA-001, or nil on BNROM
+func (m *Mapper34) PRGRAM() []uint8 {
+	return m.prgRAM
+}
@@ -1,5 +1,12 @@
 package cartridge
 
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
 // Mapper4 implements iNES Mapper 4 (MMC3)
 //
 // MMC3 is the most common mapper (~23% of games).
@@ -40,10 +47,17 @@ package cartridge
 //   $C001-$DFFF (odd):  IRQ reload
 //   $E000-$FFFE (even): IRQ disable
 //   $E001-$FFFF (odd):  IRQ enable
+//
+// Variants (NES 2.0 submapper):
+//   0: MMC3B/MMC3C, IRQ whenever a clock leaves the counter at zero
+//   1: MMC6 (StarTropics), 1KB PRG-RAM with per-half protection, MMC3A IRQ
+//   3: Acclaim MC-ACC, counter clocked every 8th fall of A12
+//   4: MMC3A ("alternate"), IRQ only when the counter is decremented to
+//      zero or explicitly reloaded through $C001
 type Mapper4 struct {
 	prgROM []uint8 // Full PRG-ROM
 	chrMem []uint8 // CHR-ROM or CHR-RAM
-	prgRAM []uint8 // 8KB PRG-RAM
+	prgRAM []uint8 // PRG-RAM, 8KB by default
 
 	prgBanks uint8 // Number of 8KB PRG banks
 	chrBanks uint8 // Number of 1KB CHR banks
@@ -70,11 +84,30 @@ type Mapper4 struct {
 	irqEnabled     bool  // IRQ enable flag
 	irqPending     bool  // IRQ pending flag
 	irqReloadFlag  bool  // IRQ reload flag (set when counter should reload)
+
+	// Variant behavior
+	variant      uint8 // One of the mmc3* variant constants
+	accPrescaler uint8 // MC-ACC: A12 falls since the last counter clock
+	mmc6RAM      bool  // MMC6: PRG-RAM enable ($8000 bit 5)
+	mmc6Protect  uint8 // MMC6: PRG-RAM read/write enables ($A001)
 }
 
-// NewMapper4 creates a new MMC3 mapper (Mapper 4)
-func NewMapper4(prgROM, chrROM []uint8, mirroring uint8) *Mapper4 {
+// MMC3 behavioral variants
+const (
+	mmc3C = iota // MMC3B/MMC3C (the common case)
+	mmc3A        // MMC3A "alternate" IRQ
+	mmc6         // MMC6
+	mcACC        // Acclaim MC-ACC
+)
+
+// mmc3Variants maps NES 2.0 submapper numbers to variants
+var mmc3Variants = map[uint8]uint8{1: mmc6, 3: mcACC, 4: mmc3A}
+
+// NewMapper4 creates a new MMC3 mapper (Mapper 4). submapper selects the
+// chip variant; unknown values get the common MMC3C behavior.
+func NewMapper4(prgROM, chrROM []uint8, mirroring, submapper uint8) *Mapper4 {
 	m := &Mapper4{
+		variant:       mmc3Variants[submapper],
 		prgROM:        make([]uint8, len(prgROM)),
 		prgRAM:        make([]uint8, 8192),
 		prgBanks:      uint8(len(prgROM) / 8192), // 8KB banks
@@ -105,47 +138,51 @@ func (m *Mapper4) ReadPRG(addr uint16) uint8 {
 	switch {
 	case addr >= 0x6000 && addr < 0x8000:
 		// $6000-$7FFF: PRG-RAM
+		if m.variant == mmc6 {
+			return m.readMMC6RAM(addr)
+		}
 		if m.prgRAMEnabled {
-			return m.prgRAM[addr-0x6000]
+			return readPRGRAM(m.prgRAM, addr)
 		}
 		return 0
 
-	case addr >= 0x8000 && addr < 0xA000:
+	case addr >= 0x8000:
+		offset := uint32(m.PRGBank(addr))*0x2000 + uint32(addr&0x1FFF)
+		return m.prgROM[offset%uint32(len(m.prgROM))]
+	}
+
+	return 0
+}
+
+// PRGBank returns the 8KB PRG bank mapped at addr ($8000-$FFFF)
+func (m *Mapper4) PRGBank(addr uint16) int {
+	switch {
+	case addr < 0xA000:
 		// $8000-$9FFF
-		var bank uint8
 		if m.prgMode == 0 {
-			bank = m.registers[6] & (m.prgBanks - 1) // R6: swappable, masked
-		} else {
-			bank = m.prgBanks - 2 // Fixed to second-last bank
+			return int(m.registers[6] & (m.prgBanks - 1)) // R6: swappable, masked
 		}
-		offset := uint32(bank)*0x2000 + uint32(addr-0x8000)
-		return m.prgROM[offset%uint32(len(m.prgROM))]
+		return int(m.prgBanks - 2) // Fixed to second-last bank
 
-	case addr >= 0xA000 && addr < 0xC000:
+	case addr < 0xC000:
 		// $A000-$BFFF: R7 (always swappable)
-		bank := m.registers[7] & (m.prgBanks - 1)
-		offset := uint32(bank)*0x2000 + uint32(addr-0xA000)
-		return m.prgROM[offset%uint32(len(m.prgROM))]
+		return int(m.registers[7] & (m.prgBanks - 1))
 
-	case addr >= 0xC000 && addr < 0xE000:
+	case addr < 0xE000:
 		// $C000-$DFFF
-		var bank uint8
 		if m.prgMode == 0 {
-			bank = m.prgBanks - 2 // Fixed to second-last bank
-		} else {
-			bank = m.registers[6] & (m.prgBanks - 1) // R6: swappable, masked
+			return int(m.prgBanks - 2) // Fixed to second-last bank
 		}
-		offset := uint32(bank)*0x2000 + uint32(addr-0xC000)
-		return m.prgROM[offset%uint32(len(m.prgROM))]
-
-	case addr >= 0xE000:
-		// $E000-$FFFF: Fixed to last bank
-		bank := m.prgBanks - 1
-		offset := uint32(bank)*0x2000 + uint32(addr-0xE000)
-		return m.prgROM[offset%uint32(len(m.prgROM))]
+		return int(m.registers[6] & (m.prgBanks - 1)) // R6: swappable, masked
 	}
 
-	return 0
+	// $E000-$FFFF: Fixed to last bank
+	return int(m.prgBanks - 1)
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper4) PRGBankSize() int {
+	return 0x2000
 }
 
 // WritePRG handles writes to PRG space (CPU $6000-$FFFF)
@@ -153,8 +190,12 @@ func (m *Mapper4) WritePRG(addr uint16, value uint8) {
 	switch {
 	case addr >= 0x6000 && addr < 0x8000:
 		// $6000-$7FFF: PRG-RAM
+		if m.variant == mmc6 {
+			m.writeMMC6RAM(addr, value)
+			return
+		}
 		if m.prgRAMEnabled && !m.prgRAMWriteProtect {
-			m.prgRAM[addr-0x6000] = value
+			writePRGRAM(m.prgRAM, addr, value)
 		}
 
 	case addr >= 0x8000 && addr < 0xA000:
@@ -163,9 +204,19 @@ func (m *Mapper4) WritePRG(addr uint16, value uint8) {
 			m.bankSelect = value & 0x07
 			m.prgMode = (value >> 6) & 0x01
 			m.chrMode = (value >> 7) & 0x01
+			if m.variant == mmc6 {
+				m.mmc6RAM = value&0x20 != 0
+				if !m.mmc6RAM {
+					m.mmc6Protect = 0
+				}
+			}
 		} else {
 			// $8001, $8003, ..., $9FFF: Bank data
 			m.registers[m.bankSelect] = value
+			if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+				logging.Log(logging.Mapper, slog.LevelDebug, "mmc3 bank data",
+					"register", m.bankSelect, "bank", value, "prgMode", m.prgMode, "chrMode", m.chrMode)
+			}
 		}
 
 	case addr >= 0xA000 && addr < 0xC000:
@@ -178,6 +229,12 @@ func (m *Mapper4) WritePRG(addr uint16, value uint8) {
 			}
 		} else {
 			// $A001, $A003, ..., $BFFF: PRG-RAM protect
+			if m.variant == mmc6 {
+				if m.mmc6RAM {
+					m.mmc6Protect = value & 0xF0
+				}
+				return
+			}
 			m.prgRAMWriteProtect = (value & 0x40) != 0
 			m.prgRAMEnabled = (value & 0x80) != 0
 		}
@@ -190,6 +247,7 @@ func (m *Mapper4) WritePRG(addr uint16, value uint8) {
 			// $C001, $C003, ..., $DFFF: IRQ reload
 			m.irqCounter = 0
 			m.irqReloadFlag = true
+			m.accPrescaler = 0
 		}
 
 	case addr >= 0xE000:
@@ -206,70 +264,7 @@ func (m *Mapper4) WritePRG(addr uint16, value uint8) {
 
 // ReadCHR reads from CHR-ROM/RAM (PPU $0000-$1FFF)
 func (m *Mapper4) ReadCHR(addr uint16) uint8 {
-	var bank uint8
-	var offset uint32
-
-	// CHR mode determines bank arrangement
-	if m.chrMode == 0 {
-		// Mode 0: 2KB banks at $0000, 1KB banks at $1000
-		switch {
-		case addr < 0x0800:
-			// $0000-$07FF: R0 (2KB)
-			bank = m.registers[0] & 0xFE
-			offset = uint32(bank)*0x400 + uint32(addr)
-		case addr < 0x1000:
-			// $0800-$0FFF: R1 (2KB)
-			bank = m.registers[1] & 0xFE
-			offset = uint32(bank)*0x400 + uint32(addr-0x0800)
-		case addr < 0x1400:
-			// $1000-$13FF: R2 (1KB)
-			bank = m.registers[2]
-			offset = uint32(bank)*0x400 + uint32(addr-0x1000)
-		case addr < 0x1800:
-			// $1400-$17FF: R3 (1KB)
-			bank = m.registers[3]
-			offset = uint32(bank)*0x400 + uint32(addr-0x1400)
-		case addr < 0x1C00:
-			// $1800-$1BFF: R4 (1KB)
-			bank = m.registers[4]
-			offset = uint32(bank)*0x400 + uint32(addr-0x1800)
-		default:
-			// $1C00-$1FFF: R5 (1KB)
-			bank = m.registers[5]
-			offset = uint32(bank)*0x400 + uint32(addr-0x1C00)
-		}
-	} else {
-		// Mode 1: 2KB banks at $1000, 1KB banks at $0000
-		switch {
-		case addr < 0x0400:
-			// $0000-$03FF: R2 (1KB)
-			bank = m.registers[2]
-			offset = uint32(bank)*0x400 + uint32(addr)
-		case addr < 0x0800:
-			// $0400-$07FF: R3 (1KB)
-			bank = m.registers[3]
-			offset = uint32(bank)*0x400 + uint32(addr-0x0400)
-		case addr < 0x0C00:
-			// $0800-$0BFF: R4 (1KB)
-			bank = m.registers[4]
-			offset = uint32(bank)*0x400 + uint32(addr-0x0800)
-		case addr < 0x1000:
-			// $0C00-$0FFF: R5 (1KB)
-			bank = m.registers[5]
-			offset = uint32(bank)*0x400 + uint32(addr-0x0C00)
-		case addr < 0x1800:
-			// $1000-$17FF: R0 (2KB)
-			bank = m.registers[0] & 0xFE
-			offset = uint32(bank)*0x400 + uint32(addr-0x1000)
-		default:
-			// $1800-$1FFF: R1 (2KB)
-			bank = m.registers[1] & 0xFE
-			offset = uint32(bank)*0x400 + uint32(addr-0x1800)
-		}
-	}
-
-	// Wrap offset to CHR memory size
-	return m.chrMem[offset%uint32(len(m.chrMem))]
+	return m.chrMem[m.chrOffset(addr)]
 }
 
 // WriteCHR writes to CHR-RAM (PPU $0000-$1FFF)
@@ -277,62 +272,76 @@ func (m *Mapper4) WriteCHR(addr uint16, value uint8) {
 	if !m.chrIsRAM {
 		return // CHR-ROM is read-only
 	}
+	m.chrMem[m.chrOffset(addr)] = value
+}
 
-	var bank uint8
-	var offset uint32
-
-	// Same bank calculation as ReadCHR
-	if m.chrMode == 0 {
-		switch {
-		case addr < 0x0800:
-			bank = m.registers[0] & 0xFE
-			offset = uint32(bank)*0x400 + uint32(addr)
-		case addr < 0x1000:
-			bank = m.registers[1] & 0xFE
-			offset = uint32(bank)*0x400 + uint32(addr-0x0800)
-		case addr < 0x1400:
-			bank = m.registers[2]
-			offset = uint32(bank)*0x400 + uint32(addr-0x1000)
-		case addr < 0x1800:
-			bank = m.registers[3]
-			offset = uint32(bank)*0x400 + uint32(addr-0x1400)
-		case addr < 0x1C00:
-			bank = m.registers[4]
-			offset = uint32(bank)*0x400 + uint32(addr-0x1800)
-		default:
-			bank = m.registers[5]
-			offset = uint32(bank)*0x400 + uint32(addr-0x1C00)
-		}
-	} else {
-		switch {
-		case addr < 0x0400:
-			bank = m.registers[2]
-			offset = uint32(bank)*0x400 + uint32(addr)
-		case addr < 0x0800:
-			bank = m.registers[3]
-			offset = uint32(bank)*0x400 + uint32(addr-0x0400)
-		case addr < 0x0C00:
-			bank = m.registers[4]
-			offset = uint32(bank)*0x400 + uint32(addr-0x0800)
-		case addr < 0x1000:
-			bank = m.registers[5]
-			offset = uint32(bank)*0x400 + uint32(addr-0x0C00)
-		case addr < 0x1800:
-			bank = m.registers[0] & 0xFE
-			offset = uint32(bank)*0x400 + uint32(addr-0x1000)
-		default:
-			bank = m.registers[1] & 0xFE
-			offset = uint32(bank)*0x400 + uint32(addr-0x1800)
-		}
-	}
+// chrOffset maps a PPU address to an offset in CHR memory
+func (m *Mapper4) chrOffset(addr uint16) uint32 {
+	offset := uint32(m.chrBank(addr))*0x400 + uint32(addr&0x03FF)
 
 	// Wrap offset to CHR memory size
-	m.chrMem[offset%uint32(len(m.chrMem))] = value
+	return offset % uint32(len(m.chrMem))
 }
 
-// Scanline is called by PPU on each scanline
-// MMC3 uses this for IRQ generation
+// chrBank returns the 1KB CHR bank mapped at a PPU address
+//
+// Mode 0 puts the 2KB banks (R0, R1) at $0000 and the 1KB banks (R2-R5)
+// at $1000; mode 1 swaps the two halves.
+func (m *Mapper4) chrBank(addr uint16) uint8 {
+	slot := (addr >> 10) & 0x07
+	if m.chrMode == 1 {
+		slot ^= 0x04
+	}
+
+	switch slot {
+	case 0, 1:
+		// R0 (2KB): low bit selects the 1KB half
+		return m.registers[0]&0xFE | uint8(slot&1)
+	case 2, 3:
+		// R1 (2KB)
+		return m.registers[1]&0xFE | uint8(slot&1)
+	default:
+		// R2-R5 (1KB)
+		return m.registers[slot-2]
+	}
+}
+
+// Scanline clocks the IRQ counter once. The PPU uses A12Rise instead;
+// this remains for callers without A12 tracking.
 func (m *Mapper4) Scanline() {
+	m.clockIRQCounter()
+}
+
+// A12Rise clocks the IRQ counter on a filtered rise of PPU A12, which
+// happens once per scanline when backgrounds and sprites use different
+// pattern tables
+func (m *Mapper4) A12Rise() {
+	if m.variant != mcACC {
+		m.clockIRQCounter()
+	}
+}
+
+// A12Fall drives the MC-ACC counter, which sees every fall of A12
+// (eight per scanline from the sprite fetches) through a divide-by-8
+// prescaler
+func (m *Mapper4) A12Fall() {
+	if m.variant != mcACC {
+		return
+	}
+	m.accPrescaler++
+	if m.accPrescaler == 8 {
+		m.accPrescaler = 0
+		m.clockIRQCounter()
+	}
+}
+
+// clockIRQCounter reloads or decrements the IRQ counter and raises an IRQ
+// when it reaches zero
+func (m *Mapper4) clockIRQCounter() {
+	// MMC3A and MMC6 only fire on a decrement to zero or a $C001 reload
+	alternate := m.variant == mmc3A || m.variant == mmc6
+	fire := !alternate || m.irqCounter != 0 || m.irqReloadFlag
+
 	if m.irqCounter == 0 || m.irqReloadFlag {
 		// Reload counter
 		m.irqCounter = m.irqLatch
@@ -342,9 +351,12 @@ func (m *Mapper4) Scanline() {
 		m.irqCounter--
 	}
 
-	if m.irqCounter == 0 && m.irqEnabled {
+	if m.irqCounter == 0 && m.irqEnabled && fire {
 		// Trigger IRQ
 		m.irqPending = true
+		if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+			logging.Log(logging.Mapper, slog.LevelDebug, "mmc3 irq", "latch", m.irqLatch)
+		}
 	}
 }
 
@@ -353,11 +365,110 @@ func (m *Mapper4) GetMirroring() uint8 {
 	return m.mirroring
 }
 
-// IRQState returns true if an IRQ is pending and clears the flag
+// IRQState reports whether the IRQ line is asserted (until acknowledged)
 func (m *Mapper4) IRQState() bool {
-	if m.irqPending {
-		m.irqPending = false
-		return true
+	return m.irqPending
+}
+
+// WriteState appends the bank registers, IRQ counter, PRG-RAM and CHR-RAM
+// to w
+func (m *Mapper4) WriteState(w *state.Writer) {
+	w.U8(m.bankSelect)
+	w.U8(m.prgMode)
+	w.U8(m.chrMode)
+	w.Block(m.registers[:])
+	w.U8(m.mirroring)
+	w.Bool(m.prgRAMEnabled)
+	w.Bool(m.prgRAMWriteProtect)
+	w.U8(m.irqLatch)
+	w.U8(m.irqCounter)
+	w.Bool(m.irqEnabled)
+	w.Bool(m.irqPending)
+	w.Bool(m.irqReloadFlag)
+	w.U8(m.accPrescaler)
+	w.Bool(m.mmc6RAM)
+	w.U8(m.mmc6Protect)
+	w.Block(m.prgRAM)
+	if m.chrIsRAM {
+		w.Block(m.chrMem)
+	}
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper4) ReadState(r *state.Reader) {
+	m.bankSelect = r.U8()
+	m.prgMode = r.U8()
+	m.chrMode = r.U8()
+	r.Block(m.registers[:])
+	m.mirroring = r.U8()
+	m.prgRAMEnabled = r.Bool()
+	m.prgRAMWriteProtect = r.Bool()
+	m.irqLatch = r.U8()
+	m.irqCounter = r.U8()
+	m.irqEnabled = r.Bool()
+	m.irqPending = r.Bool()
+	m.irqReloadFlag = r.Bool()
+	m.accPrescaler = r.U8()
+	m.mmc6RAM = r.Bool()
+	m.mmc6Protect = r.U8()
+	r.Block(m.prgRAM)
+	if m.chrIsRAM {
+		r.Block(m.chrMem)
+	}
+}
+
+// CHRRAM returns the CHR-RAM, or nil for CHR-ROM cartridges
+func (m *Mapper4) CHRRAM() []uint8 {
+	if !m.chrIsRAM {
+		return nil
+	}
+	return m.chrMem
+}
+
+// readMMC6RAM reads the MMC6's 1KB PRG-RAM, mirrored across $7000-$7FFF.
+// A half with reads disabled returns 0, unless both halves are disabled,
+// in which case $7000-$7FFF is open bus (returned as 0 here too).
+func (m *Mapper4) readMMC6RAM(addr uint16) uint8 {
+	if addr < 0x7000 || !m.mmc6RAM {
+		return 0
+	}
+	offset := addr & 0x03FF
+	readBit := uint8(0x20) // Low 512 bytes
+	if offset >= 0x200 {
+		readBit = 0x80 // High 512 bytes
+	}
+	if m.mmc6Protect&readBit == 0 {
+		return 0
+	}
+	return m.prgRAM[offset]
+}
+
+// writeMMC6RAM writes the MMC6's PRG-RAM; a half must have both its read
+// and write enables set
+func (m *Mapper4) writeMMC6RAM(addr uint16, value uint8) {
+	if addr < 0x7000 || !m.mmc6RAM {
+		return
+	}
+	offset := addr & 0x03FF
+	enables := uint8(0x30) // Low 512 bytes: read, write
+	if offset >= 0x200 {
+		enables = 0xC0 // High 512 bytes
+	}
+	if m.mmc6Protect&enables == enables {
+		m.prgRAM[offset] = value
+	}
+}
+
+// PRGRAM returns the PRG-RAM at $6000-$7FFF (8KB unless the header says
+// otherwise)
+func (m *Mapper4) PRGRAM() []uint8 {
+	return m.prgRAM
+}
+
+// setPRGRAMSize resizes PRG-RAM to the header's size, up to 8KB. The
+// MMC6's RAM is inside the chip, so its size is fixed.
+func (m *Mapper4) setPRGRAMSize(size int) {
+	if m.variant != mmc6 {
+		m.prgRAM = make([]uint8, min(size, prgRAMWindow))
 	}
-	return false
 }
@@ -1,5 +1,12 @@
 package cartridge
 
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
 // Mapper7 implements iNES Mapper 7 (AxROM)
 //
 // AxROM is used by games like Battletoads, Marble Madness, Wizards & Warriors.
@@ -61,6 +68,16 @@ func (m *Mapper7) ReadPRG(addr uint16) uint8 {
 	return 0
 }
 
+// PRGBank returns the 32KB PRG bank mapped at $8000-$FFFF
+func (m *Mapper7) PRGBank(addr uint16) int {
+	return int(m.prgBank)
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper7) PRGBankSize() int {
+	return 0x8000
+}
+
 // WritePRG handles writes to PRG space (CPU $8000-$FFFF)
 // Writing to any address in $8000-$FFFF selects PRG bank and mirroring
 func (m *Mapper7) WritePRG(addr uint16, value uint8) {
@@ -81,6 +98,10 @@ func (m *Mapper7) WritePRG(addr uint16, value uint8) {
 		} else {
 			m.mirroring = MirrorSingleLow // Single-screen lower bank
 		}
+
+		if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+			logging.Log(logging.Mapper, slog.LevelDebug, "axrom bank", "bank", m.prgBank, "mirroring", m.mirroring)
+		}
 	}
 }
 
@@ -114,3 +135,22 @@ func (m *Mapper7) GetMirroring() uint8 {
 func (m *Mapper7) IRQState() bool {
 	return false
 }
+
+// WriteState appends the selected bank, mirroring and CHR-RAM to w
+func (m *Mapper7) WriteState(w *state.Writer) {
+	w.U8(m.prgBank)
+	w.U8(m.mirroring)
+	w.Block(m.chrRAM)
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper7) ReadState(r *state.Reader) {
+	m.prgBank = r.U8()
+	m.mirroring = r.U8()
+	r.Block(m.chrRAM)
+}
+
+// CHRRAM returns the 8KB CHR-RAM
+func (m *Mapper7) CHRRAM() []uint8 {
+	return m.chrRAM
+}
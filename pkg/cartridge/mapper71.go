@@ -0,0 +1,145 @@
+package cartridge
+
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// Mapper71 implements iNES Mapper 71 (Camerica/Codemasters BF909x)
+//
+// Used by Codemasters' unlicensed releases: Micro Machines, Fire Hawk,
+// Bee 52, Dizzy titles.
+//
+// PRG-ROM: Up to 256KB (16 banks of 16KB)
+// CHR-RAM: 8KB (not switchable)
+//
+// CPU Memory Map:
+//
+//	$8000-$BFFF: 16 KB switchable PRG-ROM bank
+//	$C000-$FFFF: 16 KB PRG-ROM bank (fixed to last bank)
+//
+// Registers:
+//
+//	$8000-$9FFF: Bit 4 selects the single-screen nametable (BF9097 only)
+//	$C000-$FFFF: PRG bank at $8000 (bits 0-3)
+//
+// Fire Hawk's BF9097 board adds the mirroring register (NES 2.0 submapper
+// 1). Most Fire Hawk dumps are plain iNES, so the register is also enabled
+// the first time a game writes to $9000-$9FFF, which the other boards'
+// games never do.
+type Mapper71 struct {
+	prgROM []uint8 // Full PRG-ROM
+	chrRAM []uint8 // 8KB CHR-RAM
+
+	prgBanks      uint8 // Number of 16KB PRG banks
+	prgBank       uint8 // Selected PRG bank at $8000-$BFFF
+	mirroring     uint8 // Nametable mirroring mode
+	mirrorControl bool  // BF9097 single-screen register active
+}
+
+// NewMapper71 creates a new Camerica mapper (Mapper 71). fireHawk enables
+// the BF9097 mirroring register from power-on.
+func NewMapper71(prgROM, chrROM []uint8, mirroring uint8, fireHawk bool) *Mapper71 {
+	m := &Mapper71{
+		prgROM:        make([]uint8, len(prgROM)),
+		chrRAM:        make([]uint8, 8192),
+		prgBanks:      uint8(len(prgROM) / 16384),
+		mirroring:     mirroring,
+		mirrorControl: fireHawk,
+	}
+	copy(m.prgROM, prgROM)
+	return m
+}
+
+// ReadPRG reads from PRG-ROM (CPU $8000-$FFFF)
+func (m *Mapper71) ReadPRG(addr uint16) uint8 {
+	if addr < 0x8000 {
+		return 0
+	}
+	offset := uint32(m.PRGBank(addr))*0x4000 + uint32(addr&0x3FFF)
+	return m.prgROM[offset%uint32(len(m.prgROM))]
+}
+
+// PRGBank returns the 16KB PRG bank mapped at addr ($8000-$FFFF): the
+// selected one below $C000, the last one above
+func (m *Mapper71) PRGBank(addr uint16) int {
+	if addr >= 0xC000 {
+		return int(m.prgBanks - 1)
+	}
+	return int(m.prgBank)
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper71) PRGBankSize() int {
+	return 0x4000
+}
+
+// WritePRG handles the mirroring and PRG bank registers
+func (m *Mapper71) WritePRG(addr uint16, value uint8) {
+	switch {
+	case addr >= 0xC000:
+		m.prgBank = value & 0x0F
+		if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+			logging.Log(logging.Mapper, slog.LevelDebug, "camerica prg bank", "bank", m.prgBank)
+		}
+
+	case addr >= 0x8000 && addr < 0xA000:
+		if addr >= 0x9000 {
+			m.mirrorControl = true
+		}
+		if !m.mirrorControl {
+			return
+		}
+		if value&0x10 != 0 {
+			m.mirroring = MirrorSingleHigh
+		} else {
+			m.mirroring = MirrorSingleLow
+		}
+	}
+}
+
+// ReadCHR reads from CHR-RAM (PPU $0000-$1FFF)
+func (m *Mapper71) ReadCHR(addr uint16) uint8 {
+	return m.chrRAM[addr&0x1FFF]
+}
+
+// WriteCHR writes to CHR-RAM (PPU $0000-$1FFF)
+func (m *Mapper71) WriteCHR(addr uint16, value uint8) {
+	m.chrRAM[addr&0x1FFF] = value
+}
+
+// Scanline is a no-op (no IRQ hardware)
+func (m *Mapper71) Scanline() {}
+
+// GetMirroring returns the nametable mirroring mode
+func (m *Mapper71) GetMirroring() uint8 {
+	return m.mirroring
+}
+
+// IRQState returns false (BF909x has no IRQ support)
+func (m *Mapper71) IRQState() bool {
+	return false
+}
+
+// CHRRAM returns the 8KB CHR-RAM
+func (m *Mapper71) CHRRAM() []uint8 {
+	return m.chrRAM
+}
+
+// WriteState appends the bank, mirroring and CHR-RAM to w
+func (m *Mapper71) WriteState(w *state.Writer) {
+	w.U8(m.prgBank)
+	w.U8(m.mirroring)
+	w.Bool(m.mirrorControl)
+	w.Block(m.chrRAM)
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper71) ReadState(r *state.Reader) {
+	m.prgBank = r.U8()
+	m.mirroring = r.U8()
+	m.mirrorControl = r.Bool()
+	r.Block(m.chrRAM)
+}
@@ -0,0 +1,298 @@
+package cartridge
+
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// Mapper85 implements iNES Mapper 85 (Konami VRC7)
+//
+// Used by: Lagrange Point, Tiny Toon Adventures 2 (Japan)
+//
+// PRG-ROM: Up to 512KB (64 banks of 8KB)
+// CHR-ROM: Up to 256KB (256 banks of 1KB), or 8KB CHR-RAM
+// PRG-RAM: 8KB at $6000-$7FFF
+//
+// CPU Memory Map:
+//
+//	$6000-$7FFF: 8 KB PRG-RAM
+//	$8000-$9FFF: 8 KB switchable PRG-ROM bank
+//	$A000-$BFFF: 8 KB switchable PRG-ROM bank
+//	$C000-$DFFF: 8 KB switchable PRG-ROM bank
+//	$E000-$FFFF: 8 KB PRG-ROM bank (fixed to last bank)
+//
+// Registers (the second register of each pair is selected by A4 on VRC7a
+// and A3 on VRC7b; both are decoded):
+//
+//	$8000/$8010: PRG banks 0/1
+//	$9000:       PRG bank 2
+//	$9010/$9030: FM sound register select / data
+//	$A000-$D010: CHR banks 0-7
+//	$E000:       Mirroring (bits 0-1), PRG-RAM enable (bit 6), sound reset (bit 7)
+//	$E010:       IRQ latch
+//	$F000/$F010: IRQ control / acknowledge
+//
+// The IRQ counter is the same as VRC4's (see vrcIRQ). The FM synthesizer
+// lives in pkg/apu/vrc7 and can be left out with the novrc7 build tag, in
+// which case the board is silent.
+type Mapper85 struct {
+	prgROM []uint8 // Full PRG-ROM
+	chrMem []uint8 // CHR-ROM or CHR-RAM
+	prgRAM []uint8 // PRG-RAM, 8KB by default
+
+	prgBanks uint8 // Number of 8KB PRG banks
+	chrIsRAM bool
+
+	prgBank      [3]uint8
+	chrBanks     [8]uint8
+	mirroring    uint8
+	prgRAMEnable bool
+
+	irq   vrcIRQ
+	audio vrc7Audio // nil when built without FM synthesis
+}
+
+// vrc7Audio is the interface of the FM synthesizer (vrc7.Chip)
+type vrc7Audio interface {
+	SelectRegister(value uint8)
+	WriteData(value uint8)
+	SetMuted(muted bool)
+	Clock()
+	Output() float32
+	WriteState(w *state.Writer)
+	ReadState(r *state.Reader)
+}
+
+// NewMapper85 creates a new VRC7 mapper (Mapper 85)
+func NewMapper85(prgROM, chrROM []uint8, mirroring uint8) *Mapper85 {
+	m := &Mapper85{
+		prgROM:    make([]uint8, len(prgROM)),
+		prgRAM:    make([]uint8, 8192),
+		prgBanks:  uint8(len(prgROM) / 8192),
+		mirroring: mirroring,
+		audio:     newVRC7Audio(),
+	}
+	copy(m.prgROM, prgROM)
+
+	if len(chrROM) > 0 {
+		m.chrMem = make([]uint8, len(chrROM))
+		copy(m.chrMem, chrROM)
+	} else {
+		m.chrMem = make([]uint8, 8192)
+		m.chrIsRAM = true
+	}
+	return m
+}
+
+// ReadPRG reads from PRG-RAM or banked PRG-ROM (CPU $6000-$FFFF)
+func (m *Mapper85) ReadPRG(addr uint16) uint8 {
+	if addr < 0x6000 {
+		return 0
+	}
+	if addr < 0x8000 {
+		if m.prgRAMEnable {
+			return readPRGRAM(m.prgRAM, addr)
+		}
+		return 0
+	}
+
+	offset := uint32(m.PRGBank(addr))*0x2000 + uint32(addr&0x1FFF)
+	return m.prgROM[offset%uint32(len(m.prgROM))]
+}
+
+// PRGBank returns the 8KB PRG bank mapped at addr ($8000-$FFFF): one of the
+// three switchable slots, or the last bank at $E000
+func (m *Mapper85) PRGBank(addr uint16) int {
+	if slot := (addr - 0x8000) >> 13; slot < 3 {
+		return int(m.prgBank[slot])
+	}
+	return int(m.prgBanks - 1)
+}
+
+// PRGBankSize returns the PRG bank size PRGBank counts in
+func (m *Mapper85) PRGBankSize() int {
+	return 0x2000
+}
+
+// WritePRG handles PRG-RAM and register writes (CPU $6000-$FFFF)
+func (m *Mapper85) WritePRG(addr uint16, value uint8) {
+	if addr < 0x6000 {
+		return
+	}
+	if addr < 0x8000 {
+		if m.prgRAMEnable {
+			writePRGRAM(m.prgRAM, addr, value)
+		}
+		return
+	}
+
+	second := addr&0x18 != 0
+	switch block := addr & 0xF000; block {
+	case 0x8000:
+		if second {
+			m.prgBank[1] = value & 0x3F
+		} else {
+			m.prgBank[0] = value & 0x3F
+		}
+
+	case 0x9000:
+		switch {
+		case !second:
+			m.prgBank[2] = value & 0x3F
+		case addr&0x20 != 0:
+			if m.audio != nil {
+				m.audio.WriteData(value)
+			}
+		default:
+			if m.audio != nil {
+				m.audio.SelectRegister(value)
+			}
+		}
+
+	case 0xA000, 0xB000, 0xC000, 0xD000:
+		slot := (block-0xA000)>>11 | boolBit(second)
+		m.chrBanks[slot] = value
+		if logging.Enabled(logging.Mapper, slog.LevelDebug) {
+			logging.Log(logging.Mapper, slog.LevelDebug, "vrc7 chr bank", "slot", slot, "bank", value)
+		}
+
+	case 0xE000:
+		if second {
+			m.irq.latch = value
+			return
+		}
+		switch value & 0x03 {
+		case 0:
+			m.mirroring = MirrorVertical
+		case 1:
+			m.mirroring = MirrorHorizontal
+		case 2:
+			m.mirroring = MirrorSingleLow
+		case 3:
+			m.mirroring = MirrorSingleHigh
+		}
+		m.prgRAMEnable = value&0x40 != 0
+		if m.audio != nil {
+			m.audio.SetMuted(value&0x80 != 0)
+		}
+
+	case 0xF000:
+		if second {
+			m.irq.acknowledge()
+		} else {
+			m.irq.writeControl(value)
+		}
+	}
+}
+
+// boolBit returns 1 for true and 0 for false
+func boolBit(b bool) uint16 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ReadCHR reads from banked CHR memory (PPU $0000-$1FFF)
+func (m *Mapper85) ReadCHR(addr uint16) uint8 {
+	return m.chrMem[m.chrOffset(addr)]
+}
+
+// WriteCHR writes to CHR-RAM (PPU $0000-$1FFF)
+func (m *Mapper85) WriteCHR(addr uint16, value uint8) {
+	if m.chrIsRAM {
+		m.chrMem[m.chrOffset(addr)] = value
+	}
+}
+
+// chrOffset maps a PPU address through the 1KB CHR banks
+func (m *Mapper85) chrOffset(addr uint16) uint32 {
+	offset := uint32(m.chrBanks[(addr>>10)&7])*0x400 + uint32(addr&0x03FF)
+	return offset % uint32(len(m.chrMem))
+}
+
+// ClockCPU runs the IRQ counter for one CPU cycle
+func (m *Mapper85) ClockCPU() {
+	m.irq.clock()
+}
+
+// ClockAudio runs the FM synthesizer for one CPU cycle
+func (m *Mapper85) ClockAudio() {
+	if m.audio != nil {
+		m.audio.Clock()
+	}
+}
+
+// AudioOutput returns the FM synthesizer's current level
+func (m *Mapper85) AudioOutput() float32 {
+	if m.audio != nil {
+		return m.audio.Output()
+	}
+	return 0
+}
+
+// Scanline is a no-op; the IRQ counter runs on CPU cycles
+func (m *Mapper85) Scanline() {}
+
+// GetMirroring returns the nametable mirroring mode
+func (m *Mapper85) GetMirroring() uint8 {
+	return m.mirroring
+}
+
+// IRQState reports whether the IRQ line is asserted (until acknowledged)
+func (m *Mapper85) IRQState() bool {
+	return m.irq.asserted()
+}
+
+// CHRRAM returns the CHR-RAM, or nil for CHR-ROM cartridges
+func (m *Mapper85) CHRRAM() []uint8 {
+	if !m.chrIsRAM {
+		return nil
+	}
+	return m.chrMem
+}
+
+// WriteState appends PRG-RAM, bank registers, IRQ and sound state to w
+func (m *Mapper85) WriteState(w *state.Writer) {
+	w.Block(m.prgRAM)
+	w.Block(m.prgBank[:])
+	w.Block(m.chrBanks[:])
+	w.U8(m.mirroring)
+	w.Bool(m.prgRAMEnable)
+	m.irq.writeState(w)
+	if m.audio != nil {
+		m.audio.WriteState(w)
+	}
+	if m.chrIsRAM {
+		w.Block(m.chrMem)
+	}
+}
+
+// ReadState restores state written by WriteState
+func (m *Mapper85) ReadState(r *state.Reader) {
+	r.Block(m.prgRAM)
+	r.Block(m.prgBank[:])
+	r.Block(m.chrBanks[:])
+	m.mirroring = r.U8()
+	m.prgRAMEnable = r.Bool()
+	m.irq.readState(r)
+	if m.audio != nil {
+		m.audio.ReadState(r)
+	}
+	if m.chrIsRAM {
+		r.Block(m.chrMem)
+	}
+}
+
+// PRGRAM returns the PRG-RAM at $6000-$7FFF (8KB unless the header says
+// otherwise)
+func (m *Mapper85) PRGRAM() []uint8 {
+	return m.prgRAM
+}
+
+// setPRGRAMSize resizes PRG-RAM to the header's size, up to 8KB
+func (m *Mapper85) setPRGRAMSize(size int) {
+	m.prgRAM = make([]uint8, min(size, prgRAMWindow))
+}
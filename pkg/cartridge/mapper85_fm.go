@@ -0,0 +1,10 @@
+//go:build !novrc7
+
+package cartridge
+
+import "github.com/andrewthecodertx/go-nes-emulator/pkg/apu/vrc7"
+
+// newVRC7Audio creates the VRC7's FM synthesizer
+func newVRC7Audio() vrc7Audio {
+	return vrc7.New()
+}
@@ -0,0 +1,8 @@
+//go:build novrc7
+
+package cartridge
+
+// newVRC7Audio returns nil: this build leaves out FM synthesis
+func newVRC7Audio() vrc7Audio {
+	return nil
+}
@@ -0,0 +1,487 @@
+package cartridge
+
+import (
+	"testing"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testutil"
+)
+
+// busConflictSafe is the offset in every 8KB PRG block of taggedROM that
+// holds $FF, so register writes there are not changed by bus conflicts
+const busConflictSafe = 0x1FF0
+
+// taggedROM builds an image whose every 8KB PRG block is filled with its
+// block number and every 1KB CHR block with its block number (mod 256), so
+// a read tells which bank is mapped. chrKB of 0 leaves the board CHR-RAM.
+func taggedROM(mapperID uint16, prgKB, chrKB int) *testutil.ROMBuilder {
+	prg := make([]byte, prgKB*1024)
+	for i := range prg {
+		prg[i] = byte(i / 0x2000)
+		if i%0x2000 == busConflictSafe {
+			prg[i] = 0xFF
+		}
+	}
+	b := testutil.NewROM(mapperID).PRG(0, prg...)
+	if chrKB > 0 {
+		chr := make([]byte, chrKB*1024)
+		for i := range chr {
+			chr[i] = byte(i / 0x400)
+		}
+		b.CHR(0, chr...)
+	}
+	return b
+}
+
+// loadMapper loads an image and returns its mapper
+func loadMapper(t *testing.T, b *testutil.ROMBuilder) Mapper {
+	t.Helper()
+	cart, err := LoadFromBytes(b.Build())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cart.GetMapper()
+}
+
+type regWrite struct {
+	addr  uint16
+	value uint8
+}
+
+// mmc1Write expands a write to an MMC1 register into its five serial writes
+func mmc1Write(addr uint16, value uint8) []regWrite {
+	writes := make([]regWrite, 5)
+	for i := range writes {
+		writes[i] = regWrite{addr, value >> i & 1}
+	}
+	return writes
+}
+
+// seq concatenates write lists
+func seq(lists ...[]regWrite) []regWrite {
+	var out []regWrite
+	for _, l := range lists {
+		out = append(out, l...)
+	}
+	return out
+}
+
+func TestMapperBanking(t *testing.T) {
+	tests := []struct {
+		name      string
+		rom       *testutil.ROMBuilder
+		writes    []regWrite
+		chrWrites []regWrite
+		prg       map[uint16]int // CPU address: 8KB PRG block, or value read
+		chr       map[uint16]int // PPU address: 1KB CHR block, or value read
+		mirroring string         // MirroringName, "" to skip
+	}{
+		// Color Dreams
+		{
+			name: "11 power-on",
+			rom:  taggedROM(11, 128, 128),
+			prg:  map[uint16]int{0x8000: 0, 0xE000: 3},
+			chr:  map[uint16]int{0x0000: 0, 0x1C00: 7},
+		},
+		{
+			name:   "11 banks",
+			rom:    taggedROM(11, 128, 128),
+			writes: []regWrite{{0xFFF0, 0x32}},
+			prg:    map[uint16]int{0x8000: 8, 0xE000: 11},
+			chr:    map[uint16]int{0x0000: 24, 0x1C00: 31},
+		},
+		{
+			name:   "11 bus conflict",
+			rom:    taggedROM(11, 128, 128),
+			writes: []regWrite{{0xFFF0, 0x01}, {0x8000, 0x35}}, // ROM holds $04
+			prg:    map[uint16]int{0x8000: 0},
+			chr:    map[uint16]int{0x0000: 0},
+		},
+
+		// BNROM and NINA-001
+		{
+			name:   "34 bnrom",
+			rom:    taggedROM(34, 128, 0),
+			writes: []regWrite{{0xBFF0, 0x02}},
+			prg:    map[uint16]int{0x8000: 8, 0xE000: 11},
+		},
+		{
+			name:   "34 bnrom bus conflict",
+			rom:    taggedROM(34, 128, 0),
+			writes: []regWrite{{0xBFF0, 0x01}, {0x8000, 0x03}}, // ROM holds $04
+			prg:    map[uint16]int{0x8000: 0},
+		},
+		{
+			name:   "34 nina-001",
+			rom:    taggedROM(34, 64, 64),
+			writes: []regWrite{{0x7FFD, 1}, {0x7FFE, 3}, {0x7FFF, 5}},
+			prg:    map[uint16]int{0x8000: 4, 0xE000: 7, 0x7FFE: 3},
+			chr:    map[uint16]int{0x0000: 12, 0x0C00: 15, 0x1000: 20},
+		},
+
+		// Camerica
+		{
+			name: "71 power-on",
+			rom:  taggedROM(71, 256, 0),
+			prg:  map[uint16]int{0x8000: 0, 0xC000: 30, 0xE000: 31},
+		},
+		{
+			name:      "71 bank, no mirroring register",
+			rom:       taggedROM(71, 256, 0),
+			writes:    []regWrite{{0xC000, 5}, {0x8000, 0x10}},
+			prg:       map[uint16]int{0x8000: 10, 0xA000: 11, 0xC000: 30},
+			mirroring: "horizontal",
+		},
+		{
+			name:      "71 fire hawk mirroring",
+			rom:       taggedROM(71, 256, 0),
+			writes:    []regWrite{{0x9000, 0x10}},
+			mirroring: "single-screen high",
+		},
+		{
+			name:      "71 fire hawk submapper",
+			rom:       taggedROM(71, 256, 0).NES20(1, 0),
+			writes:    []regWrite{{0x8000, 0x00}},
+			mirroring: "single-screen low",
+		},
+
+		// Action 52: A11-A12 chip, A6-A10 bank, A5 16KB mode, A13
+		// mirroring, A0-A3 and D0-D1 CHR bank
+		{
+			name:      "228 32KB",
+			rom:       taggedROM(228, 1536, 128),
+			writes:    []regWrite{{0x8000 | 1<<11 | 3<<6 | 1, 2}},
+			prg:       map[uint16]int{0x8000: 68, 0xC000: 70, 0xE000: 71},
+			chr:       map[uint16]int{0x0000: 48},
+			mirroring: "vertical",
+		},
+		{
+			name:      "228 16KB on the third chip",
+			rom:       taggedROM(228, 1536, 128),
+			writes:    []regWrite{{0x8000 | 0x2000 | 3<<11 | 5<<6 | 0x20, 0}},
+			prg:       map[uint16]int{0x8000: 138, 0xC000: 138, 0xE000: 139},
+			mirroring: "horizontal",
+		},
+		{
+			name:   "228 empty socket and RAM registers",
+			rom:    taggedROM(228, 1536, 128),
+			writes: []regWrite{{0x8000 | 2<<11 | 1<<6, 0}, {0x5FF1, 0xAB}},
+			prg:    map[uint16]int{0xA000: 0, 0xE000: 0, 0x4021: 0x0B},
+		},
+
+		// Namco 108: MMC3 banking without mode bits or mirroring
+		{
+			name: "206 banks",
+			rom:  taggedROM(206, 128, 64),
+			writes: []regWrite{
+				{0x8000, 0xC6}, {0x8001, 4},
+				{0x8000, 7}, {0x8001, 5},
+				{0x8000, 0}, {0x8001, 9},
+				{0x8000, 2}, {0x8001, 42},
+				{0xA000, 0},
+			},
+			prg:       map[uint16]int{0x8000: 4, 0xA000: 5, 0xC000: 14, 0xE000: 15},
+			chr:       map[uint16]int{0x0000: 8, 0x0400: 9, 0x1000: 42},
+			mirroring: "horizontal",
+		},
+
+		// TxSROM: CHR bank bit 7 picks the nametable page
+		{
+			name:      "118 chr mode 0",
+			rom:       taggedROM(118, 128, 128),
+			writes:    []regWrite{{0x8000, 0}, {0x8001, 0x80}, {0x8000, 1}, {0x8001, 0x00}, {0xA000, 0}},
+			mirroring: "horizontal",
+		},
+		{
+			name: "118 chr mode 1",
+			rom:  taggedROM(118, 128, 128),
+			writes: []regWrite{
+				{0x8000, 0x82}, {0x8001, 0x80}, {0x8000, 0x83}, {0x8001, 0x00},
+				{0x8000, 0x84}, {0x8001, 0x80}, {0x8000, 0x85}, {0x8001, 0x00},
+			},
+			mirroring: "vertical",
+		},
+
+		// TQROM: CHR bank bit 6 picks CHR-RAM
+		{
+			name:      "119 chr-ram bank",
+			rom:       taggedROM(119, 128, 64),
+			writes:    []regWrite{{0x8000, 2}, {0x8001, 0x41}},
+			chrWrites: []regWrite{{0x1000, 0x77}},
+			chr:       map[uint16]int{0x1000: 0x77, 0x1400: 0},
+		},
+		{
+			name:      "119 chr-rom bank",
+			rom:       taggedROM(119, 128, 64),
+			writes:    []regWrite{{0x8000, 2}, {0x8001, 0x05}},
+			chrWrites: []regWrite{{0x1000, 0x77}},
+			chr:       map[uint16]int{0x1000: 5},
+		},
+
+		// VRC2/VRC4: register select lines differ per board
+		{
+			name:      "21 vrc4a",
+			rom:       taggedROM(21, 256, 128),
+			writes:    []regWrite{{0x8000, 4}, {0xA000, 5}, {0x9000, 1}, {0xB000, 5}, {0xB002, 1}, {0xB004, 3}, {0xB006, 0}},
+			prg:       map[uint16]int{0x8000: 4, 0xA000: 5, 0xC000: 30, 0xE000: 31},
+			chr:       map[uint16]int{0x0000: 21, 0x0400: 3},
+			mirroring: "horizontal",
+		},
+		{
+			name:   "21 vrc4c",
+			rom:    taggedROM(21, 256, 128),
+			writes: []regWrite{{0x8000, 4}, {0x9080, 2}, {0xB000, 5}, {0xB040, 1}, {0xB080, 3}, {0xB0C0, 0}},
+			prg:    map[uint16]int{0x8000: 30, 0xC000: 4},
+			chr:    map[uint16]int{0x0000: 21, 0x0400: 3},
+		},
+		{
+			name:   "22 vrc2a",
+			rom:    taggedROM(22, 256, 128),
+			writes: []regWrite{{0xB000, 4}, {0xB002, 1}, {0xB001, 6}, {0xB003, 0}},
+			chr:    map[uint16]int{0x0000: 10, 0x0400: 3},
+		},
+		{
+			name:   "23 vrc2b",
+			rom:    taggedROM(23, 256, 128),
+			writes: []regWrite{{0xE000, 2}, {0xE001, 1}, {0xE002, 7}, {0xE003, 0}},
+			chr:    map[uint16]int{0x1800: 18, 0x1C00: 7},
+		},
+		{
+			name:   "23 vrc4e",
+			rom:    taggedROM(23, 256, 128),
+			writes: []regWrite{{0x8000, 6}, {0x9008, 2}, {0xC000, 2}, {0xC004, 1}, {0xC008, 7}, {0xC00C, 0}},
+			prg:    map[uint16]int{0x8000: 30, 0xC000: 6},
+			chr:    map[uint16]int{0x0800: 18, 0x0C00: 7},
+		},
+		{
+			name:   "25 vrc4b",
+			rom:    taggedROM(25, 256, 128),
+			writes: []regWrite{{0xB000, 4}, {0xB002, 1}, {0xB001, 6}, {0xB003, 0}},
+			chr:    map[uint16]int{0x0000: 20, 0x0400: 6},
+		},
+		{
+			name:   "25 vrc4d",
+			rom:    taggedROM(25, 256, 128),
+			writes: []regWrite{{0x8000, 6}, {0x9004, 2}, {0xB000, 4}, {0xB008, 1}, {0xB004, 6}, {0xB00C, 0}},
+			prg:    map[uint16]int{0x8000: 30, 0xC000: 6},
+			chr:    map[uint16]int{0x0000: 20, 0x0400: 6},
+		},
+
+		// VRC7: A4 (VRC7a) or A3 (VRC7b) selects the second register
+		{
+			name: "85 vrc7a",
+			rom:  taggedROM(85, 256, 128),
+			writes: []regWrite{
+				{0x8000, 3}, {0x8010, 4}, {0x9000, 5},
+				{0xA000, 7}, {0xA010, 8}, {0xD010, 0x7F},
+				{0xE000, 0x41}, {0x6000, 0x5A},
+			},
+			prg:       map[uint16]int{0x8000: 3, 0xA000: 4, 0xC000: 5, 0xE000: 31, 0x6000: 0x5A},
+			chr:       map[uint16]int{0x0000: 7, 0x0400: 8, 0x1C00: 127},
+			mirroring: "horizontal",
+		},
+		{
+			name:   "85 vrc7b and PRG-RAM disabled",
+			rom:    taggedROM(85, 256, 128),
+			writes: []regWrite{{0x8008, 6}, {0xB008, 9}, {0x6000, 0x5A}},
+			prg:    map[uint16]int{0xA000: 6, 0x6000: 0},
+			chr:    map[uint16]int{0x0C00: 9},
+		},
+
+		// Bandai FCG-1/2 registers at $6000, LZ93D50 at $8000
+		{
+			name:      "16 lz93d50",
+			rom:       taggedROM(16, 256, 128),
+			writes:    []regWrite{{0x8008, 3}, {0x8000, 9}, {0x800F, 0}, {0x8007, 0x7F}, {0x8009, 3}},
+			prg:       map[uint16]int{0x8000: 6, 0xA000: 7, 0xC000: 30, 0xE000: 31},
+			chr:       map[uint16]int{0x0000: 9, 0x1C00: 127},
+			mirroring: "single-screen high",
+		},
+		{
+			name:      "16 fcg",
+			rom:       taggedROM(16, 256, 128),
+			writes:    []regWrite{{0x6008, 4}, {0x6001, 12}, {0x7FF9, 1}},
+			prg:       map[uint16]int{0x8000: 8, 0xC000: 30},
+			chr:       map[uint16]int{0x0400: 12},
+			mirroring: "horizontal",
+		},
+		{
+			name:   "159 lz93d50",
+			rom:    taggedROM(159, 256, 128),
+			writes: []regWrite{{0x8008, 2}, {0x8003, 44}},
+			prg:    map[uint16]int{0x8000: 4, 0xE000: 31},
+			chr:    map[uint16]int{0x0C00: 44},
+		},
+		{
+			name:      "157 datach",
+			rom:       taggedROM(157, 256, 0),
+			writes:    []regWrite{{0x8008, 5}, {0x8000, 3}},
+			chrWrites: []regWrite{{0x0000, 0x99}},
+			prg:       map[uint16]int{0x8000: 10, 0xC000: 30, 0x6000: 0x18}, // EEPROM and reader idle high
+			chr:       map[uint16]int{0x0000: 0x99},
+		},
+
+		// Namco 163
+		{
+			name: "19 banks",
+			rom:  taggedROM(19, 256, 128),
+			writes: []regWrite{
+				{0xE000, 3}, {0xE800, 4}, {0xF000, 5},
+				{0x8000, 7}, {0xB800, 0x7F},
+				{0xC000, 0xE1}, {0xC800, 0xE0},
+			},
+			prg:       map[uint16]int{0x8000: 3, 0xA000: 4, 0xC000: 5, 0xE000: 31},
+			chr:       map[uint16]int{0x0000: 7, 0x1C00: 127},
+			mirroring: "vertical",
+		},
+		{
+			name:   "19 PRG-RAM write enable",
+			rom:    taggedROM(19, 256, 128),
+			writes: []regWrite{{0xF800, 0x40}, {0x6000, 0x12}, {0x6800, 0x13}, {0xF800, 0x42}, {0x6000, 0x34}, {0x6800, 0x35}},
+			prg:    map[uint16]int{0x6000: 0x34, 0x6800: 0x13},
+		},
+		{
+			name:   "19 PRG-RAM write protect",
+			rom:    taggedROM(19, 256, 128),
+			writes: []regWrite{{0xF800, 0x00}, {0x6000, 0x12}},
+			prg:    map[uint16]int{0x6000: 0},
+		},
+
+		// NES-EVENT: MMC1 plus board control in the CHR bank 0 register
+		{
+			name: "105 power-on",
+			rom:  taggedROM(105, 256, 0),
+			prg:  map[uint16]int{0x8000: 0, 0xA000: 1, 0xC000: 2, 0xE000: 3},
+		},
+		{
+			name:   "105 first chip",
+			rom:    taggedROM(105, 256, 0),
+			writes: seq(mmc1Write(0xA000, 0x10), mmc1Write(0xA000, 0x04)),
+			prg:    map[uint16]int{0x8000: 8, 0xE000: 11},
+		},
+		{
+			name:   "105 held until the timer is reset",
+			rom:    taggedROM(105, 256, 0),
+			writes: mmc1Write(0xA000, 0x04),
+			prg:    map[uint16]int{0x8000: 0, 0xE000: 3},
+		},
+		{
+			name: "105 second chip",
+			rom:  taggedROM(105, 256, 0),
+			writes: seq(mmc1Write(0xA000, 0x10), mmc1Write(0xA000, 0x08),
+				mmc1Write(0xE000, 2), mmc1Write(0x8000, 0x0C)),
+			prg: map[uint16]int{0x8000: 20, 0xA000: 21, 0xC000: 30, 0xE000: 31},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := loadMapper(t, tt.rom)
+			for _, w := range tt.writes {
+				m.WritePRG(w.addr, w.value)
+			}
+			for _, w := range tt.chrWrites {
+				m.WriteCHR(w.addr, w.value)
+			}
+			for addr, want := range tt.prg {
+				if got := m.ReadPRG(addr); int(got) != want {
+					t.Errorf("CPU $%04X reads %d, want %d", addr, got, want)
+				}
+			}
+			for addr, want := range tt.chr {
+				if got := m.ReadCHR(addr); int(got) != want {
+					t.Errorf("PPU $%04X reads %d, want %d", addr, got, want)
+				}
+			}
+			if tt.mirroring != "" {
+				if got := MirroringName(m.GetMirroring()); got != tt.mirroring {
+					t.Errorf("mirroring %s, want %s", got, tt.mirroring)
+				}
+			}
+		})
+	}
+}
+
+func TestMapperIRQ(t *testing.T) {
+	tests := []struct {
+		name   string
+		mapper uint16
+		setup  []regWrite
+		cycles int // CPU cycles until the IRQ line rises
+		ack    []regWrite
+	}{
+		// VRC4/VRC7 counter in cycle mode from latch $FE: $FF, then overflow
+		{"21 vrc4a", 21, []regWrite{{0xF000, 0x0E}, {0xF002, 0x0F}, {0xF004, 0x06}}, 2, []regWrite{{0xF006, 0}}},
+		{"21 vrc4c", 21, []regWrite{{0xF000, 0x0E}, {0xF040, 0x0F}, {0xF080, 0x06}}, 2, []regWrite{{0xF0C0, 0}}},
+		{"23 vrc4e", 23, []regWrite{{0xF000, 0x0E}, {0xF004, 0x0F}, {0xF008, 0x06}}, 2, []regWrite{{0xF00C, 0}}},
+		{"25 vrc4b", 25, []regWrite{{0xF000, 0x0E}, {0xF002, 0x0F}, {0xF001, 0x06}}, 2, []regWrite{{0xF003, 0}}},
+		{"25 vrc4d", 25, []regWrite{{0xF000, 0x0E}, {0xF008, 0x0F}, {0xF004, 0x06}}, 2, []regWrite{{0xF00C, 0}}},
+		{"85 vrc7", 85, []regWrite{{0xE010, 0xFE}, {0xF000, 0x06}}, 2, []regWrite{{0xF010, 0}}},
+		{"85 vrc7 scanline mode", 85, []regWrite{{0xE010, 0xFF}, {0xF000, 0x02}}, 114, []regWrite{{0xF008, 0}}},
+
+		// Bandai: the counter fires on the cycle after it reaches zero
+		{"16 fcg", 16, []regWrite{{0x600B, 2}, {0x600C, 0}, {0x600A, 1}}, 3, []regWrite{{0x600A, 0}}},
+		{"16 lz93d50", 16, []regWrite{{0x800B, 2}, {0x800C, 0}, {0x800A, 1}}, 3, []regWrite{{0x800A, 0}}},
+		{"159 lz93d50", 159, []regWrite{{0x800B, 0}, {0x800C, 1}, {0x800A, 1}}, 257, []regWrite{{0x800A, 0}}},
+
+		// Namco 163: counts up to $7FFF
+		{"19", 19, []regWrite{{0x5000, 0xFD}, {0x5800, 0xFF}}, 2, []regWrite{{0x5000, 0}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := loadMapper(t, taggedROM(tt.mapper, 256, 128))
+			clocked, ok := m.(CPUClockedMapper)
+			if !ok {
+				t.Fatal("mapper does not count CPU cycles")
+			}
+			for _, w := range tt.setup {
+				m.WritePRG(w.addr, w.value)
+			}
+			for i := 1; i < tt.cycles; i++ {
+				clocked.ClockCPU()
+				if m.IRQState() {
+					t.Fatalf("IRQ after %d cycles, want %d", i, tt.cycles)
+				}
+			}
+			clocked.ClockCPU()
+			if !m.IRQState() || !m.IRQState() {
+				t.Fatalf("no IRQ held after %d cycles", tt.cycles)
+			}
+			for _, w := range tt.ack {
+				m.WritePRG(w.addr, w.value)
+			}
+			if m.IRQState() {
+				t.Fatal("IRQ still asserted after acknowledge")
+			}
+		})
+	}
+}
+
+func TestMapper105Timer(t *testing.T) {
+	m := loadMapper(t, taggedROM(105, 256, 0)).(*Mapper105)
+	m.SetDIPSwitches(0)
+	m.ClockCPU()
+	if m.counter != 0 {
+		t.Fatal("timer ran while held in reset")
+	}
+
+	for _, w := range mmc1Write(0xA000, 0x00) {
+		m.WritePRG(w.addr, w.value)
+	}
+	m.counter = 16<<25 - 2
+	m.ClockCPU()
+	if m.IRQState() {
+		t.Fatal("IRQ a cycle early")
+	}
+	m.ClockCPU()
+	if !m.IRQState() {
+		t.Fatal("no IRQ at 5:00 with the DIP switches off")
+	}
+
+	for _, w := range mmc1Write(0xA000, 0x10) {
+		m.WritePRG(w.addr, w.value)
+	}
+	if m.IRQState() || m.counter != 0 {
+		t.Fatal("stopping the timer did not reset it")
+	}
+}
@@ -0,0 +1,61 @@
+package cartridge
+
+import (
+	"fmt"
+	"os"
+)
+
+// LoadOptions adjusts how a ROM is loaded: patching it, and overriding
+// what its header (or the ROM database) says about the board for dumps
+// with incorrect headers. Nil fields are left alone.
+type LoadOptions struct {
+	Mapper     *uint16 // iNES mapper number
+	Submapper  *uint8  // NES 2.0 submapper
+	Mirroring  *uint8  // Mirror* constant
+	PRGRAMSize *int    // PRG-RAM size in bytes, see GetPRGRAMSize
+	Region     *Region // Timing region
+
+	// Patch is an IPS or BPS patch applied to the whole file before the
+	// header is parsed. PatchFile names one to read instead.
+	Patch     []byte
+	PatchFile string
+
+	// SkipDatabase loads the header as-is instead of repairing it from
+	// the ROM database
+	SkipDatabase bool
+}
+
+// LoadWithOptions loads a ROM file like LoadFromFile, applying opts on top
+// of the header
+func LoadWithOptions(filename string, opts LoadOptions) (*Cartridge, error) {
+	data, err := readROMFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if opts.PatchFile != "" {
+		opts.Patch, err = os.ReadFile(opts.PatchFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read patch file: %w", err)
+		}
+	}
+	return LoadBytesWithOptions(data, opts)
+}
+
+// apply overrides the header fields set in opts
+func (opts LoadOptions) apply(h *inesHeader) {
+	if opts.Mapper != nil {
+		h.mapperID = *opts.Mapper
+	}
+	if opts.Submapper != nil {
+		h.submapper = *opts.Submapper
+	}
+	if opts.Mirroring != nil {
+		h.mirroring = *opts.Mirroring
+	}
+	if opts.PRGRAMSize != nil {
+		h.prgRAMSize = *opts.PRGRAMSize
+	}
+	if opts.Region != nil {
+		h.region = *opts.Region
+	}
+}
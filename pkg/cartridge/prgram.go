@@ -0,0 +1,53 @@
+package cartridge
+
+// prgRAMWindow is the size of the $6000-$7FFF window boards map PRG-RAM
+// into
+const prgRAMWindow = 0x2000
+
+// prgRAMSizer is implemented by mappers whose PRG-RAM size comes from the
+// header or ROM database rather than being fixed by the board. size is in
+// bytes and may be 0 (no PRG-RAM).
+type prgRAMSizer interface {
+	setPRGRAMSize(size int)
+}
+
+// readPRGRAM reads unbanked PRG-RAM through the $6000-$7FFF window. RAM
+// smaller than 8KB is mirrored; a board without any reads open bus.
+func readPRGRAM(ram []uint8, addr uint16) uint8 {
+	if len(ram) == 0 {
+		return 0
+	}
+	return ram[int(addr-0x6000)%len(ram)]
+}
+
+// writePRGRAM writes unbanked PRG-RAM through the $6000-$7FFF window
+func writePRGRAM(ram []uint8, addr uint16, value uint8) {
+	if len(ram) > 0 {
+		ram[int(addr-0x6000)%len(ram)] = value
+	}
+}
+
+// detectPRGRAM returns the PRG-RAM size and its battery-backed subset in
+// bytes, or -1 for both if the header does not say
+//
+// NES 2.0 gives both as shift counts in byte 10 (64 << n bytes, 0 for
+// none). Plain iNES only has a total in 8KB units in byte 8, trusted when
+// non-zero and the padding bytes are clean, as for detectRegion.
+func detectPRGRAM(data []byte) (size, nvSize int) {
+	if isNES20(data) {
+		shiftSize := func(n uint8) int {
+			if n == 0 {
+				return 0
+			}
+			return 64 << n
+		}
+		volatile := shiftSize(data[10] & 0x0F)
+		nvSize = shiftSize(data[10] >> 4)
+		return volatile + nvSize, nvSize
+	}
+
+	if data[8] != 0 && cleanPadding(data) {
+		return int(data[8]) * 0x2000, -1
+	}
+	return -1, -1
+}
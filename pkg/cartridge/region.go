@@ -0,0 +1,81 @@
+package cartridge
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Region identifies the console timing a cartridge was made for
+//
+// The values match the CPU/PPU timing field of the NES 2.0 header (byte 12).
+type Region uint8
+
+const (
+	RegionNTSC  Region = 0 // RP2C02, 60 Hz (North America, Japan)
+	RegionPAL   Region = 1 // RP2C07, 50 Hz (Europe, Australia)
+	RegionMulti Region = 2 // Runs on either; emulated as NTSC
+	RegionDendy Region = 3 // UMC 6527P clones, 50 Hz with NTSC-like CPU timing
+)
+
+// String returns the region's conventional name
+func (r Region) String() string {
+	switch r {
+	case RegionNTSC:
+		return "NTSC"
+	case RegionPAL:
+		return "PAL"
+	case RegionMulti:
+		return "Multi-region"
+	case RegionDendy:
+		return "Dendy"
+	}
+	return "Unknown"
+}
+
+// ParseRegion parses a region name as accepted on command lines: "ntsc",
+// "pal", "dendy" or "multi", case-insensitively
+func ParseRegion(s string) (Region, error) {
+	switch strings.ToLower(s) {
+	case "ntsc":
+		return RegionNTSC, nil
+	case "pal":
+		return RegionPAL, nil
+	case "dendy":
+		return RegionDendy, nil
+	case "multi":
+		return RegionMulti, nil
+	}
+	return 0, fmt.Errorf("unknown region %q (want ntsc, pal, dendy or multi)", s)
+}
+
+// detectRegion determines the timing region from the iNES/NES 2.0 header
+//
+// NES 2.0 headers carry an explicit timing field in byte 12. Plain iNES only
+// has the rarely-set TV system bit in byte 9, which is trusted only when the
+// padding bytes 12-15 are clean (old dumps often have garbage such as
+// "DiskDude!" there).
+func detectRegion(data []byte) Region {
+	if isNES20(data) {
+		return Region(data[12] & 0x03)
+	}
+
+	if cleanPadding(data) {
+		if data[9]&0x01 != 0 {
+			return RegionPAL
+		}
+	}
+
+	return RegionNTSC
+}
+
+// cleanPadding reports whether the iNES 1.0 padding bytes 12-15 are zero,
+// i.e. the header has not been scribbled on by an old dumping tool
+func cleanPadding(data []byte) bool {
+	return data[12] == 0 && data[13] == 0 && data[14] == 0 && data[15] == 0
+}
+
+// isNES20 reports whether the header uses the NES 2.0 format
+// (flags 7 bits 2-3 == 10)
+func isNES20(data []byte) bool {
+	return data[7]&0x0C == 0x08
+}
@@ -0,0 +1,111 @@
+package cartridge
+
+import (
+	"fmt"
+)
+
+// ROMParts is an iNES file split into its sections, for ROM-hacking tools
+// that edit PRG or CHR data separately and reassemble the image
+type ROMParts struct {
+	Header  [inesHeaderSize]byte
+	Trainer []byte // 512 bytes if the header declares a trainer, else nil
+	PRG     []byte
+	CHR     []byte // nil for CHR-RAM cartridges
+	Extra   []byte // Anything after the declared CHR-ROM (overdump, title data)
+}
+
+// SplitROM divides an iNES image into header, trainer, PRG-ROM, CHR-ROM and
+// trailing data, using the sizes the header declares
+func SplitROM(data []byte) (*ROMParts, error) {
+	if len(data) < inesHeaderSize {
+		return nil, fmt.Errorf("%w: file is %d bytes, smaller than the %d-byte header", ErrTruncatedROM, len(data), inesHeaderSize)
+	}
+	if string(data[0:4]) != inesMagic {
+		return nil, fmt.Errorf("%w: bad magic: expected %q, got %q", ErrBadHeader, inesMagic, string(data[0:4]))
+	}
+
+	parts := &ROMParts{}
+	copy(parts.Header[:], data)
+	header := parseINESHeader(data)
+
+	offset := inesHeaderSize
+	take := func(name string, size int) ([]byte, error) {
+		if len(data) < offset+size {
+			return nil, fmt.Errorf("%w: need %d bytes of %s, have %d", ErrTruncatedROM, size, name, max(len(data)-offset, 0))
+		}
+		section := data[offset : offset+size]
+		offset += size
+		return section, nil
+	}
+
+	var err error
+	if header.hasTrainer {
+		if parts.Trainer, err = take("trainer", trainerSize); err != nil {
+			return nil, err
+		}
+	}
+	if parts.PRG, err = take("PRG-ROM", int(header.prgBanks)*prgROMBankSize); err != nil {
+		return nil, err
+	}
+	if header.chrBanks > 0 {
+		if parts.CHR, err = take("CHR-ROM", int(header.chrBanks)*chrROMBankSize); err != nil {
+			return nil, err
+		}
+	}
+	if offset < len(data) {
+		parts.Extra = data[offset:]
+	}
+	return parts, nil
+}
+
+// Validate checks that the section sizes match what the header declares
+func (p *ROMParts) Validate() error {
+	if string(p.Header[0:4]) != inesMagic {
+		return fmt.Errorf("%w: bad magic %q", ErrBadHeader, string(p.Header[0:4]))
+	}
+	header := parseINESHeader(p.Header[:])
+
+	if header.hasTrainer != (p.Trainer != nil) {
+		return fmt.Errorf("%w: header trainer flag is %v but trainer data is %d bytes", ErrBadHeader, header.hasTrainer, len(p.Trainer))
+	}
+	if p.Trainer != nil && len(p.Trainer) != trainerSize {
+		return fmt.Errorf("trainer is %d bytes, want %d", len(p.Trainer), trainerSize)
+	}
+	if want := int(header.prgBanks) * prgROMBankSize; len(p.PRG) != want || want == 0 {
+		return fmt.Errorf("PRG-ROM is %d bytes, header declares %d", len(p.PRG), want)
+	}
+	if want := int(header.chrBanks) * chrROMBankSize; len(p.CHR) != want {
+		return fmt.Errorf("CHR-ROM is %d bytes, header declares %d", len(p.CHR), want)
+	}
+	return nil
+}
+
+// FixHeader rewrites the header's PRG/CHR bank counts and trainer flag to
+// match the section sizes, which must be whole banks
+func (p *ROMParts) FixHeader() error {
+	if len(p.PRG) == 0 || len(p.PRG)%prgROMBankSize != 0 || len(p.PRG)/prgROMBankSize > 255 {
+		return fmt.Errorf("PRG-ROM is %d bytes, want 1-255 banks of %d", len(p.PRG), prgROMBankSize)
+	}
+	if len(p.CHR)%chrROMBankSize != 0 || len(p.CHR)/chrROMBankSize > 255 {
+		return fmt.Errorf("CHR-ROM is %d bytes, want 0-255 banks of %d", len(p.CHR), chrROMBankSize)
+	}
+
+	p.Header[4] = uint8(len(p.PRG) / prgROMBankSize)
+	p.Header[5] = uint8(len(p.CHR) / chrROMBankSize)
+	if p.Trainer != nil {
+		p.Header[6] |= 0x04
+	} else {
+		p.Header[6] &^= 0x04
+	}
+	return nil
+}
+
+// Bytes reassembles the iNES image
+func (p *ROMParts) Bytes() []byte {
+	out := make([]byte, 0, inesHeaderSize+len(p.Trainer)+len(p.PRG)+len(p.CHR)+len(p.Extra))
+	out = append(out, p.Header[:]...)
+	out = append(out, p.Trainer...)
+	out = append(out, p.PRG...)
+	out = append(out, p.CHR...)
+	return append(out, p.Extra...)
+}
@@ -0,0 +1,85 @@
+package cartridge
+
+import "github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+
+// vrcIRQ is the IRQ counter shared by Konami's VRC4, VRC6 and VRC7
+//
+// The 8-bit counter counts up and fires when it overflows from $FF,
+// reloading from the latch. In scanline mode a prescaler clocks it every
+// 113.667 CPU cycles (341 PPU dots); in cycle mode it counts every CPU cycle.
+type vrcIRQ struct {
+	latch     uint8
+	counter   uint8
+	prescaler int16
+	enabled   bool
+	enableAck bool // Enable state restored by acknowledging
+	cycleMode bool
+	pending   bool
+}
+
+// writeControl handles the IRQ control register: bit 0 = enable after
+// acknowledge, bit 1 = enable, bit 2 = cycle mode
+func (q *vrcIRQ) writeControl(value uint8) {
+	q.enableAck = value&0x01 != 0
+	q.enabled = value&0x02 != 0
+	q.cycleMode = value&0x04 != 0
+	q.pending = false
+	if q.enabled {
+		q.counter = q.latch
+		q.prescaler = 341
+	}
+}
+
+// acknowledge clears the pending IRQ and restores the enable-after-ack state
+func (q *vrcIRQ) acknowledge() {
+	q.pending = false
+	q.enabled = q.enableAck
+}
+
+// clock runs the counter for one CPU cycle
+func (q *vrcIRQ) clock() {
+	if !q.enabled {
+		return
+	}
+	if !q.cycleMode {
+		// Scanline mode: 341 PPU dots at 3 dots per CPU cycle
+		q.prescaler -= 3
+		if q.prescaler > 0 {
+			return
+		}
+		q.prescaler += 341
+	}
+
+	if q.counter == 0xFF {
+		q.counter = q.latch
+		q.pending = true
+	} else {
+		q.counter++
+	}
+}
+
+// asserted reports whether the IRQ line is held, from the counter
+// overflow until acknowledge or writeControl
+func (q *vrcIRQ) asserted() bool {
+	return q.pending
+}
+
+func (q *vrcIRQ) writeState(w *state.Writer) {
+	w.U8(q.latch)
+	w.U8(q.counter)
+	w.U16(uint16(q.prescaler))
+	w.Bool(q.enabled)
+	w.Bool(q.enableAck)
+	w.Bool(q.cycleMode)
+	w.Bool(q.pending)
+}
+
+func (q *vrcIRQ) readState(r *state.Reader) {
+	q.latch = r.U8()
+	q.counter = r.U8()
+	q.prescaler = int16(r.U16())
+	q.enabled = r.Bool()
+	q.enableAck = r.Bool()
+	q.cycleMode = r.Bool()
+	q.pending = r.Bool()
+}
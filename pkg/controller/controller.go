@@ -4,6 +4,12 @@
 // CPU registers $4016 (controller 1) and $4017 (controller 2).
 package controller
 
+import (
+	"strings"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
 // Button represents NES controller buttons
 type Button uint8
 
@@ -18,6 +24,26 @@ const (
 	ButtonRight
 )
 
+var buttonNames = [8]string{"A", "B", "SELECT", "START", "UP", "DOWN", "LEFT", "RIGHT"}
+
+// String returns the button's name ("A", "START", "UP", ...)
+func (b Button) String() string {
+	if b < 8 {
+		return buttonNames[b]
+	}
+	return "?"
+}
+
+// ParseButton looks up a button by name, case-insensitively
+func ParseButton(name string) (Button, bool) {
+	for i, n := range buttonNames {
+		if strings.EqualFold(n, name) {
+			return Button(i), true
+		}
+	}
+	return 0, false
+}
+
 // Controller represents an NES controller state
 type Controller struct {
 	// Current button states (true = pressed)
@@ -103,3 +129,21 @@ func (c *Controller) Reset() {
 	c.index = 0
 	// Don't reset button states - they persist
 }
+
+// WriteState appends the button, strobe and shift position state to w
+func (c *Controller) WriteState(w *state.Writer) {
+	for _, pressed := range c.buttons {
+		w.Bool(pressed)
+	}
+	w.Bool(c.strobe)
+	w.U8(c.index)
+}
+
+// ReadState restores state written by WriteState
+func (c *Controller) ReadState(r *state.Reader) {
+	for i := range c.buttons {
+		c.buttons[i] = r.Bool()
+	}
+	c.strobe = r.Bool()
+	c.index = r.U8()
+}
@@ -0,0 +1,85 @@
+package cpu
+
+import "fmt"
+
+// maxCallDepth bounds the shadow call stack, for code that never returns
+const maxCallDepth = 256
+
+// CallKind says how a call stack frame was entered
+type CallKind uint8
+
+const (
+	CallJSR CallKind = iota
+	CallBRK
+	CallNMI
+	CallIRQ
+)
+
+var callKindNames = [...]string{"JSR", "BRK", "NMI", "IRQ"}
+
+// String returns the kind's name ("JSR", "NMI", ...)
+func (k CallKind) String() string {
+	if int(k) < len(callKindNames) {
+		return callKindNames[k]
+	}
+	return "?"
+}
+
+// CallFrame is one entry of the shadow call stack
+type CallFrame struct {
+	Kind   CallKind
+	Caller uint16 // Address of the JSR/BRK, or the PC an interrupt preempted
+	Target uint16 // Subroutine or handler address
+	SP     uint8  // Stack pointer before the return address was pushed
+}
+
+// String describes the frame as "JSR $C123 from $C0A0"
+func (f CallFrame) String() string {
+	return fmt.Sprintf("%s $%04X from $%04X", f.Kind, f.Target, f.Caller)
+}
+
+// SetCallTracking turns the shadow call stack on or off. It follows JSR,
+// BRK and interrupts in and RTS/RTI out, so debuggers can show how the game
+// got to the current PC. Tracking starts empty and costs nothing while off.
+func (c *CPU) SetCallTracking(on bool) {
+	c.trackCalls = on
+	c.callStack = c.callStack[:0]
+}
+
+// GetCallStack returns a copy of the shadow call stack, outermost call
+// first. It is empty unless SetCallTracking is on.
+func (c *CPU) GetCallStack() []CallFrame {
+	return append([]CallFrame(nil), c.callStack...)
+}
+
+// enterCall records a call whose return address (and status, for
+// interrupts) has just been pushed, pushed bytes in all
+func (c *CPU) enterCall(kind CallKind, caller uint16, pushed uint8) {
+	sp := c.SP + pushed
+
+	// Frames at or below this stack level can no longer be returned to:
+	// the game reset the stack pointer (e.g. an NMI handler that never
+	// returns) or dropped return addresses
+	n := len(c.callStack)
+	for n > 0 && c.callStack[n-1].SP <= sp {
+		n--
+	}
+	c.callStack = c.callStack[:n]
+
+	if len(c.callStack) == maxCallDepth {
+		c.callStack = append(c.callStack[:0], c.callStack[1:]...)
+	}
+	c.callStack = append(c.callStack, CallFrame{Kind: kind, Caller: caller, Target: c.PC, SP: sp})
+}
+
+// leaveCall unwinds to the frame an RTS or RTI has just returned from,
+// matched by stack level. Returns through a pushed address that no call
+// made (the "RTS trick" used for jump tables) leave the stack alone.
+func (c *CPU) leaveCall() {
+	for i := len(c.callStack) - 1; i >= 0; i-- {
+		if c.callStack[i].SP == c.SP {
+			c.callStack = c.callStack[:i]
+			return
+		}
+	}
+}
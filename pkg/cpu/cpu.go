@@ -0,0 +1,528 @@
+// Package cpu provides the NES's 6502 processor.
+//
+// The core is cycle-stepped: every call to Step performs exactly one bus
+// access, in the order the real chip makes them, including the dummy reads
+// of indexed addressing and the double write of read-modify-write
+// instructions. Mapper IRQ counters and PPU/APU register side effects
+// depend on that ordering. All 256 opcodes are implemented, unofficial ones
+// included.
+//
+// It also carries the debugging hooks the emulator and its tools need: a
+// state snapshot, and setters for placing the CPU at an exact address and
+// register state (e.g. nestest's automated "C000 mode").
+package cpu
+
+import (
+	"fmt"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// Processor status flags (P register)
+const (
+	FlagCarry            = 0x01
+	FlagZero             = 0x02
+	FlagInterruptDisable = 0x04
+	FlagDecimal          = 0x08 // Settable, but the 2A03 has no decimal mode
+	FlagBreak            = 0x10 // Only exists in copies pushed by BRK/PHP
+	FlagUnused           = 0x20 // Always reads as set
+	FlagOverflow         = 0x40
+	FlagNegative         = 0x80
+)
+
+// Interrupt vectors
+const (
+	vectorNMI   = 0xFFFA
+	vectorReset = 0xFFFC
+	vectorIRQ   = 0xFFFE
+)
+
+// Power-on state
+const (
+	powerOnSP     = 0xFD
+	powerOnStatus = 0x34 // I set, plus the always-set bits
+
+	// resetCycles is how long the reset sequence takes before the first
+	// instruction fetch
+	resetCycles = 7
+)
+
+// Bus is the CPU's view of the system: every access is one CPU cycle
+type Bus interface {
+	Read(addr uint16) uint8
+	Write(addr uint16, data uint8)
+}
+
+// CPU is the NES's 6502 processor
+type CPU struct {
+	PC     uint16 // Program counter
+	SP     uint8  // Stack pointer (offset into $0100-$01FF)
+	A      uint8  // Accumulator
+	X      uint8  // X index
+	Y      uint8  // Y index
+	Status uint8  // Status flags (NV-BDIZC)
+
+	Bus Bus
+
+	// Cycles is the number of cycles left before the next instruction
+	// fetch: zero exactly at instruction boundaries
+	Cycles uint8
+	Halted bool // Jammed by STP or a trapped unstable opcode
+
+	NMIPending   bool // NMI edge seen and not yet serviced
+	IRQPending   bool // IRQ line asserted (level-triggered)
+	ResetPending bool // Reset requested, taken at the next boundary
+
+	// Instruction in flight
+	opcode    uint8
+	opcodePC  uint16 // Address the opcode was fetched from (see InstructionPC)
+	step      uint8  // Cycle of the instruction just run (0 = at a boundary)
+	addr      uint16 // Effective address, or operand being assembled
+	base      uint16 // Address before indexing
+	ptr       uint8  // Zero-page pointer for indirect modes
+	data      uint8  // Value read, for read-modify-write
+	dataStart uint8  // Cycle the data access starts on, once the address is known
+	interrupt uint8  // interruptNMI/interruptIRQ during an interrupt sequence
+	stall     uint8  // Idle cycles before the next fetch (Stall, reset)
+
+	// interruptDue is the result of the last interrupt poll, acted on at
+	// the next instruction boundary (see poll)
+	interruptDue bool
+
+	trapUnstable bool // Halt on unstable opcodes (see SetTrapUnstable)
+
+	trackCalls bool        // Maintain callStack (see SetCallTracking)
+	callStack  []CallFrame // Shadow call stack, outermost first
+}
+
+// Interrupt sequences, which run as a pseudo-instruction
+const (
+	interruptNone = iota
+	interruptNMI
+	interruptIRQ
+)
+
+// New creates a CPU attached to the given bus
+func New(bus Bus) *CPU {
+	return &CPU{
+		Bus:    bus,
+		SP:     powerOnSP,
+		Status: powerOnStatus,
+	}
+}
+
+// PowerOn puts the CPU in its power-on state (A, X and Y cleared, P=$34)
+// and runs the reset sequence, which leaves SP at $FD
+func (c *CPU) PowerOn() {
+	c.A, c.X, c.Y = 0, 0, 0
+	c.SP = 0 // The reset sequence takes it to powerOnSP
+	c.Status = powerOnStatus
+	c.Reset()
+}
+
+// Reset runs the reset sequence, as the console's reset button does: an
+// interrupt whose three pushes are turned into reads, so SP drops by 3
+// without anything being written, I is set and PC is loaded from the reset
+// vector. A, X, Y and the other flags keep their values. The first
+// instruction is fetched after the sequence's 7 cycles.
+func (c *CPU) Reset() {
+	c.SP -= 3
+	c.Status |= FlagInterruptDisable
+	c.PC = uint16(c.Bus.Read(vectorReset)) | uint16(c.Bus.Read(vectorReset+1))<<8
+
+	c.step = 0
+	c.interrupt = interruptNone
+	c.interruptDue = false
+	c.callStack = c.callStack[:0]
+	c.Halted = false
+	c.NMIPending = false
+	c.stall = resetCycles
+	c.Cycles = resetCycles
+}
+
+// Step runs one CPU cycle
+func (c *CPU) Step() {
+	if c.step == 0 {
+		if c.stall > 0 {
+			c.stall--
+			c.Cycles--
+			return
+		}
+		if c.ResetPending {
+			c.ResetPending = false
+			c.Reset()
+			c.stall--
+			c.Cycles--
+			return
+		}
+		if c.Halted {
+			// STP jams the CPU until reset; interrupts are ignored
+			return
+		}
+		c.begin()
+		return
+	}
+
+	c.step++
+	var done bool
+	if c.interrupt != interruptNone {
+		done = c.interruptCycle(c.step)
+	} else {
+		done = instructions[c.opcode].cycle(c, c.step)
+	}
+
+	if done {
+		c.step = 0
+		c.Cycles = 0
+		return
+	}
+	if c.Cycles > 1 {
+		c.Cycles--
+	}
+	c.poll()
+}
+
+// poll samples the interrupt lines at the end of a cycle. The 6502 polls at
+// the end of each instruction's second-to-last cycle, so the last poll
+// before the final cycle decides whether an interrupt sequence follows;
+// in particular, CLI, SEI and PLP change I only after their poll, while RTI
+// restores it before. The exceptions are branches, which skip the poll on
+// their operand cycle (so a taken branch that stays on its page delays an
+// interrupt by an instruction), and BRK and the interrupt sequences, which
+// never poll: the handler's first instruction always runs.
+func (c *CPU) poll() {
+	switch {
+	case c.interrupt != interruptNone || c.opcode == 0x00:
+		c.interruptDue = false
+	case c.step == 2 && Opcodes[c.opcode].Mode == Relative:
+	default:
+		c.interruptDue = c.NMIPending || (c.IRQPending && c.Status&FlagInterruptDisable == 0)
+	}
+}
+
+// begin runs the first cycle of an instruction or interrupt sequence: the
+// opcode fetch
+func (c *CPU) begin() {
+	c.step = 1
+	c.dataStart = 0
+
+	if c.interruptDue {
+		c.interruptDue = false
+		kind := uint8(interruptIRQ)
+		if c.NMIPending {
+			kind = interruptNMI
+		}
+		c.startInterrupt(kind)
+		return
+	}
+
+	c.opcodePC = c.PC
+	c.opcode = c.Bus.Read(c.PC)
+	c.PC++
+	info := Opcodes[c.opcode]
+	c.Cycles = info.Cycles - 1
+
+	if info.Mnemonic == "STP" || (info.Unstable && c.trapUnstable) {
+		c.Halted = true
+		c.step = 0
+		c.Cycles = 0
+		return
+	}
+	c.poll()
+}
+
+// startInterrupt begins an NMI or IRQ sequence. Its first cycle is an
+// opcode fetch whose result is discarded.
+func (c *CPU) startInterrupt(kind uint8) {
+	c.interrupt = kind
+	c.opcodePC = c.PC
+	c.Bus.Read(c.PC)
+	c.Cycles = 6
+}
+
+// interruptVector picks the vector for an interrupt sequence or BRK, on the
+// cycle after the status push. An NMI that arrived by then hijacks the
+// sequence: the NMI vector is used, even by BRK (whose pushed B flag is
+// still set), and the NMI is not taken again afterwards.
+func (c *CPU) interruptVector() uint16 {
+	if c.NMIPending {
+		c.NMIPending = false
+		return vectorNMI
+	}
+	return vectorIRQ
+}
+
+// interruptCycle runs cycle t of an interrupt sequence, which is BRK's
+// without the PC increment or the B flag
+func (c *CPU) interruptCycle(t uint8) bool {
+	switch t {
+	case 2:
+		c.Bus.Read(c.PC)
+	case 3:
+		c.push(uint8(c.PC >> 8))
+	case 4:
+		c.push(uint8(c.PC))
+	case 5:
+		c.push(c.Status&^FlagBreak | FlagUnused)
+		c.addr = c.interruptVector()
+	case 6:
+		c.PC = uint16(c.Bus.Read(c.addr))
+		c.Status |= FlagInterruptDisable
+	case 7:
+		c.PC |= uint16(c.Bus.Read(c.addr+1)) << 8
+		if c.trackCalls {
+			kind := CallIRQ
+			if c.addr == vectorNMI {
+				kind = CallNMI
+			}
+			c.enterCall(kind, c.opcodePC, 3)
+		}
+		c.interrupt = interruptNone
+		return true
+	}
+	return false
+}
+
+// push writes a byte to the stack
+func (c *CPU) push(v uint8) {
+	c.Bus.Write(0x0100|uint16(c.SP), v)
+	c.SP--
+}
+
+// pull reads the byte at the top of the stack; the caller has already
+// incremented SP
+func (c *CPU) pull() uint8 {
+	return c.Bus.Read(0x0100 | uint16(c.SP))
+}
+
+// InstructionPC returns the address of the instruction in flight, or of the
+// last one executed when called at an instruction boundary. During an
+// interrupt sequence it is the address the interrupt preempted.
+func (c *CPU) InstructionPC() uint16 {
+	return c.opcodePC
+}
+
+// GetPC returns the program counter
+func (c *CPU) GetPC() uint16 {
+	return c.PC
+}
+
+// AtBoundary reports whether the next Step fetches an opcode or starts an
+// interrupt sequence (Cycles is zero)
+func (c *CPU) AtBoundary() bool {
+	return c.Cycles == 0
+}
+
+// IsHalted reports whether the CPU is jammed
+func (c *CPU) IsHalted() bool {
+	return c.Halted
+}
+
+// TriggerNMI latches an NMI edge, serviced at the next poll
+func (c *CPU) TriggerNMI() {
+	c.NMIPending = true
+}
+
+// SetIRQ drives the IRQ line, which is level-triggered
+func (c *CPU) SetIRQ(asserted bool) {
+	c.IRQPending = asserted
+}
+
+// GetFlag reports whether a status flag is set
+func (c *CPU) GetFlag(flag uint8) bool {
+	return c.Status&flag != 0
+}
+
+// SetFlag sets or clears a status flag
+func (c *CPU) SetFlag(flag uint8, on bool) {
+	if on {
+		c.Status |= flag
+	} else {
+		c.Status &^= flag
+	}
+}
+
+// setZN sets Z and N from a result
+func (c *CPU) setZN(v uint8) {
+	c.Status &^= FlagZero | FlagNegative
+	if v == 0 {
+		c.Status |= FlagZero
+	}
+	c.Status |= v & FlagNegative
+}
+
+// SetTrapUnstable makes the CPU halt, as on STP, when it reaches one of the
+// unstable opcodes (XAA, LXA, AHX, TAS, SHX, SHY) instead of emulating
+// their typical behavior. Their results depend on the individual chip and
+// temperature, so a game using them is more likely buggy than intentional;
+// trapping helps spot that while debugging.
+func (c *CPU) SetTrapUnstable(trap bool) {
+	c.trapUnstable = trap
+}
+
+// DebugState is a snapshot of the CPU registers and interrupt lines
+type DebugState struct {
+	PC uint16 // Program counter
+	A  uint8  // Accumulator
+	X  uint8  // X index
+	Y  uint8  // Y index
+	SP uint8  // Stack pointer (offset into $0100-$01FF)
+	P  uint8  // Status flags (NV-BDIZC)
+
+	Cycles     uint8 // Cycles remaining in the current instruction
+	Halted     bool  // Stopped by STP or a trapped unstable opcode
+	NMIPending bool
+	IRQPending bool
+}
+
+// DebugState returns a snapshot of the CPU registers
+func (c *CPU) DebugState() DebugState {
+	return DebugState{
+		PC:         c.PC,
+		A:          c.A,
+		X:          c.X,
+		Y:          c.Y,
+		SP:         c.SP,
+		P:          c.Status,
+		Cycles:     c.Cycles,
+		Halted:     c.Halted,
+		NMIPending: c.NMIPending,
+		IRQPending: c.IRQPending,
+	}
+}
+
+// Flags returns the status register in the conventional "NV-BDIZC" form,
+// with set flags in upper case and clear flags in lower case
+func (s DebugState) Flags() string {
+	const names = "nv-bdizc"
+	b := []byte(names)
+	for i := range b {
+		if s.P&(0x80>>i) != 0 && b[i] != '-' {
+			b[i] -= 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// SetPC moves execution to addr. Any instruction in flight is abandoned so
+// the next Step fetches from addr.
+func (c *CPU) SetPC(addr uint16) {
+	c.PC = addr
+	c.step = 0
+	c.stall = 0
+	c.interrupt = interruptNone
+	c.interruptDue = false
+	c.Cycles = 0
+	c.Halted = false
+}
+
+// Stall makes the CPU idle for the given number of cycles before its next
+// instruction fetch, as if an instruction of that length were in flight
+func (c *CPU) Stall(cycles uint8) {
+	c.stall += cycles
+	c.Cycles += cycles
+}
+
+// SetRegisters loads the accumulator, index registers and stack pointer
+func (c *CPU) SetRegisters(a, x, y, sp uint8) {
+	c.A = a
+	c.X = x
+	c.Y = y
+	c.SP = sp
+}
+
+// SetStatusFlags loads the status register. The unused bit always reads
+// back as set, as on hardware.
+func (c *CPU) SetStatusFlags(p uint8) {
+	c.Status = p | FlagUnused
+}
+
+// WriteState appends the registers, the instruction in flight and the
+// interrupt lines to w
+func (c *CPU) WriteState(w *state.Writer) {
+	w.U16(c.PC)
+	w.U8(c.A)
+	w.U8(c.X)
+	w.U8(c.Y)
+	w.U8(c.SP)
+	w.U8(c.Status)
+	w.U8(c.Cycles)
+	w.Bool(c.Halted)
+	w.Bool(c.NMIPending)
+	w.Bool(c.IRQPending)
+	w.Bool(c.ResetPending)
+
+	w.U8(c.opcode)
+	w.U16(c.opcodePC)
+	w.U8(c.step)
+	w.U16(c.addr)
+	w.U16(c.base)
+	w.U8(c.ptr)
+	w.U8(c.data)
+	w.U8(c.dataStart)
+	w.U8(c.interrupt)
+	w.U8(c.stall)
+	w.Bool(c.interruptDue)
+}
+
+// cpuStateVersion tags MarshalBinary's encoding, so states from another
+// layout are rejected rather than misread
+const cpuStateVersion = 1
+
+// MarshalBinary encodes the CPU's state: registers, the interrupt lines and
+// the poll result, the instruction in flight (down to its current cycle)
+// and any pending stall, so a CPU restored with UnmarshalBinary continues
+// on exactly the same cycle. Settings (SetTrapUnstable, SetCallTracking)
+// are not included. DMA transfers halting the CPU belong to the bus (see
+// bus.NESBus.MarshalDMA).
+func (c *CPU) MarshalBinary() ([]byte, error) {
+	w := state.NewWriter(32)
+	w.U8(cpuStateVersion)
+	c.WriteState(w)
+	return w.Bytes(), nil
+}
+
+// UnmarshalBinary restores state encoded by MarshalBinary, leaving the CPU
+// untouched if data is invalid. The shadow call stack is cleared, since it
+// is not part of the encoding.
+func (c *CPU) UnmarshalBinary(data []byte) error {
+	r := state.NewReader(data)
+	if v := r.U8(); v != cpuStateVersion {
+		return fmt.Errorf("cpu: unsupported state version %d", v)
+	}
+
+	next := *c
+	next.PC = r.U16()
+	next.A = r.U8()
+	next.X = r.U8()
+	next.Y = r.U8()
+	next.SP = r.U8()
+	next.Status = r.U8()
+	next.Cycles = r.U8()
+	next.Halted = r.Bool()
+	next.NMIPending = r.Bool()
+	next.IRQPending = r.Bool()
+	next.ResetPending = r.Bool()
+
+	next.opcode = r.U8()
+	next.opcodePC = r.U16()
+	next.step = r.U8()
+	next.addr = r.U16()
+	next.base = r.U16()
+	next.ptr = r.U8()
+	next.data = r.U8()
+	next.dataStart = r.U8()
+	next.interrupt = r.U8()
+	next.stall = r.U8()
+	next.interruptDue = r.Bool()
+
+	if err := r.Err(); err != nil {
+		return fmt.Errorf("cpu: %w", err)
+	}
+	if r.Remaining() != 0 {
+		return fmt.Errorf("cpu: %d trailing bytes in state", r.Remaining())
+	}
+	*c = next
+	c.callStack = c.callStack[:0]
+	return nil
+}
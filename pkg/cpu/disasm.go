@@ -0,0 +1,126 @@
+package cpu
+
+import "fmt"
+
+// Instruction is a decoded 6502 instruction
+type Instruction struct {
+	Addr    uint16     // Address of the opcode
+	Opcode  uint8      // Opcode byte
+	Operand uint16     // Operand (low byte only for one-byte operands)
+	Info    OpcodeInfo // Opcode table entry
+}
+
+// Decode reads the instruction at pc through peek, which must not have side
+// effects (e.g. bus.NESBus.Peek)
+func Decode(peek func(uint16) uint8, pc uint16) Instruction {
+	in := Instruction{Addr: pc, Opcode: peek(pc)}
+	in.Info = Opcodes[in.Opcode]
+
+	switch operandSize[in.Info.Mode] {
+	case 1:
+		in.Operand = uint16(peek(pc + 1))
+	case 2:
+		in.Operand = uint16(peek(pc+1)) | uint16(peek(pc+2))<<8
+	}
+	return in
+}
+
+// Size returns the instruction length in bytes
+func (in Instruction) Size() int {
+	return in.Info.Size()
+}
+
+// Bytes returns the instruction's raw bytes as hex ("4C F5 C5")
+func (in Instruction) Bytes() string {
+	switch in.Size() {
+	case 2:
+		return fmt.Sprintf("%02X %02X", in.Opcode, in.Operand&0xFF)
+	case 3:
+		return fmt.Sprintf("%02X %02X %02X", in.Opcode, in.Operand&0xFF, in.Operand>>8)
+	}
+	return fmt.Sprintf("%02X", in.Opcode)
+}
+
+// String returns the instruction in assembler syntax ("LDA $0300,X")
+func (in Instruction) String() string {
+	op := in.Operand
+	switch in.Info.Mode {
+	case Accumulator:
+		return in.Info.Mnemonic + " A"
+	case Immediate:
+		return fmt.Sprintf("%s #$%02X", in.Info.Mnemonic, op)
+	case ZeroPage:
+		return fmt.Sprintf("%s $%02X", in.Info.Mnemonic, op)
+	case ZeroPageX:
+		return fmt.Sprintf("%s $%02X,X", in.Info.Mnemonic, op)
+	case ZeroPageY:
+		return fmt.Sprintf("%s $%02X,Y", in.Info.Mnemonic, op)
+	case Relative:
+		return fmt.Sprintf("%s $%04X", in.Info.Mnemonic, in.branchTarget())
+	case Absolute:
+		return fmt.Sprintf("%s $%04X", in.Info.Mnemonic, op)
+	case AbsoluteX:
+		return fmt.Sprintf("%s $%04X,X", in.Info.Mnemonic, op)
+	case AbsoluteY:
+		return fmt.Sprintf("%s $%04X,Y", in.Info.Mnemonic, op)
+	case Indirect:
+		return fmt.Sprintf("%s ($%04X)", in.Info.Mnemonic, op)
+	case IndirectX:
+		return fmt.Sprintf("%s ($%02X,X)", in.Info.Mnemonic, op)
+	case IndirectY:
+		return fmt.Sprintf("%s ($%02X),Y", in.Info.Mnemonic, op)
+	}
+	return in.Info.Mnemonic
+}
+
+// Annotate returns the instruction in the style of Nintendulator/nestest
+// logs: assembler syntax followed by the effective address and the value
+// currently stored there ("LDA ($80),Y = 0200 @ 0200 = 5A"). regs must be
+// the CPU state before the instruction executes.
+func (in Instruction) Annotate(regs DebugState, peek func(uint16) uint8) string {
+	op := in.Operand
+	text := in.String()
+
+	switch in.Info.Mode {
+	case ZeroPage:
+		return fmt.Sprintf("%s = %02X", text, peek(op))
+	case ZeroPageX, ZeroPageY:
+		index := regs.X
+		if in.Info.Mode == ZeroPageY {
+			index = regs.Y
+		}
+		addr := uint16(uint8(op) + index)
+		return fmt.Sprintf("%s @ %02X = %02X", text, addr, peek(addr))
+	case Absolute:
+		if in.Info.Mnemonic == "JMP" || in.Info.Mnemonic == "JSR" {
+			return text
+		}
+		return fmt.Sprintf("%s = %02X", text, peek(op))
+	case AbsoluteX, AbsoluteY:
+		index := regs.X
+		if in.Info.Mode == AbsoluteY {
+			index = regs.Y
+		}
+		addr := op + uint16(index)
+		return fmt.Sprintf("%s @ %04X = %02X", text, addr, peek(addr))
+	case Indirect:
+		// The 6502 does not carry into the high byte of the pointer
+		hi := op&0xFF00 | uint16(uint8(op)+1)
+		return fmt.Sprintf("%s = %04X", text, uint16(peek(op))|uint16(peek(hi))<<8)
+	case IndirectX:
+		ptr := uint8(op) + regs.X
+		addr := uint16(peek(uint16(ptr))) | uint16(peek(uint16(ptr+1)))<<8
+		return fmt.Sprintf("%s @ %02X = %04X = %02X", text, ptr, addr, peek(addr))
+	case IndirectY:
+		ptr := uint8(op)
+		base := uint16(peek(uint16(ptr))) | uint16(peek(uint16(ptr+1)))<<8
+		addr := base + uint16(regs.Y)
+		return fmt.Sprintf("%s = %04X @ %04X = %02X", text, base, addr, peek(addr))
+	}
+	return text
+}
+
+// branchTarget returns the destination of a relative branch
+func (in Instruction) branchTarget() uint16 {
+	return in.Addr + 2 + uint16(int8(in.Operand))
+}
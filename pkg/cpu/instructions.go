@@ -0,0 +1,609 @@
+package cpu
+
+// accessKind is how an instruction uses its operand, which decides the bus
+// accesses it makes once the address is known
+type accessKind uint8
+
+const (
+	accessRead    accessKind = iota // One read (LDA, CMP, NOP $nn)
+	accessWrite                     // One write (STA, SAX)
+	accessRMW                       // Read, write back the original, write the result (INC, SLO)
+	accessImplied                   // No operand; a dummy read of the next byte (TAX, ASL A)
+	accessCustom                    // Its own sequence (branches, stack and jump instructions)
+)
+
+// instruction is the cycle-by-cycle behavior of one opcode
+type instruction struct {
+	mode AddressingMode
+	kind accessKind
+
+	read    func(c *CPU, v uint8)       // accessRead: consume the operand
+	store   func(c *CPU) uint8          // accessWrite: value to write (may adjust c.addr)
+	modify  func(c *CPU, v uint8) uint8 // accessRMW, and accessImplied in Accumulator mode
+	implied func(c *CPU)                // accessImplied
+	custom  func(c *CPU, t uint8) bool  // accessCustom
+}
+
+// instructions is built from Opcodes at init
+var instructions [256]instruction
+
+func init() {
+	for op, info := range Opcodes {
+		in := instruction{mode: info.Mode}
+		m := info.Mnemonic
+
+		if f, ok := customOps[m]; ok {
+			in.kind, in.custom = accessCustom, f
+		} else if info.Mode == Accumulator {
+			in.kind, in.modify = accessImplied, rmwOps[m]
+		} else if f, ok := impliedOps[m]; ok && info.Mode == Implied {
+			in.kind, in.implied = accessImplied, f
+		} else if f, ok := readOps[m]; ok {
+			in.kind, in.read = accessRead, f
+		} else if f, ok := writeOps[m]; ok {
+			in.kind, in.store = accessWrite, f
+		} else if f, ok := rmwOps[m]; ok {
+			in.kind, in.modify = accessRMW, f
+		} else {
+			panic("cpu: no implementation for " + m)
+		}
+		instructions[op] = in
+	}
+}
+
+// cycle runs cycle t (2 onwards; 1 is the opcode fetch) and reports whether
+// the instruction has finished
+func (in *instruction) cycle(c *CPU, t uint8) bool {
+	switch in.kind {
+	case accessCustom:
+		return in.custom(c, t)
+	case accessImplied:
+		c.Bus.Read(c.PC)
+		if in.mode == Accumulator {
+			c.A = in.modify(c, c.A)
+		} else {
+			in.implied(c)
+		}
+		return true
+	}
+
+	if c.dataStart != 0 {
+		return c.dataCycle(in, t-c.dataStart)
+	}
+
+	switch in.mode {
+	case Immediate:
+		c.addr = c.PC
+		c.PC++
+		return c.dataCycle(in, 0)
+
+	case ZeroPage:
+		c.addr = uint16(c.fetch())
+		c.dataStart = t + 1
+
+	case ZeroPageX, ZeroPageY:
+		switch t {
+		case 2:
+			c.addr = uint16(c.fetch())
+		case 3:
+			// The index is added during a dummy read of the unindexed
+			// address, and never carries out of the zero page
+			c.Bus.Read(c.addr)
+			index := c.X
+			if in.mode == ZeroPageY {
+				index = c.Y
+			}
+			c.addr = uint16(uint8(c.addr) + index)
+			c.dataStart = t + 1
+		}
+
+	case Absolute:
+		switch t {
+		case 2:
+			c.addr = uint16(c.fetch())
+		case 3:
+			c.addr |= uint16(c.fetch()) << 8
+			c.dataStart = t + 1
+		}
+
+	case AbsoluteX, AbsoluteY:
+		switch t {
+		case 2:
+			c.addr = uint16(c.fetch())
+		case 3:
+			c.addr |= uint16(c.fetch()) << 8
+			c.base = c.addr
+		case 4:
+			return c.indexedCycle(in, t)
+		}
+
+	case IndirectX:
+		switch t {
+		case 2:
+			c.ptr = c.fetch()
+		case 3:
+			c.Bus.Read(uint16(c.ptr))
+			c.ptr += c.X
+		case 4:
+			c.addr = uint16(c.Bus.Read(uint16(c.ptr)))
+		case 5:
+			c.addr |= uint16(c.Bus.Read(uint16(c.ptr+1))) << 8
+			c.dataStart = t + 1
+		}
+
+	case IndirectY:
+		switch t {
+		case 2:
+			c.ptr = c.fetch()
+		case 3:
+			c.addr = uint16(c.Bus.Read(uint16(c.ptr)))
+		case 4:
+			c.addr |= uint16(c.Bus.Read(uint16(c.ptr+1))) << 8
+			c.base = c.addr
+		case 5:
+			return c.indexedCycle(in, t)
+		}
+	}
+	return false
+}
+
+// indexedCycle runs the cycle after an indexed mode's base address is
+// known. The CPU reads from the base's page with the index added to the low
+// byte only; if that was the right address and the instruction only reads,
+// the read was the real one. Otherwise it was a dummy read, and the real
+// access follows with the high byte fixed.
+func (c *CPU) indexedCycle(in *instruction, t uint8) bool {
+	index := c.Y
+	if in.mode == AbsoluteX {
+		index = c.X
+	}
+	c.addr = c.base + uint16(index)
+	partial := c.base&0xFF00 | c.addr&0x00FF
+
+	if in.kind == accessRead && partial == c.addr {
+		return c.dataCycle(in, 0)
+	}
+	c.Bus.Read(partial)
+	c.dataStart = t + 1
+	return false
+}
+
+// dataCycle runs cycle d of the data access at c.addr
+func (c *CPU) dataCycle(in *instruction, d uint8) bool {
+	switch in.kind {
+	case accessRead:
+		in.read(c, c.Bus.Read(c.addr))
+		return true
+	case accessWrite:
+		v := in.store(c)
+		c.Bus.Write(c.addr, v)
+		return true
+	}
+
+	// Read-modify-write: the unmodified value is written back while the
+	// ALU works, then the result
+	switch d {
+	case 0:
+		c.data = c.Bus.Read(c.addr)
+	case 1:
+		c.Bus.Write(c.addr, c.data)
+		c.data = in.modify(c, c.data)
+	default:
+		c.Bus.Write(c.addr, c.data)
+		return true
+	}
+	return false
+}
+
+// fetch reads the byte at PC and advances past it
+func (c *CPU) fetch() uint8 {
+	v := c.Bus.Read(c.PC)
+	c.PC++
+	return v
+}
+
+// addWithCarry adds m and the carry to A, setting N, V, Z and C. The 2A03
+// has no decimal mode, so SBC is ADC of the complement.
+func (c *CPU) addWithCarry(m uint8) {
+	sum := uint16(c.A) + uint16(m) + uint16(c.Status&FlagCarry)
+	result := uint8(sum)
+	c.SetFlag(FlagCarry, sum > 0xFF)
+	c.SetFlag(FlagOverflow, (c.A^result)&(m^result)&0x80 != 0)
+	c.A = result
+	c.setZN(c.A)
+}
+
+// compare sets the flags for CMP, CPX and CPY
+func (c *CPU) compare(reg, m uint8) {
+	c.SetFlag(FlagCarry, reg >= m)
+	c.setZN(reg - m)
+}
+
+// storeHigh is the SHX/SHY/AHX/TAS store: value ANDed with the high byte of
+// the base address plus one. When indexing crossed a page, the same AND
+// corrupts the high byte of the address written to.
+func (c *CPU) storeHigh(value uint8) uint8 {
+	value &= uint8(c.base>>8) + 1
+	if c.base&0xFF00 != c.addr&0xFF00 {
+		c.addr = uint16(uint8(c.addr>>8)&value)<<8 | c.addr&0x00FF
+	}
+	return value
+}
+
+func asl(c *CPU, v uint8) uint8 {
+	c.SetFlag(FlagCarry, v&0x80 != 0)
+	v <<= 1
+	c.setZN(v)
+	return v
+}
+
+func lsr(c *CPU, v uint8) uint8 {
+	c.SetFlag(FlagCarry, v&0x01 != 0)
+	v >>= 1
+	c.setZN(v)
+	return v
+}
+
+func rol(c *CPU, v uint8) uint8 {
+	carry := c.Status & FlagCarry
+	c.SetFlag(FlagCarry, v&0x80 != 0)
+	v = v<<1 | carry
+	c.setZN(v)
+	return v
+}
+
+func ror(c *CPU, v uint8) uint8 {
+	carry := c.Status & FlagCarry
+	c.SetFlag(FlagCarry, v&0x01 != 0)
+	v = v>>1 | carry<<7
+	c.setZN(v)
+	return v
+}
+
+// readOps are the instructions that read their operand
+var readOps = map[string]func(c *CPU, v uint8){
+	"LDA": func(c *CPU, v uint8) { c.A = v; c.setZN(v) },
+	"LDX": func(c *CPU, v uint8) { c.X = v; c.setZN(v) },
+	"LDY": func(c *CPU, v uint8) { c.Y = v; c.setZN(v) },
+	"AND": func(c *CPU, v uint8) { c.A &= v; c.setZN(c.A) },
+	"ORA": func(c *CPU, v uint8) { c.A |= v; c.setZN(c.A) },
+	"EOR": func(c *CPU, v uint8) { c.A ^= v; c.setZN(c.A) },
+	"ADC": func(c *CPU, v uint8) { c.addWithCarry(v) },
+	"SBC": func(c *CPU, v uint8) { c.addWithCarry(^v) },
+	"CMP": func(c *CPU, v uint8) { c.compare(c.A, v) },
+	"CPX": func(c *CPU, v uint8) { c.compare(c.X, v) },
+	"CPY": func(c *CPU, v uint8) { c.compare(c.Y, v) },
+	"BIT": func(c *CPU, v uint8) {
+		c.SetFlag(FlagZero, c.A&v == 0)
+		c.Status = c.Status&^(FlagNegative|FlagOverflow) | v&(FlagNegative|FlagOverflow)
+	},
+
+	// The multi-byte NOPs still perform their read
+	"NOP": func(c *CPU, v uint8) {},
+
+	// The immediate form (LXA) is really (A | magic) & operand, with the
+	// magic varying by chip; $FF, as assumed here, matches most NES
+	// consoles
+	"LAX": func(c *CPU, v uint8) { c.A, c.X = v, v; c.setZN(v) },
+
+	"ANC": func(c *CPU, v uint8) {
+		c.A &= v
+		c.setZN(c.A)
+		c.SetFlag(FlagCarry, c.A&0x80 != 0)
+	},
+	"ALR": func(c *CPU, v uint8) { c.A = lsr(c, c.A&v) },
+	"ARR": func(c *CPU, v uint8) {
+		c.A &= v
+		c.A = c.A>>1 | (c.Status&FlagCarry)<<7
+		c.setZN(c.A)
+		c.SetFlag(FlagCarry, c.A&0x40 != 0)
+		c.SetFlag(FlagOverflow, (c.A>>6^c.A>>5)&0x01 != 0)
+	},
+	"AXS": func(c *CPU, v uint8) {
+		ax := c.A & c.X
+		c.SetFlag(FlagCarry, ax >= v)
+		c.X = ax - v
+		c.setZN(c.X)
+	},
+	// (A | magic) & X & operand; $EE is the commonly observed magic
+	"XAA": func(c *CPU, v uint8) { c.A = (c.A | 0xEE) & c.X & v; c.setZN(c.A) },
+	"LAS": func(c *CPU, v uint8) {
+		v &= c.SP
+		c.A, c.X, c.SP = v, v, v
+		c.setZN(v)
+	},
+}
+
+// writeOps are the instructions that only write their operand
+var writeOps = map[string]func(c *CPU) uint8{
+	"STA": func(c *CPU) uint8 { return c.A },
+	"STX": func(c *CPU) uint8 { return c.X },
+	"STY": func(c *CPU) uint8 { return c.Y },
+	"SAX": func(c *CPU) uint8 { return c.A & c.X },
+	"AHX": func(c *CPU) uint8 { return c.storeHigh(c.A & c.X) },
+	"SHX": func(c *CPU) uint8 { return c.storeHigh(c.X) },
+	"SHY": func(c *CPU) uint8 { return c.storeHigh(c.Y) },
+	"TAS": func(c *CPU) uint8 {
+		c.SP = c.A & c.X
+		return c.storeHigh(c.SP)
+	},
+}
+
+// rmwOps are the read-modify-write instructions; the shifts also run on A
+// in Accumulator mode
+var rmwOps = map[string]func(c *CPU, v uint8) uint8{
+	"ASL": asl,
+	"LSR": lsr,
+	"ROL": rol,
+	"ROR": ror,
+	"INC": func(c *CPU, v uint8) uint8 { v++; c.setZN(v); return v },
+	"DEC": func(c *CPU, v uint8) uint8 { v--; c.setZN(v); return v },
+
+	"SLO": func(c *CPU, v uint8) uint8 {
+		v = asl(c, v)
+		c.A |= v
+		c.setZN(c.A)
+		return v
+	},
+	"RLA": func(c *CPU, v uint8) uint8 {
+		v = rol(c, v)
+		c.A &= v
+		c.setZN(c.A)
+		return v
+	},
+	"SRE": func(c *CPU, v uint8) uint8 {
+		v = lsr(c, v)
+		c.A ^= v
+		c.setZN(c.A)
+		return v
+	},
+	"RRA": func(c *CPU, v uint8) uint8 {
+		v = ror(c, v)
+		c.addWithCarry(v)
+		return v
+	},
+	"DCP": func(c *CPU, v uint8) uint8 {
+		v--
+		c.compare(c.A, v)
+		return v
+	},
+	"ISB": func(c *CPU, v uint8) uint8 {
+		v++
+		c.addWithCarry(^v)
+		return v
+	},
+}
+
+// impliedOps are the single-byte register and flag instructions
+var impliedOps = map[string]func(c *CPU){
+	"NOP": func(c *CPU) {},
+	"CLC": func(c *CPU) { c.Status &^= FlagCarry },
+	"SEC": func(c *CPU) { c.Status |= FlagCarry },
+	"CLI": func(c *CPU) { c.Status &^= FlagInterruptDisable },
+	"SEI": func(c *CPU) { c.Status |= FlagInterruptDisable },
+	"CLV": func(c *CPU) { c.Status &^= FlagOverflow },
+	"CLD": func(c *CPU) { c.Status &^= FlagDecimal },
+	"SED": func(c *CPU) { c.Status |= FlagDecimal },
+	"TAX": func(c *CPU) { c.X = c.A; c.setZN(c.X) },
+	"TAY": func(c *CPU) { c.Y = c.A; c.setZN(c.Y) },
+	"TXA": func(c *CPU) { c.A = c.X; c.setZN(c.A) },
+	"TYA": func(c *CPU) { c.A = c.Y; c.setZN(c.A) },
+	"TSX": func(c *CPU) { c.X = c.SP; c.setZN(c.X) },
+	"TXS": func(c *CPU) { c.SP = c.X },
+	"INX": func(c *CPU) { c.X++; c.setZN(c.X) },
+	"INY": func(c *CPU) { c.Y++; c.setZN(c.Y) },
+	"DEX": func(c *CPU) { c.X--; c.setZN(c.X) },
+	"DEY": func(c *CPU) { c.Y--; c.setZN(c.Y) },
+
+	// Never executed: the CPU jams at the opcode fetch
+	"STP": func(c *CPU) {},
+}
+
+// customOps are the instructions with their own access sequences
+var customOps = map[string]func(c *CPU, t uint8) bool{
+	"BPL": branch(FlagNegative, false),
+	"BMI": branch(FlagNegative, true),
+	"BVC": branch(FlagOverflow, false),
+	"BVS": branch(FlagOverflow, true),
+	"BCC": branch(FlagCarry, false),
+	"BCS": branch(FlagCarry, true),
+	"BNE": branch(FlagZero, false),
+	"BEQ": branch(FlagZero, true),
+
+	"JMP": jmp,
+	"JSR": jsr,
+	"RTS": rts,
+	"RTI": rti,
+	"BRK": brk,
+	"PHA": func(c *CPU, t uint8) bool { return pushCycle(c, t, c.A) },
+	"PHP": func(c *CPU, t uint8) bool { return pushCycle(c, t, c.Status|FlagBreak|FlagUnused) },
+	"PLA": func(c *CPU, t uint8) bool {
+		if pullCycle(c, t) {
+			c.A = c.pull()
+			c.setZN(c.A)
+			return true
+		}
+		return false
+	},
+	"PLP": func(c *CPU, t uint8) bool {
+		if pullCycle(c, t) {
+			c.Status = c.pull()&^FlagBreak | FlagUnused
+			return true
+		}
+		return false
+	},
+}
+
+// branch returns the sequence for a branch taken when flag is set (or
+// clear). Taking it costs a cycle, and crossing a page another, each with
+// a dummy read.
+func branch(flag uint8, set bool) func(c *CPU, t uint8) bool {
+	return func(c *CPU, t uint8) bool {
+		switch t {
+		case 2:
+			c.data = c.fetch()
+			return c.GetFlag(flag) != set
+		case 3:
+			c.Bus.Read(c.PC)
+			c.addr = c.PC + uint16(int8(c.data))
+			if c.addr&0xFF00 == c.PC&0xFF00 {
+				c.PC = c.addr
+				return true
+			}
+			c.PC = c.PC&0xFF00 | c.addr&0x00FF
+			c.Cycles++
+			return false
+		}
+		c.Bus.Read(c.PC)
+		c.PC = c.addr
+		return true
+	}
+}
+
+// jmp is JMP absolute (3 cycles) and indirect (5 cycles). The indirect
+// form's pointer never carries into its high byte: JMP ($10FF) reads
+// $10FF and $1000.
+func jmp(c *CPU, t uint8) bool {
+	switch t {
+	case 2:
+		c.addr = uint16(c.fetch())
+	case 3:
+		c.addr |= uint16(c.Bus.Read(c.PC)) << 8
+		if Opcodes[c.opcode].Mode == Absolute {
+			c.PC = c.addr
+			return true
+		}
+	case 4:
+		c.data = c.Bus.Read(c.addr)
+	case 5:
+		hi := c.Bus.Read(c.addr&0xFF00 | (c.addr+1)&0x00FF)
+		c.PC = uint16(c.data) | uint16(hi)<<8
+		return true
+	}
+	return false
+}
+
+// jsr pushes the address of its own last byte, then jumps
+func jsr(c *CPU, t uint8) bool {
+	switch t {
+	case 2:
+		c.data = c.fetch()
+	case 3:
+		c.Bus.Read(0x0100 | uint16(c.SP))
+	case 4:
+		c.push(uint8(c.PC >> 8))
+	case 5:
+		c.push(uint8(c.PC))
+	case 6:
+		c.PC = uint16(c.data) | uint16(c.Bus.Read(c.PC))<<8
+		if c.trackCalls {
+			c.enterCall(CallJSR, c.opcodePC, 2)
+		}
+		return true
+	}
+	return false
+}
+
+// rts pulls the return address and steps past it
+func rts(c *CPU, t uint8) bool {
+	switch t {
+	case 2:
+		c.Bus.Read(c.PC)
+	case 3:
+		c.Bus.Read(0x0100 | uint16(c.SP))
+		c.SP++
+	case 4:
+		c.PC = uint16(c.pull())
+		c.SP++
+	case 5:
+		c.PC |= uint16(c.pull()) << 8
+	case 6:
+		c.Bus.Read(c.PC)
+		c.PC++
+		if c.trackCalls {
+			c.leaveCall()
+		}
+		return true
+	}
+	return false
+}
+
+// rti pulls the status and return address
+func rti(c *CPU, t uint8) bool {
+	switch t {
+	case 2:
+		c.Bus.Read(c.PC)
+	case 3:
+		c.Bus.Read(0x0100 | uint16(c.SP))
+		c.SP++
+	case 4:
+		c.Status = c.pull()&^FlagBreak | FlagUnused
+		c.SP++
+	case 5:
+		c.PC = uint16(c.pull())
+		c.SP++
+	case 6:
+		c.PC |= uint16(c.pull()) << 8
+		if c.trackCalls {
+			c.leaveCall()
+		}
+		return true
+	}
+	return false
+}
+
+// brk is a software interrupt: it skips a padding byte and pushes the
+// status with B set. An NMI can hijack it (see interruptVector).
+func brk(c *CPU, t uint8) bool {
+	switch t {
+	case 2:
+		c.fetch()
+	case 3:
+		c.push(uint8(c.PC >> 8))
+	case 4:
+		c.push(uint8(c.PC))
+	case 5:
+		c.push(c.Status | FlagBreak | FlagUnused)
+		c.addr = c.interruptVector()
+	case 6:
+		c.PC = uint16(c.Bus.Read(c.addr))
+		c.Status |= FlagInterruptDisable
+	case 7:
+		c.PC |= uint16(c.Bus.Read(c.addr+1)) << 8
+		if c.trackCalls {
+			kind := CallBRK
+			if c.addr == vectorNMI {
+				kind = CallNMI
+			}
+			c.enterCall(kind, c.opcodePC, 3)
+		}
+		return true
+	}
+	return false
+}
+
+// pushCycle is PHA/PHP: a dummy read, then the push
+func pushCycle(c *CPU, t uint8, v uint8) bool {
+	if t == 2 {
+		c.Bus.Read(c.PC)
+		return false
+	}
+	c.push(v)
+	return true
+}
+
+// pullCycle is the shared part of PLA/PLP: a dummy read, a dummy stack
+// read while SP is incremented, and then it reports that the pull itself
+// is due
+func pullCycle(c *CPU, t uint8) bool {
+	switch t {
+	case 2:
+		c.Bus.Read(c.PC)
+	case 3:
+		c.Bus.Read(0x0100 | uint16(c.SP))
+		c.SP++
+	default:
+		return true
+	}
+	return false
+}
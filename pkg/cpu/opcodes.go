@@ -0,0 +1,329 @@
+package cpu
+
+// AddressingMode identifies how an instruction forms its operand address
+type AddressingMode uint8
+
+const (
+	Implied     AddressingMode = iota // No operand (CLC, RTS)
+	Accumulator                       // Operates on A (LSR A)
+	Immediate                         // #$nn
+	ZeroPage                          // $nn
+	ZeroPageX                         // $nn,X
+	ZeroPageY                         // $nn,Y
+	Relative                          // Branch offset
+	Absolute                          // $nnnn
+	AbsoluteX                         // $nnnn,X
+	AbsoluteY                         // $nnnn,Y
+	Indirect                          // ($nnnn), JMP only
+	IndirectX                         // ($nn,X)
+	IndirectY                         // ($nn),Y
+)
+
+// operandSize is the number of operand bytes following the opcode for each mode
+var operandSize = [...]int{
+	Implied:     0,
+	Accumulator: 0,
+	Immediate:   1,
+	ZeroPage:    1,
+	ZeroPageX:   1,
+	ZeroPageY:   1,
+	Relative:    1,
+	Absolute:    2,
+	AbsoluteX:   2,
+	AbsoluteY:   2,
+	Indirect:    2,
+	IndirectX:   1,
+	IndirectY:   1,
+}
+
+// OpcodeInfo describes one of the 256 6502 opcodes
+type OpcodeInfo struct {
+	Mnemonic  string         // Instruction name; unofficial opcodes use the common community names
+	Mode      AddressingMode // Addressing mode
+	Cycles    uint8          // Base cycle count
+	PageCycle bool           // One extra cycle when indexing crosses a page (or a branch is taken)
+	Official  bool           // Documented by MOS; false for the unofficial/illegal opcodes
+	Unstable  bool           // Unofficial opcode whose result varies between chips (see CPU.SetTrapUnstable)
+}
+
+// Size returns the instruction length in bytes, including the opcode
+func (o OpcodeInfo) Size() int {
+	return 1 + operandSize[o.Mode]
+}
+
+// Opcodes is the full NMOS 6502 opcode table as used by the NES's 2A03
+// (unofficial opcodes included; STP is also known as KIL/JAM)
+var Opcodes = [256]OpcodeInfo{
+	// $0x
+	0x00: {Mnemonic: "BRK", Mode: Implied, Cycles: 7, Official: true},
+	0x01: {Mnemonic: "ORA", Mode: IndirectX, Cycles: 6, Official: true},
+	0x02: {Mnemonic: "STP", Mode: Implied, Cycles: 2},
+	0x03: {Mnemonic: "SLO", Mode: IndirectX, Cycles: 8},
+	0x04: {Mnemonic: "NOP", Mode: ZeroPage, Cycles: 3},
+	0x05: {Mnemonic: "ORA", Mode: ZeroPage, Cycles: 3, Official: true},
+	0x06: {Mnemonic: "ASL", Mode: ZeroPage, Cycles: 5, Official: true},
+	0x07: {Mnemonic: "SLO", Mode: ZeroPage, Cycles: 5},
+	0x08: {Mnemonic: "PHP", Mode: Implied, Cycles: 3, Official: true},
+	0x09: {Mnemonic: "ORA", Mode: Immediate, Cycles: 2, Official: true},
+	0x0A: {Mnemonic: "ASL", Mode: Accumulator, Cycles: 2, Official: true},
+	0x0B: {Mnemonic: "ANC", Mode: Immediate, Cycles: 2},
+	0x0C: {Mnemonic: "NOP", Mode: Absolute, Cycles: 4},
+	0x0D: {Mnemonic: "ORA", Mode: Absolute, Cycles: 4, Official: true},
+	0x0E: {Mnemonic: "ASL", Mode: Absolute, Cycles: 6, Official: true},
+	0x0F: {Mnemonic: "SLO", Mode: Absolute, Cycles: 6},
+	// $1x
+	0x10: {Mnemonic: "BPL", Mode: Relative, Cycles: 2, PageCycle: true, Official: true},
+	0x11: {Mnemonic: "ORA", Mode: IndirectY, Cycles: 5, PageCycle: true, Official: true},
+	0x12: {Mnemonic: "STP", Mode: Implied, Cycles: 2},
+	0x13: {Mnemonic: "SLO", Mode: IndirectY, Cycles: 8},
+	0x14: {Mnemonic: "NOP", Mode: ZeroPageX, Cycles: 4},
+	0x15: {Mnemonic: "ORA", Mode: ZeroPageX, Cycles: 4, Official: true},
+	0x16: {Mnemonic: "ASL", Mode: ZeroPageX, Cycles: 6, Official: true},
+	0x17: {Mnemonic: "SLO", Mode: ZeroPageX, Cycles: 6},
+	0x18: {Mnemonic: "CLC", Mode: Implied, Cycles: 2, Official: true},
+	0x19: {Mnemonic: "ORA", Mode: AbsoluteY, Cycles: 4, PageCycle: true, Official: true},
+	0x1A: {Mnemonic: "NOP", Mode: Implied, Cycles: 2},
+	0x1B: {Mnemonic: "SLO", Mode: AbsoluteY, Cycles: 7},
+	0x1C: {Mnemonic: "NOP", Mode: AbsoluteX, Cycles: 4, PageCycle: true},
+	0x1D: {Mnemonic: "ORA", Mode: AbsoluteX, Cycles: 4, PageCycle: true, Official: true},
+	0x1E: {Mnemonic: "ASL", Mode: AbsoluteX, Cycles: 7, Official: true},
+	0x1F: {Mnemonic: "SLO", Mode: AbsoluteX, Cycles: 7},
+	// $2x
+	0x20: {Mnemonic: "JSR", Mode: Absolute, Cycles: 6, Official: true},
+	0x21: {Mnemonic: "AND", Mode: IndirectX, Cycles: 6, Official: true},
+	0x22: {Mnemonic: "STP", Mode: Implied, Cycles: 2},
+	0x23: {Mnemonic: "RLA", Mode: IndirectX, Cycles: 8},
+	0x24: {Mnemonic: "BIT", Mode: ZeroPage, Cycles: 3, Official: true},
+	0x25: {Mnemonic: "AND", Mode: ZeroPage, Cycles: 3, Official: true},
+	0x26: {Mnemonic: "ROL", Mode: ZeroPage, Cycles: 5, Official: true},
+	0x27: {Mnemonic: "RLA", Mode: ZeroPage, Cycles: 5},
+	0x28: {Mnemonic: "PLP", Mode: Implied, Cycles: 4, Official: true},
+	0x29: {Mnemonic: "AND", Mode: Immediate, Cycles: 2, Official: true},
+	0x2A: {Mnemonic: "ROL", Mode: Accumulator, Cycles: 2, Official: true},
+	0x2B: {Mnemonic: "ANC", Mode: Immediate, Cycles: 2},
+	0x2C: {Mnemonic: "BIT", Mode: Absolute, Cycles: 4, Official: true},
+	0x2D: {Mnemonic: "AND", Mode: Absolute, Cycles: 4, Official: true},
+	0x2E: {Mnemonic: "ROL", Mode: Absolute, Cycles: 6, Official: true},
+	0x2F: {Mnemonic: "RLA", Mode: Absolute, Cycles: 6},
+	// $3x
+	0x30: {Mnemonic: "BMI", Mode: Relative, Cycles: 2, PageCycle: true, Official: true},
+	0x31: {Mnemonic: "AND", Mode: IndirectY, Cycles: 5, PageCycle: true, Official: true},
+	0x32: {Mnemonic: "STP", Mode: Implied, Cycles: 2},
+	0x33: {Mnemonic: "RLA", Mode: IndirectY, Cycles: 8},
+	0x34: {Mnemonic: "NOP", Mode: ZeroPageX, Cycles: 4},
+	0x35: {Mnemonic: "AND", Mode: ZeroPageX, Cycles: 4, Official: true},
+	0x36: {Mnemonic: "ROL", Mode: ZeroPageX, Cycles: 6, Official: true},
+	0x37: {Mnemonic: "RLA", Mode: ZeroPageX, Cycles: 6},
+	0x38: {Mnemonic: "SEC", Mode: Implied, Cycles: 2, Official: true},
+	0x39: {Mnemonic: "AND", Mode: AbsoluteY, Cycles: 4, PageCycle: true, Official: true},
+	0x3A: {Mnemonic: "NOP", Mode: Implied, Cycles: 2},
+	0x3B: {Mnemonic: "RLA", Mode: AbsoluteY, Cycles: 7},
+	0x3C: {Mnemonic: "NOP", Mode: AbsoluteX, Cycles: 4, PageCycle: true},
+	0x3D: {Mnemonic: "AND", Mode: AbsoluteX, Cycles: 4, PageCycle: true, Official: true},
+	0x3E: {Mnemonic: "ROL", Mode: AbsoluteX, Cycles: 7, Official: true},
+	0x3F: {Mnemonic: "RLA", Mode: AbsoluteX, Cycles: 7},
+	// $4x
+	0x40: {Mnemonic: "RTI", Mode: Implied, Cycles: 6, Official: true},
+	0x41: {Mnemonic: "EOR", Mode: IndirectX, Cycles: 6, Official: true},
+	0x42: {Mnemonic: "STP", Mode: Implied, Cycles: 2},
+	0x43: {Mnemonic: "SRE", Mode: IndirectX, Cycles: 8},
+	0x44: {Mnemonic: "NOP", Mode: ZeroPage, Cycles: 3},
+	0x45: {Mnemonic: "EOR", Mode: ZeroPage, Cycles: 3, Official: true},
+	0x46: {Mnemonic: "LSR", Mode: ZeroPage, Cycles: 5, Official: true},
+	0x47: {Mnemonic: "SRE", Mode: ZeroPage, Cycles: 5},
+	0x48: {Mnemonic: "PHA", Mode: Implied, Cycles: 3, Official: true},
+	0x49: {Mnemonic: "EOR", Mode: Immediate, Cycles: 2, Official: true},
+	0x4A: {Mnemonic: "LSR", Mode: Accumulator, Cycles: 2, Official: true},
+	0x4B: {Mnemonic: "ALR", Mode: Immediate, Cycles: 2},
+	0x4C: {Mnemonic: "JMP", Mode: Absolute, Cycles: 3, Official: true},
+	0x4D: {Mnemonic: "EOR", Mode: Absolute, Cycles: 4, Official: true},
+	0x4E: {Mnemonic: "LSR", Mode: Absolute, Cycles: 6, Official: true},
+	0x4F: {Mnemonic: "SRE", Mode: Absolute, Cycles: 6},
+	// $5x
+	0x50: {Mnemonic: "BVC", Mode: Relative, Cycles: 2, PageCycle: true, Official: true},
+	0x51: {Mnemonic: "EOR", Mode: IndirectY, Cycles: 5, PageCycle: true, Official: true},
+	0x52: {Mnemonic: "STP", Mode: Implied, Cycles: 2},
+	0x53: {Mnemonic: "SRE", Mode: IndirectY, Cycles: 8},
+	0x54: {Mnemonic: "NOP", Mode: ZeroPageX, Cycles: 4},
+	0x55: {Mnemonic: "EOR", Mode: ZeroPageX, Cycles: 4, Official: true},
+	0x56: {Mnemonic: "LSR", Mode: ZeroPageX, Cycles: 6, Official: true},
+	0x57: {Mnemonic: "SRE", Mode: ZeroPageX, Cycles: 6},
+	0x58: {Mnemonic: "CLI", Mode: Implied, Cycles: 2, Official: true},
+	0x59: {Mnemonic: "EOR", Mode: AbsoluteY, Cycles: 4, PageCycle: true, Official: true},
+	0x5A: {Mnemonic: "NOP", Mode: Implied, Cycles: 2},
+	0x5B: {Mnemonic: "SRE", Mode: AbsoluteY, Cycles: 7},
+	0x5C: {Mnemonic: "NOP", Mode: AbsoluteX, Cycles: 4, PageCycle: true},
+	0x5D: {Mnemonic: "EOR", Mode: AbsoluteX, Cycles: 4, PageCycle: true, Official: true},
+	0x5E: {Mnemonic: "LSR", Mode: AbsoluteX, Cycles: 7, Official: true},
+	0x5F: {Mnemonic: "SRE", Mode: AbsoluteX, Cycles: 7},
+	// $6x
+	0x60: {Mnemonic: "RTS", Mode: Implied, Cycles: 6, Official: true},
+	0x61: {Mnemonic: "ADC", Mode: IndirectX, Cycles: 6, Official: true},
+	0x62: {Mnemonic: "STP", Mode: Implied, Cycles: 2},
+	0x63: {Mnemonic: "RRA", Mode: IndirectX, Cycles: 8},
+	0x64: {Mnemonic: "NOP", Mode: ZeroPage, Cycles: 3},
+	0x65: {Mnemonic: "ADC", Mode: ZeroPage, Cycles: 3, Official: true},
+	0x66: {Mnemonic: "ROR", Mode: ZeroPage, Cycles: 5, Official: true},
+	0x67: {Mnemonic: "RRA", Mode: ZeroPage, Cycles: 5},
+	0x68: {Mnemonic: "PLA", Mode: Implied, Cycles: 4, Official: true},
+	0x69: {Mnemonic: "ADC", Mode: Immediate, Cycles: 2, Official: true},
+	0x6A: {Mnemonic: "ROR", Mode: Accumulator, Cycles: 2, Official: true},
+	0x6B: {Mnemonic: "ARR", Mode: Immediate, Cycles: 2},
+	0x6C: {Mnemonic: "JMP", Mode: Indirect, Cycles: 5, Official: true},
+	0x6D: {Mnemonic: "ADC", Mode: Absolute, Cycles: 4, Official: true},
+	0x6E: {Mnemonic: "ROR", Mode: Absolute, Cycles: 6, Official: true},
+	0x6F: {Mnemonic: "RRA", Mode: Absolute, Cycles: 6},
+	// $7x
+	0x70: {Mnemonic: "BVS", Mode: Relative, Cycles: 2, PageCycle: true, Official: true},
+	0x71: {Mnemonic: "ADC", Mode: IndirectY, Cycles: 5, PageCycle: true, Official: true},
+	0x72: {Mnemonic: "STP", Mode: Implied, Cycles: 2},
+	0x73: {Mnemonic: "RRA", Mode: IndirectY, Cycles: 8},
+	0x74: {Mnemonic: "NOP", Mode: ZeroPageX, Cycles: 4},
+	0x75: {Mnemonic: "ADC", Mode: ZeroPageX, Cycles: 4, Official: true},
+	0x76: {Mnemonic: "ROR", Mode: ZeroPageX, Cycles: 6, Official: true},
+	0x77: {Mnemonic: "RRA", Mode: ZeroPageX, Cycles: 6},
+	0x78: {Mnemonic: "SEI", Mode: Implied, Cycles: 2, Official: true},
+	0x79: {Mnemonic: "ADC", Mode: AbsoluteY, Cycles: 4, PageCycle: true, Official: true},
+	0x7A: {Mnemonic: "NOP", Mode: Implied, Cycles: 2},
+	0x7B: {Mnemonic: "RRA", Mode: AbsoluteY, Cycles: 7},
+	0x7C: {Mnemonic: "NOP", Mode: AbsoluteX, Cycles: 4, PageCycle: true},
+	0x7D: {Mnemonic: "ADC", Mode: AbsoluteX, Cycles: 4, PageCycle: true, Official: true},
+	0x7E: {Mnemonic: "ROR", Mode: AbsoluteX, Cycles: 7, Official: true},
+	0x7F: {Mnemonic: "RRA", Mode: AbsoluteX, Cycles: 7},
+	// $8x
+	0x80: {Mnemonic: "NOP", Mode: Immediate, Cycles: 2},
+	0x81: {Mnemonic: "STA", Mode: IndirectX, Cycles: 6, Official: true},
+	0x82: {Mnemonic: "NOP", Mode: Immediate, Cycles: 2},
+	0x83: {Mnemonic: "SAX", Mode: IndirectX, Cycles: 6},
+	0x84: {Mnemonic: "STY", Mode: ZeroPage, Cycles: 3, Official: true},
+	0x85: {Mnemonic: "STA", Mode: ZeroPage, Cycles: 3, Official: true},
+	0x86: {Mnemonic: "STX", Mode: ZeroPage, Cycles: 3, Official: true},
+	0x87: {Mnemonic: "SAX", Mode: ZeroPage, Cycles: 3},
+	0x88: {Mnemonic: "DEY", Mode: Implied, Cycles: 2, Official: true},
+	0x89: {Mnemonic: "NOP", Mode: Immediate, Cycles: 2},
+	0x8A: {Mnemonic: "TXA", Mode: Implied, Cycles: 2, Official: true},
+	0x8B: {Mnemonic: "XAA", Mode: Immediate, Cycles: 2, Unstable: true},
+	0x8C: {Mnemonic: "STY", Mode: Absolute, Cycles: 4, Official: true},
+	0x8D: {Mnemonic: "STA", Mode: Absolute, Cycles: 4, Official: true},
+	0x8E: {Mnemonic: "STX", Mode: Absolute, Cycles: 4, Official: true},
+	0x8F: {Mnemonic: "SAX", Mode: Absolute, Cycles: 4},
+	// $9x
+	0x90: {Mnemonic: "BCC", Mode: Relative, Cycles: 2, PageCycle: true, Official: true},
+	0x91: {Mnemonic: "STA", Mode: IndirectY, Cycles: 6, Official: true},
+	0x92: {Mnemonic: "STP", Mode: Implied, Cycles: 2},
+	0x93: {Mnemonic: "AHX", Mode: IndirectY, Cycles: 6, Unstable: true},
+	0x94: {Mnemonic: "STY", Mode: ZeroPageX, Cycles: 4, Official: true},
+	0x95: {Mnemonic: "STA", Mode: ZeroPageX, Cycles: 4, Official: true},
+	0x96: {Mnemonic: "STX", Mode: ZeroPageY, Cycles: 4, Official: true},
+	0x97: {Mnemonic: "SAX", Mode: ZeroPageY, Cycles: 4},
+	0x98: {Mnemonic: "TYA", Mode: Implied, Cycles: 2, Official: true},
+	0x99: {Mnemonic: "STA", Mode: AbsoluteY, Cycles: 5, Official: true},
+	0x9A: {Mnemonic: "TXS", Mode: Implied, Cycles: 2, Official: true},
+	0x9B: {Mnemonic: "TAS", Mode: AbsoluteY, Cycles: 5, Unstable: true},
+	0x9C: {Mnemonic: "SHY", Mode: AbsoluteX, Cycles: 5, Unstable: true},
+	0x9D: {Mnemonic: "STA", Mode: AbsoluteX, Cycles: 5, Official: true},
+	0x9E: {Mnemonic: "SHX", Mode: AbsoluteY, Cycles: 5, Unstable: true},
+	0x9F: {Mnemonic: "AHX", Mode: AbsoluteY, Cycles: 5, Unstable: true},
+	// $Ax
+	0xA0: {Mnemonic: "LDY", Mode: Immediate, Cycles: 2, Official: true},
+	0xA1: {Mnemonic: "LDA", Mode: IndirectX, Cycles: 6, Official: true},
+	0xA2: {Mnemonic: "LDX", Mode: Immediate, Cycles: 2, Official: true},
+	0xA3: {Mnemonic: "LAX", Mode: IndirectX, Cycles: 6},
+	0xA4: {Mnemonic: "LDY", Mode: ZeroPage, Cycles: 3, Official: true},
+	0xA5: {Mnemonic: "LDA", Mode: ZeroPage, Cycles: 3, Official: true},
+	0xA6: {Mnemonic: "LDX", Mode: ZeroPage, Cycles: 3, Official: true},
+	0xA7: {Mnemonic: "LAX", Mode: ZeroPage, Cycles: 3},
+	0xA8: {Mnemonic: "TAY", Mode: Implied, Cycles: 2, Official: true},
+	0xA9: {Mnemonic: "LDA", Mode: Immediate, Cycles: 2, Official: true},
+	0xAA: {Mnemonic: "TAX", Mode: Implied, Cycles: 2, Official: true},
+	0xAB: {Mnemonic: "LAX", Mode: Immediate, Cycles: 2, Unstable: true},
+	0xAC: {Mnemonic: "LDY", Mode: Absolute, Cycles: 4, Official: true},
+	0xAD: {Mnemonic: "LDA", Mode: Absolute, Cycles: 4, Official: true},
+	0xAE: {Mnemonic: "LDX", Mode: Absolute, Cycles: 4, Official: true},
+	0xAF: {Mnemonic: "LAX", Mode: Absolute, Cycles: 4},
+	// $Bx
+	0xB0: {Mnemonic: "BCS", Mode: Relative, Cycles: 2, PageCycle: true, Official: true},
+	0xB1: {Mnemonic: "LDA", Mode: IndirectY, Cycles: 5, PageCycle: true, Official: true},
+	0xB2: {Mnemonic: "STP", Mode: Implied, Cycles: 2},
+	0xB3: {Mnemonic: "LAX", Mode: IndirectY, Cycles: 5, PageCycle: true},
+	0xB4: {Mnemonic: "LDY", Mode: ZeroPageX, Cycles: 4, Official: true},
+	0xB5: {Mnemonic: "LDA", Mode: ZeroPageX, Cycles: 4, Official: true},
+	0xB6: {Mnemonic: "LDX", Mode: ZeroPageY, Cycles: 4, Official: true},
+	0xB7: {Mnemonic: "LAX", Mode: ZeroPageY, Cycles: 4},
+	0xB8: {Mnemonic: "CLV", Mode: Implied, Cycles: 2, Official: true},
+	0xB9: {Mnemonic: "LDA", Mode: AbsoluteY, Cycles: 4, PageCycle: true, Official: true},
+	0xBA: {Mnemonic: "TSX", Mode: Implied, Cycles: 2, Official: true},
+	0xBB: {Mnemonic: "LAS", Mode: AbsoluteY, Cycles: 4, PageCycle: true},
+	0xBC: {Mnemonic: "LDY", Mode: AbsoluteX, Cycles: 4, PageCycle: true, Official: true},
+	0xBD: {Mnemonic: "LDA", Mode: AbsoluteX, Cycles: 4, PageCycle: true, Official: true},
+	0xBE: {Mnemonic: "LDX", Mode: AbsoluteY, Cycles: 4, PageCycle: true, Official: true},
+	0xBF: {Mnemonic: "LAX", Mode: AbsoluteY, Cycles: 4, PageCycle: true},
+	// $Cx
+	0xC0: {Mnemonic: "CPY", Mode: Immediate, Cycles: 2, Official: true},
+	0xC1: {Mnemonic: "CMP", Mode: IndirectX, Cycles: 6, Official: true},
+	0xC2: {Mnemonic: "NOP", Mode: Immediate, Cycles: 2},
+	0xC3: {Mnemonic: "DCP", Mode: IndirectX, Cycles: 8},
+	0xC4: {Mnemonic: "CPY", Mode: ZeroPage, Cycles: 3, Official: true},
+	0xC5: {Mnemonic: "CMP", Mode: ZeroPage, Cycles: 3, Official: true},
+	0xC6: {Mnemonic: "DEC", Mode: ZeroPage, Cycles: 5, Official: true},
+	0xC7: {Mnemonic: "DCP", Mode: ZeroPage, Cycles: 5},
+	0xC8: {Mnemonic: "INY", Mode: Implied, Cycles: 2, Official: true},
+	0xC9: {Mnemonic: "CMP", Mode: Immediate, Cycles: 2, Official: true},
+	0xCA: {Mnemonic: "DEX", Mode: Implied, Cycles: 2, Official: true},
+	0xCB: {Mnemonic: "AXS", Mode: Immediate, Cycles: 2},
+	0xCC: {Mnemonic: "CPY", Mode: Absolute, Cycles: 4, Official: true},
+	0xCD: {Mnemonic: "CMP", Mode: Absolute, Cycles: 4, Official: true},
+	0xCE: {Mnemonic: "DEC", Mode: Absolute, Cycles: 6, Official: true},
+	0xCF: {Mnemonic: "DCP", Mode: Absolute, Cycles: 6},
+	// $Dx
+	0xD0: {Mnemonic: "BNE", Mode: Relative, Cycles: 2, PageCycle: true, Official: true},
+	0xD1: {Mnemonic: "CMP", Mode: IndirectY, Cycles: 5, PageCycle: true, Official: true},
+	0xD2: {Mnemonic: "STP", Mode: Implied, Cycles: 2},
+	0xD3: {Mnemonic: "DCP", Mode: IndirectY, Cycles: 8},
+	0xD4: {Mnemonic: "NOP", Mode: ZeroPageX, Cycles: 4},
+	0xD5: {Mnemonic: "CMP", Mode: ZeroPageX, Cycles: 4, Official: true},
+	0xD6: {Mnemonic: "DEC", Mode: ZeroPageX, Cycles: 6, Official: true},
+	0xD7: {Mnemonic: "DCP", Mode: ZeroPageX, Cycles: 6},
+	0xD8: {Mnemonic: "CLD", Mode: Implied, Cycles: 2, Official: true},
+	0xD9: {Mnemonic: "CMP", Mode: AbsoluteY, Cycles: 4, PageCycle: true, Official: true},
+	0xDA: {Mnemonic: "NOP", Mode: Implied, Cycles: 2},
+	0xDB: {Mnemonic: "DCP", Mode: AbsoluteY, Cycles: 7},
+	0xDC: {Mnemonic: "NOP", Mode: AbsoluteX, Cycles: 4, PageCycle: true},
+	0xDD: {Mnemonic: "CMP", Mode: AbsoluteX, Cycles: 4, PageCycle: true, Official: true},
+	0xDE: {Mnemonic: "DEC", Mode: AbsoluteX, Cycles: 7, Official: true},
+	0xDF: {Mnemonic: "DCP", Mode: AbsoluteX, Cycles: 7},
+	// $Ex
+	0xE0: {Mnemonic: "CPX", Mode: Immediate, Cycles: 2, Official: true},
+	0xE1: {Mnemonic: "SBC", Mode: IndirectX, Cycles: 6, Official: true},
+	0xE2: {Mnemonic: "NOP", Mode: Immediate, Cycles: 2},
+	0xE3: {Mnemonic: "ISB", Mode: IndirectX, Cycles: 8},
+	0xE4: {Mnemonic: "CPX", Mode: ZeroPage, Cycles: 3, Official: true},
+	0xE5: {Mnemonic: "SBC", Mode: ZeroPage, Cycles: 3, Official: true},
+	0xE6: {Mnemonic: "INC", Mode: ZeroPage, Cycles: 5, Official: true},
+	0xE7: {Mnemonic: "ISB", Mode: ZeroPage, Cycles: 5},
+	0xE8: {Mnemonic: "INX", Mode: Implied, Cycles: 2, Official: true},
+	0xE9: {Mnemonic: "SBC", Mode: Immediate, Cycles: 2, Official: true},
+	0xEA: {Mnemonic: "NOP", Mode: Implied, Cycles: 2, Official: true},
+	0xEB: {Mnemonic: "SBC", Mode: Immediate, Cycles: 2},
+	0xEC: {Mnemonic: "CPX", Mode: Absolute, Cycles: 4, Official: true},
+	0xED: {Mnemonic: "SBC", Mode: Absolute, Cycles: 4, Official: true},
+	0xEE: {Mnemonic: "INC", Mode: Absolute, Cycles: 6, Official: true},
+	0xEF: {Mnemonic: "ISB", Mode: Absolute, Cycles: 6},
+	// $Fx
+	0xF0: {Mnemonic: "BEQ", Mode: Relative, Cycles: 2, PageCycle: true, Official: true},
+	0xF1: {Mnemonic: "SBC", Mode: IndirectY, Cycles: 5, PageCycle: true, Official: true},
+	0xF2: {Mnemonic: "STP", Mode: Implied, Cycles: 2},
+	0xF3: {Mnemonic: "ISB", Mode: IndirectY, Cycles: 8},
+	0xF4: {Mnemonic: "NOP", Mode: ZeroPageX, Cycles: 4},
+	0xF5: {Mnemonic: "SBC", Mode: ZeroPageX, Cycles: 4, Official: true},
+	0xF6: {Mnemonic: "INC", Mode: ZeroPageX, Cycles: 6, Official: true},
+	0xF7: {Mnemonic: "ISB", Mode: ZeroPageX, Cycles: 6},
+	0xF8: {Mnemonic: "SED", Mode: Implied, Cycles: 2, Official: true},
+	0xF9: {Mnemonic: "SBC", Mode: AbsoluteY, Cycles: 4, PageCycle: true, Official: true},
+	0xFA: {Mnemonic: "NOP", Mode: Implied, Cycles: 2},
+	0xFB: {Mnemonic: "ISB", Mode: AbsoluteY, Cycles: 7},
+	0xFC: {Mnemonic: "NOP", Mode: AbsoluteX, Cycles: 4, PageCycle: true},
+	0xFD: {Mnemonic: "SBC", Mode: AbsoluteX, Cycles: 4, PageCycle: true, Official: true},
+	0xFE: {Mnemonic: "INC", Mode: AbsoluteX, Cycles: 7, Official: true},
+	0xFF: {Mnemonic: "ISB", Mode: AbsoluteX, Cycles: 7},
+}
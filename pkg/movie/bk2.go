@@ -0,0 +1,148 @@
+package movie
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/controller"
+)
+
+// bk2Buttons maps BizHawk NES button names to controller buttons
+var bk2Buttons = map[string]controller.Button{
+	"Up": controller.ButtonUp, "Down": controller.ButtonDown,
+	"Left": controller.ButtonLeft, "Right": controller.ButtonRight,
+	"Start": controller.ButtonStart, "Select": controller.ButtonSelect,
+	"B": controller.ButtonB, "A": controller.ButtonA,
+}
+
+// LoadBK2 reads a BizHawk movie archive
+func LoadBK2(path string) (*Movie, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	m := &Movie{Format: "bk2"}
+	var input io.ReadCloser
+	for _, f := range zr.File {
+		switch f.Name {
+		case "Header.txt":
+			if err := readBK2Header(f, m); err != nil {
+				return nil, err
+			}
+		case "Input Log.txt":
+			if input, err = f.Open(); err != nil {
+				return nil, err
+			}
+			defer input.Close()
+		}
+	}
+	if input == nil {
+		return nil, fmt.Errorf("bk2: no Input Log.txt in %s", path)
+	}
+	if err := parseBK2Input(input, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// readBK2Header reads the "Key Value" header lines
+func readBK2Header(f *zip.File, m *Movie) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(strings.TrimRight(scanner.Text(), "\r"), " ")
+		switch key {
+		case "Platform":
+			if value != "NES" {
+				return fmt.Errorf("%w: platform %q", ErrUnsupported, value)
+			}
+		case "GameName":
+			m.ROMName = value
+		case "PAL":
+			m.PAL = strings.EqualFold(value, "true")
+		case "rerecordCount":
+			m.Rerecords, _ = strconv.Atoi(value)
+		case "StartsFromSavestate":
+			if strings.EqualFold(value, "true") {
+				return fmt.Errorf("%w: movie starts from a savestate", ErrUnsupported)
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// bk2Key is one column of the input log
+type bk2Key struct {
+	port   int // 0/1 for controller buttons, -1 for console buttons
+	button controller.Button
+	name   string
+}
+
+// parseBK2Input reads the input log. The LogKey line names every column
+// ("#Reset|Power|#P1 Up|P1 Down|..."); input lines then carry one character
+// per column, with '.' meaning released.
+func parseBK2Input(r io.Reader, m *Movie) error {
+	var keys []bk2Key
+	scanner := bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case strings.HasPrefix(line, "LogKey:"):
+			keys = parseBK2LogKey(strings.TrimPrefix(line, "LogKey:"))
+
+		case strings.HasPrefix(line, "|"):
+			if keys == nil {
+				return fmt.Errorf("bk2: input before LogKey")
+			}
+			columns := strings.ReplaceAll(line, "|", "")
+			var frame Frame
+			for i, key := range keys {
+				if i >= len(columns) || columns[i] == '.' {
+					continue
+				}
+				switch {
+				case key.port >= 0:
+					frame.Buttons[key.port] |= 1 << key.button
+				case key.name == "Reset":
+					frame.Reset = true
+				case key.name == "Power":
+					frame.Power = true
+				}
+			}
+			m.Frames = append(m.Frames, frame)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseBK2LogKey decodes the column names of the input log
+func parseBK2LogKey(spec string) []bk2Key {
+	var keys []bk2Key
+	for _, name := range strings.Split(spec, "|") {
+		name = strings.TrimPrefix(name, "#")
+		if name == "" {
+			continue
+		}
+		key := bk2Key{port: -1, name: name}
+		if player, button, ok := strings.Cut(name, " "); ok && len(player) == 2 && player[0] == 'P' {
+			if b, known := bk2Buttons[button]; known && (player[1] == '1' || player[1] == '2') {
+				key.port = int(player[1] - '1')
+				key.button = b
+			}
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
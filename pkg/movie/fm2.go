@@ -0,0 +1,84 @@
+package movie
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/controller"
+)
+
+// fm2Order is the button order of an FM2 controller field, "RLDUTSBA"
+var fm2Order = [8]controller.Button{
+	controller.ButtonRight, controller.ButtonLeft, controller.ButtonDown, controller.ButtonUp,
+	controller.ButtonStart, controller.ButtonSelect, controller.ButtonB, controller.ButtonA,
+}
+
+// FM2 command bits (the first field of an input line)
+const (
+	fm2SoftReset = 1 << 0
+	fm2HardReset = 1 << 1
+)
+
+// ParseFM2 reads an FCEUX text movie. Header lines are "key value"; each
+// input line is "|commands|port0|port1|port2|" where a controller field
+// lists RLDUTSBA and any character other than '.' or ' ' is a press.
+func ParseFM2(r io.Reader) (*Movie, error) {
+	m := &Movie{Format: "fm2"}
+	scanner := bufio.NewScanner(r)
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if line[0] != '|' {
+			key, value, _ := strings.Cut(line, " ")
+			switch key {
+			case "romFilename":
+				m.ROMName = value
+			case "palFlag":
+				m.PAL = value == "1"
+			case "rerecordCount":
+				m.Rerecords, _ = strconv.Atoi(value)
+			case "binary":
+				if value == "1" {
+					return nil, fmt.Errorf("%w: binary FM2 input", ErrUnsupported)
+				}
+			case "savestate":
+				return nil, fmt.Errorf("%w: movie starts from a savestate", ErrUnsupported)
+			}
+			continue
+		}
+
+		fields := strings.Split(line, "|")
+		if len(fields) < 4 {
+			return nil, fmt.Errorf("fm2 line %d: malformed input line", lineNo)
+		}
+		var frame Frame
+		if cmd, err := strconv.Atoi(fields[1]); err == nil {
+			frame.Reset = cmd&fm2SoftReset != 0
+			frame.Power = cmd&fm2HardReset != 0
+		}
+		for port := 0; port < 2; port++ {
+			frame.Buttons[port] = parseButtonField(fields[2+port], fm2Order[:])
+		}
+		m.Frames = append(m.Frames, frame)
+	}
+	return m, scanner.Err()
+}
+
+// parseButtonField converts a mnemonic field such as "R...T..A" to a
+// button mask, given the button each character position stands for
+func parseButtonField(field string, order []controller.Button) uint8 {
+	var mask uint8
+	for i := 0; i < len(field) && i < len(order); i++ {
+		if field[i] != '.' && field[i] != ' ' {
+			mask |= 1 << order[i]
+		}
+	}
+	return mask
+}
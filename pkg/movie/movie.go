@@ -0,0 +1,91 @@
+// Package movie reads TAS input movies (FCEUX .fm2 and BizHawk .bk2) and
+// plays them back on an emulator.
+//
+// A movie is a list of per-frame inputs recorded from power-on. Playback
+// applies frame N's input before the emulator runs frame N, so a movie is
+// only faithful if the emulator's timing matches the one it was recorded on.
+// Movies that start from a savestate are not supported.
+package movie
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/controller"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
+)
+
+// ErrUnsupported is returned for movie features the player cannot handle
+// (binary FM2 input, savestate-anchored movies, non-NES BK2 files)
+var ErrUnsupported = errors.New("unsupported movie")
+
+// Frame is the input for one frame
+type Frame struct {
+	Buttons [2]uint8 // Held buttons per controller, bit n = controller.Button(n)
+	Reset   bool     // Soft reset before this frame
+	Power   bool     // Power cycle before this frame
+}
+
+// Movie is a parsed input movie
+type Movie struct {
+	Format    string // "fm2" or "bk2"
+	ROMName   string // ROM name recorded in the header, if any
+	PAL       bool   // Recorded on PAL timing
+	Rerecords int    // Rerecord count from the header
+	Frames    []Frame
+}
+
+// Load reads a movie, choosing the parser from the file extension
+func Load(path string) (*Movie, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".fm2":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return ParseFM2(f)
+	case ".bk2":
+		return LoadBK2(path)
+	}
+	return nil, fmt.Errorf("%w: unknown movie extension %q", ErrUnsupported, filepath.Ext(path))
+}
+
+// Apply sets the emulator's controllers (and performs any reset) for the
+// given frame. Frames past the end of the movie release all buttons.
+func (m *Movie) Apply(emulator *nes.NES, frame int) {
+	var in Frame
+	if frame < len(m.Frames) {
+		in = m.Frames[frame]
+	}
+	switch {
+	case in.Power:
+		emulator.PowerOn()
+	case in.Reset:
+		emulator.Reset()
+	}
+
+	bus := emulator.GetBus()
+	for port := 0; port < 2; port++ {
+		c := bus.GetController(port)
+		for b := controller.ButtonA; b <= controller.ButtonRight; b++ {
+			c.SetButton(b, in.Buttons[port]&(1<<b) != 0)
+		}
+	}
+}
+
+// Play runs the whole movie from power-on, calling onFrame (if non-nil)
+// after each frame
+func (m *Movie) Play(emulator *nes.NES, onFrame func(frame int)) {
+	emulator.PowerOn()
+	for frame := range m.Frames {
+		m.Apply(emulator, frame)
+		emulator.RunFrame()
+		if onFrame != nil {
+			onFrame(frame)
+		}
+	}
+}
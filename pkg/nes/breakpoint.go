@@ -0,0 +1,141 @@
+package nes
+
+import (
+	"errors"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+)
+
+// BreakKind selects what triggers a breakpoint. Kinds can be combined.
+type BreakKind uint8
+
+const (
+	BreakExecute BreakKind = 1 << iota // The CPU is about to execute the instruction at the address
+	BreakRead                          // The CPU read the address (opcode and operand fetches included)
+	BreakWrite                         // The CPU wrote the address
+)
+
+// ErrBreakpoint is returned by RunFrame and RunLoop when a breakpoint pauses
+// emulation
+var ErrBreakpoint = errors.New("nes: breakpoint hit")
+
+// BreakEvent describes a breakpoint hit
+type BreakEvent struct {
+	Kind   BreakKind      // The single kind that triggered
+	Addr   uint16         // Address of the breakpoint
+	Value  uint8          // Byte read or written; the opcode for BreakExecute
+	CPU    cpu.DebugState // Registers when emulation paused
+	Cycles uint64         // Total CPU cycles when emulation paused
+}
+
+// SetBreakpoint adds a breakpoint of the given kinds at addr
+//
+// When one is hit, the OnBreakpoint callback runs and emulation pauses at
+// the end of the current CPU cycle: RunFrame and RunLoop return
+// ErrBreakpoint mid-frame. Calling either again resumes exactly where it left
+// off. Execute breakpoints pause before the instruction's opcode fetch;
+// read and write breakpoints pause right after the access, possibly in the
+// middle of an instruction.
+func (n *NES) SetBreakpoint(addr uint16, kind BreakKind) {
+	if n.breakpoints == nil {
+		n.breakpoints = make(map[uint16]BreakKind)
+	}
+	n.breakpoints[addr] |= kind
+	n.updateAccessHook()
+}
+
+// ClearBreakpoint removes the given kinds from the breakpoint at addr
+func (n *NES) ClearBreakpoint(addr uint16, kind BreakKind) {
+	if k := n.breakpoints[addr] &^ kind; k != 0 {
+		n.breakpoints[addr] = k
+	} else {
+		delete(n.breakpoints, addr)
+	}
+	n.updateAccessHook()
+}
+
+// ClearBreakpoints removes all breakpoints
+func (n *NES) ClearBreakpoints() {
+	clear(n.breakpoints)
+	n.updateAccessHook()
+}
+
+// OnBreakpoint registers a callback run on the emulation goroutine when a
+// breakpoint is hit (nil removes it)
+func (n *NES) OnBreakpoint(fn func(BreakEvent)) {
+	n.onBreak = fn
+}
+
+// LastBreak returns the breakpoint hit during the most recent Step, which
+// is what made RunFrame return early
+func (n *NES) LastBreak() (BreakEvent, bool) {
+	return n.lastBreak, n.breakHit
+}
+
+// updateAccessHook watches the bus only while read or write breakpoints
+// exist or the code/data logger runs, so the normal path pays nothing for
+// them
+func (n *NES) updateAccessHook() {
+	n.accessBreaks = false
+	for _, kind := range n.breakpoints {
+		if kind&(BreakRead|BreakWrite) != 0 {
+			n.accessBreaks = true
+			break
+		}
+	}
+	if n.accessBreaks || n.cdl != nil {
+		n.bus.SetAccessHook(n.observeAccess)
+	} else {
+		n.bus.SetAccessHook(nil)
+	}
+}
+
+// observeAccess is the bus access hook, feeding read/write breakpoints and
+// the code/data logger
+func (n *NES) observeAccess(addr uint16, value uint8, write bool) {
+	if n.speculating {
+		return
+	}
+	if n.cdl != nil && !write {
+		n.cdl.read(addr)
+	}
+	if n.accessBreaks {
+		n.checkAccess(addr, value, write)
+	}
+}
+
+// checkAccess is the bus access hook behind read and write breakpoints
+func (n *NES) checkAccess(addr uint16, value uint8, write bool) {
+	kind := BreakRead
+	if write {
+		kind = BreakWrite
+	}
+	if n.breakpoints[addr]&kind != 0 {
+		n.hitBreakpoint(kind, addr, value)
+	}
+}
+
+// checkExecute raises an execute breakpoint if the CPU has just reached an
+// instruction boundary at one
+func (n *NES) checkExecute() {
+	pc := n.cpu.GetPC()
+	if n.breakpoints[pc]&BreakExecute != 0 && n.cpu.AtBoundary() && !n.cpu.IsHalted() {
+		n.hitBreakpoint(BreakExecute, pc, n.bus.Peek(pc))
+	}
+}
+
+// hitBreakpoint records a hit and notifies the callback. The event's
+// register snapshot is taken at the end of the cycle.
+func (n *NES) hitBreakpoint(kind BreakKind, addr uint16, value uint8) {
+	n.breakHit = true
+	n.lastBreak = BreakEvent{Kind: kind, Addr: addr, Value: value}
+}
+
+// finishBreak completes a hit recorded during the cycle just run
+func (n *NES) finishBreak() {
+	n.lastBreak.CPU = n.cpu.DebugState()
+	n.lastBreak.Cycles = n.cycles
+	if n.onBreak != nil {
+		n.onBreak(n.lastBreak)
+	}
+}
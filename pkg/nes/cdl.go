@@ -0,0 +1,181 @@
+package nes
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+)
+
+// Code/data log flags for a PRG-ROM byte, as in FCEUX's .cdl files. Bits
+// 2-3 (CDLWindow) hold which 8KB CPU window, $8000/$A000/$C000/$E000, the
+// byte was last used through.
+const (
+	CDLCode         = 0x01 // Executed as part of an instruction
+	CDLData         = 0x02 // Read as data
+	CDLWindow       = 0x0C
+	CDLIndirectCode = 0x10 // Jumped to through JMP ($nnnn)
+	CDLIndirectData = 0x20 // Read through ($nn,X) or ($nn),Y
+	CDLPCM          = 0x40 // Fetched by the DMC as sample data
+)
+
+// CodeDataLog records how each PRG-ROM byte has been used, laid out as an
+// FCEUX .cdl file: a flag byte per PRG-ROM byte, then one per CHR-ROM byte.
+// CHR usage is not tracked; those bytes stay zero so the file still has
+// the size FCEUX and disassemblers expect.
+type CodeDataLog struct {
+	PRG []uint8
+	CHR []uint8
+}
+
+// CDLCoverage summarizes a CodeDataLog's PRG-ROM section
+type CDLCoverage struct {
+	Code   int // Bytes executed
+	Data   int // Bytes read as data (or DMC samples) but never executed
+	Unused int // Bytes never touched
+	Total  int // PRG-ROM size
+}
+
+// Percent returns the share of PRG-ROM used as code or data
+func (c CDLCoverage) Percent() float64 {
+	if c.Total == 0 {
+		return 0
+	}
+	return 100 * float64(c.Code+c.Data) / float64(c.Total)
+}
+
+// NewCodeDataLog returns an empty log sized for the cartridge
+func NewCodeDataLog(cart *cartridge.Cartridge) *CodeDataLog {
+	return &CodeDataLog{
+		PRG: make([]uint8, int(cart.GetPRGBanks())*16384),
+		CHR: make([]uint8, int(cart.GetCHRBanks())*8192),
+	}
+}
+
+// ReadCodeDataLog reads a .cdl file written for the cartridge, e.g. to keep
+// adding to it over several runs
+func ReadCodeDataLog(r io.Reader, cart *cartridge.Cartridge) (*CodeDataLog, error) {
+	log := NewCodeDataLog(cart)
+	if _, err := io.ReadFull(r, log.PRG); err != nil {
+		return nil, fmt.Errorf("reading CDL PRG section: %w", err)
+	}
+	if _, err := io.ReadFull(r, log.CHR); err != nil {
+		return nil, fmt.Errorf("reading CDL CHR section: %w", err)
+	}
+	return log, nil
+}
+
+// WriteTo writes the log in .cdl format
+func (l *CodeDataLog) WriteTo(w io.Writer) (int64, error) {
+	n, err := w.Write(l.PRG)
+	if err != nil {
+		return int64(n), err
+	}
+	m, err := w.Write(l.CHR)
+	return int64(n + m), err
+}
+
+// Coverage counts the PRG-ROM bytes used as code and as data
+func (l *CodeDataLog) Coverage() CDLCoverage {
+	c := CDLCoverage{Total: len(l.PRG)}
+	for _, flags := range l.PRG {
+		switch {
+		case flags&CDLCode != 0:
+			c.Code++
+		case flags&(CDLData|CDLPCM) != 0:
+			c.Data++
+		default:
+			c.Unused++
+		}
+	}
+	return c
+}
+
+// cdlState is the logging behind EnableCDL
+type cdlState struct {
+	log  *CodeDataLog
+	cart *cartridge.Cartridge
+
+	// The instruction being executed. Reads of its bytes, and the dummy
+	// reads of the byte after it (implied operands, branches, BRK), are
+	// not data.
+	start, end uint32
+	indirect   bool // Uses ($nn,X) or ($nn),Y
+	jumped     bool // Is JMP ($nnnn), so its target is indirect code
+}
+
+// EnableCDL starts logging PRG-ROM usage into log, or into a new empty log
+// if log is nil, and returns the log. Bytes executed are marked as code;
+// other CPU reads of PRG-ROM as data, including most dummy reads; DMC
+// sample fetches as PCM. Bank-switched PRG is resolved through
+// cartridge.PRGBankMapper (see Cartridge.PRGOffset).
+func (n *NES) EnableCDL(log *CodeDataLog) *CodeDataLog {
+	if log == nil {
+		log = NewCodeDataLog(n.cartridge)
+	}
+	n.cdl = &cdlState{log: log, cart: n.cartridge}
+	n.bus.SetSampleHook(n.cdl.sample)
+	n.updateAccessHook()
+	return log
+}
+
+// DisableCDL stops code/data logging. The log keeps what was recorded.
+func (n *NES) DisableCDL() {
+	n.cdl = nil
+	n.bus.SetSampleHook(nil)
+	n.updateAccessHook()
+}
+
+// CDL returns the log EnableCDL is filling, or nil
+func (n *NES) CDL() *CodeDataLog {
+	if n.cdl == nil {
+		return nil
+	}
+	return n.cdl.log
+}
+
+// instruction marks the bytes of the instruction at pc as code
+func (c *cdlState) instruction(peek func(uint16) uint8, pc uint16) {
+	in := cpu.Decode(peek, pc)
+	flags := uint8(CDLCode)
+	if c.jumped {
+		flags |= CDLIndirectCode
+	}
+	for i := 0; i < in.Size(); i++ {
+		c.mark(pc+uint16(i), flags)
+	}
+
+	c.start = uint32(pc)
+	c.end = uint32(pc) + uint32(in.Size())
+	c.indirect = in.Info.Mode == cpu.IndirectX || in.Info.Mode == cpu.IndirectY
+	c.jumped = in.Info.Mode == cpu.Indirect
+}
+
+// read marks a CPU read outside the current instruction as data
+func (c *cdlState) read(addr uint16) {
+	if a := uint32(addr); a >= c.start && a <= c.end {
+		return
+	}
+	flags := uint8(CDLData)
+	if c.indirect {
+		flags |= CDLIndirectData
+	}
+	c.mark(addr, flags)
+}
+
+// sample marks a DMC sample fetch
+func (c *cdlState) sample(addr uint16, _ uint8) {
+	c.mark(addr, CDLPCM)
+}
+
+// mark sets flags on the PRG-ROM byte mapped at addr, recording the CPU
+// window it was reached through
+func (c *cdlState) mark(addr uint16, flags uint8) {
+	offset := c.cart.PRGOffset(addr)
+	if offset < 0 || offset >= len(c.log.PRG) {
+		return
+	}
+	window := uint8(addr>>13) & 0x03
+	c.log.PRG[offset] = c.log.PRG[offset]&^CDLWindow | window<<2 | flags
+}
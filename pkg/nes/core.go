@@ -0,0 +1,70 @@
+package nes
+
+import (
+	"encoding"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// CPU is the processor core the NES drives, one cycle per Step. The default
+// is *cpu.CPU, the cycle-stepped interpreter; SetCPU swaps in another core
+// (a table-driven or block-caching one, say) without touching the bus, PPU
+// or mappers. A core must make its accesses through the cpu.Bus it was
+// built with, on the cycles the 6502 makes them, since mapper IRQ counters
+// and PPU/APU registers observe that timing.
+type CPU interface {
+	// Step runs one CPU cycle
+	Step()
+
+	// PowerOn runs the power-on sequence, Reset the reset button's (see
+	// NES.PowerOn and NES.Reset)
+	PowerOn()
+	Reset()
+
+	// AtBoundary reports whether the next Step fetches an opcode or starts
+	// an interrupt sequence
+	AtBoundary() bool
+
+	// IsHalted reports whether the CPU is jammed (STP and friends)
+	IsHalted() bool
+
+	// TriggerNMI latches an NMI edge; SetIRQ drives the level-triggered
+	// IRQ line
+	TriggerNMI()
+	SetIRQ(asserted bool)
+
+	// GetPC returns the program counter, InstructionPC the address of the
+	// instruction in flight (or last executed)
+	GetPC() uint16
+	InstructionPC() uint16
+
+	// DebugState snapshots the registers for traces and debuggers
+	DebugState() cpu.DebugState
+
+	// WriteState appends the core's state to w (see HashState)
+	WriteState(w *state.Writer)
+
+	// MarshalBinary and UnmarshalBinary save and restore the core's state
+	// exactly, for save states
+	encoding.BinaryMarshaler
+	encoding.BinaryUnmarshaler
+}
+
+// The interpreter is the default core
+var _ CPU = (*cpu.CPU)(nil)
+
+// SetCPU replaces the CPU core. The core must be built on this NES's bus
+// (GetBus). Call Reset afterwards: like a new console, the next Reset
+// powers the core on.
+func (n *NES) SetCPU(core CPU) {
+	n.cpu = core
+	n.bus.AttachCPU(core)
+	n.poweredOn = false
+}
+
+// GetCPUCore returns the CPU core in use, which GetCPU only returns when it
+// is the built-in interpreter
+func (n *NES) GetCPUCore() CPU {
+	return n.cpu
+}
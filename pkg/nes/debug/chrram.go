@@ -0,0 +1,32 @@
+package debug
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoCHRRAM is returned by the CHR-RAM accessors for CHR-ROM cartridges
+var ErrNoCHRRAM = errors.New("cartridge has no CHR-RAM")
+
+// DumpCHRRAM returns a copy of the cartridge's CHR-RAM as it is now
+func (d *Debugger) DumpCHRRAM() ([]uint8, error) {
+	chr := d.nes.GetCartridge().GetCHRRAM()
+	if chr == nil {
+		return nil, ErrNoCHRRAM
+	}
+	return append([]uint8(nil), chr...), nil
+}
+
+// InjectCHRRAM overwrites CHR-RAM starting at offset. The new tiles appear
+// from the next PPU fetch, until the game uploads over them.
+func (d *Debugger) InjectCHRRAM(offset int, data []uint8) error {
+	chr := d.nes.GetCartridge().GetCHRRAM()
+	if chr == nil {
+		return ErrNoCHRRAM
+	}
+	if offset < 0 || offset+len(data) > len(chr) {
+		return fmt.Errorf("inject of %d bytes at $%04X exceeds %d bytes of CHR-RAM", len(data), offset, len(chr))
+	}
+	copy(chr[offset:], data)
+	return nil
+}
@@ -0,0 +1,146 @@
+// Package debug provides tooling access to a running emulator.
+//
+// The nes package is the gameplay API: load a ROM, run frames, read the
+// frame buffer. Everything a debugger, tracer or memory viewer needs -
+// side-effect-free memory peeks, register snapshots, per-instruction hooks,
+// breakpoints and pattern/nametable viewers - lives here instead, layered on
+// the core types, so none of it costs anything on the normal emulation path.
+//
+// Typical use:
+//
+//	dbg := debug.New(emulator)
+//	dbg.AddBreakpoint(0xC000)
+//	if dbg.RunFrame() {
+//	    fmt.Printf("hit breakpoint at $%04X\n", dbg.CPU().PC)
+//	}
+package debug
+
+import (
+	"errors"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
+)
+
+// InstructionHook is called before each instruction, with the CPU state at
+// the opcode fetch and the total CPU cycle count
+type InstructionHook = nes.InstructionHook
+
+// Debugger wraps an emulator with inspection and execution control
+type Debugger struct {
+	nes *nes.NES
+}
+
+// New creates a debugger for the given emulator
+func New(n *nes.NES) *Debugger {
+	return &Debugger{nes: n}
+}
+
+// NES returns the emulator being debugged
+func (d *Debugger) NES() *nes.NES {
+	return d.nes
+}
+
+// CPU returns a snapshot of the CPU registers
+func (d *Debugger) CPU() cpu.DebugState {
+	return d.nes.GetCPUCore().DebugState()
+}
+
+// PPU returns a snapshot of the PPU's internal state
+func (d *Debugger) PPU() ppu.DebugState {
+	return d.nes.GetPPU().DebugState()
+}
+
+// Peek reads a byte of CPU address space without side effects
+func (d *Debugger) Peek(addr uint16) uint8 {
+	return d.nes.GetBus().Peek(addr)
+}
+
+// PeekRange reads length bytes of CPU address space starting at addr,
+// wrapping at $FFFF, without side effects
+func (d *Debugger) PeekRange(addr uint16, length int) []uint8 {
+	out := make([]uint8, length)
+	for i := range out {
+		out[i] = d.Peek(addr + uint16(i))
+	}
+	return out
+}
+
+// PeekVRAM reads a byte of PPU address space without side effects
+func (d *Debugger) PeekVRAM(addr uint16) uint8 {
+	return d.nes.GetPPU().PeekVRAM(addr)
+}
+
+// PokeNametable writes a nametable byte ($2000-$2FFF) through the current
+// mirroring, e.g. for level editors prototyping layouts live
+func (d *Debugger) PokeNametable(addr uint16, value uint8) {
+	d.nes.GetPPU().PokeNametable(addr, value)
+}
+
+// PokeAttribute sets the background palette (0-3) of the 16x16 area
+// containing tile (tileX, tileY) in nametable 0-3
+func (d *Debugger) PokeAttribute(nametable, tileX, tileY int, palette uint8) {
+	d.nes.GetPPU().PokeAttribute(nametable, tileX, tileY, palette)
+}
+
+// OAM returns a copy of primary OAM (64 sprites, 4 bytes each)
+func (d *Debugger) OAM() [256]uint8 {
+	var oam [256]uint8
+	p := d.nes.GetPPU()
+	for i := range oam {
+		oam[i] = p.PeekOAM(uint8(i))
+	}
+	return oam
+}
+
+// Sprites decodes the 64 OAM entries (see ppu.PPU.GetSprites)
+func (d *Debugger) Sprites() []ppu.Sprite {
+	return d.nes.GetPPU().GetSprites()
+}
+
+// Palette returns a copy of palette RAM ($3F00-$3F1F)
+func (d *Debugger) Palette() [32]uint8 {
+	var pal [32]uint8
+	for i := range pal {
+		pal[i] = d.PeekVRAM(0x3F00 + uint16(i))
+	}
+	return pal
+}
+
+// SetInstructionHook installs a hook called before every instruction (nil
+// removes it). It replaces the emulator's instruction hooks (see
+// nes.NES.SetInstructionHooks).
+func (d *Debugger) SetInstructionHook(hook InstructionHook) {
+	d.nes.SetInstructionHooks(nes.InstructionHooks{Before: hook})
+}
+
+// AddBreakpoint stops RunFrame before the instruction at addr executes.
+// It is an execute breakpoint on the emulator (see nes.NES.SetBreakpoint
+// for read and write breakpoints).
+func (d *Debugger) AddBreakpoint(addr uint16) {
+	d.nes.SetBreakpoint(addr, nes.BreakExecute)
+}
+
+// RemoveBreakpoint clears a breakpoint set with AddBreakpoint
+func (d *Debugger) RemoveBreakpoint(addr uint16) {
+	d.nes.ClearBreakpoint(addr, nes.BreakExecute)
+}
+
+// ClearBreakpoints removes all of the emulator's breakpoints
+func (d *Debugger) ClearBreakpoints() {
+	d.nes.ClearBreakpoints()
+}
+
+// StepInstruction runs the emulator until the current CPU instruction has
+// completed and the next one is about to be fetched
+func (d *Debugger) StepInstruction() {
+	d.nes.StepInstruction()
+}
+
+// RunFrame runs until the PPU completes a frame or a breakpoint is reached.
+// It returns true if it stopped on a breakpoint; calling it again resumes
+// past that breakpoint.
+func (d *Debugger) RunFrame() bool {
+	return errors.Is(d.nes.RunFrame(), nes.ErrBreakpoint)
+}
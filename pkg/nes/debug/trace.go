@@ -0,0 +1,26 @@
+package debug
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+)
+
+// TraceLine formats the instruction about to execute as a line of a
+// Nintendulator/nestest.log style trace (see nes.NES.TraceLine)
+func (d *Debugger) TraceLine() string {
+	return d.nes.TraceLine()
+}
+
+// TraceTo writes a TraceLine for every instruction executed to w. It
+// replaces any instruction hook; pass nil to stop tracing.
+func (d *Debugger) TraceTo(w io.Writer) {
+	if w == nil {
+		d.SetInstructionHook(nil)
+		return
+	}
+	d.SetInstructionHook(func(cpu.DebugState, uint64) {
+		fmt.Fprintln(w, d.TraceLine())
+	})
+}
@@ -0,0 +1,17 @@
+package debug
+
+import "image"
+
+// PatternTable renders one 4KB pattern table (0 = $0000, 1 = $1000) as a
+// 128x128 image of 16x16 tiles, colored with the given palette (0-3
+// background, 4-7 sprite)
+func (d *Debugger) PatternTable(table int, palette uint8) *image.RGBA {
+	return d.nes.GetPPU().RenderPatternTable(table, palette)
+}
+
+// Nametables renders all four logical nametables ($2000-$2FFF), after
+// mirroring, as a 512x480 image using the background pattern table and
+// attribute palettes currently selected
+func (d *Debugger) Nametables() *image.RGBA {
+	return d.nes.GetPPU().RenderNametables()
+}
@@ -0,0 +1,68 @@
+package nes
+
+import "github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
+
+// Frame is a snapshot of one completed video frame
+type Frame struct {
+	// Number is the PPU frame counter value for this frame (starting at 0)
+	Number uint64
+
+	// Pixels holds 256x240 palette indices (0-63), row-major
+	Pixels [ppu.ScreenWidth * ppu.ScreenHeight]uint8
+}
+
+// frameChannelSize is how many completed frames Frames() buffers before the
+// oldest undelivered frame is dropped
+const frameChannelSize = 2
+
+// SetFrameSink registers a callback that receives every completed frame
+//
+// The callback runs on the emulation goroutine as soon as the PPU finishes the
+// last scanline of a frame, so it always sees a whole frame rather than one
+// that is still being drawn. The *Frame is a private copy that the callback
+// may keep. Pass nil to remove the sink.
+func (n *NES) SetFrameSink(sink func(*Frame)) {
+	n.frameSink = sink
+}
+
+// Frames returns a channel that receives a copy of every completed frame
+//
+// The channel is created on first use. Delivery never blocks emulation: if the
+// consumer falls behind, the oldest undelivered frame is discarded, so a slow
+// reader always catches up to the most recent frames. Frame numbers let the
+// reader detect skipped frames.
+func (n *NES) Frames() <-chan *Frame {
+	if n.frameChan == nil {
+		n.frameChan = make(chan *Frame, frameChannelSize)
+	}
+	return n.frameChan
+}
+
+// deliverFrame publishes the frame the PPU just completed to the registered
+// sink and channel
+func (n *NES) deliverFrame(number uint64) {
+	if n.frameSink == nil && n.frameChan == nil {
+		return
+	}
+
+	frame := &Frame{Number: number, Pixels: *n.ppu.GetFrameBuffer()}
+
+	if n.frameSink != nil {
+		n.frameSink(frame)
+	}
+
+	if n.frameChan != nil {
+		for {
+			select {
+			case n.frameChan <- frame:
+				return
+			default:
+			}
+			// Channel full: drop the oldest frame and retry
+			select {
+			case <-n.frameChan:
+			default:
+			}
+		}
+	}
+}
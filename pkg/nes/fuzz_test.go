@@ -0,0 +1,53 @@
+package nes
+
+import (
+	"testing"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testutil"
+)
+
+// fuzzCycleBudget bounds each CPU fuzz run (about two frames)
+const fuzzCycleBudget = 60000
+
+// FuzzCPUExecution runs random PRG data as a program on a full system.
+// The first byte picks the mapper; the rest is tiled across 32KB of PRG-ROM,
+// so the reset/NMI/IRQ vectors and the code they point at are all fuzzed.
+// Execution must stay within the cycle budget without panicking.
+func FuzzCPUExecution(f *testing.F) {
+	f.Add([]byte{0, 0xA9, 0x80, 0x8D, 0x00, 0x20, 0x4C, 0x00, 0x80}) // enable NMI and spin
+	f.Add([]byte{1, 0xA9, 0x1E, 0x8D, 0x01, 0x20, 0x8D, 0x14, 0x40}) // rendering on, OAM DMA
+	f.Add([]byte{4, 0xA9, 0x08, 0x8D, 0x00, 0xC0, 0x58, 0x4C, 0x00, 0x80})
+	f.Add([]byte{2, 0x00})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if len(data) < 2 {
+			return
+		}
+		mapperID := allMappers[int(data[0])%len(allMappers)]
+		program := data[1:]
+
+		tiled := make([]byte, 2*16384+8192)
+		for i := range tiled {
+			tiled[i] = program[i%len(program)]
+		}
+		vector := func(addr int) uint16 { return uint16(tiled[addr]) | uint16(tiled[addr+1])<<8 }
+		image := testutil.NewROM(mapperID).
+			PRG(0, tiled[:2*16384]...).
+			CHR(0, tiled[2*16384:]...).
+			Vectors(vector(0x7FFA), vector(0x7FFC), vector(0x7FFE)).
+			Build()
+
+		cart, err := cartridge.LoadFromBytes(image)
+		if err != nil {
+			t.Fatalf("generated image rejected: %v", err)
+		}
+
+		emulator := NewFromCartridge(cart)
+		emulator.SetHeadless(HeadlessMaxSpeed)
+		emulator.Reset()
+		for emulator.GetCycles() < fuzzCycleBudget && !emulator.GetCPU().Halted {
+			emulator.Step()
+		}
+	})
+}
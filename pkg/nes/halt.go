@@ -0,0 +1,48 @@
+package nes
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+)
+
+// ErrCPUHalted matches every HaltError with errors.Is
+var ErrCPUHalted = errors.New("nes: cpu halted")
+
+// HaltError is returned by RunFrame when the CPU has stopped making
+// progress: it executed a jam opcode (STP, also known as KIL/JAM, or an
+// unstable opcode while trapping them), or the watchdog found it spinning
+// in a tiny loop (see SetWatchdog).
+type HaltError struct {
+	PC     uint16      // Address of the jam opcode, or of the last instruction
+	Opcode uint8       // The jam opcode; zero when stuck
+	Jammed bool        // Halted by an opcode rather than stuck in a loop
+	Stuck  *StuckEvent // The watchdog's report, when stuck
+}
+
+// Error describes the halt on one line
+func (e *HaltError) Error() string {
+	if e.Jammed {
+		return fmt.Sprintf("cpu halted: %s ($%02X) at $%04X", cpu.Opcodes[e.Opcode].Mnemonic, e.Opcode, e.PC)
+	}
+	return fmt.Sprintf("cpu stuck at $%04X for %d frames (loop $%04X-$%04X)",
+		e.PC, e.Stuck.Frames, e.Stuck.LoopStart, e.Stuck.LoopEnd)
+}
+
+// Unwrap makes errors.Is(err, ErrCPUHalted) hold
+func (e *HaltError) Unwrap() error {
+	return ErrCPUHalted
+}
+
+// haltError returns the HaltError for the frame just run, if any
+func (n *NES) haltError() error {
+	pc := n.cpu.InstructionPC()
+	if n.cpu.IsHalted() {
+		return &HaltError{PC: pc, Opcode: n.bus.Peek(pc), Jammed: true}
+	}
+	if n.stuck != nil {
+		return &HaltError{PC: pc, Stuck: n.stuck}
+	}
+	return nil
+}
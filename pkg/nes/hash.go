@@ -0,0 +1,38 @@
+package nes
+
+import (
+	"hash/fnv"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// HashState returns a 64-bit FNV-1a hash of all emulated state: CPU
+// registers, RAM, PPU memories and pipeline, APU, controllers and mapper state
+//
+// Two emulators that have run the same ROM with the same inputs hash equal,
+// which makes this suitable for netplay desync detection and determinism
+// checks. The frame buffer is output derived from that state and is only
+// hashed when includeFrameBuffer is set. Frontend settings such as the
+// frame sink, overclocking and headless mode are not part of the hash.
+func (n *NES) HashState(includeFrameBuffer bool) uint64 {
+	w := state.NewWriter(16 * 1024)
+	n.WriteState(w)
+	if includeFrameBuffer {
+		w.Block(n.ppu.GetFrameBuffer()[:])
+	}
+
+	h := fnv.New64a()
+	h.Write(w.Bytes())
+	return h.Sum64()
+}
+
+// WriteState appends the state of every emulated component to w
+func (n *NES) WriteState(w *state.Writer) {
+	w.U64(n.cycles)
+	w.U8(uint8(n.region))
+	n.cpu.WriteState(w)
+	n.bus.WriteState(w)
+	n.ppu.WriteState(w)
+	n.apu.WriteState(w)
+	n.cartridge.GetMapper().WriteState(w)
+}
@@ -0,0 +1,41 @@
+package nes
+
+// Headless selects which outputs the emulator skips producing
+//
+// Headless runs (compatibility scanning, RL training, movie verification)
+// usually only need the machine state, not its audio or picture. Skipping
+// output work never changes emulation results: VBlank, sprite 0 hit, sprite
+// overflow and all timing behave exactly as with output enabled.
+type Headless struct {
+	// NoAudio skips audio sample generation. The APU channels keep
+	// running, so $4015 status and frame IRQs are unaffected.
+	NoAudio bool
+
+	// NoVideo skips pixel composition and frame buffer writes. The frame
+	// buffer keeps whatever was last drawn.
+	NoVideo bool
+}
+
+// Predefined headless profiles
+var (
+	// HeadlessOff produces all output (the default)
+	HeadlessOff = Headless{}
+
+	// HeadlessSilent keeps video but skips audio, e.g. for screenshot tools
+	HeadlessSilent = Headless{NoAudio: true}
+
+	// HeadlessMaxSpeed skips all output, keeping only timing and state
+	HeadlessMaxSpeed = Headless{NoAudio: true, NoVideo: true}
+)
+
+// SetHeadless selects which outputs to skip
+func (n *NES) SetHeadless(h Headless) {
+	n.headless = h
+	n.ppu.SetPixelOutput(!h.NoVideo)
+	n.apu.SetOutputEnabled(!h.NoAudio)
+}
+
+// GetHeadless returns the headless settings in effect
+func (n *NES) GetHeadless() Headless {
+	return n.headless
+}
@@ -3,23 +3,69 @@ package nes
 
 import (
 	"fmt"
+	"io"
+	"log/slog"
 
-	"github.com/andrewthecodertx/go-6502-emulator/pkg/mos6502"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/apu"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/bus"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
 	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
 )
 
 // NES represents the complete NES emulator system
 type NES struct {
-	cpu       *mos6502.CPU         // 6502 CPU
+	cpu       CPU                  // 6502 CPU core (see SetCPU)
 	bus       *bus.NESBus          // System bus
 	ppu       *ppu.PPU             // Picture Processing Unit
+	apu       *apu.APU             // Audio Processing Unit (owned by the bus)
 	cartridge *cartridge.Cartridge // Loaded cartridge
 	cycles    uint64               // Total CPU cycles executed
+	region    cartridge.Region     // Console timing region
+
+	// Completed-frame delivery
+	lastFrame uint64       // PPU frame counter at the last delivery check
+	frameSink func(*Frame) // Optional per-frame callback
+	frameChan chan *Frame  // Optional per-frame channel (see Frames)
+
+	poweredOn  bool // PowerOn has run (see Reset)
+	haltLogged bool // CPU halt already reported
+
+	overclock Overclock // Optional overclocking (see SetOverclock)
+	headless  Headless  // Skipped outputs (see SetHeadless)
+
+	watchdog *watchdogState // Optional stuck-CPU detection (see SetWatchdog)
+	stuck    *StuckEvent    // Freeze the watchdog detected this frame
+
+	trace    io.Writer // Optional instruction trace (see EnableTrace)
+	profiler *profiler // Optional execution profiler (see EnableProfiler)
+
+	// Instruction hooks and stepping (see SetInstructionHooks)
+	hooks           InstructionHooks
+	inInstruction   bool // An instruction began and has not completed
+	instructionDone bool // The CPU reached a boundary (see StepInstruction)
+
+	// Breakpoints (see SetBreakpoint)
+	breakpoints map[uint16]BreakKind
+	onBreak     func(BreakEvent)
+	lastBreak   BreakEvent
+	breakHit    bool // A breakpoint was hit during the last Step
+
+	accessBreaks bool      // Read or write breakpoints are set
+	cdl          *cdlState // Optional code/data logger (see EnableCDL)
+
+	runAhead    *runAheadState // Optional run-ahead (see SetRunAhead)
+	speculating bool           // Running frames ahead, invisible to tools
+	inRunFrame  bool           // RunFrame delivers the frame (run-ahead)
+	ppuState    []byte         // Scratch for PPU snapshots
 }
 
 // New creates a new NES emulator from a ROM file
+//
+// Loader errors are wrapped, so callers can use errors.Is with
+// cartridge.ErrBadHeader / cartridge.ErrTruncatedROM, or errors.As with
+// cartridge.ErrUnsupportedMapper, to tell a corrupt file from a missing mapper.
 func New(romPath string) (*NES, error) {
 	// Load cartridge from ROM file
 	cart, err := cartridge.LoadFromFile(romPath)
@@ -41,63 +87,219 @@ func NewFromCartridge(cart *cartridge.Cartridge) *NES {
 	nesbus := bus.NewNESBus(ppuUnit, cart.GetMapper())
 
 	// Create CPU with the bus
-	cpu := mos6502.NewCPU(nesbus)
+	processor := cpu.New(nesbus)
+	nesbus.AttachCPU(processor)
 
 	nes := &NES{
-		cpu:       cpu,
+		cpu:       processor,
 		bus:       nesbus,
 		ppu:       ppuUnit,
+		apu:       nesbus.GetAPU(),
 		cartridge: cart,
 		cycles:    0,
 	}
 
+	// Cartridge expansion sound, mixed by the APU
+	if audio, ok := cart.GetMapper().(cartridge.AudioMapper); ok {
+		nes.apu.SetExpansion(audio)
+	}
+
+	nes.SetRegion(cart.GetRegion())
+
 	return nes
 }
 
-// Reset resets the NES to power-on state
+// SetRegion switches the console between NTSC, PAL and Dendy timing
+//
+// The PPU frame length, CPU/PPU clock ratio, APU frame counter timing and
+// real-time frame rate are all derived from the region, so they are always
+// changed together. Multi-region cartridges run as NTSC.
+func (n *NES) SetRegion(region cartridge.Region) {
+	if region == cartridge.RegionMulti {
+		region = cartridge.RegionNTSC
+	}
+	n.region = region
+	n.ppu.SetRegion(region)
+	n.bus.SetRegion(region)
+	n.apu.SetRegion(region)
+	n.apu.SetClockRate(n.CPUClockRate())
+}
+
+// GetRegion returns the console timing region in effect
+func (n *NES) GetRegion() cartridge.Region {
+	return n.region
+}
+
+// PowerOn power-cycles the console: CPU RAM is cleared and the CPU, PPU and
+// APU start from their power-on state. Mapper registers and PRG-RAM are
+// kept, as battery-backed saves would be.
+func (n *NES) PowerOn() {
+	n.poweredOn = true
+	n.bus.ClearRAM()
+	n.cpu.PowerOn()
+	n.apu.PowerOn()
+	n.restart()
+}
+
+// Reset presses the console's reset button: RAM and the CPU's A, X and Y
+// survive, SP drops by 3, and the APU is silenced. The first Reset of a new
+// NES powers it on instead (see PowerOn).
 func (n *NES) Reset() {
+	if !n.poweredOn {
+		n.PowerOn()
+		return
+	}
 	n.cpu.Reset()
+	n.apu.Reset()
+	n.restart()
+}
+
+// restart resets the PPU and the emulator's own counters, after a reset or
+// power cycle
+func (n *NES) restart() {
 	n.ppu.Reset()
+	if n.profiler != nil {
+		n.profiler.stop(n.cycles)
+	}
 	n.cycles = 0
+	n.haltLogged = false
+	n.lastFrame = n.ppu.GetFrameCount()
 }
 
 // Step executes one CPU cycle
 // Returns 1 (always consumes 1 CPU cycle)
 func (n *NES) Step() uint8 {
-	// Execute one CPU cycle
-	// The CPU's Step() method handles multi-cycle instructions internally
-	n.cpu.Step()
+	n.breakHit = false
+	n.cycle()
+	if n.breakHit {
+		n.finishBreak()
+	}
+	return 1
+}
+
+// cycle runs one CPU cycle of the whole system. While speculating (see
+// SetRunAhead) it leaves out the watchdog, tools, halt reporting and frame
+// delivery.
+func (n *NES) cycle() {
+	n.cpuCycle()
 
 	// Clock the bus once (which clocks PPU at 3x)
 	n.bus.Clock()
 
-	// Check for NMI from PPU
+	// Overclocking: extra CPU-only cycles while the PPU is idle, stopping
+	// at a breakpoint like the main cycle
+	if n.overclock.CPUMultiplier > 1 && !n.ppu.IsRendering() {
+		for i := 1; i < n.overclock.CPUMultiplier && !n.breakHit; i++ {
+			n.cpuCycle()
+			n.cycles++
+		}
+	}
+
+	// Check for NMI from PPU. An NMI raised during this cycle (including
+	// by a PPUCTRL write on the instruction's last cycle) is latched after
+	// the CPU's poll, so the earliest poll to see it is the next cycle's,
+	// as with the 6502's edge detector
 	if n.bus.IsNMI() {
-		n.cpu.NMIPending = true
+		n.cpu.TriggerNMI()
+		if n.watchdog != nil && !n.speculating {
+			n.watchdog.nmis++
+		}
+		if logging.Enabled(logging.CPU, logging.LevelTrace) && !n.speculating {
+			logging.Log(logging.CPU, logging.LevelTrace, "nmi", logging.Hex16("pc", n.cpu.GetPC()))
+		}
 	}
 
-	// Check for IRQ from mapper (e.g., MMC3 scanline counter)
-	if n.cartridge.GetMapper().IRQState() {
-		n.cpu.IRQPending = true
+	// Report (once) if the CPU jammed on STP or a trapped unstable opcode
+	if n.cpu.IsHalted() && !n.haltLogged && !n.speculating {
+		n.haltLogged = true
+		pc := n.cpu.InstructionPC()
+		logging.Log(logging.CPU, slog.LevelWarn, "cpu halted", logging.Hex16("pc", pc),
+			slog.String("opcode", cpu.Opcodes[n.bus.Peek(pc)].Mnemonic))
+	}
+
+	// IRQ is level-triggered: the line follows the mapper (e.g., MMC3
+	// scanline counter) and the APU until the handler acknowledges them
+	n.cpu.SetIRQ(n.cartridge.GetMapper().IRQState() || n.bus.IsIRQ())
+
+	// Publish the frame if the PPU just finished one
+	if frame := n.ppu.GetFrameCount(); frame != n.lastFrame && !n.speculating {
+		n.lastFrame = frame
+		if n.runAhead == nil || !n.inRunFrame {
+			// Within RunFrame, run-ahead publishes the frame it displays
+			n.deliverFrame(frame - 1)
+		}
+		if n.watchdog != nil {
+			n.endWatchdogFrame(frame - 1)
+		}
 	}
 
 	n.cycles++
-	return 1
+}
+
+// cpuCycle executes one CPU cycle, unless DMA has the CPU halted, and
+// runs the tools that watch instruction boundaries around it. The CPU's
+// Step() method handles multi-cycle instructions internally.
+func (n *NES) cpuCycle() {
+	if n.speculating {
+		if !n.bus.StallCycle() {
+			n.cpu.Step()
+		}
+		return
+	}
+	if n.watchdog != nil && n.cpu.AtBoundary() {
+		n.watchdog.observe(n.cpu.GetPC())
+	}
+	if n.bus.StallCycle() {
+		return
+	}
+	if n.cpu.AtBoundary() && !n.cpu.IsHalted() {
+		n.beginInstruction()
+	}
+	n.cpu.Step()
+	if n.cpu.AtBoundary() {
+		n.endInstruction()
+	}
+	if n.breakpoints != nil {
+		n.checkExecute()
+	}
 }
 
 // RunFrame runs the emulator until a complete frame is rendered
 // Returns when the PPU has finished rendering one frame (~29780 CPU cycles)
-func (n *NES) RunFrame() {
-	// Run until the PPU completes a frame
-	// The PPU sets frameComplete=true at the end of scanline 261
+//
+// The error is ErrBreakpoint if a breakpoint stopped the frame early (see
+// SetBreakpoint), or a *HaltError while the CPU is jammed or once the
+// watchdog finds it stuck. The frame still completes in the halted case:
+// the PPU and APU keep running.
+//
+// With run-ahead (see SetRunAhead) the frame left in the frame buffer is
+// the one emulated ahead, while the machine state stays at this frame.
+func (n *NES) RunFrame() error {
+	n.inRunFrame = true
+	err := n.runFrame()
+	n.inRunFrame = false
+	if n.runAhead != nil && n.ppu.IsFrameComplete() {
+		if err == nil {
+			n.lookAhead()
+		}
+		n.deliverFrame(n.lastFrame - 1)
+	}
+	return err
+}
 
-	// First, clear the frame complete flag
+// runFrame runs to the end of the frame, or to a breakpoint
+func (n *NES) runFrame() error {
+	// The PPU sets frameComplete=true at the end of scanline 261
 	n.ppu.ClearFrameComplete()
+	n.stuck = nil
 
-	// Run until a frame is complete
 	for !n.ppu.IsFrameComplete() {
 		n.Step()
+		if n.breakHit {
+			return ErrBreakpoint
+		}
 	}
+	return n.haltError()
 }
 
 // Clock executes one CPU cycle
@@ -111,14 +313,27 @@ func (n *NES) GetFrameBuffer() *[ppu.ScreenWidth * ppu.ScreenHeight]uint8 {
 	return n.ppu.GetFrameBuffer()
 }
 
+// GetFrameRGBA returns the current frame as packed RGBA pixels (see
+// ppu.PPU.GetFrameRGBA), in a buffer the next call overwrites
+func (n *NES) GetFrameRGBA() []uint32 {
+	return n.ppu.GetFrameRGBA()
+}
+
 // GetPPU returns a pointer to the PPU for direct access
 func (n *NES) GetPPU() *ppu.PPU {
 	return n.ppu
 }
 
-// GetCPU returns a pointer to the CPU for direct access
-func (n *NES) GetCPU() *mos6502.CPU {
-	return n.cpu
+// GetAPU returns a pointer to the APU for direct access
+func (n *NES) GetAPU() *apu.APU {
+	return n.apu
+}
+
+// GetCPU returns a pointer to the CPU for direct access, or nil when SetCPU
+// has replaced the built-in interpreter (see GetCPUCore)
+func (n *NES) GetCPU() *cpu.CPU {
+	c, _ := n.cpu.(*cpu.CPU)
+	return c
 }
 
 // GetBus returns a pointer to the system bus for direct access
@@ -0,0 +1,32 @@
+package nes
+
+// Overclock configures optional speed-ups that reduce slowdown and sprite
+// flicker in games that run out of CPU time (Gradius, Kirby's Adventure)
+//
+// Both settings only add CPU time outside of active rendering, so raster
+// effects, sprite 0 hits and mapper scanline IRQs keep their timing. Code that
+// counts CPU cycles across a whole frame can still misbehave, so overclocking
+// is off by default (the zero value) and meant to be enabled per game.
+type Overclock struct {
+	// ExtraScanlines is the number of idle scanlines added after the visible
+	// picture each frame. The CPU runs normally during them while the PPU
+	// draws nothing. Each line adds ~113 CPU cycles of game time.
+	ExtraScanlines int
+
+	// CPUMultiplier runs the CPU this many cycles per PPU-clocked cycle while
+	// the PPU is not rendering (post-render, overclock and VBlank lines).
+	// Values below 2 disable it. The extra cycles are ordinary CPU cycles:
+	// DMA stalls use them up, and tools see the instructions they run.
+	CPUMultiplier int
+}
+
+// SetOverclock applies overclocking settings, typically chosen per game
+func (n *NES) SetOverclock(oc Overclock) {
+	n.overclock = oc
+	n.ppu.SetExtraScanlines(oc.ExtraScanlines)
+}
+
+// GetOverclock returns the overclocking settings in effect
+func (n *NES) GetOverclock() Overclock {
+	return n.overclock
+}
@@ -0,0 +1,57 @@
+package nes
+
+import (
+	"testing"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testutil"
+)
+
+// TestOverclockDMAAndHooks runs an OAM DMA loop with the CPU multiplier on
+// (rendering is off, so every cycle is overclocked) and checks that the
+// DMA still halts the CPU and that the hooks see every instruction
+func TestOverclockDMAAndHooks(t *testing.T) {
+	rom := testutil.NewROM(0).
+		Code(0x8000, 0xA9, 0x02).       // LDA #$02
+		Code(0x8002, 0x8D, 0x14, 0x40). // STA $4014
+		Code(0x8005, 0x4C, 0x00, 0x80). // JMP $8000
+		Build()
+	cart, err := cartridge.LoadFromBytes(rom)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emulator := NewFromCartridge(cart)
+	emulator.SetHeadless(HeadlessMaxSpeed)
+	emulator.SetOverclock(Overclock{CPUMultiplier: 3})
+	emulator.Reset()
+
+	next := map[uint16]uint16{0x8000: 0x8002, 0x8002: 0x8005, 0x8005: 0x8000}
+	var lastPC uint16
+	var lastCycles uint64
+	befores, afters := 0, 0
+	emulator.SetInstructionHooks(InstructionHooks{
+		Before: func(s cpu.DebugState, cycles uint64) {
+			if befores > 0 {
+				if s.PC != next[lastPC] {
+					t.Fatalf("instruction at $%04X followed by $%04X", lastPC, s.PC)
+				}
+				// STA $4014 takes 4 cycles, then the DMA 513 or 514
+				if lastPC == 0x8002 && cycles-lastCycles < 4+513 {
+					t.Fatalf("CPU ran %d cycles after starting an OAM DMA", cycles-lastCycles)
+				}
+			}
+			befores++
+			lastPC, lastCycles = s.PC, cycles
+		},
+		After: func(cpu.DebugState, uint64) { afters++ },
+	})
+
+	for emulator.GetCycles() < 30000 {
+		emulator.Step()
+	}
+	if befores < 10 || afters < befores-1 || afters > befores {
+		t.Errorf("%d instructions began and %d completed", befores, afters)
+	}
+}
@@ -0,0 +1,152 @@
+package nes
+
+import (
+	"sort"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+)
+
+// ProfileEntry is the time spent at one instruction address
+type ProfileEntry struct {
+	Addr   uint16 // CPU address of the instruction
+	Bank   int    // PRG bank mapped at Addr, or -1 outside $8000-$FFFF
+	Count  uint64 // Times the instruction ran
+	Cycles uint64 // CPU cycles charged to it
+}
+
+// BankProfile is the time spent in one PRG bank
+type BankProfile struct {
+	Bank   int    // PRG bank, or -1 for RAM and PRG-RAM
+	Cycles uint64 // CPU cycles spent running code from it
+}
+
+// Profile is a snapshot of the execution profiler (see EnableProfiler)
+type Profile struct {
+	Entries []ProfileEntry // One per address and bank, hottest first
+	Cycles  uint64         // Total cycles profiled
+}
+
+// profileKey identifies code by address and the bank mapped there
+type profileKey struct {
+	addr uint16
+	bank int
+}
+
+// profiler is the state behind EnableProfiler
+type profiler struct {
+	counts  map[profileKey]*ProfileEntry
+	banks   cartridge.PRGBankMapper // Nil for mappers without PRG banking
+	current *ProfileEntry           // Instruction being charged
+	start   uint64                  // Cycle count when it began
+	total   uint64
+}
+
+// EnableProfiler starts counting the CPU cycles spent at every instruction
+// address, discarding any previous profile. Addresses in $8000-$FFFF are
+// also split by the PRG bank mapped there, for mappers that implement
+// cartridge.PRGBankMapper, so bank-switched code sharing an address is
+// counted separately.
+//
+// Each instruction is charged from its opcode fetch until the next one, so
+// DMA stalls land on the instruction they interrupted, and an interrupt's
+// seven cycles on the instruction it preempted.
+func (n *NES) EnableProfiler() {
+	p := &profiler{counts: make(map[profileKey]*ProfileEntry)}
+	p.banks, _ = n.cartridge.GetMapper().(cartridge.PRGBankMapper)
+	n.profiler = p
+}
+
+// DisableProfiler stops profiling and discards the counts
+func (n *NES) DisableProfiler() {
+	n.profiler = nil
+}
+
+// Profile returns the counts gathered since EnableProfiler, or an empty
+// profile when the profiler is off
+func (n *NES) Profile() Profile {
+	p := n.profiler
+	if p == nil {
+		return Profile{}
+	}
+
+	out := Profile{Entries: make([]ProfileEntry, 0, len(p.counts)), Cycles: p.total}
+	for _, e := range p.counts {
+		entry := *e
+		if e == p.current {
+			entry.Cycles += n.cycles - p.start
+			out.Cycles += n.cycles - p.start
+		}
+		out.Entries = append(out.Entries, entry)
+	}
+	sort.Slice(out.Entries, func(i, j int) bool {
+		a, b := out.Entries[i], out.Entries[j]
+		if a.Cycles != b.Cycles {
+			return a.Cycles > b.Cycles
+		}
+		if a.Bank != b.Bank {
+			return a.Bank < b.Bank
+		}
+		return a.Addr < b.Addr
+	})
+	return out
+}
+
+// begin charges the previous instruction and starts counting the one at pc
+func (p *profiler) begin(pc uint16, cycles uint64) {
+	p.stop(cycles)
+
+	key := profileKey{addr: pc, bank: -1}
+	if pc >= 0x8000 {
+		key.bank = 0
+		if p.banks != nil {
+			key.bank = p.banks.PRGBank(pc)
+		}
+	}
+	e := p.counts[key]
+	if e == nil {
+		e = &ProfileEntry{Addr: pc, Bank: key.bank}
+		p.counts[key] = e
+	}
+	e.Count++
+	p.current = e
+	p.start = cycles
+}
+
+// stop charges the instruction in progress, if any, up to cycles
+func (p *profiler) stop(cycles uint64) {
+	if p.current != nil {
+		p.current.Cycles += cycles - p.start
+		p.total += cycles - p.start
+		p.current = nil
+	}
+}
+
+// Top returns the n hottest entries (all of them if n <= 0)
+func (p Profile) Top(n int) []ProfileEntry {
+	if n <= 0 || n > len(p.Entries) {
+		return p.Entries
+	}
+	return p.Entries[:n]
+}
+
+// Banks totals the profile per PRG bank, in bank order
+func (p Profile) Banks() []BankProfile {
+	totals := make(map[int]uint64)
+	for _, e := range p.Entries {
+		totals[e.Bank] += e.Cycles
+	}
+	out := make([]BankProfile, 0, len(totals))
+	for bank, cycles := range totals {
+		out = append(out, BankProfile{Bank: bank, Cycles: cycles})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Bank < out[j].Bank })
+	return out
+}
+
+// Percent returns cycles as a share of the profiled total
+func (p Profile) Percent(cycles uint64) float64 {
+	if p.Cycles == 0 {
+		return 0
+	}
+	return 100 * float64(cycles) / float64(p.Cycles)
+}
@@ -0,0 +1,30 @@
+package nes
+
+// RenderMode selects how the PPU draws visible scanlines
+type RenderMode int
+
+const (
+	// RenderAccurate steps the PPU pipeline one dot at a time (the default)
+	RenderAccurate RenderMode = iota
+
+	// RenderFast draws each visible scanline in one pass when nothing
+	// touches the PPU or the mapper mid-line, falling back to single dots
+	// when something does. Picture, sprite 0 hits and overflow match
+	// RenderAccurate; only mapper IRQs clocked by background fetches can
+	// land up to a scanline late (see ppu.SetFastRender).
+	RenderFast
+)
+
+// SetRenderMode selects how the PPU draws visible scanlines. RenderFast
+// speeds up headless runs and slow machines.
+func (n *NES) SetRenderMode(mode RenderMode) {
+	n.ppu.SetFastRender(mode == RenderFast)
+}
+
+// GetRenderMode returns the render mode in effect
+func (n *NES) GetRenderMode() RenderMode {
+	if n.ppu.FastRender() {
+		return RenderFast
+	}
+	return RenderAccurate
+}
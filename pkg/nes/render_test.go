@@ -0,0 +1,205 @@
+package nes
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/testutil"
+)
+
+// rasterROM builds a program that draws a full screen of tiles from chr
+// with clustered sprites (more than eight on some lines, so overflow is
+// set), then each frame polls for sprite 0 and rewrites the scroll
+// mid-screen through $2005 and $2006, and $2001 after that. The frame
+// count at $10 scrolls the screen.
+func rasterROM(chr []byte) []byte {
+	return testutil.NewROM(0).
+		Code(0x8000, 0x78).             // SEI
+		Code(0x8001, 0xD8).             // CLD
+		Code(0x8002, 0xA2, 0xFF).       // LDX #$FF
+		Code(0x8004, 0x9A).             // TXS
+		Code(0x8005, 0xA9, 0x00).       // LDA #$00
+		Code(0x8007, 0x8D, 0x00, 0x20). // STA $2000
+		Code(0x800A, 0x8D, 0x01, 0x20). // STA $2001
+		// Two VBlanks for warm-up
+		Code(0x800D, 0x2C, 0x02, 0x20). // BIT $2002
+		Code(0x8010, 0x10, 0xFB).       // BPL $800D
+		Code(0x8012, 0x2C, 0x02, 0x20). // BIT $2002
+		Code(0x8015, 0x10, 0xFB).       // BPL $8012
+		// Palette entries 0, 1, 2, ...
+		Code(0x8017, 0xA9, 0x3F).       // LDA #$3F
+		Code(0x8019, 0x8D, 0x06, 0x20). // STA $2006
+		Code(0x801C, 0xA9, 0x00).       // LDA #$00
+		Code(0x801E, 0x8D, 0x06, 0x20). // STA $2006
+		Code(0x8021, 0xA2, 0x00).       // LDX #$00
+		Code(0x8023, 0x8E, 0x07, 0x20). // STX $2007
+		Code(0x8026, 0xE8).             // INX
+		Code(0x8027, 0xE0, 0x20).       // CPX #$20
+		Code(0x8029, 0xD0, 0xF8).       // BNE $8023
+		// Nametable and attribute bytes 0, 1, 2, ...
+		Code(0x802B, 0xA9, 0x20).       // LDA #$20
+		Code(0x802D, 0x8D, 0x06, 0x20). // STA $2006
+		Code(0x8030, 0xA9, 0x00).       // LDA #$00
+		Code(0x8032, 0x8D, 0x06, 0x20). // STA $2006
+		Code(0x8035, 0xA0, 0x08).       // LDY #$08
+		Code(0x8037, 0xA2, 0x00).       // LDX #$00
+		Code(0x8039, 0x8E, 0x07, 0x20). // STX $2007
+		Code(0x803C, 0xE8).             // INX
+		Code(0x803D, 0xD0, 0xFA).       // BNE $8039
+		Code(0x803F, 0x88).             // DEY
+		Code(0x8040, 0xD0, 0xF7).       // BNE $8039
+		// OAM page $0200: sprites 0-31 crowd the top lines, 32-63 spread out
+		Code(0x8042, 0xA2, 0x00).       // LDX #$00
+		Code(0x8044, 0x8A).             // TXA
+		Code(0x8045, 0xE0, 0x80).       // CPX #$80
+		Code(0x8047, 0x90, 0x04).       // BCC $804D
+		Code(0x8049, 0x49, 0xA5).       // EOR #$A5
+		Code(0x804B, 0xB0, 0x02).       // BCS $804F
+		Code(0x804D, 0x29, 0x1F).       // AND #$1F
+		Code(0x804F, 0x9D, 0x00, 0x02). // STA $0200,X
+		Code(0x8052, 0xE8).             // INX
+		Code(0x8053, 0xD0, 0xEF).       // BNE $8044
+		// Sprite 0 at (128, 97)
+		Code(0x8055, 0xA9, 0x60).       // LDA #$60
+		Code(0x8057, 0x8D, 0x00, 0x02). // STA $0200
+		Code(0x805A, 0xA9, 0x05).       // LDA #$05
+		Code(0x805C, 0x8D, 0x01, 0x02). // STA $0201
+		Code(0x805F, 0xA9, 0x00).       // LDA #$00
+		Code(0x8061, 0x8D, 0x02, 0x02). // STA $0202
+		Code(0x8064, 0xA9, 0x80).       // LDA #$80
+		Code(0x8066, 0x8D, 0x03, 0x02). // STA $0203
+		// Frame loop, from VBlank: OAM DMA, scroll, rendering on
+		Code(0x8069, 0xA9, 0x00).       // LDA #$00
+		Code(0x806B, 0x8D, 0x03, 0x20). // STA $2003
+		Code(0x806E, 0xA9, 0x02).       // LDA #$02
+		Code(0x8070, 0x8D, 0x14, 0x40). // STA $4014
+		Code(0x8073, 0xA5, 0x10).       // LDA $10
+		Code(0x8075, 0x8D, 0x05, 0x20). // STA $2005
+		Code(0x8078, 0xA9, 0x00).       // LDA #$00
+		Code(0x807A, 0x8D, 0x05, 0x20). // STA $2005
+		Code(0x807D, 0xA9, 0x08).       // LDA #$08 (sprites at $1000)
+		Code(0x807F, 0x8D, 0x00, 0x20). // STA $2000
+		Code(0x8082, 0xA9, 0x1E).       // LDA #$1E
+		Code(0x8084, 0x8D, 0x01, 0x20). // STA $2001
+		// Wait for last frame's sprite 0 hit to clear, then for this one
+		Code(0x8087, 0x2C, 0x02, 0x20). // BIT $2002
+		Code(0x808A, 0x70, 0xFB).       // BVS $8087
+		Code(0x808C, 0x2C, 0x02, 0x20). // BIT $2002
+		Code(0x808F, 0x50, 0xFB).       // BVC $808C
+		// Mid-screen scroll through $2005, then $2006 a few lines on
+		Code(0x8091, 0xA9, 0x37).       // LDA #$37
+		Code(0x8093, 0x8D, 0x05, 0x20). // STA $2005
+		Code(0x8096, 0xA9, 0x11).       // LDA #$11
+		Code(0x8098, 0x8D, 0x05, 0x20). // STA $2005
+		Code(0x809B, 0xA2, 0x40).       // LDX #$40
+		Code(0x809D, 0xCA).             // DEX
+		Code(0x809E, 0xD0, 0xFD).       // BNE $809D
+		Code(0x80A0, 0xA9, 0x24).       // LDA #$24
+		Code(0x80A2, 0x8D, 0x06, 0x20). // STA $2006
+		Code(0x80A5, 0xA9, 0xA3).       // LDA #$A3
+		Code(0x80A7, 0x8D, 0x06, 0x20). // STA $2006
+		Code(0x80AA, 0xA9, 0x1F).       // LDA #$1F (greyscale)
+		Code(0x80AC, 0x8D, 0x01, 0x20). // STA $2001
+		// Next VBlank
+		Code(0x80AF, 0x2C, 0x02, 0x20). // BIT $2002
+		Code(0x80B2, 0x10, 0xFB).       // BPL $80AF
+		Code(0x80B4, 0xE6, 0x10).       // INC $10
+		Code(0x80B6, 0x4C, 0x69, 0x80). // JMP $8069
+		CHR(0, chr...).
+		Build()
+}
+
+// renderPair loads image twice, once per render mode, with video on
+func renderPair(t *testing.T, image []byte) (accurate, fast *NES) {
+	t.Helper()
+	load := func(mode RenderMode) *NES {
+		cart, err := cartridge.LoadFromBytes(image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		emulator := NewFromCartridge(cart)
+		emulator.SetHeadless(HeadlessSilent)
+		emulator.SetRenderMode(mode)
+		emulator.Reset()
+		return emulator
+	}
+	return load(RenderAccurate), load(RenderFast)
+}
+
+// TestFastRenderMatchesAccurate checks that scanline rendering draws the
+// same pixels and leaves the same PPU state as the dot-by-dot pipeline,
+// with raster effects, sprite 0 hits and sprite overflow in play
+func TestFastRenderMatchesAccurate(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	chr := make([]byte, 8192)
+	rng.Read(chr)
+	accurate, fast := renderPair(t, rasterROM(chr))
+
+	const frames = 30
+	var hits, overflows int
+	for frame := 0; frame < frames; frame++ {
+		// Compare after each visible line is drawn, while the background
+		// shifters still hold what the line left in them
+		var status uint8
+		for line := 0; line < 240; line++ {
+			accurate.RunToCycle(line, 300)
+			fast.RunToCycle(line, 300)
+			if accurate.HashState(false) != fast.HashState(false) {
+				t.Fatalf("frame %d: states differ after line %d", frame, line)
+			}
+			status |= accurate.GetPPU().PeekCPURegister(0x2002)
+		}
+		if status&0x40 != 0 {
+			hits++
+		}
+		if status&0x20 != 0 {
+			overflows++
+		}
+
+		accurate.RunFrame()
+		fast.RunFrame()
+		if *accurate.GetPPU().GetFrameBuffer() != *fast.GetPPU().GetFrameBuffer() {
+			t.Fatalf("frame %d: pictures differ", frame)
+		}
+		if accurate.HashState(true) != fast.HashState(true) {
+			t.Fatalf("frame %d: states differ", frame)
+		}
+	}
+
+	// Make sure the program exercised what it is meant to
+	if hits < frames-3 || overflows < frames-3 {
+		t.Errorf("%d sprite 0 hits and %d overflows in %d frames", hits, overflows, frames)
+	}
+	// Setup and the two warm-up VBlanks take the first few frames
+	if count := accurate.GetBus().Read(0x10); count < frames-6 {
+		t.Errorf("program finished %d frames of %d", count, frames)
+	}
+}
+
+// TestFastRenderMatchesAccurateRandom runs random programs, which poke the
+// PPU and mappers at arbitrary points in the frame. MMC3 boards are left
+// out: their IRQ is clocked by fetches the fast renderer batches, which is
+// the documented difference between the modes.
+func TestFastRenderMatchesAccurateRandom(t *testing.T) {
+	for _, mapperID := range allMappers {
+		if mapperID == 4 || mapperID == 118 || mapperID == 119 {
+			continue
+		}
+		for seed := int64(0); seed < 4; seed++ {
+			accurate := randomSystem(t, mapperID, seed)
+			fast := randomSystem(t, mapperID, seed)
+			accurate.SetHeadless(HeadlessSilent)
+			fast.SetHeadless(HeadlessSilent)
+			fast.SetRenderMode(RenderFast)
+			for frame := 0; frame < 5; frame++ {
+				accurate.RunFrame()
+				fast.RunFrame()
+				if accurate.HashState(true) != fast.HashState(true) {
+					t.Errorf("mapper %d seed %d: frame %d differs", mapperID, seed, frame)
+					break
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package nes
+
+import (
+	"fmt"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// MaxRunAhead is the largest number of frames SetRunAhead accepts
+const MaxRunAhead = 8
+
+// runAheadState is the state behind SetRunAhead
+type runAheadState struct {
+	frames   int
+	base     *state.Writer // Save state of the real frame
+	frame    [ppu.ScreenWidth * ppu.ScreenHeight]uint8
+	emphasis [ppu.ScreenWidth * ppu.ScreenHeight]uint8
+}
+
+// SetRunAhead hides input lag by showing the future: after each RunFrame,
+// the emulator saves its state, emulates the given number of further
+// frames with the current input, keeps the last picture and restores the
+// state. Games that take a frame or more to react to a button press then
+// appear to react at once. Zero turns run-ahead off.
+//
+// Every frame is emulated frames+1 times, and input changes take effect
+// on the next RunFrame since the frames ahead are always recomputed. Only
+// the real frame produces audio and reaches the frame sink, watchdog,
+// breakpoints and other tools; the machine state between calls is always
+// the real one, so save states, RAM reads and Step behave as without
+// run-ahead. Frames completed by Step, StepInstruction, RunScanline or
+// RunToCycle get no look-ahead and reach the frame sink as emulated. A
+// game that reacts to input within the same frame gains nothing and may
+// show a frame it later contradicts (a dropped input reverts), so 1 or 2
+// frames is usually right.
+func (n *NES) SetRunAhead(frames int) error {
+	if frames < 0 || frames > MaxRunAhead {
+		return fmt.Errorf("nes: run-ahead of %d frames out of range 0-%d", frames, MaxRunAhead)
+	}
+	if frames == 0 {
+		n.runAhead = nil
+		return nil
+	}
+	if n.runAhead == nil {
+		n.runAhead = &runAheadState{base: state.NewWriter(160 * 1024)}
+	}
+	n.runAhead.frames = frames
+	return nil
+}
+
+// GetRunAhead returns the number of frames emulated ahead, 0 when off
+func (n *NES) GetRunAhead() int {
+	if n.runAhead == nil {
+		return 0
+	}
+	return n.runAhead.frames
+}
+
+// lookAhead emulates the run-ahead frames from the frame just completed,
+// then restores it with the last of them in the frame buffer
+func (n *NES) lookAhead() {
+	a := n.runAhead
+	a.base.Reset()
+	if err := n.writeSaveState(a.base); err != nil {
+		return // A CPU core that cannot save: show the real frame
+	}
+
+	n.speculating = true
+	n.apu.HoldOutput(true)
+	n.ppu.SetPixelOutput(false)
+	for i := 0; i < a.frames; i++ {
+		if i == a.frames-1 {
+			n.ppu.SetPixelOutput(!n.headless.NoVideo)
+		}
+		n.ppu.ClearFrameComplete()
+		for !n.ppu.IsFrameComplete() {
+			n.cycle()
+		}
+	}
+	a.frame = *n.ppu.GetFrameBuffer()
+	a.emphasis = *n.ppu.GetEmphasisBuffer()
+
+	if err := n.readState(a.base.Bytes()); err != nil {
+		panic(fmt.Sprintf("nes: restoring state after run-ahead: %v", err))
+	}
+	n.ppu.SetFrame(&a.frame, &a.emphasis)
+	n.apu.HoldOutput(false)
+	n.speculating = false
+}
@@ -0,0 +1,24 @@
+package nes
+
+import "testing"
+
+// TestRunAheadKeepsRealState checks on every mapper that run-ahead leaves
+// the machine in the same state as running the frames without it
+func TestRunAheadKeepsRealState(t *testing.T) {
+	for _, mapperID := range allMappers {
+		for seed := int64(0); seed < 2; seed++ {
+			plain := randomSystem(t, mapperID, seed)
+			ahead := randomSystem(t, mapperID, seed)
+			if err := ahead.SetRunAhead(2); err != nil {
+				t.Fatal(err)
+			}
+			for frame := 0; frame < 3; frame++ {
+				plain.RunFrame()
+				ahead.RunFrame()
+			}
+			if plain.HashState(false) != ahead.HashState(false) {
+				t.Errorf("mapper %d seed %d: run-ahead changed the emulated state", mapperID, seed)
+			}
+		}
+	}
+}
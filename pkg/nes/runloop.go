@@ -0,0 +1,110 @@
+package nes
+
+import (
+	"context"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/avsync"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
+)
+
+// Refresh rates in frames per second for each timing region
+const (
+	FrameRateNTSC  = 60.0988 // 1.789773 MHz CPU / 29780.5 cycles per frame
+	FrameRatePAL   = 50.0070 // 1.662607 MHz CPU / 33247.5 cycles per frame
+	FrameRateDendy = 50.0070 // 1.773448 MHz CPU / 35464 cycles per frame
+)
+
+// CPU clock rates in Hz for each timing region
+const (
+	CPUClockNTSC  = 1789773
+	CPUClockPAL   = 1662607
+	CPUClockDendy = 1773448
+)
+
+// FrameRate returns the real-time refresh rate of the current region
+func (n *NES) FrameRate() float64 {
+	switch n.region {
+	case cartridge.RegionPAL:
+		return FrameRatePAL
+	case cartridge.RegionDendy:
+		return FrameRateDendy
+	}
+	return FrameRateNTSC
+}
+
+// CPUClockRate returns the CPU clock frequency of the current region in Hz
+func (n *NES) CPUClockRate() float64 {
+	switch n.region {
+	case cartridge.RegionPAL:
+		return CPUClockPAL
+	case cartridge.RegionDendy:
+		return CPUClockDendy
+	}
+	return CPUClockNTSC
+}
+
+// RunOptions configures RunLoop
+type RunOptions struct {
+	// FrameRate overrides the emulated refresh rate in frames per second.
+	// Zero selects the rate of the console's timing region.
+	FrameRate float64
+
+	// Unthrottled disables the frame limiter and runs as fast as possible
+	Unthrottled bool
+
+	// OnFrame is called after every completed frame with the PPU frame buffer.
+	// The buffer is only valid until the callback returns.
+	OnFrame func(frame *[ppu.ScreenWidth * ppu.ScreenHeight]uint8)
+
+	// OnAudio is called after every completed frame with the audio samples
+	// generated during that frame, at the APU's sample rate. The slice is
+	// only valid until the callback returns.
+	OnAudio func(samples []float32)
+}
+
+// RunLoop runs the emulator at its real-time frame rate until ctx is cancelled
+//
+// Frames are paced by an avsync.Pacer: scheduled against the monotonic clock
+// so callback and rendering time does not accumulate as drift, and
+// re-anchored instead of fast-forwarding if emulation falls more than a few
+// frames behind (e.g. the process was suspended).
+//
+// RunLoop returns ctx.Err() once the context is cancelled, or RunFrame's
+// error: ErrBreakpoint when a breakpoint pauses emulation mid-frame, or a
+// *HaltError when the CPU halts. Calling it again resumes. Callbacks run on
+// the calling goroutine, between frames.
+func (n *NES) RunLoop(ctx context.Context, opts RunOptions) error {
+	rate := opts.FrameRate
+	if rate <= 0 {
+		rate = n.FrameRate()
+	}
+	pacer := avsync.NewPacer(rate)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := n.RunFrame(); err != nil {
+			return err
+		}
+
+		if opts.OnFrame != nil {
+			opts.OnFrame(n.GetFrameBuffer())
+		}
+		if opts.OnAudio != nil {
+			opts.OnAudio(n.apu.Samples())
+		}
+
+		if opts.Unthrottled {
+			continue
+		}
+
+		// Wait until this frame's slot on the schedule has elapsed
+		pacer.FrameDone()
+		if err := pacer.Wait(ctx); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package nes
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// ErrStateMismatch is returned by LoadState for a save state taken with
+// another ROM or console region
+var ErrStateMismatch = errors.New("nes: save state is for a different game or region")
+
+// saveStateMagic starts every save state ("GNES" in little-endian order)
+const saveStateMagic = 0x53454E47
+
+// saveStateVersion tags SaveState's layout, so states from another version
+// are rejected rather than misread
+const saveStateVersion = 1
+
+// SaveState captures the whole machine in a versioned binary blob: CPU
+// (down to the cycle of the instruction in flight), RAM, DMA, controllers,
+// PPU (including the partly drawn frame), APU and the mapper's registers,
+// RAM and expansion audio. Restoring it with LoadState resumes emulation
+// exactly where it left off.
+//
+// The blob is tagged with the ROM's CRC32 and the console region. Settings
+// (overclocking, headless mode, render mode, palette, hooks, breakpoints)
+// are not part of it, and audio samples already buffered are not rewound.
+func (n *NES) SaveState() ([]byte, error) {
+	w := state.NewWriter(160 * 1024)
+	if err := n.writeSaveState(w); err != nil {
+		return nil, err
+	}
+	return w.Bytes(), nil
+}
+
+// writeSaveState appends a save state to w
+func (n *NES) writeSaveState(w *state.Writer) error {
+	cpuState, err := n.cpu.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	n.ppuState, err = n.ppu.AppendBinary(n.ppuState[:0])
+	if err != nil {
+		return err
+	}
+
+	w.U32(saveStateMagic)
+	w.U8(saveStateVersion)
+	w.U32(n.cartridge.Info().CRC32)
+	w.U8(uint8(n.region))
+	w.U64(n.cycles)
+	w.Block(cpuState)
+	n.bus.WriteState(w)
+	w.Block(n.ppuState)
+	n.apu.WriteState(w)
+	n.cartridge.GetMapper().WriteState(w)
+	return nil
+}
+
+// LoadState restores a state from SaveState. A state that is corrupt, from
+// another version, or (ErrStateMismatch) from another ROM or region is
+// rejected and the machine is left as it was.
+func (n *NES) LoadState(data []byte) error {
+	backup, err := n.SaveState()
+	if err != nil {
+		return err
+	}
+	if err := n.readState(data); err != nil {
+		if restoreErr := n.readState(backup); restoreErr != nil {
+			panic(fmt.Sprintf("nes: restoring state after failed load: %v", restoreErr))
+		}
+		return err
+	}
+
+	n.poweredOn = true
+	n.haltLogged = n.cpu.IsHalted()
+	n.inInstruction = false
+	if n.profiler != nil {
+		n.profiler.current = nil // Its start cycle no longer applies
+	}
+	return nil
+}
+
+// readState decodes a save state over the machine, which may be left partly
+// restored on error
+func (n *NES) readState(data []byte) error {
+	r := state.NewReader(data)
+	if r.U32() != saveStateMagic {
+		return errors.New("nes: not a save state")
+	}
+	if v := r.U8(); v != saveStateVersion {
+		return fmt.Errorf("nes: unsupported save state version %d", v)
+	}
+	if r.U32() != n.cartridge.Info().CRC32 || r.U8() != uint8(n.region) {
+		return ErrStateMismatch
+	}
+
+	cycles := r.U64()
+	cpuState := r.Bytes()
+	n.bus.ReadState(r)
+	ppuState := r.Bytes()
+	n.apu.ReadState(r)
+	n.cartridge.GetMapper().ReadState(r)
+	if err := r.Err(); err != nil {
+		return fmt.Errorf("nes: %w", err)
+	}
+	if r.Remaining() != 0 {
+		return fmt.Errorf("nes: %d trailing bytes in save state", r.Remaining())
+	}
+	if err := n.cpu.UnmarshalBinary(cpuState); err != nil {
+		return err
+	}
+	if err := n.ppu.UnmarshalBinary(ppuState); err != nil {
+		return err
+	}
+
+	n.cycles = cycles
+	n.lastFrame = n.ppu.GetFrameCount()
+	return nil
+}
+
+// SaveStateFile writes a save state (see SaveState) to a file
+func (n *NES) SaveStateFile(path string) error {
+	data, err := n.SaveState()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadStateFile restores a save state written by SaveStateFile
+func (n *NES) LoadStateFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return n.LoadState(data)
+}
@@ -0,0 +1,26 @@
+package nes
+
+import (
+	"image"
+	"image/png"
+	"os"
+)
+
+// Screenshot returns the current frame as an image, converted with the
+// PPU's palette (see ppu.SetPalette) and the color emphasis of each pixel
+func (n *NES) Screenshot() image.Image {
+	return n.ppu.Image()
+}
+
+// SaveScreenshot writes the current frame to a PNG file
+func (n *NES) SaveScreenshot(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, n.Screenshot()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,141 @@
+package nes
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// NumSlots is the number of save state slots a SlotManager offers
+const NumSlots = 10
+
+// ErrEmptySlot is returned when loading a slot that has no save state
+var ErrEmptySlot = errors.New("nes: save slot is empty")
+
+// slotMagic starts every slot file ("GNSL" in little-endian order)
+const slotMagic = 0x4C534E47
+
+// slotVersion tags the slot file layout
+const slotVersion = 1
+
+// SlotInfo describes the save state in a slot
+type SlotInfo struct {
+	Slot  int
+	Time  time.Time // When the state was saved
+	Frame uint64    // PPU frame count at the time
+	CRC32 uint32    // CRC32 of the ROM it was taken with
+}
+
+// SlotManager keeps numbered save states (see SaveState) in files that
+// share a base path, game.ss0 to game.ss9 for the base "game". Each file
+// records when it was saved, the frame and the ROM, and states taken with
+// another ROM are refused.
+type SlotManager struct {
+	nes  *NES
+	base string
+}
+
+// NewSlotManager creates a slot manager for n storing its files at base,
+// usually the ROM path without its extension
+func NewSlotManager(n *NES, base string) *SlotManager {
+	return &SlotManager{nes: n, base: base}
+}
+
+// Path returns the file of a slot
+func (s *SlotManager) Path(slot int) string {
+	return fmt.Sprintf("%s.ss%d", s.base, slot)
+}
+
+// SaveSlot saves the machine's state to a slot, replacing what it held
+func (s *SlotManager) SaveSlot(slot int) (SlotInfo, error) {
+	if err := checkSlot(slot); err != nil {
+		return SlotInfo{}, err
+	}
+	data, err := s.nes.SaveState()
+	if err != nil {
+		return SlotInfo{}, err
+	}
+	info := SlotInfo{
+		Slot:  slot,
+		Time:  time.Now(),
+		Frame: s.nes.ppu.GetFrameCount(),
+		CRC32: s.nes.cartridge.Info().CRC32,
+	}
+
+	w := state.NewWriter(len(data) + 32)
+	w.U32(slotMagic)
+	w.U8(slotVersion)
+	w.U64(uint64(info.Time.UnixNano()))
+	w.U64(info.Frame)
+	w.U32(info.CRC32)
+	w.Block(data)
+	if err := os.WriteFile(s.Path(slot), w.Bytes(), 0o644); err != nil {
+		return SlotInfo{}, err
+	}
+	return info, nil
+}
+
+// LoadSlot restores the state saved in a slot. An empty slot returns
+// ErrEmptySlot and a state from another ROM ErrStateMismatch; either way
+// the machine is left as it was.
+func (s *SlotManager) LoadSlot(slot int) (SlotInfo, error) {
+	info, data, err := s.read(slot)
+	if err != nil {
+		return SlotInfo{}, err
+	}
+	if info.CRC32 != s.nes.cartridge.Info().CRC32 {
+		return SlotInfo{}, ErrStateMismatch
+	}
+	if err := s.nes.LoadState(data); err != nil {
+		return SlotInfo{}, err
+	}
+	return info, nil
+}
+
+// Info describes a slot's save state without loading it
+func (s *SlotManager) Info(slot int) (SlotInfo, error) {
+	info, _, err := s.read(slot)
+	return info, err
+}
+
+// read loads and decodes a slot file
+func (s *SlotManager) read(slot int) (SlotInfo, []byte, error) {
+	if err := checkSlot(slot); err != nil {
+		return SlotInfo{}, nil, err
+	}
+	file, err := os.ReadFile(s.Path(slot))
+	if errors.Is(err, os.ErrNotExist) {
+		return SlotInfo{}, nil, ErrEmptySlot
+	}
+	if err != nil {
+		return SlotInfo{}, nil, err
+	}
+
+	r := state.NewReader(file)
+	if r.U32() != slotMagic {
+		return SlotInfo{}, nil, fmt.Errorf("nes: %s is not a save slot", s.Path(slot))
+	}
+	if v := r.U8(); v != slotVersion {
+		return SlotInfo{}, nil, fmt.Errorf("nes: unsupported save slot version %d", v)
+	}
+	info := SlotInfo{Slot: slot}
+	info.Time = time.Unix(0, int64(r.U64()))
+	info.Frame = r.U64()
+	info.CRC32 = r.U32()
+	data := r.Bytes()
+	if err := r.Err(); err != nil {
+		return SlotInfo{}, nil, fmt.Errorf("nes: slot %d: %w", slot, err)
+	}
+	return info, data, nil
+}
+
+// checkSlot rejects slot numbers outside 0 to NumSlots-1
+func checkSlot(slot int) error {
+	if slot < 0 || slot >= NumSlots {
+		return fmt.Errorf("nes: save slot %d out of range 0-%d", slot, NumSlots-1)
+	}
+	return nil
+}
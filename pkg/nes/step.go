@@ -0,0 +1,120 @@
+package nes
+
+import (
+	"fmt"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
+)
+
+// InstructionHook receives the CPU registers and the total CPU cycle count
+type InstructionHook func(state cpu.DebugState, cycles uint64)
+
+// InstructionHooks are called around every instruction the CPU executes.
+// An interrupt sequence counts as an instruction: Before sees the PC it
+// interrupts, After the handler's address.
+type InstructionHooks struct {
+	Before InstructionHook // At the opcode fetch, before the instruction runs
+	After  InstructionHook // Once its last cycle has completed
+}
+
+// SetInstructionHooks installs hooks called before and after each
+// instruction, replacing any set before. Either may be nil.
+func (n *NES) SetInstructionHooks(hooks InstructionHooks) {
+	n.hooks = hooks
+}
+
+// StepInstruction runs the emulator until the CPU completes an instruction
+// (or the reset or stall cycles in progress) and the next one is about to
+// be fetched. It returns early if the CPU is halted or a breakpoint is hit.
+func (n *NES) StepInstruction() {
+	n.instructionDone = false
+	for !n.instructionDone && !n.cpu.IsHalted() {
+		n.Step()
+		if n.breakHit {
+			return
+		}
+	}
+}
+
+// RunScanline runs the emulator until the PPU starts its next scanline,
+// stopping on the first CPU cycle that ends past the line's last dot
+// (a CPU cycle covers three PPU dots, 3.2 on PAL). It returns
+// ErrBreakpoint if a breakpoint stops it first.
+func (n *NES) RunScanline() error {
+	_, last := n.ppu.Position()
+	for {
+		n.Step()
+		if n.breakHit {
+			return ErrBreakpoint
+		}
+		_, cycle := n.ppu.Position()
+		if cycle < last {
+			return nil
+		}
+		last = cycle
+	}
+}
+
+// RunToCycle runs the emulator until the PPU reaches a dot: scanline -1
+// (pre-render) to the region's last line, cycle 0-340. It stops on the
+// first CPU cycle that ends on or past the dot, so the PPU can be up to
+// two dots beyond it (see ppu.PPU.Position). If the PPU is already there,
+// it runs to the same dot of the next frame. It returns ErrBreakpoint if a
+// breakpoint stops it first.
+func (n *NES) RunToCycle(scanline, cycle int) error {
+	lines := n.ppu.DebugState().ScanlinesPerFrame
+	if scanline < -1 || scanline > lines-2 || cycle < 0 || cycle >= ppu.CyclesPerScanline {
+		return fmt.Errorf("nes: PPU position (%d, %d) outside scanlines -1 to %d, cycles 0 to %d",
+			scanline, cycle, lines-2, ppu.CyclesPerScanline-1)
+	}
+
+	// at reports whether the PPU is on the line, at or past the dot
+	at := func(line, dot int) bool { return line == scanline && dot >= cycle }
+	line, dot := n.ppu.Position()
+	was := at(line, dot)
+	for {
+		n.Step()
+		if n.breakHit {
+			return ErrBreakpoint
+		}
+		prevLine, prevDot := line, dot
+		line, dot = n.ppu.Position()
+		now := at(line, dot)
+		// Entering the dot, or leaving its line without reaching it
+		// (only when the line ends within the last CPU cycle's dots)
+		if now && !was || prevLine == scanline && prevDot < cycle && line != scanline {
+			return nil
+		}
+		was = now
+	}
+}
+
+// beginInstruction runs at an instruction boundary, before the CPU fetches
+func (n *NES) beginInstruction() {
+	if n.trace != nil {
+		fmt.Fprintln(n.trace, n.TraceLine())
+	}
+	if n.profiler != nil {
+		n.profiler.begin(n.cpu.GetPC(), n.cycles)
+	}
+	if n.cdl != nil {
+		n.cdl.instruction(n.bus.Peek, n.cpu.GetPC())
+	}
+	if n.hooks.Before != nil {
+		n.hooks.Before(n.cpu.DebugState(), n.cycles)
+	}
+	n.inInstruction = true
+}
+
+// endInstruction runs when the CPU reaches an instruction boundary, after
+// an instruction or idle cycles
+func (n *NES) endInstruction() {
+	n.instructionDone = true
+	if n.inInstruction {
+		n.inInstruction = false
+		if n.hooks.After != nil {
+			n.hooks.After(n.cpu.DebugState(), n.cycles+1)
+		}
+	}
+}
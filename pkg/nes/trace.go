@@ -0,0 +1,43 @@
+package nes
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+)
+
+// EnableTrace writes a TraceLine to w before every instruction the CPU
+// executes, so runs can be diffed against nestest.log or another
+// emulator's trace. Lines are written unbuffered; wrap w in a bufio.Writer
+// for long traces (and flush it). Pass nil to stop tracing.
+func (n *NES) EnableTrace(w io.Writer) {
+	n.trace = w
+}
+
+// TraceLine formats the instruction about to execute as a line of a
+// Nintendulator/nestest.log style trace:
+//
+//	C000  4C F5 C5  JMP $C5F5                       A:00 X:00 Y:00 P:24 SP:FD PPU:  0, 21 CYC:7
+//
+// Unofficial opcodes are marked with '*' before the mnemonic. The pre-render
+// scanline is printed as 261, as in Nintendulator.
+func (n *NES) TraceLine() string {
+	regs := n.cpu.DebugState()
+	video := n.ppu.DebugState()
+	in := cpu.Decode(n.bus.Peek, regs.PC)
+
+	mark := ' '
+	if !in.Info.Official {
+		mark = '*'
+	}
+	scanline := video.Scanline
+	if scanline < 0 {
+		scanline += video.ScanlinesPerFrame
+	}
+
+	return fmt.Sprintf("%04X  %-9s%c%-32sA:%02X X:%02X Y:%02X P:%02X SP:%02X PPU:%3d,%3d CYC:%d",
+		regs.PC, in.Bytes(), mark, in.Annotate(regs, n.bus.Peek),
+		regs.A, regs.X, regs.Y, regs.P, regs.SP,
+		scanline, video.Cycle, n.cycles)
+}
@@ -0,0 +1,136 @@
+package nes
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cpu"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
+)
+
+// Watchdog defaults
+const (
+	DefaultWatchdogFrames    = 120
+	DefaultWatchdogLoopBytes = 16
+)
+
+// Watchdog detects a frozen game: the CPU spinning at one PC or in a tiny
+// loop while no NMI is taken, for several frames in a row
+//
+// Waiting for VBlank in a short loop is normal, so a frame only counts as
+// stuck if no NMI was serviced during it. Polling $2002 with NMI disabled
+// also looks stuck, but only for a frame or two, well under the default.
+// A CPU jammed by STP is always stuck.
+type Watchdog struct {
+	// Frames is how many consecutive stuck frames trigger an event.
+	// Zero selects DefaultWatchdogFrames.
+	Frames int
+
+	// LoopBytes is the largest span of PC values still considered a tiny
+	// loop. Zero selects DefaultWatchdogLoopBytes.
+	LoopBytes int
+
+	// OnStuck is called once per freeze, when it is detected. A new event
+	// is only raised after the CPU has made progress again. The event is
+	// also logged to the cpu category at Warn level.
+	OnStuck func(StuckEvent)
+}
+
+// StuckEvent describes a detected freeze
+type StuckEvent struct {
+	Frame       uint64         // PPU frame at detection
+	Frames      int            // Consecutive stuck frames
+	LoopStart   uint16         // Lowest PC executed in the last frame
+	LoopEnd     uint16         // Highest PC executed in the last frame
+	Disassembly []string       // The loop body, one instruction per line
+	CPU         cpu.DebugState // Registers at detection
+	PPU         ppu.DebugState // PPU state at detection
+}
+
+// watchdogState is the per-frame tracking behind Watchdog
+type watchdogState struct {
+	config    Watchdog
+	minPC     uint16
+	maxPC     uint16
+	seen      bool // An instruction was fetched this frame
+	nmis      int  // NMIs raised this frame
+	stuck     int  // Consecutive stuck frames
+	triggered bool // Event already raised for this freeze
+}
+
+// SetWatchdog enables the stuck-CPU watchdog (nil disables it)
+func (n *NES) SetWatchdog(w *Watchdog) {
+	if w == nil {
+		n.watchdog = nil
+		return
+	}
+	config := *w
+	if config.Frames <= 0 {
+		config.Frames = DefaultWatchdogFrames
+	}
+	if config.LoopBytes <= 0 {
+		config.LoopBytes = DefaultWatchdogLoopBytes
+	}
+	n.watchdog = &watchdogState{config: config}
+}
+
+// observe records the PC of an instruction about to be fetched
+func (w *watchdogState) observe(pc uint16) {
+	if !w.seen {
+		w.minPC, w.maxPC, w.seen = pc, pc, true
+		return
+	}
+	w.minPC = min(w.minPC, pc)
+	w.maxPC = max(w.maxPC, pc)
+}
+
+// endFrame classifies the frame just completed and raises an event when
+// the stuck threshold is reached
+func (n *NES) endWatchdogFrame(frame uint64) {
+	w := n.watchdog
+	progress := w.nmis > 0 && !n.cpu.IsHalted()
+	stuck := w.seen && !progress && int(w.maxPC-w.minPC) < w.config.LoopBytes
+	if stuck {
+		w.stuck++
+	} else {
+		w.stuck = 0
+		w.triggered = false
+	}
+
+	if stuck && !w.triggered && w.stuck >= w.config.Frames {
+		w.triggered = true
+		event := n.stuckEvent(frame)
+		n.stuck = &event
+		logging.Log(logging.CPU, slog.LevelWarn, "cpu stuck",
+			logging.Hex16("pc", event.CPU.PC),
+			logging.Hex16("loop_start", event.LoopStart),
+			logging.Hex16("loop_end", event.LoopEnd),
+			slog.Int("frames", event.Frames))
+		if w.config.OnStuck != nil {
+			w.config.OnStuck(event)
+		}
+	}
+
+	w.seen = false
+	w.nmis = 0
+}
+
+// stuckEvent builds the event for the current freeze
+func (n *NES) stuckEvent(frame uint64) StuckEvent {
+	w := n.watchdog
+	event := StuckEvent{
+		Frame:     frame,
+		Frames:    w.stuck,
+		LoopStart: w.minPC,
+		LoopEnd:   w.maxPC,
+		CPU:       n.cpu.DebugState(),
+		PPU:       n.ppu.DebugState(),
+	}
+	for pc := uint32(w.minPC); pc <= uint32(w.maxPC); {
+		in := cpu.Decode(n.bus.Peek, uint16(pc))
+		event.Disassembly = append(event.Disassembly, fmt.Sprintf("%04X  %-9s %s", in.Addr, in.Bytes(), in))
+		pc += uint32(in.Size())
+	}
+	return event
+}
@@ -0,0 +1,157 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+)
+
+// bpsFooterSize is the three trailing CRC32s: source, target and patch
+const bpsFooterSize = 12
+
+// maxTargetSize bounds the output of a BPS patch, well above the largest
+// real cartridge
+const maxTargetSize = 16 << 20
+
+// BPS actions, in the low two bits of each command
+const (
+	bpsSourceRead = iota
+	bpsTargetRead
+	bpsSourceCopy
+	bpsTargetCopy
+)
+
+// ApplyBPS applies a BPS patch to rom
+//
+// The source, target and patch CRC32s are all verified, so a patch made
+// for a different dump fails with ErrChecksum instead of producing a
+// corrupt ROM.
+func ApplyBPS(rom, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, bpsMagic) {
+		return nil, ErrUnknownFormat
+	}
+	if len(patch) < len(bpsMagic)+bpsFooterSize {
+		return nil, fmt.Errorf("%w: BPS too short", ErrBadPatch)
+	}
+
+	footer := patch[len(patch)-bpsFooterSize:]
+	sourceCRC := binary.LittleEndian.Uint32(footer[0:])
+	targetCRC := binary.LittleEndian.Uint32(footer[4:])
+	patchCRC := binary.LittleEndian.Uint32(footer[8:])
+	if crc32.ChecksumIEEE(patch[:len(patch)-4]) != patchCRC {
+		return nil, fmt.Errorf("%w: BPS patch file is corrupt", ErrChecksum)
+	}
+	if crc32.ChecksumIEEE(rom) != sourceCRC {
+		return nil, fmt.Errorf("%w: patch is for a different ROM (CRC32 %08X, ROM is %08X)",
+			ErrChecksum, sourceCRC, crc32.ChecksumIEEE(rom))
+	}
+
+	r := bpsReader{data: patch[:len(patch)-bpsFooterSize], pos: len(bpsMagic)}
+	sourceSize := r.number()
+	targetSize := r.number()
+	metadataSize := r.number()
+	if r.err != nil || sourceSize != uint64(len(rom)) || targetSize > maxTargetSize {
+		return nil, fmt.Errorf("%w: BPS header", ErrBadPatch)
+	}
+	if metadataSize > uint64(len(r.data)-r.pos) {
+		return nil, fmt.Errorf("%w: BPS metadata truncated", ErrBadPatch)
+	}
+	r.pos += int(metadataSize)
+
+	out := make([]byte, targetSize)
+	var outPos, sourceRel, targetRel int
+	for r.pos < len(r.data) {
+		cmd := r.number()
+		length := int(cmd>>2) + 1
+		if r.err != nil || length > len(out)-outPos {
+			return nil, fmt.Errorf("%w: BPS action overruns target", ErrBadPatch)
+		}
+
+		switch cmd & 3 {
+		case bpsSourceRead:
+			if outPos+length > len(rom) {
+				return nil, fmt.Errorf("%w: BPS source read past end", ErrBadPatch)
+			}
+			copy(out[outPos:], rom[outPos:outPos+length])
+
+		case bpsTargetRead:
+			if length > len(r.data)-r.pos {
+				return nil, fmt.Errorf("%w: BPS data truncated", ErrBadPatch)
+			}
+			copy(out[outPos:], r.data[r.pos:r.pos+length])
+			r.pos += length
+
+		case bpsSourceCopy:
+			sourceRel += r.offset()
+			if r.err != nil || sourceRel < 0 || sourceRel+length > len(rom) {
+				return nil, fmt.Errorf("%w: BPS source copy out of range", ErrBadPatch)
+			}
+			copy(out[outPos:], rom[sourceRel:sourceRel+length])
+			sourceRel += length
+
+		case bpsTargetCopy:
+			targetRel += r.offset()
+			if r.err != nil || targetRel < 0 || targetRel >= outPos {
+				return nil, fmt.Errorf("%w: BPS target copy out of range", ErrBadPatch)
+			}
+			// Byte at a time: the source may overlap the bytes being written
+			for i := 0; i < length; i++ {
+				out[outPos+i] = out[targetRel+i]
+			}
+			targetRel += length
+		}
+		outPos += length
+	}
+
+	if outPos != len(out) {
+		return nil, fmt.Errorf("%w: BPS output %d bytes short", ErrBadPatch, len(out)-outPos)
+	}
+	if crc32.ChecksumIEEE(out) != targetCRC {
+		return nil, fmt.Errorf("%w: patched ROM does not match target", ErrChecksum)
+	}
+	return out, nil
+}
+
+// bpsReader decodes BPS variable-length numbers, recording the first error
+type bpsReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+// number reads a BPS varint: 7 bits per byte, low first, with the high bit
+// marking the last byte and each continuation biased by one
+func (r *bpsReader) number() uint64 {
+	var n, shift uint64 = 0, 1
+	for i := 0; i < 10; i++ {
+		if r.pos >= len(r.data) {
+			r.err = ErrBadPatch
+			return 0
+		}
+		b := r.data[r.pos]
+		r.pos++
+		n += uint64(b&0x7F) * shift
+		if b&0x80 != 0 {
+			return n
+		}
+		shift <<= 7
+		n += shift
+	}
+	r.err = ErrBadPatch
+	return 0
+}
+
+// offset reads a signed copy offset: magnitude in the upper bits, sign in
+// bit 0
+func (r *bpsReader) offset() int {
+	n := r.number()
+	if n>>1 > maxTargetSize {
+		r.err = ErrBadPatch
+		return 0
+	}
+	if n&1 != 0 {
+		return -int(n >> 1)
+	}
+	return int(n >> 1)
+}
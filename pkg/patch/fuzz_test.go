@@ -0,0 +1,60 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+)
+
+// FuzzApply checks that malformed patches are rejected with one of the
+// package's errors instead of panicking, and that the ROM is never
+// modified in place
+func FuzzApply(f *testing.F) {
+	rom := testROM(64)
+	want := append([]byte("hi"), rom[2:]...)
+	want = append(want, bytes.Repeat([]byte{'!'}, 8)...)
+
+	f.Add(ips(ipsRecord(2, 0xAA, 0xBB), ipsRLE(70, 4, 0x55), ipsEOF, []byte{0, 0, 40}))
+	f.Add(ips(ipsRecord(0x454F46, 1), ipsEOF))
+	f.Add(ips(ipsRLE(0, 0, 0)))
+	f.Add(newBPS(len(rom), len(want), "meta").
+		action(bpsTargetRead, 2).bytes('h', 'i').
+		action(bpsSourceCopy, len(rom)-2).offset(2).
+		action(bpsTargetRead, 1).bytes('!').
+		action(bpsTargetCopy, 7).offset(len(rom)).
+		finish(rom, want))
+	f.Add(newBPS(len(rom), 4, "").action(bpsSourceRead, 4).finish(rom, rom[:4]))
+	f.Add(newBPS(len(rom), 4, "").action(bpsTargetCopy, 4).offset(-1).finish(rom, rom[:4]))
+	f.Add([]byte("BPS1"))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		check := func(data []byte) {
+			orig := append([]byte(nil), rom...)
+			out, err := Apply(rom, data)
+			if err != nil {
+				if !errors.Is(err, ErrUnknownFormat) && !errors.Is(err, ErrBadPatch) && !errors.Is(err, ErrChecksum) {
+					t.Fatalf("untyped patch error: %v", err)
+				}
+				if out != nil {
+					t.Fatalf("output returned with error %v", err)
+				}
+			}
+			if !bytes.Equal(rom, orig) {
+				t.Fatal("ROM modified in place")
+			}
+		}
+		check(data)
+
+		// Mutated BPS patches almost never pass the CRC checks, so fix the
+		// source and patch CRCs up to reach the action decoder as well
+		if bytes.HasPrefix(data, bpsMagic) && len(data) >= len(bpsMagic)+bpsFooterSize {
+			fixed := append([]byte(nil), data...)
+			footer := fixed[len(fixed)-bpsFooterSize:]
+			binary.LittleEndian.PutUint32(footer[0:], crc32.ChecksumIEEE(rom))
+			binary.LittleEndian.PutUint32(footer[8:], crc32.ChecksumIEEE(fixed[:len(fixed)-4]))
+			check(fixed)
+		}
+	})
+}
@@ -0,0 +1,65 @@
+package patch
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ApplyIPS applies an IPS patch to rom
+//
+// Records past the end of rom grow the output. The optional truncation
+// extension (a 24-bit size after the EOF marker) is honoured.
+func ApplyIPS(rom, patch []byte) ([]byte, error) {
+	if !bytes.HasPrefix(patch, ipsMagic) {
+		return nil, ErrUnknownFormat
+	}
+
+	out := append([]byte(nil), rom...)
+	p := patch[len(ipsMagic):]
+	for {
+		if len(p) < 3 {
+			return nil, fmt.Errorf("%w: IPS missing EOF marker", ErrBadPatch)
+		}
+		if string(p[:3]) == "EOF" {
+			p = p[3:]
+			break
+		}
+		if len(p) < 5 {
+			return nil, fmt.Errorf("%w: IPS record truncated", ErrBadPatch)
+		}
+		offset := int(p[0])<<16 | int(p[1])<<8 | int(p[2])
+		size := int(p[3])<<8 | int(p[4])
+		p = p[5:]
+
+		var data []byte
+		if size == 0 {
+			// RLE record: 16-bit count and a fill byte
+			if len(p) < 3 {
+				return nil, fmt.Errorf("%w: IPS RLE record truncated", ErrBadPatch)
+			}
+			size = int(p[0])<<8 | int(p[1])
+			data = bytes.Repeat(p[2:3], size)
+			p = p[3:]
+		} else {
+			if len(p) < size {
+				return nil, fmt.Errorf("%w: IPS record at $%06X truncated", ErrBadPatch, offset)
+			}
+			data = p[:size]
+			p = p[size:]
+		}
+
+		if end := offset + size; end > len(out) {
+			out = append(out, make([]byte, end-len(out))...)
+		}
+		copy(out[offset:], data)
+	}
+
+	// Truncation extension
+	if len(p) >= 3 {
+		size := int(p[0])<<16 | int(p[1])<<8 | int(p[2])
+		if size < len(out) {
+			out = out[:size]
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,42 @@
+// Package patch applies IPS and BPS patches to ROM images, so translations
+// and ROM hacks distributed as patches can be loaded without external tools.
+//
+// Patches are applied to the complete .nes file, header included, which is
+// how both formats are conventionally distributed for the NES.
+package patch
+
+import (
+	"bytes"
+	"errors"
+)
+
+// Sentinel errors returned (wrapped) by Apply. Use errors.Is to test for
+// them; the wrapped message carries the specifics.
+var (
+	// ErrUnknownFormat means the data is neither an IPS nor a BPS patch
+	ErrUnknownFormat = errors.New("unknown patch format")
+
+	// ErrBadPatch means the patch is truncated or malformed
+	ErrBadPatch = errors.New("malformed patch")
+
+	// ErrChecksum means a BPS checksum did not match, usually because the
+	// patch was made for a different dump of the game
+	ErrChecksum = errors.New("patch checksum mismatch")
+)
+
+var (
+	ipsMagic = []byte("PATCH")
+	bpsMagic = []byte("BPS1")
+)
+
+// Apply returns rom with patch applied, detecting the format from the
+// patch's magic bytes. rom is not modified.
+func Apply(rom, patch []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(patch, ipsMagic):
+		return ApplyIPS(rom, patch)
+	case bytes.HasPrefix(patch, bpsMagic):
+		return ApplyBPS(rom, patch)
+	}
+	return nil, ErrUnknownFormat
+}
@@ -0,0 +1,331 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"testing"
+)
+
+// testROM returns n bytes that differ from their neighbours
+func testROM(n int) []byte {
+	rom := make([]byte, n)
+	for i := range rom {
+		rom[i] = uint8(i * 7)
+	}
+	return rom
+}
+
+// ipsRecord encodes a literal IPS record
+func ipsRecord(offset int, data ...byte) []byte {
+	rec := []byte{uint8(offset >> 16), uint8(offset >> 8), uint8(offset), uint8(len(data) >> 8), uint8(len(data))}
+	return append(rec, data...)
+}
+
+// ipsRLE encodes an IPS RLE record
+func ipsRLE(offset, count int, fill byte) []byte {
+	return []byte{uint8(offset >> 16), uint8(offset >> 8), uint8(offset), 0, 0, uint8(count >> 8), uint8(count), fill}
+}
+
+// ips joins records, the EOF marker and any trailer into a patch
+func ips(records ...[]byte) []byte {
+	p := append([]byte(nil), ipsMagic...)
+	for _, rec := range records {
+		p = append(p, rec...)
+	}
+	return p
+}
+
+var ipsEOF = []byte("EOF")
+
+func TestApplyIPS(t *testing.T) {
+	rom := testROM(16)
+	for _, tc := range []struct {
+		name  string
+		patch []byte
+		want  func([]byte) []byte
+	}{
+		{"empty", ips(ipsEOF), func(b []byte) []byte { return b }},
+		{"record", ips(ipsRecord(2, 0xAA, 0xBB), ipsEOF), func(b []byte) []byte {
+			b[2], b[3] = 0xAA, 0xBB
+			return b
+		}},
+		{"rle", ips(ipsRLE(4, 3, 0x55), ipsEOF), func(b []byte) []byte {
+			b[4], b[5], b[6] = 0x55, 0x55, 0x55
+			return b
+		}},
+		{"later record wins", ips(ipsRLE(0, 4, 0x11), ipsRecord(1, 0x22), ipsEOF), func(b []byte) []byte {
+			b[0], b[1], b[2], b[3] = 0x11, 0x22, 0x11, 0x11
+			return b
+		}},
+		{"grows past end", ips(ipsRecord(18, 0xCC), ipsEOF), func(b []byte) []byte {
+			return append(b, 0, 0, 0xCC)
+		}},
+		{"rle grows past end", ips(ipsRLE(15, 3, 0x99), ipsEOF), func(b []byte) []byte {
+			b[15] = 0x99
+			return append(b, 0x99, 0x99)
+		}},
+		{"truncation", ips(ipsEOF, []byte{0, 0, 10}), func(b []byte) []byte { return b[:10] }},
+		{"truncation never grows", ips(ipsEOF, []byte{0, 1, 0}), func(b []byte) []byte { return b }},
+		{"short trailer ignored", ips(ipsEOF, []byte{0, 0}), func(b []byte) []byte { return b }},
+		// A record at offset $454F46 reads as the EOF marker, which is why
+		// real patchers avoid that offset
+		{"eof offset", ips(ipsRecord(0x454F46, 1), ipsEOF), func(b []byte) []byte { return b }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			orig := append([]byte(nil), rom...)
+			got, err := Apply(rom, tc.patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := tc.want(append([]byte(nil), rom...)); !bytes.Equal(got, want) {
+				t.Errorf("got % X\nwant % X", got, want)
+			}
+			if !bytes.Equal(rom, orig) {
+				t.Error("ROM modified in place")
+			}
+		})
+	}
+}
+
+func TestApplyIPSMalformed(t *testing.T) {
+	rom := testROM(16)
+	for _, tc := range []struct {
+		name  string
+		patch []byte
+	}{
+		{"no records", ips()},
+		{"missing EOF", ips(ipsRecord(0, 1))},
+		{"partial EOF", ips([]byte("EO"))},
+		{"record header truncated", ips([]byte{0, 0, 1, 0})},
+		{"record data truncated", ips(ipsRecord(0, 1, 2, 3)[:7])},
+		{"rle truncated", ips(ipsRLE(0, 4, 0xFF)[:7])},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, err := ApplyIPS(rom, tc.patch); !errors.Is(err, ErrBadPatch) {
+				t.Errorf("got %d bytes, %v; want ErrBadPatch", len(got), err)
+			}
+		})
+	}
+}
+
+// bpsBuilder writes BPS patches for tests
+type bpsBuilder struct{ buf []byte }
+
+// newBPS starts a patch with its header
+func newBPS(sourceSize, targetSize int, metadata string) *bpsBuilder {
+	b := &bpsBuilder{buf: append([]byte(nil), bpsMagic...)}
+	b.number(uint64(sourceSize))
+	b.number(uint64(targetSize))
+	b.number(uint64(len(metadata)))
+	b.buf = append(b.buf, metadata...)
+	return b
+}
+
+// number is the inverse of bpsReader.number
+func (b *bpsBuilder) number(n uint64) *bpsBuilder {
+	for {
+		x := uint8(n & 0x7F)
+		n >>= 7
+		if n == 0 {
+			b.buf = append(b.buf, 0x80|x)
+			return b
+		}
+		b.buf = append(b.buf, x)
+		n--
+	}
+}
+
+// offset is the inverse of bpsReader.offset
+func (b *bpsBuilder) offset(n int) *bpsBuilder {
+	if n < 0 {
+		return b.number(uint64(-n)<<1 | 1)
+	}
+	return b.number(uint64(n) << 1)
+}
+
+func (b *bpsBuilder) action(kind, length int) *bpsBuilder {
+	return b.number(uint64(length-1)<<2 | uint64(kind))
+}
+
+func (b *bpsBuilder) bytes(data ...byte) *bpsBuilder {
+	b.buf = append(b.buf, data...)
+	return b
+}
+
+// finish appends the footer with the CRC32s of source, target and patch
+func (b *bpsBuilder) finish(source, target []byte) []byte {
+	p := binary.LittleEndian.AppendUint32(b.buf, crc32.ChecksumIEEE(source))
+	p = binary.LittleEndian.AppendUint32(p, crc32.ChecksumIEEE(target))
+	return binary.LittleEndian.AppendUint32(p, crc32.ChecksumIEEE(p))
+}
+
+func TestBPSNumber(t *testing.T) {
+	for _, tc := range []struct {
+		data []byte
+		want uint64
+	}{
+		{[]byte{0x80}, 0},
+		{[]byte{0xFF}, 127},
+		{[]byte{0x00, 0x80}, 128},
+		{[]byte{0x7F, 0x80}, 255},
+		{[]byte{0x00, 0x81}, 256},
+		{[]byte{0x7F, 0xFF}, 16511},
+		{[]byte{0x00, 0x00, 0x80}, 16512},
+	} {
+		r := bpsReader{data: tc.data}
+		if got := r.number(); got != tc.want || r.err != nil || r.pos != len(tc.data) {
+			t.Errorf("% X: got %d, %v at %d; want %d", tc.data, got, r.err, r.pos, tc.want)
+		}
+	}
+
+	for _, n := range []uint64{0, 1, 127, 128, 16511, 16512, 1 << 21, 1<<40 + 3} {
+		var b bpsBuilder
+		r := bpsReader{data: b.number(n).buf}
+		if got := r.number(); got != n || r.err != nil {
+			t.Errorf("round trip %d: got %d, %v", n, got, r.err)
+		}
+	}
+	for _, n := range []int{0, 1, -1, 200, -200, maxTargetSize} {
+		var b bpsBuilder
+		r := bpsReader{data: b.offset(n).buf}
+		if got := r.offset(); got != n || r.err != nil {
+			t.Errorf("round trip offset %d: got %d, %v", n, got, r.err)
+		}
+	}
+
+	for _, data := range [][]byte{
+		nil,
+		{0x00},           // unterminated
+		make([]byte, 11), // too long
+	} {
+		r := bpsReader{data: data}
+		if r.number(); r.err == nil {
+			t.Errorf("% X: no error", data)
+		}
+	}
+	var b bpsBuilder
+	r := bpsReader{data: b.offset(maxTargetSize + 1).buf}
+	if r.offset(); r.err == nil {
+		t.Error("oversized offset: no error")
+	}
+}
+
+func TestApplyBPS(t *testing.T) {
+	src := testROM(300)
+
+	// Every action, multi-byte numbers, relative offsets in both directions
+	// and an overlapping target copy used as a run-length fill
+	var want []byte
+	want = append(want, src[0:10]...)
+	want = append(want, "abc"...)
+	want = append(want, src[200:220]...)
+	want = append(want, src[120:125]...)
+	want = append(want, 'z')
+	want = append(want, bytes.Repeat([]byte{'z'}, 361)...)
+
+	p := newBPS(len(src), len(want), "<meta/>").
+		action(bpsSourceRead, 10).
+		action(bpsTargetRead, 3).bytes('a', 'b', 'c').
+		action(bpsSourceCopy, 20).offset(200).
+		action(bpsSourceCopy, 5).offset(-100). // sourceRel 220 -> 120
+		action(bpsTargetRead, 1).bytes('z').
+		action(bpsTargetCopy, 361).offset(38).
+		finish(src, want)
+
+	orig := append([]byte(nil), src...)
+	got, err := Apply(src, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got % X\nwant % X", got, want)
+	}
+	if !bytes.Equal(src, orig) {
+		t.Error("ROM modified in place")
+	}
+
+	// Relative offsets carry over between copies of the same kind
+	want = append(append(append([]byte(nil), src[4:6]...), src[6:8]...), src[4:6]...)
+	p = newBPS(len(src), len(want), "").
+		action(bpsSourceCopy, 2).offset(4).
+		action(bpsSourceCopy, 2).offset(0).
+		action(bpsTargetCopy, 2).offset(0).
+		finish(src, want)
+	if got, err := ApplyBPS(src, p); err != nil || !bytes.Equal(got, want) {
+		t.Errorf("chained copies: got % X, %v; want % X", got, err, want)
+	}
+}
+
+func TestApplyBPSChecksums(t *testing.T) {
+	src := testROM(64)
+	want := append([]byte("hi"), src[2:]...)
+	valid := func() []byte {
+		return newBPS(len(src), len(want), "").
+			action(bpsTargetRead, 2).bytes('h', 'i').
+			action(bpsSourceCopy, len(src)-2).offset(2).
+			finish(src, want)
+	}
+	if _, err := ApplyBPS(src, valid()); err != nil {
+		t.Fatal(err)
+	}
+
+	otherROM := append([]byte(nil), src...)
+	otherROM[40] ^= 1
+	if _, err := ApplyBPS(otherROM, valid()); !errors.Is(err, ErrChecksum) {
+		t.Errorf("different ROM: %v, want ErrChecksum", err)
+	}
+
+	corrupt := valid()
+	corrupt[len(bpsMagic)+4] ^= 1 // the 'h'
+	if _, err := ApplyBPS(src, corrupt); !errors.Is(err, ErrChecksum) {
+		t.Errorf("corrupt patch: %v, want ErrChecksum", err)
+	}
+
+	wrongTarget := newBPS(len(src), len(want), "").
+		action(bpsTargetRead, 2).bytes('h', 'o').
+		action(bpsSourceCopy, len(src)-2).offset(2).
+		finish(src, want)
+	if _, err := ApplyBPS(src, wrongTarget); !errors.Is(err, ErrChecksum) {
+		t.Errorf("wrong target: %v, want ErrChecksum", err)
+	}
+}
+
+func TestApplyBPSMalformed(t *testing.T) {
+	src := testROM(16)
+	target := make([]byte, 8)
+	for _, tc := range []struct {
+		name  string
+		patch []byte
+	}{
+		{"too short", append(append([]byte(nil), bpsMagic...), make([]byte, 11)...)},
+		{"source size", newBPS(15, 8, "").finish(src, target)},
+		{"target too large", newBPS(16, maxTargetSize+1, "").finish(src, target)},
+		{"header truncated", (&bpsBuilder{buf: append([]byte(nil), bpsMagic...)}).number(16).finish(src, target)},
+		{"metadata overrun", (&bpsBuilder{buf: append([]byte(nil), bpsMagic...)}).number(16).number(8).number(100).finish(src, target)},
+		{"action overruns target", newBPS(16, 8, "").action(bpsSourceRead, 9).finish(src, target)},
+		{"source read past end", newBPS(16, 20, "").action(bpsSourceRead, 20).finish(src, target)},
+		{"target read truncated", newBPS(16, 8, "").action(bpsTargetRead, 8).bytes(1, 2, 3).finish(src, target)},
+		{"source copy before start", newBPS(16, 8, "").action(bpsSourceCopy, 8).offset(-1).finish(src, target)},
+		{"source copy past end", newBPS(16, 8, "").action(bpsSourceCopy, 8).offset(9).finish(src, target)},
+		{"target copy of nothing", newBPS(16, 8, "").action(bpsTargetCopy, 8).offset(0).finish(src, target)},
+		{"target copy ahead", newBPS(16, 8, "").action(bpsSourceRead, 2).action(bpsTargetCopy, 6).offset(2).finish(src, target)},
+		{"copy offset truncated", newBPS(16, 8, "").action(bpsSourceCopy, 8).finish(src, target)},
+		{"output short", newBPS(16, 8, "").action(bpsSourceRead, 4).finish(src, target)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, err := ApplyBPS(src, tc.patch); !errors.Is(err, ErrBadPatch) {
+				t.Errorf("got %d bytes, %v; want ErrBadPatch", len(got), err)
+			}
+		})
+	}
+}
+
+func TestApplyUnknownFormat(t *testing.T) {
+	for _, p := range [][]byte{nil, []byte("PATC"), []byte("UPS1"), []byte("BPS")} {
+		if _, err := Apply(testROM(16), p); !errors.Is(err, ErrUnknownFormat) {
+			t.Errorf("%q: %v, want ErrUnknownFormat", p, err)
+		}
+	}
+}
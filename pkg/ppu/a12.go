@@ -0,0 +1,36 @@
+package ppu
+
+// a12FilterDots is how long A12 must stay low before a rise counts. MMC3
+// filters A12 through M2, ignoring lows shorter than about three CPU
+// cycles, so the back-to-back pattern fetches within a tile row or a
+// sprite slot produce a single clock.
+const a12FilterDots = 10
+
+// fetch performs a rendering memory access, tracking A12 when rendering is
+// enabled (with rendering off, the PPU leaves the bus alone)
+func (p *PPU) fetch(addr uint16) uint8 {
+	if p.mask.IsRenderingEnabled() {
+		p.watchA12(addr)
+	}
+	return p.ppuRead(addr)
+}
+
+// watchA12 records an address placed on the PPU bus and notifies the
+// mapper of filtered A12 rises and of every fall
+func (p *PPU) watchA12(addr uint16) {
+	if p.a12Mapper == nil {
+		return
+	}
+
+	high := addr&0x1000 != 0
+	switch {
+	case high && !p.a12High:
+		if p.dot-p.a12LowDot >= a12FilterDots {
+			p.a12Mapper.A12Rise()
+		}
+	case !high && p.a12High:
+		p.a12LowDot = p.dot
+		p.a12Mapper.A12Fall()
+	}
+	p.a12High = high
+}
@@ -0,0 +1,165 @@
+package ppu
+
+// DebugState is a snapshot of the PPU's internal state for debuggers and
+// inspection tools. Taking it has no side effects.
+type DebugState struct {
+	// Timing
+	Scanline int    // Current scanline (-1 = pre-render, 0-239 visible)
+	Cycle    int    // Current dot within the scanline (0-340)
+	Frame    uint64 // Frames completed since power-on
+	OddFrame bool   // Odd/even frame parity
+
+	ScanlinesPerFrame int // Frame length for the region, including pre-render
+	VBlankScanline    int // Line on which VBlank starts for the region
+
+	// CPU-visible registers
+	Control    uint8 // PPUCTRL ($2000)
+	Mask       uint8 // PPUMASK ($2001)
+	Status     uint8 // PPUSTATUS ($2002)
+	OAMAddress uint8 // OAMADDR ($2003)
+
+	// Loopy registers and latches
+	V          uint16 // Current VRAM address
+	T          uint16 // Temporary VRAM address
+	FineX      uint8  // Fine X scroll (0-7)
+	WriteLatch bool   // $2005/$2006 second-write toggle
+	ReadBuffer uint8  // Buffered $2007 read value
+	NMIOutput  bool   // NMI raised and not yet taken by the CPU
+
+	Mirroring uint8 // Nametable mirroring in effect (Mirror* constants)
+
+	// Background pipeline
+	BgNextTileID       uint8
+	BgNextTileAttrib   uint8
+	BgNextTileLSB      uint8
+	BgNextTileMSB      uint8
+	BgShifterPatternLo uint16
+	BgShifterPatternHi uint16
+	BgShifterAttribLo  uint16
+	BgShifterAttribHi  uint16
+
+	// Sprite evaluation results for the current scanline
+	SpriteCount      uint8     // Sprites found (0-8)
+	Sprite0Present   bool      // Sprite 0 is among them
+	SecondaryOAM     [32]uint8 // Copied sprite entries
+	SpritePatternLo  [8]uint8  // Sprite pattern shifters
+	SpritePatternHi  [8]uint8
+	SpriteAttributes [8]uint8
+	SpritePositions  [8]uint8
+}
+
+// DebugState returns a snapshot of the PPU's internal registers, latches,
+// shifters and sprite evaluation state
+func (p *PPU) DebugState() DebugState {
+	return DebugState{
+		Scanline: int(p.scanline),
+		Cycle:    int(p.cycle),
+		Frame:    p.frame,
+		OddFrame: p.oddFrame,
+
+		ScanlinesPerFrame: int(p.scanlinesPerFrame),
+		VBlankScanline:    int(p.vblankScanline),
+
+		Control:    p.control.Get(),
+		Mask:       p.mask.Get(),
+		Status:     p.status.Get(),
+		OAMAddress: p.oamAddress,
+
+		V:          p.vramAddress.Get(),
+		T:          p.tempVRAMAddress.Get(),
+		FineX:      p.fineX,
+		WriteLatch: p.writeLatch,
+		ReadBuffer: p.readBuffer,
+		NMIOutput:  p.nmiOutput,
+
+		Mirroring: p.Mirroring(),
+
+		BgNextTileID:       p.bgNextTileID,
+		BgNextTileAttrib:   p.bgNextTileAttrib,
+		BgNextTileLSB:      p.bgNextTileLSB,
+		BgNextTileMSB:      p.bgNextTileMSB,
+		BgShifterPatternLo: p.bgShifterPatternLo,
+		BgShifterPatternHi: p.bgShifterPatternHi,
+		BgShifterAttribLo:  p.bgShifterAttribLo,
+		BgShifterAttribHi:  p.bgShifterAttribHi,
+
+		SpriteCount:      p.spriteCount,
+		Sprite0Present:   p.sprite0Present,
+		SecondaryOAM:     p.secondaryOAM,
+		SpritePatternLo:  p.spriteShifterPatternLo,
+		SpritePatternHi:  p.spriteShifterPatternHi,
+		SpriteAttributes: p.spriteAttributes,
+		SpritePositions:  p.spritePositions,
+	}
+}
+
+// DebugRead reads PPU address space ($0000-$3FFF, mirrored above) without
+// the side effects of setting $2006 and reading $2007: v, t, the write
+// latch and the read buffer are untouched, and no fetch is seen by the
+// mapper's A12 watcher. The byte at addr is returned directly, not the
+// buffered value a $2007 read would give.
+func (p *PPU) DebugRead(addr uint16) uint8 {
+	return p.ppuRead(addr)
+}
+
+// PeekVRAM is DebugRead, named like the other Peek helpers
+func (p *PPU) PeekVRAM(addr uint16) uint8 {
+	return p.DebugRead(addr)
+}
+
+// PeekOAM returns a byte of primary OAM without touching OAMADDR
+func (p *PPU) PeekOAM(index uint8) uint8 {
+	return p.oam[index]
+}
+
+// PeekCPURegister returns what a CPU read of a PPU register would see,
+// without clearing vblank, the write latch or advancing v. Write-only
+// registers read as 0.
+func (p *PPU) PeekCPURegister(addr uint16) uint8 {
+	switch 0x2000 + (addr & 0x0007) {
+	case 0x2002:
+		return p.status.Get()
+	case 0x2004:
+		return p.readOAMData()
+	case 0x2007:
+		if p.vramAddress.Get()&0x3FFF >= 0x3F00 {
+			return p.ppuRead(p.vramAddress.Get())
+		}
+		return p.readBuffer
+	}
+	return 0
+}
+
+// PokeNametable writes a byte of nametable memory ($2000-$2FFF, mirrored
+// up to $3EFF) through the current mirroring, without touching v, the
+// write latch or the read buffer. Values outside that range are ignored.
+func (p *PPU) PokeNametable(addr uint16, value uint8) {
+	addr &= 0x3FFF
+	if addr < 0x2000 || addr >= 0x3F00 {
+		return
+	}
+	p.ppuWrite(addr, value)
+}
+
+// attributeLocation returns the attribute byte address and bit shift that
+// hold the palette of tile (tileX, tileY) in nametable 0-3
+func attributeLocation(nametable, tileX, tileY int) (uint16, uint) {
+	addr := 0x23C0 + uint16(nametable&3)*0x400 + uint16(tileY/4)*8 + uint16(tileX/4)
+	shift := uint((tileY%4)/2*4 + (tileX%4)/2*2)
+	return addr, shift
+}
+
+// PeekAttribute returns the background palette (0-3) of tile (tileX,
+// tileY) in nametable 0-3
+func (p *PPU) PeekAttribute(nametable, tileX, tileY int) uint8 {
+	addr, shift := attributeLocation(nametable, tileX&31, tileY%30)
+	return (p.ppuRead(addr) >> shift) & 0x03
+}
+
+// PokeAttribute sets the background palette (0-3) of the 16x16 pixel area
+// containing tile (tileX, tileY) in nametable 0-3
+func (p *PPU) PokeAttribute(nametable, tileX, tileY int, palette uint8) {
+	addr, shift := attributeLocation(nametable, tileX&31, tileY%30)
+	value := p.ppuRead(addr)&^(0x03<<shift) | (palette&0x03)<<shift
+	p.ppuWrite(addr, value)
+}
@@ -0,0 +1,167 @@
+package ppu
+
+// SetFastRender selects scanline rendering
+//
+// With fast rendering on, the PPU defers dots 1-256 of each visible line and
+// draws the whole line in one pass at dot 257, which is much cheaper than
+// stepping the pipeline dot by dot. A register access or mapper write in
+// the middle of a line (see Sync) first catches up on the deferred dots one
+// at a time, so raster effects and sprite 0 polling still see the right
+// state. What is not exact is the timing of side effects of the deferred
+// fetches: mapper IRQs clocked by background fetches (MMC3 with the
+// background at $1000) fire when the line is drawn rather than mid-line.
+func (p *PPU) SetFastRender(enabled bool) {
+	p.catchUp()
+	p.fastRender = enabled
+}
+
+// FastRender reports whether scanline rendering is on
+func (p *PPU) FastRender() bool {
+	return p.fastRender
+}
+
+// Sync draws any dots deferred by fast rendering, so the current line
+// reflects the PPU state up to now. Callers that change what the PPU
+// fetches behind its back, such as mapper bank writes, call it first.
+func (p *PPU) Sync() {
+	p.catchUp()
+}
+
+// catchUp runs the dots deferred by fast rendering, up to the current one
+func (p *PPU) catchUp() {
+	start := p.pendingDot
+	if start == 0 {
+		return
+	}
+	p.pendingDot = 0
+
+	cycle, dot := p.cycle, p.dot
+	end := min(cycle, 257)
+	if start == 1 && end == 257 {
+		p.dot = dot - uint64(cycle-1)
+		p.renderScanline()
+	} else {
+		for c := start; c < end; c++ {
+			p.cycle = c
+			p.dot = dot - uint64(cycle-c)
+			p.clockDot()
+		}
+	}
+	p.cycle, p.dot = cycle, dot
+}
+
+// renderScanline draws dots 1-256 of a visible line in one pass, starting
+// with the PPU at dot 1. It does the same fetches, scroll increments and
+// sprite evaluation as stepping the dots and produces the same pixels and
+// state, without the per-dot shifting.
+func (p *PPU) renderScanline() {
+	base := p.dot
+	enabled := p.mask.IsRenderingEnabled()
+	fetch := func(c uint16, addr uint16) uint8 {
+		p.dot = base + uint64(c-1)
+		return p.fetch(addr)
+	}
+
+	// The background bits of the line, as the shifters would present them:
+	// the two tiles already loaded, then one per tile fetched (loaded at
+	// dots 9, 17, ..., 249)
+	var patLo, patHi, attLo, attHi [33]uint8
+	patLo[0], patLo[1] = uint8(p.bgShifterPatternLo>>8), uint8(p.bgShifterPatternLo)
+	patHi[0], patHi[1] = uint8(p.bgShifterPatternHi>>8), uint8(p.bgShifterPatternHi)
+	attLo[0], attLo[1] = uint8(p.bgShifterAttribLo>>8), uint8(p.bgShifterAttribLo)
+	attHi[0], attHi[1] = uint8(p.bgShifterAttribHi>>8), uint8(p.bgShifterAttribHi)
+
+	// Tile k's nametable byte is fetched at dot 8k-15, its attribute and
+	// pattern bytes 2, 4 and 6 dots later, and coarse X is incremented at
+	// dot 8k-8. The first nametable fetch falls on dot 1, which is idle, so
+	// tile 2 uses the ID fetched at the end of the previous line.
+	for k := uint16(2); k <= 33; k++ {
+		c := 8*k - 15
+		if k > 2 {
+			p.bgNextTileID = fetch(c, 0x2000|(p.vramAddress.Get()&0x0FFF))
+		}
+
+		address := uint16(0x23C0) |
+			(p.vramAddress.NametableY() << 11) |
+			(p.vramAddress.NametableX() << 10) |
+			((p.vramAddress.CoarseY() >> 2) << 3) |
+			(p.vramAddress.CoarseX() >> 2)
+		p.bgNextTileAttrib = fetch(c+2, address)
+		if p.vramAddress.CoarseY()&0x02 != 0 {
+			p.bgNextTileAttrib >>= 4
+		}
+		if p.vramAddress.CoarseX()&0x02 != 0 {
+			p.bgNextTileAttrib >>= 2
+		}
+		p.bgNextTileAttrib &= 0x03
+
+		pattern := p.control.BackgroundPatternTable() | uint16(p.bgNextTileID)<<4 | p.vramAddress.FineY()
+		p.bgNextTileLSB = fetch(c+4, pattern)
+		p.bgNextTileMSB = fetch(c+6, pattern+8)
+		if enabled {
+			p.vramAddress.IncrementX()
+		}
+
+		if k <= 32 {
+			patLo[k], patHi[k] = p.bgNextTileLSB, p.bgNextTileMSB
+			attLo[k] = -(p.bgNextTileAttrib & 0x01)
+			attHi[k] = -(p.bgNextTileAttrib >> 1)
+		}
+	}
+	if enabled {
+		p.vramAddress.IncrementY()
+	}
+	p.dot = base
+
+	// Pixel x is drawn after max(x-1, 0) shifts, from the bit fine X
+	// further along
+	background := p.mask.RenderBackground()
+	y := uint16(p.scanline)
+	for x := uint16(0); x < ScreenWidth; x++ {
+		if !enabled {
+			p.cycle = x + 1
+			p.renderPixel()
+			continue
+		}
+		if p.noPixelOutput && (!p.sprite0Present || p.status.Sprite0Hit()) {
+			continue
+		}
+
+		bgPixel, bgPalette := uint8(0), uint8(0)
+		if background {
+			i := max(x, 1) - 1 + uint16(p.fineX)
+			tile, shift := i>>3, 7-(i&7)
+			bgPixel = (patHi[tile]>>shift&1)<<1 | patLo[tile]>>shift&1
+			bgPalette = (attHi[tile]>>shift&1)<<1 | attLo[tile]>>shift&1
+		}
+		p.composePixel(x, y, bgPixel, bgPalette)
+	}
+
+	if enabled {
+		for c := uint16(1); c <= 256; c++ {
+			p.cycle = c
+			if c == 65 {
+				p.startSpriteEvaluation()
+			}
+			p.evaluateSprites()
+		}
+	} else {
+		// Evaluation still restarts at dot 65 with rendering off
+		p.startSpriteEvaluation()
+	}
+
+	// Leave the shifters as 255 shifts and 31 loads would have: the last two
+	// tiles loaded, shifted up by 7. Without background rendering nothing
+	// shifts and only the last load shows.
+	shifted := func(s *uint16, b *[33]uint8) {
+		if background {
+			*s = (uint16(b[31])<<8 | uint16(b[32])) << 7
+		} else {
+			*s = *s&0xFF00 | uint16(b[32])
+		}
+	}
+	shifted(&p.bgShifterPatternLo, &patLo)
+	shifted(&p.bgShifterPatternHi, &patHi)
+	shifted(&p.bgShifterAttribLo, &attLo)
+	shifted(&p.bgShifterAttribHi, &attHi)
+}
@@ -0,0 +1,244 @@
+package ppu
+
+import (
+	"encoding/binary"
+	"image"
+	"sync"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+)
+
+// Color emphasis bits, as returned by Emphasis
+const (
+	EmphasisRed uint8 = 1 << iota
+	EmphasisGreen
+	EmphasisBlue
+)
+
+// emphasisAttenuation is how much emphasis dims the channels it does not
+// emphasize (measured on NTSC hardware)
+const emphasisAttenuation = 0.816
+
+// EmphasizedColor returns the RGB color of a palette index (0-63) in the
+// default palette with emphasis bits applied
+func EmphasizedColor(index uint8, emphasis uint8) Color {
+	return emphasize(HardwarePalette[index&0x3F], index, emphasis)
+}
+
+// emphasize applies emphasis to the base color of a palette index
+func emphasize(c Color, index uint8, emphasis uint8) Color {
+	if emphasis&0x07 == 0 {
+		return c
+	}
+	// Black and the unused $xE/$xF columns are unaffected
+	if index&0x0E == 0x0E {
+		return c
+	}
+
+	// Each emphasis bit dims the other two channels
+	dim := func(v uint8, own uint8) uint8 {
+		f := float64(v)
+		for _, bit := range [...]uint8{EmphasisRed, EmphasisGreen, EmphasisBlue} {
+			if bit != own && emphasis&bit != 0 {
+				f *= emphasisAttenuation
+			}
+		}
+		return uint8(f)
+	}
+	return Color{
+		R: dim(c.R, EmphasisRed),
+		G: dim(c.G, EmphasisGreen),
+		B: dim(c.B, EmphasisBlue),
+	}
+}
+
+// Emphasis returns the current PPUMASK emphasis bits as EmphasisRed/Green/
+// Blue flags, with the red/green swap of the PAL PPU applied
+func (p *PPU) Emphasis() uint8 {
+	var e uint8
+	if p.mask.EmphasizeRed() {
+		e |= EmphasisRed
+	}
+	if p.mask.EmphasizeGreen() {
+		e |= EmphasisGreen
+	}
+	if p.mask.EmphasizeBlue() {
+		e |= EmphasisBlue
+	}
+	if p.region == cartridge.RegionPAL {
+		e = e&EmphasisBlue | (e&EmphasisRed)<<1 | (e&EmphasisGreen)>>1
+	}
+	return e
+}
+
+// Palette maps each palette index (0-63) to an RGB color, for all 8
+// emphasis combinations
+type Palette [8][64]Color
+
+// NewPalette builds a full palette from 64 base colors, deriving the
+// emphasis variants by attenuation
+func NewPalette(base [64]Color) *Palette {
+	var pal Palette
+	for e := range pal {
+		for i := range pal[e] {
+			pal[e][i] = emphasize(base[i], uint8(i), uint8(e))
+		}
+	}
+	return &pal
+}
+
+// defaultPalette is built from HardwarePalette on first use
+var defaultPalette = sync.OnceValue(func() *Palette {
+	return NewPalette(HardwarePalette)
+})
+
+// DefaultPalette returns the built-in NTSC palette. It is shared and must
+// not be modified.
+func DefaultPalette() *Palette {
+	return defaultPalette()
+}
+
+// Color returns the color of a palette index under the given emphasis
+func (pal *Palette) Color(index uint8, emphasis uint8) Color {
+	return pal[emphasis&0x07][index&0x3F]
+}
+
+// Image converts a frame of palette indices to an RGBA image, applying the
+// given emphasis bits to every pixel
+func (pal *Palette) Image(frame *[ScreenWidth * ScreenHeight]uint8, emphasis uint8) *image.RGBA {
+	colors := &pal[emphasis&0x07]
+	img := image.NewRGBA(image.Rect(0, 0, ScreenWidth, ScreenHeight))
+	for i, index := range frame {
+		c := colors[index&0x3F]
+		img.Pix[i*4+0] = c.R
+		img.Pix[i*4+1] = c.G
+		img.Pix[i*4+2] = c.B
+		img.Pix[i*4+3] = 255
+	}
+	return img
+}
+
+// EmphasizedImage converts a frame of palette indices to an RGBA image,
+// applying each pixel's own emphasis bits (see PPU.GetEmphasisBuffer) so
+// emphasis changed mid-frame shows up where it took effect
+func (pal *Palette) EmphasizedImage(frame, emphasis *[ScreenWidth * ScreenHeight]uint8) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, ScreenWidth, ScreenHeight))
+	for i, index := range frame {
+		c := pal.Color(index, emphasis[i])
+		img.Pix[i*4+0] = c.R
+		img.Pix[i*4+1] = c.G
+		img.Pix[i*4+2] = c.B
+		img.Pix[i*4+3] = 255
+	}
+	return img
+}
+
+// Image returns the current frame converted with the PPU's palette and the
+// emphasis each pixel was drawn with, cropped to the overscan setting
+func (p *PPU) Image() *image.RGBA {
+	return p.overscan.Crop(p.GetPalette().EmphasizedImage(&p.frameBuffer, &p.emphasisBuffer))
+}
+
+// Overscan is the number of pixels hidden at each edge of the picture.
+// TVs cut off roughly the top and bottom 8 lines, and games often leave
+// scrolling garbage or mapper artifacts there.
+type Overscan struct {
+	Top, Bottom, Left, Right int
+}
+
+// OverscanTV crops the 8 lines at the top and bottom a typical NTSC TV hides
+var OverscanTV = Overscan{Top: 8, Bottom: 8}
+
+// Rect returns the part of the 256x240 frame left visible, clamped so at
+// least one pixel remains
+func (o Overscan) Rect() image.Rectangle {
+	x0 := min(max(o.Left, 0), ScreenWidth-1)
+	y0 := min(max(o.Top, 0), ScreenHeight-1)
+	x1 := max(ScreenWidth-max(o.Right, 0), x0+1)
+	y1 := max(ScreenHeight-max(o.Bottom, 0), y0+1)
+	return image.Rect(x0, y0, x1, y1)
+}
+
+// Crop returns the visible part of a full frame image as a new image with
+// its origin at 0,0. The zero Overscan returns img itself.
+func (o Overscan) Crop(img *image.RGBA) *image.RGBA {
+	r := o.Rect()
+	if r == img.Bounds() {
+		return img
+	}
+	out := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		copy(out.Pix[(y-r.Min.Y)*out.Stride:], img.Pix[img.PixOffset(r.Min.X, y):img.PixOffset(r.Max.X, y)])
+	}
+	return out
+}
+
+// SetOverscan selects the edges cropped from Image (and so from
+// screenshots). FrameRGBA and the frame buffer always hold the full frame;
+// frontends show GetOverscan().Rect() of them. It does not affect emulation.
+func (p *PPU) SetOverscan(o Overscan) {
+	p.overscan = o
+}
+
+// GetOverscan returns the overscan setting in effect
+func (p *PPU) GetOverscan() Overscan {
+	return p.overscan
+}
+
+// FrameImage converts a frame of palette indices to an RGBA image with the
+// default palette, applying the given emphasis bits to every pixel
+func FrameImage(frame *[ScreenWidth * ScreenHeight]uint8, emphasis uint8) *image.RGBA {
+	return DefaultPalette().Image(frame, emphasis)
+}
+
+// SetPalette selects the palette used to convert frames to RGB (nil
+// restores the default). It does not affect emulation.
+func (p *PPU) SetPalette(pal *Palette) {
+	p.palette = pal
+	p.rgba = nil
+}
+
+// GetPalette returns the palette used to convert frames to RGB
+func (p *PPU) GetPalette() *Palette {
+	if p.palette == nil {
+		return DefaultPalette()
+	}
+	return p.palette
+}
+
+// RGBA32 packs every color of the palette as a uint32 whose bytes are R, G,
+// B and A in memory order (SDL's PIXELFORMAT_RGBA32, or canvas ImageData
+// viewed as bytes), indexed by emphasis then palette index
+func (pal *Palette) RGBA32() *[8][64]uint32 {
+	var packed [8][64]uint32
+	for e := range pal {
+		for i, c := range pal[e] {
+			packed[e][i] = binary.NativeEndian.Uint32([]byte{c.R, c.G, c.B, 255})
+		}
+	}
+	return &packed
+}
+
+// FrameRGBA writes the frame buffer to dst, which must hold ScreenWidth *
+// ScreenHeight pixels, packed as by Palette.RGBA32 with the PPU's palette
+// and each pixel's emphasis. The packed palette is cached until the next
+// SetPalette.
+func (p *PPU) FrameRGBA(dst []uint32) {
+	if p.rgba == nil {
+		p.rgba = p.GetPalette().RGBA32()
+	}
+	dst = dst[:len(p.frameBuffer)]
+	for i, index := range p.frameBuffer {
+		dst[i] = p.rgba[p.emphasisBuffer[i]&0x07][index&0x3F]
+	}
+}
+
+// GetFrameRGBA converts the frame buffer as FrameRGBA does, into a buffer
+// owned by the PPU that the next call overwrites
+func (p *PPU) GetFrameRGBA() []uint32 {
+	if p.frameRGBA == nil {
+		p.frameRGBA = make([]uint32, ScreenWidth*ScreenHeight)
+	}
+	p.FrameRGBA(p.frameRGBA)
+	return p.frameRGBA
+}
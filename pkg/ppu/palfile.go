@@ -0,0 +1,67 @@
+package ppu
+
+import (
+	"fmt"
+	"os"
+)
+
+// .pal file sizes: 64 RGB triples, or 64 triples for each of the 8
+// emphasis combinations (in emphasis-bit order)
+const (
+	PaletteFileSize     = 64 * 3
+	PaletteFileSizeFull = 8 * 64 * 3
+)
+
+// ParsePalette decodes a .pal file. Base-only files get their emphasis
+// variants derived by attenuation.
+func ParsePalette(data []byte) (*Palette, error) {
+	switch len(data) {
+	case PaletteFileSize:
+		var base [64]Color
+		for i := range base {
+			base[i] = Color{data[i*3], data[i*3+1], data[i*3+2]}
+		}
+		return NewPalette(base), nil
+
+	case PaletteFileSizeFull:
+		var pal Palette
+		for e := range pal {
+			for i := range pal[e] {
+				o := (e*64 + i) * 3
+				pal[e][i] = Color{data[o], data[o+1], data[o+2]}
+			}
+		}
+		return &pal, nil
+	}
+	return nil, fmt.Errorf("palette: %d bytes, want %d or %d", len(data), PaletteFileSize, PaletteFileSizeFull)
+}
+
+// LoadPalette reads a .pal file
+func LoadPalette(path string) (*Palette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePalette(data)
+}
+
+// Bytes encodes the palette as a .pal file, with all emphasis variants if
+// full is set or only the 64 base colors otherwise
+func (pal *Palette) Bytes(full bool) []byte {
+	variants := 1
+	if full {
+		variants = 8
+	}
+	out := make([]byte, 0, variants*64*3)
+	for e := 0; e < variants; e++ {
+		for _, c := range pal[e] {
+			out = append(out, c.R, c.G, c.B)
+		}
+	}
+	return out
+}
+
+// Save writes the palette to a .pal file (see Bytes)
+func (pal *Palette) Save(path string, full bool) error {
+	return os.WriteFile(path, pal.Bytes(full), 0o644)
+}
@@ -22,7 +22,12 @@
 //   - $3F20-$3FFF: Mirrors of $3F00-$3F1F
 package ppu
 
-import "github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+import (
+	"log/slog"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/logging"
+)
 
 // Mirroring modes for nametables
 const (
@@ -46,6 +51,17 @@ const (
 	VisibleScanlines  = 240
 )
 
+// ScanlinesPerFramePAL is the frame length of the PAL (2C07) and Dendy PPUs
+const ScanlinesPerFramePAL = 312
+
+// Line on which VBlank starts. NTSC and PAL start it right after the
+// post-render line; the Dendy keeps NTSC's 20-line VBlank and puts its 50
+// extra lines before it instead.
+const (
+	VBlankScanline      = 241
+	VBlankScanlineDendy = 291
+)
+
 // PPU represents the NES Picture Processing Unit (2C02)
 type PPU struct {
 	// Memory Banks
@@ -114,6 +130,17 @@ type PPU struct {
 	// Frame complete flag
 	frameComplete bool
 
+	// Timing region and the frame layout it implies
+	region            cartridge.Region
+	scanlinesPerFrame int16
+	vblankScanline    int16 // Line on which VBlank starts
+	oddFrameSkip      bool  // Odd frames drop a dot when rendering (NTSC only)
+
+	// Overclocking: number of extra idle post-render scanlines per frame,
+	// and how many of them have elapsed in the current frame
+	extraScanlines int16
+	overclockLine  int16
+
 	// Background Rendering State
 	// Next background tile ID from nametable
 	bgNextTileID uint8
@@ -144,6 +171,15 @@ type PPU struct {
 	// sprites that are visible on the next scanline to secondary OAM
 	secondaryOAM [32]uint8 // 8 sprites * 4 bytes each
 
+	// Sprite evaluation state, stepped over cycles 1-256
+	oamLatch    uint8 // Byte last read from OAM
+	evalN       uint8 // Sprite being examined (0-63)
+	evalM       uint8 // Byte within it (0-3)
+	evalSlot    uint8 // Next secondary OAM byte to write (0-32)
+	evalCopy    uint8 // Bytes of an in-range sprite still to copy
+	evalSprite0 bool  // First sprite examined is in range
+	evalDone    bool  // All 64 sprites examined
+
 	// Sprite count for current scanline (0-8)
 	spriteCount uint8
 
@@ -164,6 +200,19 @@ type PPU struct {
 	// Cartridge mapper for CHR-ROM/CHR-RAM access
 	mapper cartridge.Mapper
 
+	// Per-nametable page selection (TxSROM), nil for most mappers
+	nametables cartridge.NametableMapper
+
+	// Cartridge memory mapped into the nametables (Namco 163), usually nil
+	nametableROM cartridge.NametableROMMapper
+
+	// A12 edge detection for mappers that count its rises (MMC3), nil
+	// for the rest
+	a12Mapper cartridge.A12Mapper
+	a12High   bool   // Level of A12 on the last access
+	a12LowDot uint64 // Dot at which A12 last went low
+	dot       uint64 // Total PPU cycles since power-on
+
 	// Nametable mirroring mode
 	mirroringMode uint8
 
@@ -171,16 +220,42 @@ type PPU struct {
 	// Frame buffer (256x240 pixels, each pixel is a palette index 0-63)
 	frameBuffer [ScreenWidth * ScreenHeight]uint8
 
+	// Emphasis bits (see Emphasis) in effect when each pixel was output
+	emphasisBuffer [ScreenWidth * ScreenHeight]uint8
+
+	// Skip writing pixels to the frame buffer (headless runs)
+	noPixelOutput bool
+
+	// Defer visible dots and draw each line in one pass (see SetFastRender)
+	fastRender bool
+	pendingDot uint16 // First deferred dot of the current line, 0 if none
+
+	// RGB palette for frame conversion (nil = DefaultPalette)
+	palette   *Palette
+	rgba      *[8][64]uint32 // Packed palette for FrameRGBA, nil until used
+	overscan  Overscan       // Edges cropped from Image
+	frameRGBA []uint32       // GetFrameRGBA's buffer
+
 	// NMI output signal (triggers CPU interrupt)
-	nmiOutput bool
+	nmiOutput      bool
+	nmiDelay       uint8 // Dots until a pending VBlank NMI is output
+	suppressVBlank bool  // PPUSTATUS read one dot before VBlank starts
+
+	// Optional progress callbacks (see OnScanline and OnFrame)
+	scanlineHook func(line int)
+	frameHook    func()
 }
 
 // NewPPU creates and initializes a new PPU
 func NewPPU() *PPU {
 	ppu := &PPU{
-		scanline: 0,
-		cycle:    0,
-		frame:    0,
+		scanline:          0,
+		cycle:             0,
+		frame:             0,
+		region:            cartridge.RegionNTSC,
+		scanlinesPerFrame: ScanlinesPerFrame,
+		vblankScanline:    VBlankScanline,
+		oddFrameSkip:      true,
 	}
 
 	// Initialize palette RAM to default values
@@ -194,16 +269,111 @@ func NewPPU() *PPU {
 // SetMapper connects a cartridge mapper to the PPU for CHR-ROM/RAM access
 func (p *PPU) SetMapper(mapper cartridge.Mapper) {
 	p.mapper = mapper
+	p.nametables, _ = mapper.(cartridge.NametableMapper)
+	p.nametableROM, _ = mapper.(cartridge.NametableROMMapper)
+	p.a12Mapper, _ = mapper.(cartridge.A12Mapper)
+}
+
+// SetRegion selects NTSC, PAL or Dendy frame timing: frame length, the
+// line VBlank starts on, and whether odd frames are a dot short
+func (p *PPU) SetRegion(region cartridge.Region) {
+	p.region = region
+	p.scanlinesPerFrame = ScanlinesPerFrame
+	p.vblankScanline = VBlankScanline
+	p.oddFrameSkip = true
+	switch region {
+	case cartridge.RegionPAL:
+		p.scanlinesPerFrame = ScanlinesPerFramePAL
+		p.oddFrameSkip = false
+	case cartridge.RegionDendy:
+		p.scanlinesPerFrame = ScanlinesPerFramePAL
+		p.vblankScanline = VBlankScanlineDendy
+		p.oddFrameSkip = false
+	}
+}
+
+// SetExtraScanlines adds idle post-render scanlines to every frame
+//
+// The extra lines are inserted after scanline 240, before VBlank starts. The
+// CPU keeps running during them but nothing is rendered and no PPU state
+// changes, so games get more CPU time per frame (less slowdown) without any
+// change to VBlank length or raster timing. Zero disables overclocking.
+func (p *PPU) SetExtraScanlines(lines int) {
+	p.extraScanlines = int16(max(lines, 0))
 }
 
-// SetMirroring sets the nametable mirroring mode
+// SetPixelOutput enables or disables frame buffer output
+//
+// With output disabled the PPU still performs all fetches and evaluates
+// sprite 0 hits, but skips composing pixels, which speeds up headless runs.
+func (p *PPU) SetPixelOutput(enabled bool) {
+	p.noPixelOutput = !enabled
+}
+
+// OnScanline sets a callback run after the last dot of every scanline,
+// with the line just finished (-1 for pre-render, 0-239 visible, then
+// post-render and VBlank). Overclock lines report 240 again. Nil removes it.
+func (p *PPU) OnScanline(fn func(line int)) {
+	p.scanlineHook = fn
+}
+
+// OnFrame sets a callback run when a frame completes, after the last
+// scanline's callback. Nil removes it.
+func (p *PPU) OnFrame(fn func()) {
+	p.frameHook = fn
+}
+
+// InOverclock reports whether the PPU is currently in one of the extra
+// scanlines added by SetExtraScanlines
+func (p *PPU) InOverclock() bool {
+	return p.scanline == VisibleScanlines && p.overclockLine > 0
+}
+
+// IsRendering reports whether the PPU is on a scanline that draws or fetches
+// (pre-render and visible lines) with rendering enabled
+func (p *PPU) IsRendering() bool {
+	return p.scanline < VisibleScanlines && p.mask.IsRenderingEnabled()
+}
+
+// GetRegion returns the PPU's timing region
+func (p *PPU) GetRegion() cartridge.Region {
+	return p.region
+}
+
+// SetMirroring sets the nametable mirroring mode used when no mapper is
+// connected. With a mapper, its GetMirroring is consulted on every
+// nametable access, so runtime switches (MMC1, MMC3, AxROM) apply at once.
 func (p *PPU) SetMirroring(mode uint8) {
 	p.mirroringMode = mode
 }
 
+// Mirroring returns the nametable mirroring mode currently in effect
+func (p *PPU) Mirroring() uint8 {
+	if p.mapper != nil {
+		return p.mapper.GetMirroring()
+	}
+	return p.mirroringMode
+}
+
 // Clock advances the PPU by one cycle
 // The PPU runs at 3x the CPU speed, so this should be called 3 times per CPU cycle
 func (p *PPU) Clock() {
+	if p.fastRender && p.scanline >= 0 && p.scanline < VisibleScanlines && p.cycle >= 1 && p.cycle <= 256 {
+		// Fast rendering: defer the dot until the line is drawn by catchUp
+		if p.pendingDot == 0 {
+			p.pendingDot = p.cycle
+		}
+	} else {
+		p.catchUp()
+		p.clockDot()
+	}
+
+	p.advance()
+}
+
+// clockDot does the work of the current dot: drawing, fetches, sprite
+// evaluation and VBlank
+func (p *PPU) clockDot() {
 	// Pixel Rendering - happens BEFORE shifter updates and fetching
 	if p.scanline >= 0 && p.scanline < 240 && p.cycle >= 1 && p.cycle <= 256 {
 		p.renderPixel()
@@ -217,7 +387,7 @@ func (p *PPU) Clock() {
 			p.status.SetVBlank(false)
 			p.status.SetSprite0Hit(false)
 			p.status.SetSpriteOverflow(false)
-			p.frameComplete = false
+			p.corruptOAM()
 		}
 
 		// Background rendering cycles
@@ -233,7 +403,7 @@ func (p *PPU) Clock() {
 				p.loadBackgroundShifters()
 
 				// Fetch next tile ID from nametable
-				p.bgNextTileID = p.ppuRead(0x2000 | (p.vramAddress.Get() & 0x0FFF))
+				p.bgNextTileID = p.fetch(0x2000 | (p.vramAddress.Get() & 0x0FFF))
 
 			case 2:
 				// Fetch attribute byte
@@ -243,7 +413,7 @@ func (p *PPU) Clock() {
 					((p.vramAddress.CoarseY() >> 2) << 3) |
 					(p.vramAddress.CoarseX() >> 2)
 
-				p.bgNextTileAttrib = p.ppuRead(address)
+				p.bgNextTileAttrib = p.fetch(address)
 
 				// Extract the 2 bits for this 2x2 tile quadrant
 				if p.vramAddress.CoarseY()&0x02 != 0 {
@@ -260,7 +430,7 @@ func (p *PPU) Clock() {
 				tileID := uint16(p.bgNextTileID)
 				fineY := p.vramAddress.FineY()
 				address := table | (tileID << 4) | fineY
-				p.bgNextTileLSB = p.ppuRead(address)
+				p.bgNextTileLSB = p.fetch(address)
 
 			case 6:
 				// Fetch tile pattern high byte (same as low + 8)
@@ -268,7 +438,7 @@ func (p *PPU) Clock() {
 				tileID := uint16(p.bgNextTileID)
 				fineY := p.vramAddress.FineY()
 				address := table | (tileID << 4) | fineY
-				p.bgNextTileMSB = p.ppuRead(address + 8)
+				p.bgNextTileMSB = p.fetch(address + 8)
 
 			case 7:
 				// Increment horizontal scroll
@@ -291,31 +461,42 @@ func (p *PPU) Clock() {
 			if p.mask.IsRenderingEnabled() {
 				p.vramAddress.TransferX(&p.tempVRAMAddress)
 			}
-			// Sprite evaluation for next scanline
-			if p.scanline >= -1 && p.scanline < 240 {
-				p.spriteEvaluation()
-			}
+			p.finishSpriteEvaluation()
 		}
 
-		// Sprite pattern fetching (cycles 257-320)
-		if p.cycle == 320 {
-			if p.scanline >= -1 && p.scanline < 240 {
-				p.spriteFetching()
+		// Sprite evaluation for the next scanline (cycles 1-256)
+		if p.scanline >= 0 && p.cycle >= 1 && p.cycle <= 256 {
+			if p.cycle == 65 {
+				p.startSpriteEvaluation()
 			}
+			if p.mask.IsRenderingEnabled() {
+				p.evaluateSprites()
+			}
+		}
+
+		// OAMADDR is cleared during the sprite fetches
+		if p.cycle >= 257 && p.cycle <= 320 && p.mask.IsRenderingEnabled() {
+			p.oamAddress = 0
+		}
+
+		// Sprite pattern fetching (cycles 257-320), one slot per 8 cycles
+		if p.cycle >= 257 && p.cycle <= 320 && (p.cycle-257)%8 == 4 {
+			p.fetchSprite(uint8((p.cycle - 257) / 8))
 		}
 
-		// Notify mapper of scanline for IRQ counting (MMC3)
+		// Notify mapper of scanline for IRQ counting
 		// Only on visible scanlines (0-239), not pre-render
-		// Called at cycle 280 which is during sprite tile fetching
+		// Called at cycle 280 which is during sprite tile fetching.
+		// Mappers watching A12 (MMC3) are clocked by fetches instead.
 		if p.cycle == 280 && p.scanline >= 0 && p.mask.IsRenderingEnabled() {
-			if p.mapper != nil {
+			if p.mapper != nil && p.a12Mapper == nil {
 				p.mapper.Scanline()
 			}
 		}
 
 		// Superfluous nametable fetches at end of scanline
 		if p.cycle == 338 || p.cycle == 340 {
-			p.bgNextTileID = p.ppuRead(0x2000 | (p.vramAddress.Get() & 0x0FFF))
+			p.bgNextTileID = p.fetch(0x2000 | (p.vramAddress.Get() & 0x0FFF))
 		}
 
 		// Pre-render scanline: restore vertical position
@@ -329,38 +510,71 @@ func (p *PPU) Clock() {
 	// Post-render Scanline (240)
 	// Idle - PPU does nothing
 
-	// VBlank Scanlines (241-260)
-	if p.scanline == 241 && p.cycle == 1 {
-		// Set VBlank flag
-		p.status.SetVBlank(true)
+	// VBlank Scanlines (241-260 on NTSC)
+	if p.scanline == p.vblankScanline && p.cycle == 1 {
+		// Set VBlank flag, unless a PPUSTATUS read just before prevented it
+		if !p.suppressVBlank {
+			p.status.SetVBlank(true)
 
-		// Trigger NMI if enabled
-		if p.control.EnableNMI() {
+			// The NMI reaches the CPU two dots later, so a PPUSTATUS read
+			// in between can still cancel it
+			if p.control.EnableNMI() {
+				p.nmiDelay = 2
+			}
+		}
+		p.suppressVBlank = false
+
+		if logging.Enabled(logging.PPU, slog.LevelDebug) {
+			logging.Log(logging.PPU, slog.LevelDebug, "vblank start", "frame", p.frame, "nmi", p.control.EnableNMI())
+		}
+	} else if p.nmiDelay > 0 {
+		p.nmiDelay--
+		if p.nmiDelay == 0 && p.control.EnableNMI() {
 			p.nmiOutput = true
 		}
 	}
+}
 
-	// Advance Timing
+// advance moves the PPU to the next dot, handling the end of scanlines and
+// frames
+func (p *PPU) advance() {
 	p.cycle++
+	p.dot++
 
 	// End of scanline
 	if p.cycle >= CyclesPerScanline {
 		p.cycle = 0
-		p.scanline++
+		line := p.scanline
+
+		// Repeat the idle post-render line for overclocking
+		if p.scanline == VisibleScanlines && p.overclockLine < p.extraScanlines {
+			p.overclockLine++
+		} else {
+			p.overclockLine = 0
+			p.scanline++
+		}
 
 		// Odd frame skip: On odd frames, when rendering is enabled,
-		// cycle 0 of scanline 0 is skipped
-		if p.scanline == 0 && (p.frame&1) == 1 && p.mask.IsRenderingEnabled() {
+		// cycle 0 of scanline 0 is skipped (NTSC only)
+		if p.scanline == 0 && p.oddFrameSkip && (p.frame&1) == 1 && p.mask.IsRenderingEnabled() {
 			p.cycle = 1
 		}
 
 		// End of frame
-		if p.scanline >= ScanlinesPerFrame {
+		endOfFrame := p.scanline >= p.scanlinesPerFrame-1
+		if endOfFrame {
 			p.scanline = -1
 			p.frameComplete = true
 			p.frame++
 			p.oddFrame = !p.oddFrame
 		}
+
+		if p.scanlineHook != nil {
+			p.scanlineHook(int(line))
+		}
+		if endOfFrame && p.frameHook != nil {
+			p.frameHook()
+		}
 	}
 }
 
@@ -376,6 +590,31 @@ func (p *PPU) GetFrameBuffer() *[ScreenWidth * ScreenHeight]uint8 {
 	return &p.frameBuffer
 }
 
+// GetEmphasisBuffer returns the color emphasis of each pixel in the frame
+// buffer, as EmphasisRed/Green/Blue flags
+func (p *PPU) GetEmphasisBuffer() *[ScreenWidth * ScreenHeight]uint8 {
+	return &p.emphasisBuffer
+}
+
+// SetFrame replaces the frame buffer and the emphasis of each pixel, e.g. to
+// show a frame emulated ahead of the current state. Emulation never reads
+// the frame buffer, so this only changes what is displayed.
+func (p *PPU) SetFrame(frame, emphasis *[ScreenWidth * ScreenHeight]uint8) {
+	p.frameBuffer = *frame
+	p.emphasisBuffer = *emphasis
+}
+
+// GetFrameCount returns the number of frames completed since power-on
+func (p *PPU) GetFrameCount() uint64 {
+	return p.frame
+}
+
+// Position returns the scanline (-1 for pre-render) and dot the PPU runs
+// next
+func (p *PPU) Position() (scanline, cycle int) {
+	return int(p.scanline), int(p.cycle)
+}
+
 // IsFrameComplete returns true if a frame has been fully rendered
 func (p *PPU) IsFrameComplete() bool {
 	return p.frameComplete
@@ -386,8 +625,8 @@ func (p *PPU) ClearFrameComplete() {
 	p.frameComplete = false
 }
 
-// WriteOAM writes a byte directly to OAM at the specified address
-// Used by DMA transfer
+// WriteOAM writes a byte directly to OAM at the specified address,
+// bypassing OAMADDR
 func (p *PPU) WriteOAM(addr uint8, data uint8) {
 	p.oam[addr] = data
 }
@@ -405,14 +644,36 @@ func (p *PPU) Reset() {
 	p.readBuffer = 0
 	p.scanline = -1 // Start at pre-render scanline
 	p.cycle = 0
+	p.pendingDot = 0
 	p.nmiOutput = false
+	p.nmiDelay = 0
+	p.suppressVBlank = false
 }
 
 // WriteCPURegister handles writes from the CPU to PPU registers ($2000-$2007)
 func (p *PPU) WriteCPURegister(addr uint16, value uint8) {
+	p.catchUp()
+
+	if logging.Enabled(logging.PPU, logging.LevelTrace) {
+		logging.Log(logging.PPU, logging.LevelTrace, "register write",
+			logging.Hex16("addr", addr), logging.Hex8("value", value),
+			"scanline", p.scanline, "cycle", p.cycle)
+	}
+
 	switch addr {
 	case 0x2000: // PPUCTRL
+		nmiEnabled := p.control.EnableNMI()
 		p.control.Set(value)
+		// The NMI line is VBlank AND enable, so enabling NMI while the
+		// flag is set (even after a previous NMI this VBlank) drops the
+		// line again and raises a new NMI at once. The CPU sees the edge
+		// too late for this write's poll, so it is taken after the next
+		// instruction. Disabling NMI before a pending VBlank NMI reaches
+		// the CPU cancels it (see nmiDelay).
+		if !nmiEnabled && p.control.EnableNMI() && p.status.VBlank() {
+			p.nmiOutput = true
+			p.nmiDelay = 0
+		}
 		// t: ...GH.. ........ <- d: ......GH
 		p.tempVRAMAddress.SetNametableX(uint16(p.control.NametableX()))
 		p.tempVRAMAddress.SetNametableY(uint16(p.control.NametableY()))
@@ -424,6 +685,12 @@ func (p *PPU) WriteCPURegister(addr uint16, value uint8) {
 		p.oamAddress = value
 
 	case 0x2004: // OAMDATA
+		if p.IsRendering() {
+			// The write is lost while rendering owns OAM, but it still
+			// bumps the sprite index of OAMADDR
+			p.oamAddress += 4
+			break
+		}
 		p.oam[p.oamAddress] = value
 		p.oamAddress++ // Wraps around
 
@@ -457,16 +724,32 @@ func (p *PPU) WriteCPURegister(addr uint16, value uint8) {
 			p.tempVRAMAddress.Set((p.tempVRAMAddress.Get() & 0xFF00) | uint16(value))
 			p.vramAddress.Set(p.tempVRAMAddress.Get())
 			p.writeLatch = false
+			p.watchA12(p.vramAddress.Get())
 		}
 
 	case 0x2007: // PPUDATA
+		p.watchA12(p.vramAddress.Get())
 		p.ppuWrite(p.vramAddress.Get(), value)
-		p.vramAddress.Set(p.vramAddress.Get() + p.control.IncrementMode())
+		p.incrementAddress()
+	}
+}
+
+// incrementAddress advances v after a PPUDATA access. While rendering, the
+// access collides with the background fetches, and v gets the coarse X and
+// Y increments of the scroll logic instead of +1/+32.
+func (p *PPU) incrementAddress() {
+	if p.IsRendering() {
+		p.vramAddress.IncrementX()
+		p.vramAddress.IncrementY()
+		return
 	}
+	p.vramAddress.Set(p.vramAddress.Get() + p.control.IncrementMode())
 }
 
 // ReadCPURegister handles reads from the CPU to PPU registers ($2000-$2007)
 func (p *PPU) ReadCPURegister(addr uint16) uint8 {
+	p.catchUp()
+
 	var value uint8
 
 	switch addr {
@@ -476,19 +759,34 @@ func (p *PPU) ReadCPURegister(addr uint16) uint8 {
 		p.status.SetVBlank(false)
 		p.writeLatch = false
 
+		// Reads racing the start of VBlank (dot 1 of its first line)
+		if p.scanline == p.vblankScanline {
+			switch p.cycle {
+			case 1:
+				// One dot early: the flag reads clear and stays clear for
+				// this frame, with no NMI
+				p.suppressVBlank = true
+			case 2, 3:
+				// On the same dot or one later: the flag reads set, but
+				// the NMI is cancelled
+				p.nmiDelay = 0
+			}
+		}
+
 	case 0x2004: // OAMDATA
-		value = p.oam[p.oamAddress]
+		value = p.readOAMData()
 
 	case 0x2007: // PPUDATA
 		value = p.readBuffer
+		p.watchA12(p.vramAddress.Get())
 		p.readBuffer = p.ppuRead(p.vramAddress.Get())
 
 		// Palette reads are not buffered
-		if p.vramAddress.Get() >= 0x3F00 {
+		if p.vramAddress.Get()&0x3FFF >= 0x3F00 {
 			value = p.readBuffer
 		}
 
-		p.vramAddress.Set(p.vramAddress.Get() + p.control.IncrementMode())
+		p.incrementAddress()
 	}
 
 	return value
@@ -509,6 +807,11 @@ func (p *PPU) ppuRead(addr uint16) uint8 {
 
 	case addr < 0x3F00:
 		// Nametables
+		if p.nametableROM != nil {
+			if value, ok := p.nametableROM.ReadNametable(0x2000 | addr&0x0FFF); ok {
+				return value
+			}
+		}
 		return p.nametable[p.mirrorNametableAddress(addr)]
 
 	case addr < 0x4000:
@@ -533,6 +836,11 @@ func (p *PPU) ppuWrite(addr uint16, value uint8) {
 
 	case addr < 0x3F00:
 		// Nametables
+		if p.nametableROM != nil {
+			if _, ok := p.nametableROM.ReadNametable(0x2000 | addr&0x0FFF); ok {
+				return
+			}
+		}
 		p.nametable[p.mirrorNametableAddress(addr)] = value
 
 	case addr < 0x4000:
@@ -549,13 +857,12 @@ func (p *PPU) mirrorNametableAddress(addr uint16) uint16 {
 	table := addr / 0x0400
 	offset := addr % 0x0400
 
-	// Query mapper for current mirroring mode (some mappers like MMC3 change it dynamically)
-	mirrorMode := p.mirroringMode
-	if p.mapper != nil {
-		mirrorMode = p.mapper.GetMirroring()
+	if p.nametables != nil {
+		return uint16(p.nametables.NametablePage(uint8(table))&1)*0x0400 + offset
 	}
 
-	switch mirrorMode {
+	// Query mapper for current mirroring mode (some mappers like MMC3 change it dynamically)
+	switch p.Mirroring() {
 	case MirrorVertical:
 		return addr % 0x0800
 	case MirrorHorizontal:
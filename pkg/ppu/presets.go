@@ -0,0 +1,157 @@
+package ppu
+
+import (
+	"math"
+	"sync"
+)
+
+// PalettePreset is a built-in palette selectable by name
+type PalettePreset struct {
+	Name        string
+	Description string
+	build       func() *Palette
+}
+
+// Palette returns the preset's palette. It is shared and must not be
+// modified.
+func (p PalettePreset) Palette() *Palette {
+	return p.build()
+}
+
+// PalettePresets lists the built-in palettes; the first is the default
+var PalettePresets = []PalettePreset{
+	{"default", "standard NTSC palette", DefaultPalette},
+	{"composite", "generated from the NTSC composite signal", sync.OnceValue(compositePalette)},
+	{"deuteranopia", "default palette adjusted for green-blind viewers", sync.OnceValue(func() *Palette {
+		return daltonize(DefaultPalette(), deuteranopia)
+	})},
+	{"protanopia", "default palette adjusted for red-blind viewers", sync.OnceValue(func() *Palette {
+		return daltonize(DefaultPalette(), protanopia)
+	})},
+	{"tritanopia", "default palette adjusted for blue-blind viewers", sync.OnceValue(func() *Palette {
+		return daltonize(DefaultPalette(), tritanopia)
+	})},
+}
+
+// LookupPalettePreset finds a built-in palette by name
+func LookupPalettePreset(name string) (PalettePreset, bool) {
+	for _, p := range PalettePresets {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return PalettePreset{}, false
+}
+
+// compositePalette decodes each color from a model of the PPU's composite
+// video output: a square wave between two voltage levels at one of 12
+// phases, demodulated to YIQ and converted to sRGB. Emphasis attenuates
+// the signal during the phases of the emphasized colors.
+func compositePalette() *Palette {
+	const (
+		black, white = 0.518, 1.962
+		attenuation  = 0.746
+		hue          = 4.0 // Demodulation phase offset, in twelfths of a cycle
+		gamma        = 2.2 / 1.8
+	)
+	levels := [8]float64{0.350, 0.518, 0.962, 1.550, 1.094, 1.506, 1.962, 1.962}
+	inPhase := func(color, phase int) bool { return (color+phase)%12 < 6 }
+
+	var pal Palette
+	for emphasis := range pal {
+		for index := range pal[emphasis] {
+			color, level := index&0x0F, (index>>4)&3
+			if color > 13 {
+				level = 1
+			}
+			low, high := levels[level], levels[4+level]
+			if color == 0 {
+				low = high
+			}
+			if color > 12 {
+				high = low
+			}
+
+			var y, i, q float64
+			for phase := 0; phase < 12; phase++ {
+				signal := low
+				if inPhase(color, phase) {
+					signal = high
+				}
+				if (emphasis&1 != 0 && inPhase(0, phase)) ||
+					(emphasis&2 != 0 && inPhase(4, phase)) ||
+					(emphasis&4 != 0 && inPhase(8, phase)) {
+					signal *= attenuation
+				}
+				signal = (signal - black) / (white - black) / 12
+				angle := math.Pi * (float64(phase) + hue) / 6
+				y += signal
+				i += signal * math.Cos(angle)
+				q += signal * math.Sin(angle)
+			}
+
+			channel := func(v float64) uint8 {
+				if v <= 0 {
+					return 0
+				}
+				return uint8(min(255, math.Round(255*math.Pow(v, gamma))))
+			}
+			pal[emphasis][index] = Color{
+				R: channel(y + 0.946882*i + 0.623557*q),
+				G: channel(y - 0.274788*i - 0.635691*q),
+				B: channel(y - 1.108545*i + 1.709007*q),
+			}
+		}
+	}
+	return &pal
+}
+
+// Color vision deficiencies, as LMS-space projections
+type deficiency [3][3]float64
+
+var (
+	protanopia   = deficiency{{0, 2.02344, -2.52581}, {0, 1, 0}, {0, 0, 1}}
+	deuteranopia = deficiency{{1, 0, 0}, {0.494207, 0, 1.24827}, {0, 0, 1}}
+	tritanopia   = deficiency{{1, 0, 0}, {0, 1, 0}, {-0.395913, 0.801109, 0}}
+)
+
+// daltonize shifts the color information a viewer with the deficiency
+// cannot see into channels they can, so colors that would look alike stay
+// distinguishable
+func daltonize(base *Palette, d deficiency) *Palette {
+	toLMS := [3][3]float64{
+		{17.8824, 43.5161, 4.11935},
+		{3.45565, 27.1554, 3.86714},
+		{0.0299566, 0.184309, 1.46709},
+	}
+	toRGB := [3][3]float64{
+		{0.0809444479, -0.130504409, 0.116721066},
+		{-0.0102485335, 0.0540193266, -0.113614708},
+		{-0.000365296938, -0.00412161469, 0.693511405},
+	}
+	mul := func(m [3][3]float64, v [3]float64) [3]float64 {
+		var out [3]float64
+		for r := range out {
+			out[r] = m[r][0]*v[0] + m[r][1]*v[1] + m[r][2]*v[2]
+		}
+		return out
+	}
+	clamp := func(v float64) uint8 {
+		return uint8(min(max(math.Round(v), 0), 255))
+	}
+
+	var pal Palette
+	for e := range pal {
+		for i, c := range base[e] {
+			rgb := [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+			seen := mul(toRGB, mul(d, mul(toLMS, rgb)))
+			errR, errG, errB := rgb[0]-seen[0], rgb[1]-seen[1], rgb[2]-seen[2]
+			pal[e][i] = Color{
+				R: c.R,
+				G: clamp(rgb[1] + 0.7*errR + errG),
+				B: clamp(rgb[2] + 0.7*errR + errB),
+			}
+		}
+	}
+	return &pal
+}
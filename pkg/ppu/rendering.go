@@ -51,11 +51,19 @@ func (p *PPU) renderPixel() {
 		return
 	}
 
+	// Headless: only sprite 0 hit detection needs the pixel pipeline
+	if p.noPixelOutput && (!p.sprite0Present || p.status.Sprite0Hit()) {
+		return
+	}
+
 	// If rendering is completely disabled, output backdrop color only
 	if !p.mask.IsRenderingEnabled() {
+		if p.noPixelOutput {
+			return
+		}
 		// Rendering disabled - show backdrop color ($3F00)
 		backdropColor := p.ppuRead(0x3F00) & 0x3F
-		p.frameBuffer[y*ScreenWidth+x] = backdropColor
+		p.outputPixel(y*ScreenWidth+x, backdropColor)
 		return
 	}
 
@@ -91,6 +99,12 @@ func (p *PPU) renderPixel() {
 		bgPalette = (pal1 << 1) | pal0
 	}
 
+	p.composePixel(x, y, bgPixel, bgPalette)
+}
+
+// composePixel combines a background pixel with the sprites at x, detects
+// sprite 0 hits and outputs the result
+func (p *PPU) composePixel(x, y uint16, bgPixel, bgPalette uint8) {
 	// Render sprites and get sprite pixel
 	spritePixel, spritePalette, spritePriority, isSprite0 := p.renderSprites(x)
 
@@ -136,8 +150,23 @@ func (p *PPU) renderPixel() {
 		}
 	}
 
+	if p.noPixelOutput {
+		return
+	}
+
 	// Write to frame buffer
 	address := uint16((finalPalette << 2) | (finalPixel & 0x03))
 	colorIndex := p.ppuRead(0x3F00+address) & 0x3F
-	p.frameBuffer[y*ScreenWidth+x] = colorIndex
+	p.outputPixel(y*ScreenWidth+x, colorIndex)
+}
+
+// outputPixel writes a palette index to the frame buffer, applying the
+// grayscale and emphasis bits of PPUMASK
+func (p *PPU) outputPixel(i uint16, colorIndex uint8) {
+	if p.mask.Grayscale() {
+		// Grayscale forces the hue to 0, leaving only the luminance column
+		colorIndex &= 0x30
+	}
+	p.frameBuffer[i] = colorIndex
+	p.emphasisBuffer[i] = p.Emphasis()
 }
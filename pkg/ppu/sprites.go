@@ -1,68 +1,150 @@
 package ppu
 
-// spriteEvaluation performs sprite evaluation for the next scanline.
-// This happens during cycles 65-256 of the current scanline.
-// The PPU examines all 64 sprites in OAM and determines which ones
-// are visible on the next scanline (up to 8 sprites max).
-func (p *PPU) spriteEvaluation() {
-	// Clear secondary OAM
-	for i := range p.secondaryOAM {
-		p.secondaryOAM[i] = 0xFF
+// evaluateSprites runs one cycle of sprite evaluation for the next
+// scanline. Cycles 1-64 clear secondary OAM to $FF, two cycles per byte.
+// Cycles 65-256 scan primary OAM from OAMADDR, reading a byte on odd cycles
+// and writing it to secondary OAM on even ones, until 8 sprites in range
+// are copied or all 64 have been seen.
+func (p *PPU) evaluateSprites() {
+	if p.cycle <= 64 {
+		// OAMDATA reads return $FF while secondary OAM is cleared
+		p.oamLatch = 0xFF
+		if p.cycle&1 == 0 {
+			p.secondaryOAM[p.cycle/2-1] = p.oamLatch
+		}
+		return
+	}
+
+	if p.cycle&1 == 1 {
+		p.oamLatch = p.oam[p.evalN*4+p.evalM]
+		return
+	}
+
+	if p.evalDone {
+		// Evaluation is over but the PPU keeps stepping through OAM
+		p.evalN = (p.evalN + 1) & 63
+		return
+	}
+
+	// Secondary OAM full: keep scanning for a ninth sprite to set the
+	// overflow flag. A hardware bug advances the byte index m along with
+	// the sprite index n whenever a sprite is out of range, so the scan
+	// walks OAM diagonally, reading tile, attribute and X bytes as Y
+	// coordinates, and the flag can be set spuriously or missed.
+	if p.evalSlot >= uint8(len(p.secondaryOAM)) {
+		if p.spriteInRange(p.oamLatch) {
+			p.status.SetSpriteOverflow(true)
+			p.evalDone = true
+			return
+		}
+		p.evalM = (p.evalM + 1) & 3
+		p.nextSprite()
+		return
+	}
+
+	// Every byte read is written to secondary OAM, but the slot only
+	// advances once a Y coordinate is found to be in range
+	p.secondaryOAM[p.evalSlot] = p.oamLatch
+	if p.evalCopy == 0 {
+		if !p.spriteInRange(p.oamLatch) {
+			p.nextSprite()
+			return
+		}
+		// The first sprite examined is the one that sets sprite 0 hit
+		if p.cycle == 66 {
+			p.evalSprite0 = true
+		}
+		p.evalCopy = 4
+	}
+	p.evalSlot++
+	p.evalCopy--
+	p.evalM = (p.evalM + 1) & 3
+	if p.evalM == 0 {
+		p.nextSprite()
 	}
+}
+
+// startSpriteEvaluation resets the evaluation state at cycle 65, taking the
+// first sprite and byte examined from OAMADDR
+func (p *PPU) startSpriteEvaluation() {
+	p.evalN = p.oamAddress >> 2
+	p.evalM = p.oamAddress & 3
+	p.evalSlot = 0
+	p.evalCopy = 0
+	p.evalSprite0 = false
+	p.evalDone = false
+}
 
+// finishSpriteEvaluation hands the sprites found for the next scanline to
+// the sprite fetches at cycle 257
+func (p *PPU) finishSpriteEvaluation() {
 	p.spriteCount = 0
 	p.sprite0Present = false
 
-	// Debug: Only evaluate if rendering is enabled
-	if !p.mask.IsRenderingEnabled() {
+	// Nothing is evaluated on the pre-render line or with rendering off
+	if p.scanline < 0 || !p.mask.IsRenderingEnabled() {
 		return
 	}
+	p.spriteCount = (p.evalSlot + 3) / 4
+	p.sprite0Present = p.evalSprite0
+}
+
+// nextSprite advances evaluation to the next sprite, ending it once n
+// wraps back to 0
+func (p *PPU) nextSprite() {
+	p.evalN = (p.evalN + 1) & 63
+	if p.evalN == 0 {
+		p.evalDone = true
+	}
+}
 
-	// Get sprite height (8x8 or 8x16)
+// spriteInRange reports whether a sprite with Y coordinate y is visible
+// on the next scanline
+func (p *PPU) spriteInRange(y uint8) bool {
 	spriteHeight := uint16(8)
 	if p.control.SpriteSize() != 0 {
 		spriteHeight = 16
 	}
+	return uint16(p.scanline)-uint16(y) < spriteHeight
+}
 
-	// Scan through all 64 sprites
-	for i := uint8(0); i < 64; i++ {
-		// Read sprite Y position (byte 0 of sprite data)
-		oamIndex := uint16(i) * 4
-		spriteY := uint16(p.oam[oamIndex])
-
-		// Calculate the difference between current scanline and sprite Y
-		// The sprite is visible if the scanline is within sprite height
-		diff := uint16(p.scanline) - spriteY
-
-		// Check if sprite is on the next scanline
-		if diff < spriteHeight {
-			// Check if we've already found 8 sprites
-			if p.spriteCount >= 8 {
-				// Set sprite overflow flag
-				p.status.SetSpriteOverflow(true)
-				break
-			}
-
-			// Copy sprite to secondary OAM
-			secondaryIndex := uint16(p.spriteCount) * 4
-			p.secondaryOAM[secondaryIndex+0] = p.oam[oamIndex+0] // Y position
-			p.secondaryOAM[secondaryIndex+1] = p.oam[oamIndex+1] // Tile index
-			p.secondaryOAM[secondaryIndex+2] = p.oam[oamIndex+2] // Attributes
-			p.secondaryOAM[secondaryIndex+3] = p.oam[oamIndex+3] // X position
-
-			// Check if this is sprite 0
-			if i == 0 {
-				p.sprite0Present = true
-			}
+// corruptOAM emulates the 2C02's OAMADDR bug at the start of rendering: if
+// OAMADDR was left at 8 or more (typically by a write during the frame),
+// the eight bytes of the row it points into are copied over sprites 0 and
+// 1. Games avoid it by writing OAMADDR before rendering starts, usually 0
+// ahead of an OAM DMA.
+func (p *PPU) corruptOAM() {
+	if p.oamAddress < 8 || !p.mask.IsRenderingEnabled() {
+		return
+	}
+	row := p.oamAddress & 0xF8
+	copy(p.oam[:8], p.oam[row:row+8])
+}
 
-			p.spriteCount++
-		}
+// readOAMData returns what an OAMDATA read sees. While rendering, the PPU
+// owns OAM and the read returns whatever byte the sprite logic is
+// accessing on this cycle.
+func (p *PPU) readOAMData() uint8 {
+	if !p.IsRendering() {
+		return p.oam[p.oamAddress]
+	}
+	switch {
+	case p.cycle >= 1 && p.cycle <= 256:
+		return p.oamLatch
+	case p.cycle >= 257 && p.cycle <= 320:
+		// Sprite fetches read Y, tile, attribute and X, then X for the rest
+		// of the slot
+		slot := (p.cycle - 257) / 8
+		return p.secondaryOAM[slot*4+min((p.cycle-257)%8, 3)]
 	}
+	return p.secondaryOAM[0]
 }
 
-// spriteFetching fetches pattern data for all sprites in secondary OAM.
-// This happens during cycles 257-320 of the current scanline.
-func (p *PPU) spriteFetching() {
+// fetchSprite fetches pattern data for one slot of secondary OAM.
+// Hardware spreads these fetches over cycles 257-320, eight cycles per
+// slot; empty slots still fetch tile $FF, which matters to mappers that
+// watch PPU A12.
+func (p *PPU) fetchSprite(i uint8) {
 	// Get sprite height and pattern table address
 	spriteHeight := uint16(8)
 	spritePatternTable := p.control.SpritePatternTable()
@@ -72,69 +154,80 @@ func (p *PPU) spriteFetching() {
 		spriteHeight = 16
 	}
 
-	// Fetch pattern data for each sprite in secondary OAM
-	for i := uint8(0); i < p.spriteCount; i++ {
-		secondaryIndex := uint16(i) * 4
+	// Each slot starts with two garbage nametable fetches
+	p.fetch(0x2000 | (p.vramAddress.Get() & 0x0FFF))
+
+	if i >= p.spriteCount {
+		// Dummy fetch for an empty slot
+		address := spritePatternTable | 0x0FF0
+		if spriteHeight == 16 {
+			address = 0x1FF0
+		}
+		p.fetch(address)
+		p.fetch(address + 8)
+		return
+	}
 
-		// Read sprite data from secondary OAM
-		spriteY := p.secondaryOAM[secondaryIndex+0]
-		tileIndex := p.secondaryOAM[secondaryIndex+1]
-		attributes := p.secondaryOAM[secondaryIndex+2]
-		spriteX := p.secondaryOAM[secondaryIndex+3]
+	secondaryIndex := uint16(i) * 4
 
-		// Store attributes and X position for rendering
-		p.spriteAttributes[i] = attributes
-		p.spritePositions[i] = spriteX
+	// Read sprite data from secondary OAM
+	spriteY := p.secondaryOAM[secondaryIndex+0]
+	tileIndex := p.secondaryOAM[secondaryIndex+1]
+	attributes := p.secondaryOAM[secondaryIndex+2]
+	spriteX := p.secondaryOAM[secondaryIndex+3]
 
-		// Calculate which row of the sprite we're on
-		spriteRow := uint16(p.scanline) - uint16(spriteY)
+	// Store attributes and X position for rendering
+	p.spriteAttributes[i] = attributes
+	p.spritePositions[i] = spriteX
 
-		// Check vertical flip
-		if attributes&0x80 != 0 {
-			// Flip vertically
-			spriteRow = spriteHeight - 1 - spriteRow
-		}
+	// Calculate which row of the sprite we're on
+	spriteRow := uint16(p.scanline) - uint16(spriteY)
 
-		// Calculate pattern address
-		var patternAddress uint16
+	// Check vertical flip
+	if attributes&0x80 != 0 {
+		// Flip vertically
+		spriteRow = spriteHeight - 1 - spriteRow
+	}
 
-		if spriteHeight == 16 {
-			// 8x16 sprites
-			// Bit 0 of tile index selects pattern table
-			// Bits 1-7 select tile pair
-			if spriteRow < 8 {
-				// Top half
-				patternAddress = (uint16(tileIndex&0x01) << 12) |
-					(uint16(tileIndex&0xFE) << 4) |
-					(spriteRow & 0x07)
-			} else {
-				// Bottom half
-				patternAddress = (uint16(tileIndex&0x01) << 12) |
-					((uint16(tileIndex&0xFE) + 1) << 4) |
-					((spriteRow - 8) & 0x07)
-			}
-		} else {
-			// 8x8 sprites
-			patternAddress = spritePatternTable |
-				(uint16(tileIndex) << 4) |
+	// Calculate pattern address
+	var patternAddress uint16
+
+	if spriteHeight == 16 {
+		// 8x16 sprites
+		// Bit 0 of tile index selects pattern table
+		// Bits 1-7 select tile pair
+		if spriteRow < 8 {
+			// Top half
+			patternAddress = (uint16(tileIndex&0x01) << 12) |
+				(uint16(tileIndex&0xFE) << 4) |
 				(spriteRow & 0x07)
+		} else {
+			// Bottom half
+			patternAddress = (uint16(tileIndex&0x01) << 12) |
+				((uint16(tileIndex&0xFE) + 1) << 4) |
+				((spriteRow - 8) & 0x07)
 		}
+	} else {
+		// 8x8 sprites
+		patternAddress = spritePatternTable |
+			(uint16(tileIndex) << 4) |
+			(spriteRow & 0x07)
+	}
 
-		// Fetch pattern data (low and high bytes)
-		patternLow := p.ppuRead(patternAddress)
-		patternHigh := p.ppuRead(patternAddress + 8)
-
-		// Check horizontal flip
-		if attributes&0x40 != 0 {
-			// Flip horizontally by reversing bits
-			patternLow = reverseByte(patternLow)
-			patternHigh = reverseByte(patternHigh)
-		}
+	// Fetch pattern data (low and high bytes)
+	patternLow := p.fetch(patternAddress)
+	patternHigh := p.fetch(patternAddress + 8)
 
-		// Store in sprite shifters
-		p.spriteShifterPatternLo[i] = patternLow
-		p.spriteShifterPatternHi[i] = patternHigh
+	// Check horizontal flip
+	if attributes&0x40 != 0 {
+		// Flip horizontally by reversing bits
+		patternLow = reverseByte(patternLow)
+		patternHigh = reverseByte(patternHigh)
 	}
+
+	// Store in sprite shifters
+	p.spriteShifterPatternLo[i] = patternLow
+	p.spriteShifterPatternHi[i] = patternHigh
 }
 
 // reverseByte reverses the bits in a byte (used for horizontal sprite flipping)
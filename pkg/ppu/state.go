@@ -0,0 +1,178 @@
+package ppu
+
+import (
+	"fmt"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/state"
+)
+
+// WriteState appends the PPU's emulated state to w: memories, registers,
+// latches, rendering pipeline and timing. The frame buffer is output rather
+// than state and is not included.
+func (p *PPU) WriteState(w *state.Writer) {
+	w.Block(p.nametable[:])
+	w.Block(p.paletteRAM[:])
+	w.Block(p.oam[:])
+	w.U8(p.oamAddress)
+
+	w.U8(p.control.Get())
+	w.U8(p.mask.Get())
+	w.U8(p.status.Get())
+	w.U8(p.oamData)
+	w.U8(p.ppuScroll)
+	w.U8(p.ppuAddr)
+	w.U8(p.ppuData)
+
+	w.U16(p.vramAddress.Get())
+	w.U16(p.tempVRAMAddress.Get())
+	w.U8(p.fineX)
+	w.Bool(p.writeLatch)
+	w.U8(p.readBuffer)
+
+	w.U16(uint16(p.scanline))
+	w.U16(p.cycle)
+	w.U64(p.frame)
+	w.Bool(p.oddFrame)
+	w.Bool(p.frameComplete)
+	w.U16(uint16(p.overclockLine))
+
+	w.U8(p.bgNextTileID)
+	w.U8(p.bgNextTileAttrib)
+	w.U8(p.bgNextTileLSB)
+	w.U8(p.bgNextTileMSB)
+	w.U16(p.bgShifterPatternLo)
+	w.U16(p.bgShifterPatternHi)
+	w.U16(p.bgShifterAttribLo)
+	w.U16(p.bgShifterAttribHi)
+
+	w.Block(p.secondaryOAM[:])
+	w.U8(p.oamLatch)
+	w.U8(p.evalN)
+	w.U8(p.evalM)
+	w.U8(p.evalSlot)
+	w.U8(p.evalCopy)
+	w.Bool(p.evalSprite0)
+	w.Bool(p.evalDone)
+	w.U8(p.spriteCount)
+	w.Bool(p.sprite0Present)
+	w.Block(p.spriteShifterPatternLo[:])
+	w.Block(p.spriteShifterPatternHi[:])
+	w.Block(p.spriteAttributes[:])
+	w.Block(p.spritePositions[:])
+
+	w.Bool(p.a12High)
+	w.U64(p.a12LowDot)
+	w.U64(p.dot)
+	w.U16(p.pendingDot)
+
+	w.U8(p.mirroringMode)
+	w.Bool(p.nmiOutput)
+	w.U8(p.nmiDelay)
+	w.Bool(p.suppressVBlank)
+}
+
+// ppuStateVersion tags MarshalBinary's encoding, so states from another
+// layout are rejected rather than misread
+const ppuStateVersion = 1
+
+// MarshalBinary encodes the PPU's state (see WriteState) followed by the
+// frame and emphasis buffers, so a state taken mid-frame finishes drawing
+// the same picture once restored with UnmarshalBinary. Settings (region,
+// overclock, palette, overscan, render mode) and the mapper connection are
+// not included.
+func (p *PPU) MarshalBinary() ([]byte, error) {
+	return p.AppendBinary(make([]byte, 0, 4096+2*len(p.frameBuffer)))
+}
+
+// AppendBinary appends MarshalBinary's encoding to b, letting frequent
+// snapshots (see nes.NES.SetRunAhead) reuse a buffer
+func (p *PPU) AppendBinary(b []byte) ([]byte, error) {
+	w := state.NewWriterOver(b)
+	w.U8(ppuStateVersion)
+	p.WriteState(w)
+	w.Block(p.frameBuffer[:])
+	w.Block(p.emphasisBuffer[:])
+	return w.Bytes(), nil
+}
+
+// UnmarshalBinary restores state encoded by MarshalBinary, leaving the PPU
+// untouched if data is invalid
+func (p *PPU) UnmarshalBinary(data []byte) error {
+	r := state.NewReader(data)
+	if v := r.U8(); v != ppuStateVersion {
+		return fmt.Errorf("ppu: unsupported state version %d", v)
+	}
+
+	next := *p
+	r.Block(next.nametable[:])
+	r.Block(next.paletteRAM[:])
+	r.Block(next.oam[:])
+	next.oamAddress = r.U8()
+
+	next.control.Set(r.U8())
+	next.mask.Set(r.U8())
+	next.status.Set(r.U8())
+	next.oamData = r.U8()
+	next.ppuScroll = r.U8()
+	next.ppuAddr = r.U8()
+	next.ppuData = r.U8()
+
+	next.vramAddress.Set(r.U16())
+	next.tempVRAMAddress.Set(r.U16())
+	next.fineX = r.U8()
+	next.writeLatch = r.Bool()
+	next.readBuffer = r.U8()
+
+	next.scanline = int16(r.U16())
+	next.cycle = r.U16()
+	next.frame = r.U64()
+	next.oddFrame = r.Bool()
+	next.frameComplete = r.Bool()
+	next.overclockLine = int16(r.U16())
+
+	next.bgNextTileID = r.U8()
+	next.bgNextTileAttrib = r.U8()
+	next.bgNextTileLSB = r.U8()
+	next.bgNextTileMSB = r.U8()
+	next.bgShifterPatternLo = r.U16()
+	next.bgShifterPatternHi = r.U16()
+	next.bgShifterAttribLo = r.U16()
+	next.bgShifterAttribHi = r.U16()
+
+	r.Block(next.secondaryOAM[:])
+	next.oamLatch = r.U8()
+	next.evalN = r.U8()
+	next.evalM = r.U8()
+	next.evalSlot = r.U8()
+	next.evalCopy = r.U8()
+	next.evalSprite0 = r.Bool()
+	next.evalDone = r.Bool()
+	next.spriteCount = r.U8()
+	next.sprite0Present = r.Bool()
+	r.Block(next.spriteShifterPatternLo[:])
+	r.Block(next.spriteShifterPatternHi[:])
+	r.Block(next.spriteAttributes[:])
+	r.Block(next.spritePositions[:])
+
+	next.a12High = r.Bool()
+	next.a12LowDot = r.U64()
+	next.dot = r.U64()
+	next.pendingDot = r.U16()
+
+	next.mirroringMode = r.U8()
+	next.nmiOutput = r.Bool()
+	next.nmiDelay = r.U8()
+	next.suppressVBlank = r.Bool()
+
+	r.Block(next.frameBuffer[:])
+	r.Block(next.emphasisBuffer[:])
+
+	if err := r.Err(); err != nil {
+		return fmt.Errorf("ppu: %w", err)
+	}
+	if r.Remaining() != 0 {
+		return fmt.Errorf("ppu: %d trailing bytes in state", r.Remaining())
+	}
+	*p = next
+	return nil
+}
@@ -0,0 +1,162 @@
+package ppu
+
+import "image"
+
+// Pattern table image size: 16x16 tiles of 8x8 pixels
+const PatternTableSize = 128
+
+// RenderPatternTable decodes one 4KB pattern table (0 = $0000, 1 = $1000)
+// into a 128x128 image of 16x16 tiles, colored with the given palette (0-3
+// background, 4-7 sprite) from palette RAM. Reads have no side effects on
+// the PPU, though CHR is fetched through the mapper as currently banked.
+func (p *PPU) RenderPatternTable(table int, palette uint8) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, PatternTableSize, PatternTableSize))
+	base := uint16(table&1) * 0x1000
+	colors := p.paletteColors(palette)
+
+	for tile := 0; tile < 256; tile++ {
+		tx, ty := (tile%16)*8, (tile/16)*8
+		p.drawTile(img, tx, ty, base+uint16(tile)*16, &colors)
+	}
+	return img
+}
+
+// RenderNametables draws all four logical nametables ($2000-$2FFF), after
+// mirroring, as a 512x480 image using the background pattern table and
+// attribute palettes currently selected
+func (p *PPU) RenderNametables() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 2*ScreenWidth, 2*ScreenHeight))
+	base := p.control.BackgroundPatternTable()
+	var colors [4][4]Color
+	for palette := range colors {
+		colors[palette] = p.paletteColors(uint8(palette))
+	}
+
+	for nt := uint16(0); nt < 4; nt++ {
+		ntAddr := 0x2000 + nt*0x400
+		ox, oy := int(nt%2)*ScreenWidth, int(nt/2)*ScreenHeight
+		for row := uint16(0); row < 30; row++ {
+			for col := uint16(0); col < 32; col++ {
+				tile := p.ppuRead(ntAddr + row*32 + col)
+				attr := p.ppuRead(ntAddr + 0x3C0 + (row/4)*8 + col/4)
+				shift := ((row & 2) << 1) | (col & 2)
+				palette := (attr >> shift) & 0x03
+				p.drawTile(img, ox+int(col)*8, oy+int(row)*8, base+uint16(tile)*16, &colors[palette])
+			}
+		}
+	}
+	return img
+}
+
+// paletteColors resolves the four colors of a palette (0-7) through palette
+// RAM, with the backdrop as color 0
+func (p *PPU) paletteColors(palette uint8) [4]Color {
+	pal := p.GetPalette()
+	colors := [4]Color{pal.Color(p.ppuRead(0x3F00), 0)}
+	for pixel := uint16(1); pixel < 4; pixel++ {
+		colors[pixel] = pal.Color(p.ppuRead(0x3F00+uint16(palette&7)<<2+pixel), 0)
+	}
+	return colors
+}
+
+// drawTile decodes the two bit planes of the 8x8 tile whose pattern starts
+// at addr and draws it at (x, y)
+func (p *PPU) drawTile(img *image.RGBA, x, y int, addr uint16, colors *[4]Color) {
+	for row := 0; row < 8; row++ {
+		lo := p.ppuRead(addr + uint16(row))
+		hi := p.ppuRead(addr + uint16(row) + 8)
+		for col := 0; col < 8; col++ {
+			bit := uint8(7 - col)
+			c := colors[((hi>>bit)&1)<<1|(lo>>bit)&1]
+			i := img.PixOffset(x+col, y+row)
+			img.Pix[i+0] = c.R
+			img.Pix[i+1] = c.G
+			img.Pix[i+2] = c.B
+			img.Pix[i+3] = 255
+		}
+	}
+}
+
+// Sprite is a decoded OAM entry
+type Sprite struct {
+	Index   int    // OAM slot (0-63)
+	X, Y    uint8  // Position; sprites appear one line below Y
+	Tile    uint8  // Tile index byte (for 8x16, bit 0 selects the pattern table)
+	Palette uint8  // Sprite palette (4-7, as for RenderPatternTable)
+	FlipH   bool   // Mirrored horizontally
+	FlipV   bool   // Mirrored vertically
+	Behind  bool   // Drawn behind opaque background pixels
+	Height  int    // 8 or 16, from PPUCTRL
+	Pattern uint16 // Pattern address of the (top) tile
+}
+
+// GetSprites decodes all 64 OAM entries, using the sprite size and pattern
+// table currently selected in PPUCTRL. Taking it has no side effects.
+func (p *PPU) GetSprites() []Sprite {
+	height := 8
+	if p.control.SpriteSize() != 0 {
+		height = 16
+	}
+
+	sprites := make([]Sprite, 64)
+	for i := range sprites {
+		entry := p.oam[i*4 : i*4+4]
+		s := Sprite{
+			Index:   i,
+			Y:       entry[0],
+			Tile:    entry[1],
+			Palette: 4 + entry[2]&0x03,
+			FlipH:   entry[2]&0x40 != 0,
+			FlipV:   entry[2]&0x80 != 0,
+			Behind:  entry[2]&0x20 != 0,
+			X:       entry[3],
+			Height:  height,
+		}
+		if height == 16 {
+			s.Pattern = uint16(s.Tile&0x01)<<12 | uint16(s.Tile&0xFE)<<4
+		} else {
+			s.Pattern = p.control.SpritePatternTable() | uint16(s.Tile)<<4
+		}
+		sprites[i] = s
+	}
+	return sprites
+}
+
+// RenderSprite draws a sprite as it appears on screen, flipped and colored
+// with its palette, as an 8x8 or 8x16 image. Color 0 is transparent.
+func (p *PPU) RenderSprite(s Sprite) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 8, s.Height))
+	colors := p.paletteColors(s.Palette)
+
+	for row := 0; row < s.Height; row++ {
+		// The bottom half of an 8x16 sprite is the next tile
+		addr := s.Pattern + uint16(row&7)
+		if row >= 8 {
+			addr += 16
+		}
+		lo, hi := p.ppuRead(addr), p.ppuRead(addr+8)
+
+		y := row
+		if s.FlipV {
+			y = s.Height - 1 - row
+		}
+		for col := 0; col < 8; col++ {
+			bit := uint8(7 - col)
+			pixel := ((hi>>bit)&1)<<1 | (lo>>bit)&1
+			if pixel == 0 {
+				continue
+			}
+			x := col
+			if s.FlipH {
+				x = 7 - col
+			}
+			c := colors[pixel]
+			i := img.PixOffset(x, y)
+			img.Pix[i+0] = c.R
+			img.Pix[i+1] = c.G
+			img.Pix[i+2] = c.B
+			img.Pix[i+3] = 255
+		}
+	}
+	return img
+}
@@ -0,0 +1,285 @@
+// Package romdb identifies NES cartridges by the hash of their ROM
+// contents and supplies the board configuration a correct header would
+// carry.
+//
+// Many iNES dumps in circulation have wrong or incomplete headers (bad
+// mapper numbers, missing battery bits, garbage in the padding bytes).
+// Hashing the PRG-ROM and CHR-ROM ignores the header entirely, so a
+// database keyed on that hash, in the style of NesCartDB, can repair them.
+//
+// The bundled database has two parts. nescartdb.txt is generated from the
+// NesCartDB XML export by cmd/romdb-gen and ships empty, since the export
+// is not redistributed with this repository. games.txt holds hand-verified
+// dumps and overrides it; it currently lists only the nestest test ROM. As
+// shipped, Default therefore repairs no game's header and knows no game's
+// title. Regenerate nescartdb.txt from an export, or Parse a list and Add
+// it to Default, to put it to work.
+package romdb
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Mirroring is the nametable arrangement soldered on the board
+type Mirroring uint8
+
+const (
+	MirrorMapper     Mirroring = iota // Controlled by the mapper; keep the header's
+	MirrorHorizontal                  // Vertical arrangement (horizontal scrolling games)
+	MirrorVertical                    // Horizontal arrangement
+	MirrorFourScreen                  // Extra nametable RAM on the cartridge
+)
+
+// Entry describes one known ROM dump
+type Entry struct {
+	Title string
+
+	// Hashes of PRG-ROM followed by CHR-ROM, header and trainer excluded
+	CRC32 uint32
+	SHA1  [sha1.Size]byte // All zero when unknown
+
+	Mapper    uint16
+	Submapper uint8
+	Mirroring Mirroring
+	PRGRAM    int   // PRG-RAM size in bytes (work and save RAM together)
+	Battery   bool  // PRG-RAM is battery-backed
+	Region    uint8 // NES 2.0 timing value: 0 NTSC, 1 PAL, 2 multi, 3 Dendy
+
+	// BadDump marks a known corrupt dump, listed so the loader can warn
+	// about it. Set for titles carrying a GoodNES-style [b] or [bN] tag.
+	BadDump bool
+}
+
+// DB is a set of entries indexed by hash. It is safe for concurrent use.
+type DB struct {
+	mu    sync.RWMutex
+	byCRC map[uint32][]Entry
+}
+
+// New returns an empty database
+func New() *DB {
+	return &DB{byCRC: make(map[uint32][]Entry)}
+}
+
+// Add inserts entries, replacing any with the same CRC32 and SHA-1
+func (db *DB) Add(entries ...Entry) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for _, e := range entries {
+		list := db.byCRC[e.CRC32]
+		replaced := false
+		for i := range list {
+			if list[i].SHA1 == e.SHA1 {
+				list[i] = e
+				replaced = true
+			}
+		}
+		if !replaced {
+			list = append(list, e)
+		}
+		db.byCRC[e.CRC32] = list
+	}
+}
+
+// Len returns the number of entries
+func (db *DB) Len() int {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	n := 0
+	for _, list := range db.byCRC {
+		n += len(list)
+	}
+	return n
+}
+
+// Lookup hashes prg and chr and returns the matching entry. An entry
+// with a SHA-1 must match it as well as the CRC32.
+func (db *DB) Lookup(prg, chr []byte) (Entry, bool) {
+	crc, sum := Hash(prg, chr)
+	return db.LookupHash(crc, sum)
+}
+
+// LookupHash is Lookup for ROM contents that have already been hashed
+func (db *DB) LookupHash(crc uint32, sum [sha1.Size]byte) (Entry, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for _, e := range db.byCRC[crc] {
+		if e.SHA1 == ([sha1.Size]byte{}) || e.SHA1 == sum {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// LookupCRC returns the first entry with the given CRC32
+func (db *DB) LookupCRC(crc uint32) (Entry, bool) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if list := db.byCRC[crc]; len(list) > 0 {
+		return list[0], true
+	}
+	return Entry{}, false
+}
+
+// Hash returns the CRC32 and SHA-1 of prg followed by chr
+func Hash(prg, chr []byte) (uint32, [sha1.Size]byte) {
+	crc := crc32.NewIEEE()
+	sum := sha1.New()
+	w := io.MultiWriter(crc, sum)
+	w.Write(prg)
+	w.Write(chr)
+
+	var out [sha1.Size]byte
+	sum.Sum(out[:0])
+	return crc.Sum32(), out
+}
+
+var (
+	//go:embed nescartdb.txt
+	generated string
+
+	//go:embed games.txt
+	bundled string
+)
+
+var (
+	defaultOnce sync.Once
+	defaultDB   *DB
+)
+
+// Default returns the database loaded from the bundled nescartdb.txt and
+// games.txt, plus anything added to it since. See the package comment for
+// what they contain.
+func Default() *DB {
+	defaultOnce.Do(func() {
+		defaultDB = New()
+		for _, file := range []struct{ name, text string }{
+			{"nescartdb.txt", generated},
+			{"games.txt", bundled},
+		} {
+			entries, err := Parse(strings.NewReader(file.text))
+			if err != nil {
+				panic("romdb: bundled " + file.name + ": " + err.Error())
+			}
+			defaultDB.Add(entries...)
+		}
+	})
+	return defaultDB
+}
+
+// Parse reads entries in the games.txt format: one dump per line as
+//
+//	crc32 sha1 mapper[.submapper] mirroring prgram battery region title...
+//
+// crc32 and sha1 are hex (sha1 may be "-"), mirroring is one of mapper,
+// h, v or 4, prgram is a size such as 0, 8k or 32k, battery is y or n,
+// and region is ntsc, pal, multi or dendy. A [b] tag in the title marks
+// a bad dump. Blank lines and lines starting with # are ignored.
+func Parse(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+	sc := bufio.NewScanner(r)
+	for line := 1; sc.Scan(); line++ {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		e, err := parseLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("romdb: line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, sc.Err()
+}
+
+// badDumpTag matches the GoodNES bad dump tag in a title, e.g. "[b]", "[b2]"
+var badDumpTag = regexp.MustCompile(`\[b\d*\]`)
+
+// parseLine parses one games.txt entry
+func parseLine(text string) (Entry, error) {
+	fields := strings.Fields(text)
+	if len(fields) < 8 {
+		return Entry{}, fmt.Errorf("want 8 fields, have %d", len(fields))
+	}
+	var e Entry
+	e.Title = strings.Join(fields[7:], " ")
+	e.BadDump = badDumpTag.MatchString(e.Title)
+
+	crc, err := strconv.ParseUint(fields[0], 16, 32)
+	if err != nil {
+		return Entry{}, fmt.Errorf("bad crc32 %q", fields[0])
+	}
+	e.CRC32 = uint32(crc)
+
+	if fields[1] != "-" {
+		sum, err := hex.DecodeString(fields[1])
+		if err != nil || len(sum) != sha1.Size {
+			return Entry{}, fmt.Errorf("bad sha1 %q", fields[1])
+		}
+		copy(e.SHA1[:], sum)
+	}
+
+	mapper, sub, _ := strings.Cut(fields[2], ".")
+	m, err := strconv.ParseUint(mapper, 10, 12)
+	if err != nil {
+		return Entry{}, fmt.Errorf("bad mapper %q", fields[2])
+	}
+	e.Mapper = uint16(m)
+	if sub != "" {
+		s, err := strconv.ParseUint(sub, 10, 4)
+		if err != nil {
+			return Entry{}, fmt.Errorf("bad submapper %q", fields[2])
+		}
+		e.Submapper = uint8(s)
+	}
+
+	switch fields[3] {
+	case "mapper":
+		e.Mirroring = MirrorMapper
+	case "h":
+		e.Mirroring = MirrorHorizontal
+	case "v":
+		e.Mirroring = MirrorVertical
+	case "4":
+		e.Mirroring = MirrorFourScreen
+	default:
+		return Entry{}, fmt.Errorf("bad mirroring %q", fields[3])
+	}
+
+	size, unit := strings.CutSuffix(strings.ToLower(fields[4]), "k")
+	n, err := strconv.Atoi(size)
+	if err != nil || n < 0 {
+		return Entry{}, fmt.Errorf("bad prg-ram size %q", fields[4])
+	}
+	if unit {
+		n *= 1024
+	}
+	e.PRGRAM = n
+
+	switch fields[5] {
+	case "y":
+		e.Battery = true
+	case "n":
+	default:
+		return Entry{}, fmt.Errorf("bad battery flag %q", fields[5])
+	}
+
+	regions := map[string]uint8{"ntsc": 0, "pal": 1, "multi": 2, "dendy": 3}
+	region, ok := regions[fields[6]]
+	if !ok {
+		return Entry{}, fmt.Errorf("bad region %q", fields[6])
+	}
+	e.Region = region
+
+	return e, nil
+}
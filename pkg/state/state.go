@@ -0,0 +1,173 @@
+// Package state provides the binary encoding used to capture emulator state.
+//
+// Each component appends its state to a Writer in a fixed field order. The
+// result is stable across runs and platforms (little-endian, no padding), so
+// it can be hashed to compare two emulator instances for desyncs, and read
+// back field by field with a Reader to restore a component.
+package state
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Writer accumulates encoded component state
+type Writer struct {
+	buf []byte
+}
+
+// NewWriter creates a Writer with room for size bytes before growing
+func NewWriter(size int) *Writer {
+	return &Writer{buf: make([]byte, 0, size)}
+}
+
+// NewWriterOver creates a Writer that appends to b
+func NewWriterOver(b []byte) *Writer {
+	return &Writer{buf: b}
+}
+
+// Bytes returns the encoded state. The slice aliases the Writer's buffer.
+func (w *Writer) Bytes() []byte {
+	return w.buf
+}
+
+// Reset discards the encoded state, keeping the buffer for reuse
+func (w *Writer) Reset() {
+	w.buf = w.buf[:0]
+}
+
+// U8 appends a byte
+func (w *Writer) U8(v uint8) {
+	w.buf = append(w.buf, v)
+}
+
+// U16 appends a 16-bit value
+func (w *Writer) U16(v uint16) {
+	w.buf = binary.LittleEndian.AppendUint16(w.buf, v)
+}
+
+// U32 appends a 32-bit value
+func (w *Writer) U32(v uint32) {
+	w.buf = binary.LittleEndian.AppendUint32(w.buf, v)
+}
+
+// U64 appends a 64-bit value
+func (w *Writer) U64(v uint64) {
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, v)
+}
+
+// Bool appends a boolean as one byte
+func (w *Writer) Bool(v bool) {
+	if v {
+		w.buf = append(w.buf, 1)
+	} else {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+// Block appends a length-prefixed byte slice. A nil slice and an empty
+// slice encode identically.
+func (w *Writer) Block(b []byte) {
+	w.U32(uint32(len(b)))
+	w.buf = append(w.buf, b...)
+}
+
+// ErrShortState is returned by Reader.Err when the encoded state ended
+// before every field was read
+var ErrShortState = errors.New("state: unexpected end of data")
+
+// Reader decodes state written by a Writer, field by field in the same
+// order. Reads past the end return zero values and set Err, so a decoder
+// can read every field and check once at the end.
+type Reader struct {
+	buf []byte
+	err error
+}
+
+// NewReader creates a Reader over encoded state
+func NewReader(b []byte) *Reader {
+	return &Reader{buf: b}
+}
+
+// Err returns ErrShortState if any read ran past the end
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Remaining returns the number of bytes not yet read
+func (r *Reader) Remaining() int {
+	return len(r.buf)
+}
+
+// next consumes n bytes, or returns nil and records the error
+func (r *Reader) next(n int) []byte {
+	if r.err != nil || len(r.buf) < n {
+		r.err = ErrShortState
+		return nil
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+// U8 reads a byte
+func (r *Reader) U8() uint8 {
+	if b := r.next(1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+// U16 reads a 16-bit value
+func (r *Reader) U16() uint16 {
+	if b := r.next(2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+// U32 reads a 32-bit value
+func (r *Reader) U32() uint32 {
+	if b := r.next(4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+// U64 reads a 64-bit value
+func (r *Reader) U64() uint64 {
+	if b := r.next(8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+// Bool reads a boolean
+func (r *Reader) Bool() bool {
+	return r.U8() != 0
+}
+
+// Block reads a length-prefixed byte slice into dst, which must have the
+// encoded length. The data is copied.
+func (r *Reader) Block(dst []byte) {
+	n := r.U32()
+	if r.err == nil && int(n) != len(dst) {
+		r.err = fmt.Errorf("state: block of %d bytes, want %d", n, len(dst))
+		return
+	}
+	if b := r.next(len(dst)); b != nil {
+		copy(dst, b)
+	}
+}
+
+// Bytes reads a length-prefixed byte slice of any length, for nested
+// encodings. The slice aliases the Reader's buffer.
+func (r *Reader) Bytes() []byte {
+	n := r.U32()
+	if r.err == nil && uint64(n) > uint64(len(r.buf)) {
+		r.err = ErrShortState
+		return nil
+	}
+	return r.next(int(n))
+}
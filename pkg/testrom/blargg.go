@@ -0,0 +1,144 @@
+// Package testrom runs hardware test ROMs headlessly and reports results.
+package testrom
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
+)
+
+// Blargg's test ROMs report through PRG-RAM:
+//
+//	$6000:       status ($80 = running, $81 = reset requested, $00-$7F = result)
+//	$6001-$6003: signature $DE $B0 $61, written once the status is valid
+//	$6004-...:   zero-terminated result text
+const (
+	blarggStatus    = 0x6000
+	blarggSignature = 0x6001
+	blarggText      = 0x6004
+
+	blarggRunning      = 0x80
+	blarggResetRequest = 0x81
+
+	// Frames to wait before honouring a reset request; the ROMs ask for at
+	// least 100ms so the reset is not mistaken for a power glitch
+	blarggResetDelay = 6
+
+	// Upper bound on the result text, in case the terminator never arrives
+	maxTextLength = 1024
+)
+
+// DefaultMaxFrames is the emulated timeout used when BlarggOptions.MaxFrames
+// is zero (one minute at 60 fps)
+const DefaultMaxFrames = 60 * 60
+
+// BlarggOptions configures RunBlargg
+type BlarggOptions struct {
+	// MaxFrames is the timeout in emulated frames. Zero means DefaultMaxFrames.
+	MaxFrames int
+}
+
+// Result is the outcome of running one test ROM
+type Result struct {
+	ROM      string // Path of the ROM
+	Status   uint8  // Final status byte (0 = pass)
+	Text     string // Result text printed by the ROM
+	Frames   int    // Emulated frames until completion or timeout
+	Passed   bool
+	TimedOut bool  // No result before MaxFrames
+	Err      error // Load failure or CPU halt; the other fields are partial
+}
+
+// String summarizes the result on one line
+func (r Result) String() string {
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("ERROR %s: %v", r.ROM, r.Err)
+	case r.TimedOut:
+		return fmt.Sprintf("TIMEOUT %s after %d frames", r.ROM, r.Frames)
+	case r.Passed:
+		return fmt.Sprintf("PASS %s", r.ROM)
+	}
+	return fmt.Sprintf("FAIL %s (status %d): %s", r.ROM, r.Status, oneLine(r.Text))
+}
+
+// RunBlargg runs a blargg-style test ROM until it reports a result through
+// $6000 or the frame limit is reached. Video output is skipped.
+func RunBlargg(path string, opts BlarggOptions) Result {
+	res := Result{ROM: path}
+	maxFrames := opts.MaxFrames
+	if maxFrames <= 0 {
+		maxFrames = DefaultMaxFrames
+	}
+
+	emulator, err := nes.New(path)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	emulator.SetHeadless(nes.HeadlessMaxSpeed)
+	emulator.Reset()
+
+	bus := emulator.GetBus()
+	resetAt := -1
+
+	for res.Frames = 0; res.Frames < maxFrames; res.Frames++ {
+		if err := emulator.RunFrame(); err != nil {
+			res.Text = readText(emulator)
+			res.Err = err
+			return res
+		}
+		if !hasSignature(emulator) {
+			continue
+		}
+
+		switch status := bus.Peek(blarggStatus); status {
+		case blarggRunning:
+		case blarggResetRequest:
+			if resetAt < 0 {
+				resetAt = res.Frames + blarggResetDelay
+			} else if res.Frames >= resetAt {
+				emulator.Reset()
+				resetAt = -1
+			}
+		default:
+			res.Status = status
+			res.Text = readText(emulator)
+			res.Passed = status == 0
+			res.Frames++
+			return res
+		}
+	}
+
+	res.TimedOut = true
+	res.Text = readText(emulator)
+	return res
+}
+
+// hasSignature reports whether the ROM has marked $6000 as valid
+func hasSignature(emulator *nes.NES) bool {
+	bus := emulator.GetBus()
+	return bus.Peek(blarggSignature) == 0xDE &&
+		bus.Peek(blarggSignature+1) == 0xB0 &&
+		bus.Peek(blarggSignature+2) == 0x61
+}
+
+// readText reads the zero-terminated result text at $6004
+func readText(emulator *nes.NES) string {
+	bus := emulator.GetBus()
+	var sb strings.Builder
+	for addr := uint16(blarggText); addr < blarggText+maxTextLength; addr++ {
+		c := bus.Peek(addr)
+		if c == 0 {
+			break
+		}
+		sb.WriteByte(c)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// oneLine collapses multi-line result text for summaries
+func oneLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
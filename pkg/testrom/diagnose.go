@@ -0,0 +1,116 @@
+package testrom
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/cartridge"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
+)
+
+// Compatibility verdicts, from worst to best
+const (
+	VerdictLoadError   = "load-error"         // Not a valid iNES image
+	VerdictUnsupported = "unsupported-mapper" // Valid image, mapper not implemented
+	VerdictCrash       = "crash"              // CPU halted or the emulator panicked
+	VerdictNoBoot      = "no-boot"            // Never enabled rendering
+	VerdictBlank       = "blank"              // Rendering enabled but the screen stayed one color
+	VerdictStatic      = "static"             // Draws a picture that never changes
+	VerdictRenders     = "renders"            // Draws changing, multi-colored frames
+)
+
+// Diagnosis is the result of running a ROM through the compatibility
+// heuristics
+type Diagnosis struct {
+	ROM     string `json:"rom"`
+	Mapper  int    `json:"mapper"` // -1 if the header could not be read
+	Verdict string `json:"verdict"`
+	Boots   bool   `json:"boots"`   // Rendering was enabled at some point
+	Renders bool   `json:"renders"` // Produced a non-blank frame
+	Crashed bool   `json:"crashed"`
+	Frames  int    `json:"frames"`             // Frames emulated
+	Colors  int    `json:"colors"`             // Most distinct palette indices seen in one frame
+	Changes int    `json:"changes"`            // Frames that differed from the previous one
+	CrashPC string `json:"crash_pc,omitempty"` // Address of the halting opcode
+	Error   string `json:"error,omitempty"`
+}
+
+// Diagnose runs a ROM headlessly for the given number of frames with no
+// input and classifies how far it gets. Panics inside the emulator are
+// recovered and reported as crashes.
+func Diagnose(path string, frames int) (d Diagnosis) {
+	d = Diagnosis{ROM: path, Mapper: -1}
+
+	cart, err := cartridge.LoadFromFile(path)
+	if err != nil {
+		var unsupported cartridge.ErrUnsupportedMapper
+		if errors.As(err, &unsupported) {
+			d.Mapper = int(unsupported.ID)
+			d.Verdict = VerdictUnsupported
+		} else {
+			d.Verdict = VerdictLoadError
+		}
+		d.Error = err.Error()
+		return d
+	}
+	d.Mapper = int(cart.GetMapperID())
+
+	defer func() {
+		if r := recover(); r != nil {
+			d.Crashed = true
+			d.Verdict = VerdictCrash
+			d.Error = fmt.Sprint("panic: ", r)
+		}
+	}()
+
+	emulator := nes.NewFromCartridge(cart)
+	emulator.SetHeadless(nes.HeadlessSilent)
+	emulator.Reset()
+
+	var previous uint64
+	for d.Frames = 0; d.Frames < frames; d.Frames++ {
+		var halt *nes.HaltError
+		if err := emulator.RunFrame(); errors.As(err, &halt) {
+			d.Crashed = true
+			d.CrashPC = fmt.Sprintf("$%04X", halt.PC)
+			break
+		}
+		if emulator.GetPPU().DebugState().Mask&0x18 != 0 {
+			d.Boots = true
+		}
+
+		frame := emulator.GetFrameBuffer()
+		var seen [64]bool
+		colors := 0
+		for _, index := range frame {
+			if !seen[index&0x3F] {
+				seen[index&0x3F] = true
+				colors++
+			}
+		}
+		d.Colors = max(d.Colors, colors)
+		if colors > 1 {
+			d.Renders = true
+		}
+
+		hash := frameHash(frame)
+		if d.Frames > 0 && hash != previous {
+			d.Changes++
+		}
+		previous = hash
+	}
+
+	switch {
+	case d.Crashed:
+		d.Verdict = VerdictCrash
+	case !d.Boots:
+		d.Verdict = VerdictNoBoot
+	case !d.Renders:
+		d.Verdict = VerdictBlank
+	case d.Changes == 0:
+		d.Verdict = VerdictStatic
+	default:
+		d.Verdict = VerdictRenders
+	}
+	return d
+}
@@ -0,0 +1,252 @@
+package testrom
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/ppu"
+)
+
+// GoldenEntry is one manifest line: the frame a ROM should show after
+// running for Frame frames from power-on
+type GoldenEntry struct {
+	Name  string `json:"name,omitempty"` // Label for reports (defaults to the ROM path)
+	ROM   string `json:"rom"`            // ROM path, relative to the manifest
+	Frame int    `json:"frame"`          // Frames to run before capturing
+	Hash  string `json:"hash,omitempty"` // Expected FrameHash, in hex
+	PNG   string `json:"png,omitempty"`  // Expected image, relative to the manifest
+}
+
+// GoldenManifest is a list of golden-frame checks loaded from JSON
+type GoldenManifest struct {
+	Entries []GoldenEntry `json:"entries"`
+
+	dir string // Directory relative paths are resolved against
+}
+
+// GoldenResult is the outcome of one golden-frame check
+type GoldenResult struct {
+	Entry    GoldenEntry
+	Hash     string // Hash of the captured frame
+	Passed   bool
+	Mismatch int    // Pixels differing from the expected PNG
+	DiffPNG  string // Diff image written for a failure, if any
+	Err      error
+}
+
+// String summarizes the result on one line
+func (r GoldenResult) String() string {
+	name := r.Entry.Name
+	if name == "" {
+		name = r.Entry.ROM
+	}
+	switch {
+	case r.Err != nil:
+		return fmt.Sprintf("ERROR %s: %v", name, r.Err)
+	case r.Passed:
+		return fmt.Sprintf("PASS %s @ frame %d", name, r.Entry.Frame)
+	}
+	msg := fmt.Sprintf("FAIL %s @ frame %d: hash %s, want %s", name, r.Entry.Frame, r.Hash, r.Entry.Hash)
+	if r.Mismatch > 0 {
+		msg += fmt.Sprintf(", %d pixels differ", r.Mismatch)
+	}
+	if r.DiffPNG != "" {
+		msg += " (diff: " + r.DiffPNG + ")"
+	}
+	return msg
+}
+
+// LoadGoldenManifest reads a manifest file
+func LoadGoldenManifest(path string) (*GoldenManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &GoldenManifest{dir: filepath.Dir(path)}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Save writes the manifest back to path (e.g. after Update)
+func (m *GoldenManifest) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Run checks every entry. Failures with an expected image get a diff PNG
+// (mismatching pixels in red over a dimmed copy of the expected frame) and
+// the actual frame written to outDir; an empty outDir disables this.
+func (m *GoldenManifest) Run(outDir string) []GoldenResult {
+	results := make([]GoldenResult, len(m.Entries))
+	for i, entry := range m.Entries {
+		results[i] = m.check(entry, outDir)
+	}
+	return results
+}
+
+// Update re-captures every entry and records the new hashes, rewriting any
+// expected PNGs. Use it after an intentional rendering change.
+func (m *GoldenManifest) Update() error {
+	for i, entry := range m.Entries {
+		frame, err := CaptureFrame(m.path(entry.ROM), entry.Frame)
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.ROM, err)
+		}
+		m.Entries[i].Hash = FrameHash(frame)
+		if entry.PNG != "" {
+			if err := writePNG(m.path(entry.PNG), FrameImage(frame)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// check runs a single entry
+func (m *GoldenManifest) check(entry GoldenEntry, outDir string) GoldenResult {
+	res := GoldenResult{Entry: entry}
+
+	frame, err := CaptureFrame(m.path(entry.ROM), entry.Frame)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Hash = FrameHash(frame)
+	res.Passed = entry.Hash == "" || strings.EqualFold(entry.Hash, res.Hash)
+
+	if entry.PNG == "" {
+		if entry.Hash == "" {
+			res.Err = fmt.Errorf("entry has neither hash nor png")
+		}
+		return res
+	}
+
+	expected, err := readPNG(m.path(entry.PNG))
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	actual := FrameImage(frame)
+	diff, mismatch := diffImages(expected, actual)
+	res.Mismatch = mismatch
+	res.Passed = res.Passed && mismatch == 0
+
+	if !res.Passed && outDir != "" {
+		base := filepath.Join(outDir, strings.TrimSuffix(filepath.Base(entry.ROM), filepath.Ext(entry.ROM))+
+			"_"+strconv.Itoa(entry.Frame))
+		if err := os.MkdirAll(outDir, 0o755); err != nil {
+			res.Err = err
+			return res
+		}
+		if err := writePNG(base+"_actual.png", actual); err != nil {
+			res.Err = err
+			return res
+		}
+		if err := writePNG(base+"_diff.png", diff); err != nil {
+			res.Err = err
+			return res
+		}
+		res.DiffPNG = base + "_diff.png"
+	}
+	return res
+}
+
+// path resolves a manifest-relative path
+func (m *GoldenManifest) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(m.dir, p)
+}
+
+// CaptureFrame runs a ROM headlessly from power-on for the given number of
+// frames and returns a copy of the final frame buffer
+func CaptureFrame(romPath string, frames int) (*[ppu.ScreenWidth * ppu.ScreenHeight]uint8, error) {
+	emulator, err := nes.New(romPath)
+	if err != nil {
+		return nil, err
+	}
+	emulator.SetHeadless(nes.HeadlessSilent)
+	emulator.Reset()
+	for i := 0; i < frames; i++ {
+		emulator.RunFrame()
+	}
+	frame := *emulator.GetFrameBuffer()
+	return &frame, nil
+}
+
+// FrameHash returns the FNV-1a hash of a frame's palette indices, in hex
+func FrameHash(frame *[ppu.ScreenWidth * ppu.ScreenHeight]uint8) string {
+	return fmt.Sprintf("%016x", frameHash(frame))
+}
+
+// frameHash returns the FNV-1a hash of a frame's palette indices
+func frameHash(frame *[ppu.ScreenWidth * ppu.ScreenHeight]uint8) uint64 {
+	h := fnv.New64a()
+	h.Write(frame[:])
+	return h.Sum64()
+}
+
+// FrameImage converts a frame of palette indices to an RGBA image with the
+// hardware palette and no emphasis, so golden PNGs only depend on the indices
+func FrameImage(frame *[ppu.ScreenWidth * ppu.ScreenHeight]uint8) *image.RGBA {
+	return ppu.FrameImage(frame, 0)
+}
+
+// diffImages marks pixels that differ in red over a dimmed expected image
+func diffImages(expected image.Image, actual *image.RGBA) (*image.RGBA, int) {
+	bounds := actual.Bounds()
+	diff := image.NewRGBA(bounds)
+	mismatch := 0
+
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			want := color.RGBAModel.Convert(expected.At(x, y)).(color.RGBA)
+			got := actual.RGBAAt(x, y)
+			if want.R != got.R || want.G != got.G || want.B != got.B {
+				mismatch++
+				diff.SetRGBA(x, y, color.RGBA{R: 255, A: 255})
+				continue
+			}
+			diff.SetRGBA(x, y, color.RGBA{R: want.R / 4, G: want.G / 4, B: want.B / 4, A: 255})
+		}
+	}
+	return diff, mismatch
+}
+
+// readPNG loads a PNG image
+func readPNG(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return png.Decode(f)
+}
+
+// writePNG saves an image as PNG
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
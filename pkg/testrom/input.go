@@ -0,0 +1,120 @@
+package testrom
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/controller"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
+)
+
+// InputScript is a sequence of controller changes keyed by frame number.
+//
+// The text format has one change per line: the frame the change takes effect,
+// then the buttons held on controller 1 and, optionally, controller 2.
+// Buttons are joined with '+', and '-' means none. A state is held until the
+// next line that mentions that controller. Blank lines and '#' comments are
+// ignored.
+//
+//	# frame  p1        p2
+//	0        -
+//	120      START
+//	125      -
+//	300      A+RIGHT   B
+type InputScript struct {
+	events []inputEvent
+}
+
+// inputEvent is one line of an InputScript
+type inputEvent struct {
+	frame   int
+	ports   int        // Number of controllers the line sets (1 or 2)
+	buttons [2][8]bool // Held buttons per controller
+}
+
+// LoadInputScript reads an input script file
+func LoadInputScript(path string) (*InputScript, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseInputScript(f)
+}
+
+// ParseInputScript parses the text format described on InputScript
+func ParseInputScript(r io.Reader) (*InputScript, error) {
+	script := &InputScript{}
+	scanner := bufio.NewScanner(r)
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 3 {
+			return nil, fmt.Errorf("input script line %d: expected frame and up to two controllers", lineNo)
+		}
+
+		frame, err := strconv.Atoi(fields[0])
+		if err != nil || frame < 0 {
+			return nil, fmt.Errorf("input script line %d: bad frame %q", lineNo, fields[0])
+		}
+		ev := inputEvent{frame: frame, ports: len(fields) - 1}
+		for port, spec := range fields[1:] {
+			if err := parseButtons(spec, &ev.buttons[port]); err != nil {
+				return nil, fmt.Errorf("input script line %d: %w", lineNo, err)
+			}
+		}
+		script.events = append(script.events, ev)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(script.events, func(i, j int) bool {
+		return script.events[i].frame < script.events[j].frame
+	})
+	return script, nil
+}
+
+// parseButtons parses a '+'-joined button list into held
+func parseButtons(spec string, held *[8]bool) error {
+	if spec == "-" {
+		return nil
+	}
+	for _, name := range strings.Split(spec, "+") {
+		button, ok := controller.ParseButton(name)
+		if !ok {
+			return fmt.Errorf("unknown button %q", name)
+		}
+		held[button] = true
+	}
+	return nil
+}
+
+// Apply sets the controllers for the given frame. Call it before running
+// each frame, with frames counted from 0.
+func (s *InputScript) Apply(emulator *nes.NES, frame int) {
+	if s == nil {
+		return
+	}
+	bus := emulator.GetBus()
+	for _, ev := range s.events {
+		if ev.frame != frame {
+			continue
+		}
+		for port := 0; port < ev.ports; port++ {
+			c := bus.GetController(port)
+			for b, pressed := range ev.buttons[port] {
+				c.SetButton(controller.Button(b), pressed)
+			}
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package testrom
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes"
+	"github.com/andrewthecodertx/go-nes-emulator/pkg/nes/debug"
+)
+
+// NestestLines is the length of the golden nestest.log: every official and
+// unofficial opcode test, ending with the final RTS
+const NestestLines = 8991
+
+// nestest leaves its result codes in $02 (official opcodes) and $03
+// (unofficial opcodes); zero means every test passed
+const (
+	nestestOfficial   = 0x02
+	nestestUnofficial = 0x03
+)
+
+// NestestOptions configures RunNestest
+type NestestOptions struct {
+	// Golden is the golden nestest.log, one line per instruction. When set,
+	// tracing stops at the first line that differs from it. Nil just
+	// traces.
+	Golden []string
+
+	// MaxLines is the number of instructions to trace. Zero means
+	// NestestLines, or the length of Golden if shorter.
+	MaxLines int
+
+	// StrictPPU also compares the PPU scanline/dot column
+	StrictPPU bool
+}
+
+// NestestResult is the outcome of running nestest.nes in C000 mode
+type NestestResult struct {
+	Trace      []string // Trace lines, up to and including any divergence
+	Official   uint8    // Result code for the official opcodes ($02)
+	Unofficial uint8    // Result code for the unofficial opcodes ($03)
+	Halted     bool     // The CPU jammed before MaxLines
+
+	// Divergence is the 1-based line where Trace first differs from the
+	// golden log, or 0 if it never does
+	Divergence int
+
+	golden []string
+}
+
+// Passed reports whether the trace matched and both result codes are zero
+func (r NestestResult) Passed() bool {
+	return r.Divergence == 0 && !r.Halted && r.Official == 0 && r.Unofficial == 0
+}
+
+// Report describes the first divergence with the given number of preceding
+// lines for context, or returns "" if there was none
+func (r NestestResult) Report(context int) string {
+	if r.Divergence == 0 {
+		return ""
+	}
+	var b strings.Builder
+	n := r.Divergence
+	fmt.Fprintf(&b, "Divergence at line %d\n\n", n)
+	for i := max(n-1-context, 0); i < n-1; i++ {
+		fmt.Fprintf(&b, "     %s\n", r.Trace[i])
+	}
+	if n <= len(r.golden) {
+		fmt.Fprintf(&b, "want %s\n", r.golden[n-1])
+	}
+	if n <= len(r.Trace) {
+		fmt.Fprintf(&b, "got  %s\n", r.Trace[n-1])
+	} else {
+		b.WriteString("got  (CPU halted)\n")
+	}
+	return b.String()
+}
+
+// RunNestest runs Kevin Horton's nestest.nes in automated "C000 mode" and
+// traces it in nestest.log's format, comparing against opts.Golden if set
+//
+// In C000 mode execution starts at $C000 with P=$24, SP=$FD and 7 cycles
+// already elapsed, and the ROM runs every official and unofficial opcode
+// test without needing the PPU.
+func RunNestest(path string, opts NestestOptions) (NestestResult, error) {
+	res := NestestResult{golden: opts.Golden}
+	maxLines := opts.MaxLines
+	if maxLines <= 0 {
+		maxLines = NestestLines
+	}
+	if opts.Golden != nil && len(opts.Golden) < maxLines {
+		maxLines = len(opts.Golden)
+	}
+
+	emulator, err := nes.New(path)
+	if err != nil {
+		return res, err
+	}
+
+	// C000 mode: skip the reset vector and start the automated tests directly
+	emulator.Reset()
+	cpu := emulator.GetCPU()
+	cpu.SetPC(0xC000)
+	cpu.SetRegisters(0, 0, 0, 0xFD)
+	cpu.SetStatusFlags(0x24)
+	cpu.Stall(7)
+
+	dbg := debug.New(emulator)
+	dbg.StepInstruction() // burn the 7 start-up cycles
+
+	for len(res.Trace) < maxLines && !cpu.Halted {
+		line := dbg.TraceLine()
+		res.Trace = append(res.Trace, line)
+		if opts.Golden != nil && !traceMatches(line, opts.Golden[len(res.Trace)-1], opts.StrictPPU) {
+			res.Divergence = len(res.Trace)
+			break
+		}
+		dbg.StepInstruction()
+	}
+
+	res.Official = dbg.Peek(nestestOfficial)
+	res.Unofficial = dbg.Peek(nestestUnofficial)
+	if res.Divergence == 0 && len(res.Trace) < maxLines {
+		res.Halted = true
+		if opts.Golden != nil {
+			res.Divergence = len(res.Trace) + 1
+		}
+	}
+	return res, nil
+}
+
+// traceMatches compares a trace line with the golden line. Unless strict,
+// the PPU column is ignored: it depends on where the PPU was at power-on,
+// which nestest.log does not pin down for C000 mode.
+func traceMatches(got, want string, strict bool) bool {
+	if strict {
+		return got == want
+	}
+	return stripPPU(got) == stripPPU(want)
+}
+
+// stripPPU removes the "PPU:sss,ddd" field from a trace line
+func stripPPU(line string) string {
+	start := strings.Index(line, "PPU:")
+	end := strings.Index(line, "CYC:")
+	if start < 0 || end < start {
+		return line
+	}
+	return line[:start] + line[end:]
+}
+
+// ReadTraceLog reads a trace log such as nestest.log into lines, dropping
+// carriage returns
+func ReadTraceLog(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,49 @@
+package testrom
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+)
+
+// TestNestest runs nestest.nes in C000 mode and checks the ROM's own
+// result codes
+func TestNestest(t *testing.T) {
+	res, err := RunNestest("../../roms/nestest.nes", NestestOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkNestestResult(t, res)
+}
+
+// TestNestestTrace diffs the run against the golden nestest.log, failing on
+// the first divergent line. The log is not distributed with the repository;
+// the test is skipped until it is dropped in testdata/.
+func TestNestestTrace(t *testing.T) {
+	golden, err := ReadTraceLog("../../testdata/nestest.log")
+	if errors.Is(err, fs.ErrNotExist) {
+		t.Skip("testdata/nestest.log not found; per-instruction trace not checked")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := RunNestest("../../roms/nestest.nes", NestestOptions{Golden: golden})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Divergence != 0 {
+		t.Fatalf("trace differs from nestest.log\n%s", res.Report(5))
+	}
+	checkNestestResult(t, res)
+}
+
+func checkNestestResult(t *testing.T, res NestestResult) {
+	t.Helper()
+	if res.Halted {
+		t.Fatalf("CPU halted after %d of %d lines", len(res.Trace), NestestLines)
+	}
+	if res.Official != 0 || res.Unofficial != 0 {
+		t.Fatalf("result codes $02=%02X $03=%02X, want 00 00", res.Official, res.Unofficial)
+	}
+}
@@ -0,0 +1,231 @@
+package testrom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Suite groups test ROMs into categories (cpu, ppu, apu, mapper...) for
+// accuracy tracking
+type Suite struct {
+	Categories []SuiteCategory `json:"categories"`
+
+	dir string // Directory relative paths are resolved against
+}
+
+// SuiteCategory lists the ROMs (files or directories) of one category
+type SuiteCategory struct {
+	Name string   `json:"name"`
+	ROMs []string `json:"roms"`
+}
+
+// Scoreboard is the result of running a Suite
+type Scoreboard struct {
+	Generated  time.Time       `json:"generated"`
+	Passed     int             `json:"passed"`
+	Total      int             `json:"total"`
+	Categories []CategoryScore `json:"categories"`
+}
+
+// CategoryScore is the per-category tally of a Scoreboard
+type CategoryScore struct {
+	Name   string      `json:"name"`
+	Passed int         `json:"passed"`
+	Total  int         `json:"total"`
+	Tests  []TestScore `json:"tests"`
+}
+
+// TestScore is the recorded outcome of one ROM
+type TestScore struct {
+	ROM    string `json:"rom"`
+	Passed bool   `json:"passed"`
+	Result string `json:"result"`
+}
+
+// LoadSuite reads a suite definition
+func LoadSuite(path string) (*Suite, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Suite{dir: filepath.Dir(path)}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Run executes every ROM in the suite with RunBlargg. Directory entries are
+// expanded to the .nes files they contain, in sorted order.
+func (s *Suite) Run(opts BlarggOptions) (*Scoreboard, error) {
+	board := &Scoreboard{Generated: time.Now().UTC()}
+
+	for _, cat := range s.Categories {
+		score := CategoryScore{Name: cat.Name}
+		for _, entry := range cat.ROMs {
+			roms, err := ExpandROMs(s.path(entry))
+			if err != nil {
+				return nil, err
+			}
+			for _, rom := range roms {
+				res := RunBlargg(rom, opts)
+				name, _ := filepath.Rel(s.dir, rom)
+				score.Tests = append(score.Tests, TestScore{
+					ROM:    filepath.ToSlash(name),
+					Passed: res.Passed,
+					Result: res.String(),
+				})
+				score.Total++
+				if res.Passed {
+					score.Passed++
+				}
+			}
+		}
+		board.Passed += score.Passed
+		board.Total += score.Total
+		board.Categories = append(board.Categories, score)
+	}
+	return board, nil
+}
+
+// path resolves a suite-relative path
+func (s *Suite) path(p string) string {
+	if filepath.IsAbs(p) {
+		return p
+	}
+	return filepath.Join(s.dir, p)
+}
+
+// LoadScoreboard reads a scoreboard written by Scoreboard.Save
+func LoadScoreboard(path string) (*Scoreboard, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	board := &Scoreboard{}
+	if err := json.Unmarshal(data, board); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return board, nil
+}
+
+// Save writes the scoreboard as JSON
+func (b *Scoreboard) Save(path string) error {
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// WriteMarkdown renders the scoreboard as a markdown table. When previous
+// is non-nil, each category shows the change in passes and the tests that
+// started or stopped passing are listed.
+func (b *Scoreboard) WriteMarkdown(w io.Writer, previous *Scoreboard) {
+	fmt.Fprintf(w, "# Accuracy Scoreboard\n\n")
+	fmt.Fprintf(w, "Generated %s\n\n", b.Generated.Format(time.RFC3339))
+	fmt.Fprintf(w, "| Category | Passed | Total | %% | Change |\n")
+	fmt.Fprintf(w, "|----------|-------:|------:|--:|-------:|\n")
+
+	prevCats := map[string]CategoryScore{}
+	prevTests := map[string]bool{}
+	if previous != nil {
+		for _, cat := range previous.Categories {
+			prevCats[cat.Name] = cat
+			for _, t := range cat.Tests {
+				prevTests[t.ROM] = t.Passed
+			}
+		}
+	}
+
+	for _, cat := range b.Categories {
+		change := ""
+		if prev, ok := prevCats[cat.Name]; ok {
+			change = signed(cat.Passed - prev.Passed)
+		}
+		fmt.Fprintf(w, "| %s | %d | %d | %s | %s |\n", cat.Name, cat.Passed, cat.Total, percent(cat.Passed, cat.Total), change)
+	}
+	change := ""
+	if previous != nil {
+		change = signed(b.Passed - previous.Passed)
+	}
+	fmt.Fprintf(w, "| **Total** | **%d** | **%d** | **%s** | %s |\n", b.Passed, b.Total, percent(b.Passed, b.Total), change)
+
+	if previous == nil {
+		return
+	}
+
+	var fixed, broken []string
+	for _, cat := range b.Categories {
+		for _, t := range cat.Tests {
+			was, ok := prevTests[t.ROM]
+			switch {
+			case t.Passed && (!ok || !was):
+				fixed = append(fixed, t.ROM)
+			case !t.Passed && ok && was:
+				broken = append(broken, t.ROM)
+			}
+		}
+	}
+	writeList(w, "Newly passing", fixed)
+	writeList(w, "Regressions", broken)
+}
+
+// writeList writes a markdown bullet list under a heading, if non-empty
+func writeList(w io.Writer, heading string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	sort.Strings(items)
+	fmt.Fprintf(w, "\n## %s\n\n", heading)
+	for _, item := range items {
+		fmt.Fprintf(w, "- %s\n", item)
+	}
+}
+
+// signed formats a delta with an explicit sign
+func signed(n int) string {
+	if n > 0 {
+		return fmt.Sprintf("+%d", n)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+// percent formats passed/total as a percentage
+func percent(passed, total int) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.0f%%", float64(passed)*100/float64(total))
+}
+
+// ExpandROMs returns path itself, or the .nes files under it if it is a
+// directory
+func ExpandROMs(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var roms []string
+	err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".nes") {
+			roms = append(roms, p)
+		}
+		return nil
+	})
+	return roms, err
+}
@@ -0,0 +1,204 @@
+// Package testutil builds synthetic cartridge images for tests and fuzzing.
+//
+// ROMBuilder produces minimal iNES or NES 2.0 files with a chosen mapper,
+// bank counts, mirroring, vectors, code and CHR contents, so tests can
+// construct exactly the fixture they need instead of shipping binary ROMs:
+//
+//	rom := testutil.NewROM(0).
+//	    Code(0x8000, 0xA9, 0x80, 0x8D, 0x00, 0x20). // LDA #$80; STA $2000
+//	    Code(0x8005, 0x4C, 0x05, 0x80).             // JMP *
+//	    Build()
+//	cart, err := cartridge.LoadFromBytes(rom)
+package testutil
+
+const (
+	prgBankSize = 16384
+	chrBankSize = 8192
+)
+
+// Nametable mirroring as encoded in the header
+const (
+	MirrorHorizontal = iota
+	MirrorVertical
+	MirrorFourScreen
+)
+
+// NOP is the default PRG fill byte, so stray execution runs harmlessly
+// forward instead of hitting BRK
+const NOP = 0xEA
+
+// ROMBuilder assembles a cartridge image. Methods return the builder so
+// calls can be chained; Build produces the file bytes.
+type ROMBuilder struct {
+	mapper    uint16
+	submapper uint8
+	nes20     bool
+	region    uint8
+	mirroring int
+	battery   bool
+	trainer   []byte
+
+	prg []byte
+	chr []byte
+
+	nmi, reset, irq uint16
+}
+
+// NewROM starts an image for the given mapper with one 16KB PRG bank
+// filled with NOPs, no CHR-ROM (so the cartridge uses CHR-RAM), horizontal
+// mirroring, and all three vectors pointing at $8000
+func NewROM(mapper uint16) *ROMBuilder {
+	b := &ROMBuilder{
+		mapper: mapper,
+		nmi:    0x8000,
+		reset:  0x8000,
+		irq:    0x8000,
+	}
+	return b.PRGBanks(1)
+}
+
+// PRGBanks resizes PRG-ROM to n 16KB banks, preserving existing contents
+// and NOP-filling new space
+func (b *ROMBuilder) PRGBanks(n int) *ROMBuilder {
+	old := b.prg
+	b.prg = make([]byte, n*prgBankSize)
+	for i := range b.prg {
+		b.prg[i] = NOP
+	}
+	copy(b.prg, old)
+	return b
+}
+
+// CHRBanks resizes CHR-ROM to n 8KB banks, preserving existing contents.
+// Zero banks means the cartridge uses CHR-RAM.
+func (b *ROMBuilder) CHRBanks(n int) *ROMBuilder {
+	old := b.chr
+	b.chr = make([]byte, n*chrBankSize)
+	copy(b.chr, old)
+	return b
+}
+
+// PRG copies data into PRG-ROM at a file offset, growing it by whole banks
+// if needed
+func (b *ROMBuilder) PRG(offset int, data ...byte) *ROMBuilder {
+	if need := offset + len(data); need > len(b.prg) {
+		b.PRGBanks((need + prgBankSize - 1) / prgBankSize)
+	}
+	copy(b.prg[offset:], data)
+	return b
+}
+
+// CHR copies data into CHR-ROM at an offset, growing it by whole banks if
+// needed
+func (b *ROMBuilder) CHR(offset int, data ...byte) *ROMBuilder {
+	if need := offset + len(data); need > len(b.chr) {
+		b.CHRBanks((need + chrBankSize - 1) / chrBankSize)
+	}
+	copy(b.chr[offset:], data)
+	return b
+}
+
+// Tile writes an 8x8 CHR tile (16 bytes: 8 low-plane then 8 high-plane
+// rows) at the given tile index across the whole CHR space
+func (b *ROMBuilder) Tile(index int, planes [16]byte) *ROMBuilder {
+	return b.CHR(index*16, planes[:]...)
+}
+
+// Code places bytes at a CPU address in the power-on PRG layout shared by
+// the supported mappers: the last 16KB bank at $C000-$FFFF and, for $8000-
+// $BFFF, the first bank (or the only bank, mirrored, on 16KB NROM)
+func (b *ROMBuilder) Code(addr uint16, code ...byte) *ROMBuilder {
+	for i, v := range code {
+		b.prg[b.prgOffset(addr+uint16(i))] = v
+	}
+	return b
+}
+
+// prgOffset maps a CPU address to a PRG file offset per Code's layout
+func (b *ROMBuilder) prgOffset(addr uint16) int {
+	offset := int(addr & 0x3FFF)
+	if addr >= 0xC000 {
+		offset += len(b.prg) - prgBankSize
+	}
+	return offset
+}
+
+// Vectors sets the NMI, reset and IRQ/BRK vectors
+func (b *ROMBuilder) Vectors(nmi, reset, irq uint16) *ROMBuilder {
+	b.nmi, b.reset, b.irq = nmi, reset, irq
+	return b
+}
+
+// Mirroring selects MirrorHorizontal, MirrorVertical or MirrorFourScreen
+func (b *ROMBuilder) Mirroring(mode int) *ROMBuilder {
+	b.mirroring = mode
+	return b
+}
+
+// Battery marks the cartridge as having battery-backed PRG-RAM
+func (b *ROMBuilder) Battery() *ROMBuilder {
+	b.battery = true
+	return b
+}
+
+// Trainer adds a 512-byte trainer (shorter data is zero-padded)
+func (b *ROMBuilder) Trainer(data []byte) *ROMBuilder {
+	b.trainer = make([]byte, 512)
+	copy(b.trainer, data)
+	return b
+}
+
+// NES20 switches the header to NES 2.0 with the given submapper and
+// timing region (0 NTSC, 1 PAL, 2 multi-region, 3 Dendy)
+func (b *ROMBuilder) NES20(submapper, region uint8) *ROMBuilder {
+	b.nes20 = true
+	b.submapper = submapper
+	b.region = region
+	return b
+}
+
+// Header returns just the 16-byte header for the current layout
+func (b *ROMBuilder) Header() []byte {
+	prgBanks := len(b.prg) / prgBankSize
+	chrBanks := len(b.chr) / chrBankSize
+
+	h := make([]byte, 16)
+	copy(h, "NES\x1A")
+	h[4] = uint8(prgBanks)
+	h[5] = uint8(chrBanks)
+
+	h[6] = uint8(b.mapper&0x0F) << 4
+	switch b.mirroring {
+	case MirrorVertical:
+		h[6] |= 0x01
+	case MirrorFourScreen:
+		h[6] |= 0x08
+	}
+	if b.battery {
+		h[6] |= 0x02
+	}
+	if b.trainer != nil {
+		h[6] |= 0x04
+	}
+	h[7] = uint8(b.mapper & 0xF0)
+
+	if b.nes20 {
+		h[7] |= 0x08
+		h[8] = uint8(b.mapper>>8)&0x0F | b.submapper<<4
+		h[9] = uint8(prgBanks>>8)&0x0F | uint8(chrBanks>>8)<<4
+		h[12] = b.region & 0x03
+	}
+	return h
+}
+
+// Build returns the complete image: header, trainer, PRG-ROM with the
+// vectors written into its last six bytes, then CHR-ROM
+func (b *ROMBuilder) Build() []byte {
+	b.Code(0xFFFA, uint8(b.nmi), uint8(b.nmi>>8), uint8(b.reset), uint8(b.reset>>8), uint8(b.irq), uint8(b.irq>>8))
+
+	out := b.Header()
+	out = append(out, b.trainer...)
+	out = append(out, b.prg...)
+	out = append(out, b.chr...)
+	return out
+}